@@ -14,7 +14,7 @@ import (
 var (
 	stdin  io.Reader = os.Stdin
 	stdout io.Writer = os.Stdout
-	stderr io.Writer = os.Stderr //nolint:unused
+	stderr io.Writer = os.Stderr
 )
 
 // Action is the top-level CLI orchestrator. It owns one focused handler per
@@ -35,6 +35,12 @@ type Action struct {
 	binary     *binaryHandler
 	envH       *envHandler
 	otpH       *otpHandler
+	passkeyH   *passkeyHandler
+	tuiH       *tuiHandler
+	nativeHost *nativeHostHandler
+	serveH     *serveHandler
+	fuseH      *fuseHandler
+	renderH    *renderHandler
 	misc       *miscHandler
 }
 
@@ -74,6 +80,12 @@ func newAction(cfg *config.Config, sv semver.Version, remind bool) (*Action, err
 	bin := &binaryHandler{base: b}
 	env := &envHandler{base: b}
 	otp := &otpHandler{base: b}
+	pk := &passkeyHandler{base: b}
+	tui := &tuiHandler{base: b}
+	nh := &nativeHostHandler{base: b}
+	srv := &serveHandler{base: b}
+	fh := &fuseHandler{base: b}
+	rnd := &renderHandler{base: b}
 	misc := &miscHandler{base: b}
 
 	// Wire cross-handler dependencies through explicit function references so
@@ -96,6 +108,13 @@ func newAction(cfg *config.Config, sv semver.Version, remind bool) (*Action, err
 	otp.insertYAMLFn = sec.insertYAML
 	otp.findFn = srch.find
 
+	tui.editFn = sec.edit
+	tui.otpFn = otp.otpToken
+
+	nh.otpFn = otp.otpToken
+
+	srv.syncFn = syn.sync
+
 	sec.listFn = srch.List
 	sec.findFuzzyFn = srch.FindFuzzy
 
@@ -117,6 +136,12 @@ func newAction(cfg *config.Config, sv semver.Version, remind bool) (*Action, err
 		binary:     bin,
 		envH:       env,
 		otpH:       otp,
+		passkeyH:   pk,
+		tuiH:       tui,
+		nativeHost: nh,
+		serveH:     srv,
+		fuseH:      fh,
+		renderH:    rnd,
 		misc:       misc,
 	}, nil
 }
@@ -96,6 +96,40 @@ type otpHandler struct {
 	findFn       func(ctx context.Context, cmd *cli.Command, needle string, cb showFunc, fuzzy bool) error
 }
 
+// tuiHandler owns the full-screen terminal UI.
+type tuiHandler struct {
+	*base
+	editFn func(ctx context.Context, cmd *cli.Command, name string) error
+	otpFn  func(ctx context.Context, name string) (string, error)
+}
+
+// nativeHostHandler answers requests of browser extensions.
+type nativeHostHandler struct {
+	*base
+	otpFn func(ctx context.Context, name string) (string, error)
+}
+
+// serveHandler runs the API server.
+type serveHandler struct {
+	*base
+	syncFn func(ctx context.Context, store string, isAutosync bool) error
+}
+
+// fuseHandler mounts the store as a file system.
+type fuseHandler struct {
+	*base
+}
+
+// renderHandler renders secrets into files for deployments.
+type renderHandler struct {
+	*base
+}
+
+// passkeyHandler handles WebAuthn credentials (passkeys).
+type passkeyHandler struct {
+	*base
+}
+
 // miscHandler handles miscellaneous operations that do not fit a narrower
 // category (aliases, version, convert, reorg, process, unclip, update,
 // reminder, repl, doctor, completion, config, otp-adjacent helpers).
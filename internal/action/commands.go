@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/nativehost"
 	"github.com/gopasspw/gopass/pkg/debug"
 	"github.com/gopasspw/gopass/pkg/otp"
 	"github.com/gopasspw/gopass/pkg/set"
@@ -84,6 +85,10 @@ func ShowFlags() []cli.Flag {
 			Aliases: []string{"C"},
 			Usage:   "Copy the password and show everything",
 		},
+		&cli.BoolFlag{
+			Name:  "type",
+			Usage: "Type the password, the key or the autotype sequence of the secret as keystrokes after a countdown",
+		},
 		&cli.BoolFlag{
 			Name:  "qr",
 			Usage: "Print the password as a QR Code",
@@ -266,6 +271,10 @@ func (s *Action) GetCommands() []*cli.Command {
 					Name:  "storage",
 					Usage: fmt.Sprintf("Which storage backend? %v", backend.StorageRegistry.BackendNames()),
 				},
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Only estimate the time and size of the conversion",
+				},
 			},
 		},
 		{
@@ -318,7 +327,7 @@ func (s *Action) GetCommands() []*cli.Command {
 				&cli.BoolFlag{
 					Name:    "force",
 					Aliases: []string{"f"},
-					Usage:   "Force path selection",
+					Usage:   "Force path selection and override the password policy of the store",
 				},
 			},
 		},
@@ -405,6 +414,11 @@ func (s *Action) GetCommands() []*cli.Command {
 					Aliases: []string{"c"},
 					Usage:   "Create a new secret if none found",
 				},
+				&cli.BoolFlag{
+					Name:    "force",
+					Aliases: []string{"f"},
+					Usage:   "Save the secret even if its password violates the password policy of the store",
+				},
 				&cli.StringFlag{
 					Name:    "commit-message",
 					Aliases: []string{"m"},
@@ -418,14 +432,21 @@ func (s *Action) GetCommands() []*cli.Command {
 			},
 		},
 		{
-			Name:          "env",
-			Usage:         "Run a subprocess with a pre-populated environment",
-			ArgsUsage:     "[secret] [command and args...]",
-			Description:   "This command runs a sub process with the environment populated from the keys of a secret.",
+			Name:      "env",
+			Usage:     "Run a subprocess with a pre-populated environment",
+			ArgsUsage: "[secret] [command and args...]",
+			Description: "" +
+				"This command runs a sub process with the environment populated from the keys of a secret. " +
+				"With --map the variables are read from a mapping file with one NAME=secret[:key] line per " +
+				"variable and all arguments are the command. If any secret or key is missing the command is not run.",
 			Before:        s.IsInitialized,
 			Action:        s.Env,
 			ShellComplete: s.Complete,
 			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "map",
+					Usage: "Read the variables from this mapping file instead of a secret",
+				},
 				&cli.BoolFlag{
 					Name:    "keep-case",
 					Aliases: []string{"kc"},
@@ -530,6 +551,47 @@ func (s *Action) GetCommands() []*cli.Command {
 			Action:        s.BinaryMove,
 			ShellComplete: s.Complete,
 		},
+		{
+			Name:      "fuse",
+			Usage:     "Present the store as a file system",
+			ArgsUsage: "[mount]",
+			Description: "" +
+				"These commands present the secrets as read-only files for tools that " +
+				"only read credentials from files. Requires FUSE on Linux, macOS or FreeBSD.",
+			Commands: []*cli.Command{
+				{
+					Name:      "mount",
+					Usage:     "Mount the store on a directory",
+					ArgsUsage: "[dir]",
+					Description: "" +
+						"This command mounts the secrets read-only on the given directory until " +
+						"it is interrupted or unmounted. Secrets are only decrypted when a " +
+						"file is opened. By default decrypted content is never cached, use " +
+						"--cache-timeout to keep it in memory and in the page cache for a while. " +
+						"--allow limits which programs may read secrets.",
+					Before: s.IsInitialized,
+					Action: s.FuseMount,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "prefix",
+							Usage: "Only mount the secrets in this folder",
+						},
+						&cli.BoolFlag{
+							Name:  "keys",
+							Usage: "Present every secret as a directory with one file for the password and every key",
+						},
+						&cli.IntFlag{
+							Name:  "cache-timeout",
+							Usage: "Seconds to keep decrypted content cached. 0 decrypts on every open",
+						},
+						&cli.StringSliceFlag{
+							Name:  "allow",
+							Usage: "Only allow this program to read secrets. Either a name or an absolute path. Can be given multiple times",
+						},
+					},
+				},
+			},
+		},
 		{
 			Name:      "generate",
 			Usage:     "Generate a new password",
@@ -554,7 +616,7 @@ func (s *Action) GetCommands() []*cli.Command {
 				&cli.BoolFlag{
 					Name:    "force",
 					Aliases: []string{"f"},
-					Usage:   "Force to overwrite existing password",
+					Usage:   "Force to overwrite existing password and override the password policy of the store",
 				},
 				&cli.BoolFlag{
 					Name:    "edit",
@@ -601,6 +663,14 @@ func (s *Action) GetCommands() []*cli.Command {
 					Aliases: []string{"xkcdnumbers"},
 					Usage:   "Add a random number to the end of the generated XKCD password",
 				},
+				&cli.StringFlag{
+					Name:  "xkcd-wordlist",
+					Usage: "Wordlist to generate XKCD passwords from: en, de, de_short, eff-large, eff-short or the path to a wordlist file. Overrides --xkcd-lang",
+				},
+				&cli.IntFlag{
+					Name:  "xkcd-min-entropy",
+					Usage: "Minimum entropy in bits of generated XKCD passwords. Sets the number of words if no length is given",
+				},
 				&cli.StringFlag{
 					Name:    "commit-message",
 					Aliases: []string{"m"},
@@ -703,7 +773,7 @@ func (s *Action) GetCommands() []*cli.Command {
 				&cli.BoolFlag{
 					Name:    "force",
 					Aliases: []string{"f"},
-					Usage:   "Overwrite any existing secret and do not prompt to confirm recipients",
+					Usage:   "Overwrite any existing secret, do not prompt to confirm recipients and override the password policy of the store",
 				},
 				&cli.BoolFlag{
 					Name:    "append",
@@ -888,6 +958,44 @@ func (s *Action) GetCommands() []*cli.Command {
 				},
 			},
 		},
+		{
+			Name:  "native-host",
+			Usage: "Native messaging host for browser extensions",
+			Description: "" +
+				"This command speaks the native messaging protocol of Chrome and Firefox " +
+				"on STDIN and STDOUT. It is started by the browser when an extension like " +
+				"gopassbridge needs a password. Use 'gopass native-host install' to register " +
+				"it with your browsers.",
+			Action: s.NativeHost,
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					// Chrome passes the parent window on Windows.
+					Name:   "parent-window",
+					Hidden: true,
+				},
+			},
+			Commands: []*cli.Command{
+				{
+					Name:  "install",
+					Usage: "Install the native messaging manifests",
+					Description: "" +
+						"This command installs the native messaging manifests and a wrapper " +
+						"script that starts gopass native-host. By default the manifests are " +
+						"installed for all supported browsers found in your home directory.",
+					Action: s.NativeHostInstall,
+					Flags: []cli.Flag{
+						&cli.StringSliceFlag{
+							Name:  "browser",
+							Usage: fmt.Sprintf("Install the manifest for this browser %v", nativehost.BrowserNames()),
+						},
+						&cli.BoolFlag{
+							Name:  "print",
+							Usage: "Print the manifests instead of installing them",
+						},
+					},
+				},
+			},
+		},
 		{
 			Name:      "otp",
 			Usage:     "Generate time- or hmac-based tokens",
@@ -925,7 +1033,133 @@ func (s *Action) GetCommands() []*cli.Command {
 					Aliases: []string{"o"},
 					Usage:   "Only display the token",
 				},
+				&cli.BoolFlag{
+					Name:  "resync",
+					Usage: "Resynchronize the HOTP counter from two consecutive tokens: otp --resync <secret> <token1> <token2>",
+				},
 			}, otp.SnipFlags()...),
+			Commands: []*cli.Command{
+				{
+					Name:      "add",
+					Usage:     "Add an OTP secret",
+					ArgsUsage: "<secret> [otpauth URL]",
+					Description: "" +
+						"Adds an otpauth:// URL to the otpauth key of the secret. The URL can be given as an " +
+						"argument or read from a QR code image. An otpauth-migration:// URL exported by " +
+						"Google Authenticator creates one secret per account below <secret>/<issuer>/<account>.",
+					Before:        s.IsInitialized,
+					Action:        s.OTPAdd,
+					ShellComplete: s.Complete,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:    "from-image",
+							Aliases: []string{"i"},
+							Usage:   "Read the URL from a QR code in a PNG or JPEG image",
+						},
+						&cli.BoolFlag{
+							Name:    "force",
+							Aliases: []string{"f"},
+							Usage:   "Overwrite existing OTP secrets",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:  "passkey",
+			Usage: "Manage WebAuthn credentials (passkeys)",
+			Description: "" +
+				"These commands create and use WebAuthn credentials stored as secrets " +
+				"below " + passkeyPrefix + "/<rp>/<user>.",
+			Before: s.IsInitialized,
+			Commands: []*cli.Command{
+				{
+					Name:      "create",
+					Usage:     "Create a new passkey",
+					ArgsUsage: "<rp> <user>",
+					Description: "" +
+						"This command creates a new credential for the given relying party " +
+						"and user and prints its ID and public key. The key algorithm is the first " +
+						"supported one of the given COSE algorithms (ES256, EdDSA or RS256).",
+					Before: s.IsInitialized,
+					Action: s.PasskeyCreate,
+					Flags: []cli.Flag{
+						&cli.StringSliceFlag{
+							Name:    "algorithm",
+							Aliases: []string{"a"},
+							Usage:   "Acceptable COSE algorithms in order of preference, by name or ID (e.g. EdDSA or -8). Default: ES256",
+						},
+						&cli.BoolFlag{
+							Name:  "user-verified",
+							Usage: "Set the user verified flag in assertions",
+							Value: true,
+						},
+						&cli.BoolFlag{
+							Name:    "force",
+							Aliases: []string{"f"},
+							Usage:   "Overwrite an existing passkey",
+						},
+					},
+				},
+				{
+					Name:      "assert",
+					Usage:     "Sign a WebAuthn challenge",
+					ArgsUsage: "<secret>",
+					Description: "" +
+						"This command signs the given challenge and prints the assertion as JSON. " +
+						"The signature counter is incremented and committed before the assertion is printed.",
+					Before:        s.IsInitialized,
+					Action:        s.PasskeyAssert,
+					ShellComplete: s.Complete,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "challenge",
+							Usage: "The base64url encoded challenge provided by the relying party",
+						},
+						&cli.StringFlag{
+							Name:  "origin",
+							Usage: "The origin of the relying party. Default: https://<rp>",
+						},
+					},
+				},
+				{
+					Name:  "list",
+					Usage: "List passkeys by relying party",
+					Description: "" +
+						"This command lists the user and signature counter of all passkeys, " +
+						"grouped by relying party.",
+					Before: s.IsInitialized,
+					Action: s.PasskeyList,
+				},
+				{
+					Name:      "export",
+					Usage:     "Export passkeys in the Credential Exchange Format",
+					ArgsUsage: "[<secret>...]",
+					Description: "" +
+						"This command prints the given passkeys, or all passkeys, as a FIDO Credential " +
+						"Exchange Format (CXF) JSON document. The output contains the unencrypted private keys.",
+					Before:        s.IsInitialized,
+					Action:        s.PasskeyExport,
+					ShellComplete: s.Complete,
+				},
+				{
+					Name:      "import",
+					Usage:     "Import passkeys from the Credential Exchange Format",
+					ArgsUsage: "<file|->",
+					Description: "" +
+						"This command reads a FIDO Credential Exchange Format (CXF) JSON document and stores " +
+						"each passkey in " + passkeyPrefix + "/<rp>/<user>. Existing passkeys are skipped.",
+					Before: s.IsInitialized,
+					Action: s.PasskeyImport,
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:    "force",
+							Aliases: []string{"f"},
+							Usage:   "Overwrite existing passkeys",
+						},
+					},
+				},
+			},
 		},
 		{
 			Name:  "process",
@@ -1162,6 +1396,105 @@ func (s *Action) GetCommands() []*cli.Command {
 				},
 			},
 		},
+		{
+			Name:      "render",
+			Usage:     "Render templates or secrets into files for deployments",
+			ArgsUsage: "[template|k8s-secret|docker-env]",
+			Description: "" +
+				"Without a subcommand this command renders a template with secrets. With --output the result " +
+				"is written atomically to a file with permissions 0600, --watch renders it again whenever the " +
+				"store changes, e.g. after a sync, and --diff shows the line numbers of the changes. " +
+				"Rendering fails if a secret used by the template does not exist. " +
+				"The subcommands render a secret or all secrets in a folder into files used by deployments. " +
+				"The password of every secret is stored under the name of the secret, every key of a secret " +
+				"under its own name. Instead of the built-in format a template can be used. Templates can " +
+				"only access the secrets in the folder. With --check the output is compared with an existing " +
+				"file and only the names of changed keys are shown.",
+			Before: s.IsInitialized,
+			Action: s.Render,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Usage:   "Write the result atomically to this file",
+				},
+				&cli.StringSliceFlag{
+					Name:    "allow-path",
+					Aliases: []string{"p"},
+					Usage:   "Restrict template secret access to the given path prefix (repeatable). If omitted all secrets in the store are accessible.",
+				},
+				&cli.BoolFlag{
+					Name:  "diff",
+					Usage: "Show the numbers of the changed lines of the output file instead of writing it",
+				},
+				&cli.BoolFlag{
+					Name:  "watch",
+					Usage: "Render again whenever the store or the template changes",
+				},
+				&cli.IntFlag{
+					Name:  "interval",
+					Usage: "Seconds between checks for changes in watch mode",
+					Value: 10,
+				},
+			},
+			Commands: []*cli.Command{
+				{
+					Name:      "k8s-secret",
+					Usage:     "Render a Kubernetes Secret manifest",
+					ArgsUsage: "[folder]",
+					Description: "" +
+						"This command prints a Kubernetes Secret manifest with the base64 encoded values of the " +
+						"secrets in the folder. The name of the Secret defaults to the name of the folder.",
+					Before:        s.IsInitialized,
+					Action:        s.RenderK8sSecret,
+					ShellComplete: s.Complete,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "name",
+							Usage: "Name of the Secret",
+						},
+						&cli.StringFlag{
+							Name:  "namespace",
+							Usage: "Namespace of the Secret",
+						},
+						&cli.StringFlag{
+							Name:  "template",
+							Usage: "Render this template file instead of the built-in manifest",
+						},
+						&cli.StringFlag{
+							Name:  "check",
+							Usage: "Compare with this manifest instead of printing it. Fails if it is out of date",
+						},
+					},
+				},
+				{
+					Name:      "docker-env",
+					Usage:     "Render an env file for Docker",
+					ArgsUsage: "[folder]",
+					Description: "" +
+						"This command prints an env file for docker run --env-file and docker compose with the " +
+						"values of the secrets in the folder. The variable names are upper cased unless " +
+						"--keep-case is given. Values can not span multiple lines.",
+					Before:        s.IsInitialized,
+					Action:        s.RenderDockerEnv,
+					ShellComplete: s.Complete,
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "keep-case",
+							Usage: "Do not upper case the variable names",
+						},
+						&cli.StringFlag{
+							Name:  "template",
+							Usage: "Render this template file instead of the built-in env file",
+						},
+						&cli.StringFlag{
+							Name:  "check",
+							Usage: "Compare with this env file instead of printing it. Fails if it is out of date",
+						},
+					},
+				},
+			},
+		},
 		{
 			Name:      "reorg",
 			Usage:     "Reorganize a password store by editing a text file",
@@ -1174,6 +1507,110 @@ func (s *Action) GetCommands() []*cli.Command {
 			Action:        s.Reorg,
 			ShellComplete: s.Complete,
 		},
+		{
+			Name:      "rotate",
+			Usage:     "Change the password of a secret",
+			ArgsUsage: "[--confirm|--abort] <secret>",
+			Description: "" +
+				"Generates a new password that satisfies the password rules of the domain and the password policy and stores it " +
+				"in the password-pending key next to the current password. The URL to change the password " +
+				"is printed or opened. Once the password was changed on the website --confirm replaces the " +
+				"current password with the new one, --abort discards the new password and keeps the current one.",
+			Before:        s.IsInitialized,
+			Action:        s.Rotate,
+			ShellComplete: s.Complete,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:    "clip",
+					Aliases: []string{"c"},
+					Usage:   "Copy the new password to the clipboard",
+				},
+				&cli.BoolFlag{
+					Name:    "print",
+					Aliases: []string{"p"},
+					Usage:   "Print the new password to the terminal",
+				},
+				&cli.BoolFlag{
+					Name:    "open",
+					Aliases: []string{"o"},
+					Usage:   "Open the URL to change the password in the browser",
+				},
+				&cli.BoolFlag{
+					Name:    "force",
+					Aliases: []string{"f"},
+					Usage:   "Replace a pending new password or override the password policy",
+				},
+				&cli.BoolFlag{
+					Name:  "confirm",
+					Usage: "Replace the current password with the new one",
+				},
+				&cli.BoolFlag{
+					Name:  "abort",
+					Usage: "Discard the new password and keep the current one",
+				},
+			},
+		},
+		{
+			Name:  "serve",
+			Usage: "Serve the store on a local socket",
+			Description: "" +
+				"This command serves an HTTP API on a Unix socket that is only accessible " +
+				"by the current user. Tools can list, read, write, remove and rename " +
+				"secrets without starting gopass for every request. Every request needs " +
+				"a token created with 'gopass serve token add'. A token can be limited " +
+				"to some folders. The server stops after serve.idle-timeout seconds " +
+				"without requests.",
+			Action: s.Serve,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "socket",
+					Usage: "Path of the Unix socket",
+				},
+			},
+			Commands: []*cli.Command{
+				{
+					Name:      "token",
+					Usage:     "Manage API tokens",
+					ArgsUsage: "[add|list|remove]",
+					Description: "" +
+						"These commands manage the tokens accepted by gopass serve. " +
+						"A running server only picks up changes when it is restarted.",
+					Commands: []*cli.Command{
+						{
+							Name:      "add",
+							Usage:     "Create a new token",
+							ArgsUsage: "[name]",
+							Description: "" +
+								"This command creates a new token and prints it. Only a hash of the " +
+								"token is stored, so it can not be shown again.",
+							Action: s.ServeTokenAdd,
+							Flags: []cli.Flag{
+								&cli.StringSliceFlag{
+									Name:  "prefix",
+									Usage: "Limit the token to this folder. Can be given multiple times. Defaults to the whole store",
+								},
+							},
+						},
+						{
+							Name:  "list",
+							Usage: "List all tokens",
+							Description: "" +
+								"This command lists the names and folders of all tokens.",
+							Action: s.ServeTokenList,
+						},
+						{
+							Name:      "remove",
+							Aliases:   []string{"rm"},
+							Usage:     "Remove a token",
+							ArgsUsage: "[name]",
+							Description: "" +
+								"This command removes a token. It can not be used anymore.",
+							Action: s.ServeTokenRemove,
+						},
+					},
+				},
+			},
+		},
 		{
 			Name:  "setup",
 			Usage: "Initialize a new password store",
@@ -1219,7 +1656,10 @@ func (s *Action) GetCommands() []*cli.Command {
 			ArgsUsage: "[secret]",
 			Description: "" +
 				"Show an existing secret and optionally put its first line on the clipboard. " +
-				"If put on the clipboard, it will be cleared after 45 seconds.",
+				"If put on the clipboard, it will be cleared after 45 seconds. " +
+				"Use --type to type it into the focused window instead, e.g. for consoles " +
+				"that do not support pasting. If the secret has an autotype key, like " +
+				"'{username}{TAB}{password}{ENTER}', this sequence is typed.",
 			Before:        s.IsInitialized,
 			Action:        s.Show,
 			ShellComplete: s.Complete,
@@ -1292,6 +1732,19 @@ func (s *Action) GetCommands() []*cli.Command {
 				},
 			},
 		},
+		{
+			Name:  "tui",
+			Usage: "Browse the store in a full-screen terminal UI",
+			Description: "" +
+				"Shows the folder tree of the store next to a preview of the selected secret. " +
+				"The preview masks the password and other sensitive values like show.safecontent does. " +
+				"Press / to filter the secrets incrementally with fuzzy matching, c, u or o to copy the password, " +
+				"username or current OTP to the clipboard, e to edit, m to move, d to delete and ? for help. " +
+				"The clipboard is cleared after core.cliptimeout seconds. " +
+				"The UI locks itself and drops cached credentials after tui.idle-timeout seconds without input.",
+			Before: s.IsInitialized,
+			Action: s.TUI,
+		},
 		{
 			Name:        "unclip",
 			Usage:       "Internal command to clear clipboard",
@@ -1307,6 +1760,10 @@ func (s *Action) GetCommands() []*cli.Command {
 					Name:  "force",
 					Usage: "Clear clipboard even if checksum mismatches",
 				},
+				&cli.BoolFlag{
+					Name:  "serve",
+					Usage: "Own the clipboard with the content from STDIN until the timeout expired",
+				},
 			},
 		},
 		{
@@ -53,7 +53,8 @@ generate.autoclip = true
 `
 		want += "mounts.path = " + fsutil.ShrinkPath(u.StoreDir("")) + "\n" +
 			"pwgen.xkcd-lang = en\n" +
-			"show.fuzzysearch = true\n"
+			"show.fuzzysearch = true\n" +
+			"tui.idle-timeout = 300\n"
 		assert.Equal(t, want, buf.String())
 	})
 
@@ -100,7 +101,8 @@ generate.autoclip = true
 `
 		want += "mounts.path = " + fsutil.ShrinkPath(u.StoreDir("")) + "\n" +
 			"pwgen.xkcd-lang = en\n" +
-			"show.fuzzysearch = true\n"
+			"show.fuzzysearch = true\n" +
+			"tui.idle-timeout = 300\n"
 
 		assert.Equal(t, want, buf.String(), "action.printConfigValues")
 	})
@@ -140,6 +142,7 @@ generate.autoclip
 mounts.path
 pwgen.xkcd-lang
 show.fuzzysearch
+tui.idle-timeout
 `
 		assert.Equal(t, want, buf.String())
 	})
@@ -15,6 +15,7 @@ const (
 	ctxKeyPrintChars
 	ctxKeyWithQRBody
 	ctxKeyClipLine
+	ctxKeyAutotype
 )
 
 // WithClipLine returns a context with the clip line number set.
@@ -183,3 +184,19 @@ func IsQRBody(ctx context.Context) bool {
 
 	return bv
 }
+
+// WithAutotype returns the context with the value of autotype (type the
+// secret as keystrokes) set.
+func WithAutotype(ctx context.Context, at bool) context.Context {
+	return context.WithValue(ctx, ctxKeyAutotype, at)
+}
+
+// IsAutotype returns the value of autotype or the default (false).
+func IsAutotype(ctx context.Context) bool {
+	bv, ok := ctx.Value(ctxKeyAutotype).(bool)
+	if !ok {
+		return false
+	}
+
+	return bv
+}
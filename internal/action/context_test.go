@@ -75,3 +75,10 @@ func TestWithClipLine(t *testing.T) {
 	assert.Equal(t, 0, GetClipLine(WithClipLine(ctx, 0)))
 	assert.Equal(t, 2, GetClipLine(WithClipLine(ctx, 2)))
 }
+
+func TestWithAutotype(t *testing.T) {
+	ctx := config.NewContextInMemory()
+
+	assert.False(t, IsAutotype(ctx))
+	assert.True(t, IsAutotype(WithAutotype(ctx, true)))
+}
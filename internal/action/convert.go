@@ -2,7 +2,9 @@ package action
 
 import (
 	"context"
+	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/gopasspw/gopass/internal/action/exit"
 	"github.com/gopasspw/gopass/internal/backend"
 	"github.com/gopasspw/gopass/internal/backend/crypto/age"
@@ -66,6 +68,10 @@ func (s *miscHandler) Convert(ctx context.Context, cmd *cli.Command) error {
 		return nil
 	}
 
+	if cmd.Bool("dry-run") {
+		return s.convertEstimate(ctx, store, crypto, storage)
+	}
+
 	if oldCrypto != crypto.String() {
 		debug.Log("attempting to convert crypto from %q to %q", oldCrypto, crypto.String())
 
@@ -99,3 +105,21 @@ func (s *miscHandler) Convert(ctx context.Context, cmd *cli.Command) error {
 
 	return nil
 }
+
+// convertEstimate prints an estimate of the conversion without modifying
+// the store.
+func (s *miscHandler) convertEstimate(ctx context.Context, store string, crypto backend.CryptoBackend, storage backend.StorageBackend) error {
+	est, err := s.Store.EstimateConvert(ctx, store, crypto, storage)
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "failed to estimate conversion of %q: %s", store, err)
+	}
+
+	out.Printf(ctx, "Converting %q to crypto %q and storage %q would copy %d entries with about %d revisions.", store, crypto, storage, est.Entries, est.Revisions)
+	if est.Done > 0 {
+		out.Printf(ctx, "%d entries were already converted by a previous attempt and will be skipped.", est.Done)
+	}
+	out.Printf(ctx, "Estimated size: %s", humanize.Bytes(uint64(est.Size)))
+	out.Printf(ctx, "Estimated time: %s", est.Duration.Round(time.Second))
+
+	return nil
+}
@@ -6,11 +6,13 @@ import (
 	"testing"
 
 	"github.com/fatih/color"
+	"github.com/gopasspw/gopass/internal/backend/storage/sqlitefs"
 	"github.com/gopasspw/gopass/internal/config"
 	"github.com/gopasspw/gopass/internal/out"
 	"github.com/gopasspw/gopass/pkg/ctxutil"
 	"github.com/gopasspw/gopass/pkg/gopass/secrets"
 	"github.com/gopasspw/gopass/tests/gptest"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -51,3 +53,80 @@ func TestConvert(t *testing.T) {
 	})))
 	// TODO: validate converted store. t.Logf("Buffer: %s", buf.String()).
 }
+
+func TestConvertSQLite(t *testing.T) {
+	u := gptest.NewUnitTester(t)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+	ctx = ctxutil.WithTerminal(ctx, false)
+	ctx = ctxutil.WithInteractive(ctx, false)
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	require.NotNil(t, act)
+	ctx = act.cfg.WithConfig(ctx)
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	stdout = buf
+	defer func() {
+		stdout = os.Stdout
+		out.Stdout = os.Stdout
+	}()
+
+	sec := secrets.NewAKV()
+	sec.SetPassword("123")
+	require.NoError(t, act.Store.Set(ctx, "bar/baz", sec))
+
+	require.NoError(t, act.Convert(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{
+		"move":    "true",
+		"storage": "sqlitefs",
+	})))
+
+	st, err := sqlitefs.New(u.StoreDir(""))
+	require.NoError(t, err)
+	assert.Equal(t, "sqlitefs", st.Name())
+
+	entries, err := st.List(ctx, "")
+	require.NoError(t, err)
+	assert.Contains(t, entries, "bar/baz.txt")
+
+	revs, err := st.Revisions(ctx, "bar/baz.txt")
+	require.NoError(t, err)
+	assert.NotEmpty(t, revs)
+}
+
+func TestConvertDryRun(t *testing.T) {
+	u := gptest.NewUnitTester(t)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+	ctx = ctxutil.WithTerminal(ctx, false)
+	ctx = ctxutil.WithInteractive(ctx, false)
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	require.NotNil(t, act)
+	ctx = act.cfg.WithConfig(ctx)
+
+	color.NoColor = true
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	stdout = buf
+	defer func() {
+		stdout = os.Stdout
+		out.Stdout = os.Stdout
+	}()
+
+	require.NoError(t, act.Convert(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{
+		"storage": "gitfs",
+		"dry-run": "true",
+	})))
+	assert.Contains(t, buf.String(), "would copy 1 entries")
+	assert.Contains(t, buf.String(), "Estimated time:")
+
+	// nothing was converted
+	_, err = os.Stat(u.StoreDir("") + "-autoconvert")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
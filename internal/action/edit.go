@@ -66,6 +66,7 @@ func (s *secretHandler) edit(ctx context.Context, cmd *cli.Command, name string)
 		commitMsg = ""
 	}
 	ctx = ctxutil.WithCommitMessage(ctx, commitMsg)
+	ctx = ctxutil.WithForce(ctx, cmd.Bool("force"))
 
 	return s.editUpdate(ctx, name, content, newContent, changed, ed)
 }
@@ -81,6 +82,15 @@ func (s *secretHandler) editUpdate(ctx context.Context, name string, content, nC
 	// if the secret has a password, we check its strength.
 	if pw := nSec.Password(); pw != "" {
 		audit.Single(ctx, pw)
+
+		// only a changed password is checked against the policy.
+		if pw != secrets.ParseAKV(content).Password() {
+			var err error
+			ctx, err = s.enforcePolicy(ctx, name, pw)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	// write result (back) to store.
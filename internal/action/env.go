@@ -19,16 +19,23 @@ import (
 
 // Env implements the env subcommand. It populates the environment of a subprocess with
 // a set of environment variables corresponding to the secret subtree specified on the
-// command line.
+// command line or to the variables of a mapping file.
 func (s *envHandler) Env(ctx context.Context, cmd *cli.Command) error {
 	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
 	name := cmd.Args().First()
 	args := cmd.Args().Tail()
+	mapFile := cmd.String("map")
 	keepCase := cmd.Bool("keep-case")
 	useStdin := cmd.Bool("stdin")
 	useFile := cmd.Bool("file")
 	useExec := cmd.Bool("exec")
 
+	// with a mapping file all arguments are the command.
+	if mapFile != "" {
+		name = ""
+		args = cmd.Args().Slice()
+	}
+
 	if len(args) == 0 {
 		return exit.Error(exit.Usage, nil, "Missing subcommand to execute")
 	}
@@ -49,24 +56,70 @@ func (s *envHandler) Env(ctx context.Context, cmd *cli.Command) error {
 		return exit.Error(exit.Usage, nil, "Only one of --stdin, --file or --exec may be specified")
 	}
 
-	if !s.Store.Exists(ctx, name) && !s.Store.IsDir(ctx, name) {
-		return exit.Error(exit.NotFound, nil, "Secret %s not found", name)
-	}
-
-	keys, err := s.envKeys(ctx, name)
+	vars, err := s.envResolve(ctx, name, mapFile, keepCase, useStdin)
 	if err != nil {
 		return err
 	}
 
 	if useStdin {
-		return s.envRunStdin(ctx, name, keys, args)
+		if len(vars) != 1 {
+			return exit.Error(exit.Usage, nil, "--stdin requires a single variable in the mapping file")
+		}
+
+		return s.envRunStdin(ctx, vars[0], args)
 	}
 
 	if useFile {
-		return s.envRunFile(ctx, name, keys, args, keepCase)
+		return s.envRunFile(ctx, vars, args)
 	}
 
-	return s.envRunDefault(ctx, name, keys, args, keepCase, useExec)
+	return s.envRunDefault(ctx, vars, args, useExec)
+}
+
+// envVar is an environment variable with the value of a secret.
+type envVar struct {
+	name  string
+	value string
+}
+
+// envResolve decrypts all secrets before anything is run, so a missing
+// secret never starts the subprocess with a partial environment.
+func (s *envHandler) envResolve(ctx context.Context, name, mapFile string, keepCase, useStdin bool) ([]envVar, error) {
+	if mapFile != "" {
+		return s.envMapVars(ctx, mapFile)
+	}
+
+	if !s.Store.Exists(ctx, name) && !s.Store.IsDir(ctx, name) {
+		return nil, exit.Error(exit.NotFound, nil, "Secret %s not found", name)
+	}
+
+	if useStdin && s.Store.IsDir(ctx, name) {
+		return nil, exit.Error(exit.Usage, nil, "--stdin requires a single secret, not a directory")
+	}
+
+	keys, err := s.envKeys(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make([]envVar, 0, len(keys))
+	for _, key := range keys {
+		debug.Log("exporting to environment key: %s", key)
+
+		sec, err := s.Store.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get entry for env prefix %q: %w", name, err)
+		}
+
+		envKey := path.Base(key)
+		if !keepCase {
+			envKey = strings.ToUpper(envKey)
+		}
+
+		vars = append(vars, envVar{name: envKey, value: sec.Password()})
+	}
+
+	return vars, nil
 }
 
 // envKeys resolves the set of store paths to operate on for name. If name is a
@@ -92,42 +145,33 @@ func (s *envHandler) envKeys(ctx context.Context, name string) ([]string, error)
 	return subtree.List(tree.INF), nil
 }
 
-// envRunStdin runs args with the secret's password written to the subprocess's
-// stdin. No environment variable is set. Only valid for a single secret.
-func (s *envHandler) envRunStdin(ctx context.Context, name string, keys []string, args []string) error {
-	if s.Store.IsDir(ctx, name) {
-		return exit.Error(exit.Usage, nil, "--stdin requires a single secret, not a directory")
-	}
-
-	sec, err := s.Store.Get(ctx, keys[0])
-	if err != nil {
-		return fmt.Errorf("failed to get entry %q: %w", name, err)
-	}
-
+// envRunStdin runs args with the value written to the subprocess's stdin. No
+// environment variable is set.
+func (s *envHandler) envRunStdin(ctx context.Context, v envVar, args []string) error {
 	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
 	cmd.Env = os.Environ()
-	cmd.Stdin = strings.NewReader(sec.Password())
+	cmd.Stdin = strings.NewReader(v.value)
 	cmd.Stdout = stdout
 	cmd.Stderr = os.Stderr
 
 	return cmd.Run()
 }
 
-// envRunFile writes each secret to a ramdisk temp file and exports
+// envRunFile writes each value to a ramdisk temp file and exports
 // KEY_FILE=/path/to/file in the subprocess environment. All temp files are
 // removed when the subprocess exits.
-func (s *envHandler) envRunFile(ctx context.Context, name string, keys []string, args []string, keepCase bool) error {
-	tfs := make([]*tempfile.File, 0, len(keys))
+func (s *envHandler) envRunFile(ctx context.Context, vars []envVar, args []string) error {
+	tfs := make([]*tempfile.File, 0, len(vars))
 	defer func() {
 		for _, tf := range tfs {
 			_ = tf.Remove(ctx)
 		}
 	}()
 
-	fileEnv := make([]string, 0, len(keys))
+	fileEnv := make([]string, 0, len(vars))
 
-	for _, key := range keys {
-		envEntry, tf, err := s.envWriteTempFile(ctx, name, key, keepCase)
+	for _, v := range vars {
+		envEntry, tf, err := s.envWriteTempFile(ctx, v)
 		if err != nil {
 			return err
 		}
@@ -145,59 +189,36 @@ func (s *envHandler) envRunFile(ctx context.Context, name string, keys []string,
 	return cmd.Run()
 }
 
-// envWriteTempFile writes a single secret to a ramdisk temp file and returns
+// envWriteTempFile writes a single value to a ramdisk temp file and returns
 // the "KEY_FILE=path" env string and the open file handle for later cleanup.
-func (s *envHandler) envWriteTempFile(ctx context.Context, name, key string, keepCase bool) (string, *tempfile.File, error) {
-	sec, err := s.Store.Get(ctx, key)
-	if err != nil {
-		return "", nil, fmt.Errorf("failed to get entry for env prefix %q: %w", name, err)
-	}
-
+func (s *envHandler) envWriteTempFile(ctx context.Context, v envVar) (string, *tempfile.File, error) {
 	tf, err := tempfile.New(ctx, "gopass-env-")
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to create temp file for %q: %w", key, err)
+		return "", nil, fmt.Errorf("failed to create temp file for %q: %w", v.name, err)
 	}
 
-	if _, err := fmt.Fprint(tf, sec.Password()); err != nil {
+	if _, err := fmt.Fprint(tf, v.value); err != nil {
 		_ = tf.Remove(ctx)
 
-		return "", nil, fmt.Errorf("failed to write temp file for %q: %w", key, err)
+		return "", nil, fmt.Errorf("failed to write temp file for %q: %w", v.name, err)
 	}
 
 	if err := tf.Close(); err != nil {
 		_ = tf.Remove(ctx)
 
-		return "", nil, fmt.Errorf("failed to close temp file for %q: %w", key, err)
+		return "", nil, fmt.Errorf("failed to close temp file for %q: %w", v.name, err)
 	}
 
-	envKey := path.Base(key)
-	if !keepCase {
-		envKey = strings.ToUpper(envKey)
-	}
-
-	return fmt.Sprintf("%s_FILE=%s", envKey, tf.Name()), tf, nil
+	return fmt.Sprintf("%s_FILE=%s", v.name, tf.Name()), tf, nil
 }
 
 // envRunDefault runs args as a child process (or replaces the current process
-// when useExec is true) with the resolved secrets injected as KEY=value
-// environment variables.
-func (s *envHandler) envRunDefault(ctx context.Context, name string, keys []string, args []string, keepCase, useExec bool) error {
-	env := make([]string, 0, len(keys))
-
-	for _, key := range keys {
-		debug.Log("exporting to environment key: %s", key)
-
-		sec, err := s.Store.Get(ctx, key)
-		if err != nil {
-			return fmt.Errorf("failed to get entry for env prefix %q: %w", name, err)
-		}
-
-		envKey := path.Base(key)
-		if !keepCase {
-			envKey = strings.ToUpper(envKey)
-		}
-
-		env = append(env, fmt.Sprintf("%s=%s", envKey, sec.Password()))
+// when useExec is true) with the values injected as KEY=value environment
+// variables.
+func (s *envHandler) envRunDefault(ctx context.Context, vars []envVar, args []string, useExec bool) error {
+	env := make([]string, 0, len(vars))
+	for _, v := range vars {
+		env = append(env, fmt.Sprintf("%s=%s", v.name, v.value))
 	}
 
 	out.Warningf(ctx, "Secret values are being injected into the subprocess environment (visible in /proc/<pid>/environ on Linux, ps eww on macOS). Use --stdin or --file to avoid this.")
@@ -0,0 +1,144 @@
+package action
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gopasspw/gopass/internal/action/exit"
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+// reEnvName matches valid names of environment variables.
+var reEnvName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// envMapping maps an environment variable to a secret and optionally to a
+// key of it.
+type envMapping struct {
+	name   string
+	secret string
+	key    string
+	line   int
+}
+
+// parseEnvMap parses a mapping file. Every line has the form
+// NAME=secret[:key], optionally prefixed with export. Empty lines and lines
+// starting with # are skipped.
+func parseEnvMap(buf []byte) ([]envMapping, error) {
+	var mappings []envMapping
+	seen := map[string]int{}
+
+	sc := bufio.NewScanner(bytes.NewReader(buf))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		name, ref, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected NAME=secret[:key]", n)
+		}
+
+		name = strings.TrimSpace(name)
+		if !reEnvName.MatchString(name) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", n, name)
+		}
+		if other, found := seen[name]; found {
+			return nil, fmt.Errorf("line %d: %s is already defined in line %d", n, name, other)
+		}
+		seen[name] = n
+
+		ref = unquote(strings.TrimSpace(ref))
+		m := envMapping{name: name, secret: ref, line: n}
+		if i := strings.LastIndex(ref, ":"); i >= 0 {
+			m.secret, m.key = ref[:i], ref[i+1:]
+			if m.key == "" {
+				return nil, fmt.Errorf("line %d: missing key after ':' for %s", n, name)
+			}
+		}
+		if m.secret == "" {
+			return nil, fmt.Errorf("line %d: missing secret for %s", n, name)
+		}
+
+		mappings = append(mappings, m)
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return mappings, nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+
+	return s
+}
+
+// envMapVars resolves all variables of a mapping file. It fails if any
+// secret or key is missing. Every secret is only decrypted once.
+func (s *envHandler) envMapVars(ctx context.Context, file string) ([]envVar, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, exit.Error(exit.IO, err, "Failed to read mapping file %s: %s", file, err)
+	}
+
+	mappings, err := parseEnvMap(buf)
+	if err != nil {
+		return nil, exit.Error(exit.Usage, err, "Invalid mapping file %s: %s", file, err)
+	}
+	if len(mappings) < 1 {
+		return nil, exit.Error(exit.Usage, nil, "Mapping file %s is empty", file)
+	}
+
+	secs := map[string]gopass.Secret{}
+	vars := make([]envVar, 0, len(mappings))
+	for _, m := range mappings {
+		sec, found := secs[m.secret]
+		if !found {
+			if !s.Store.Exists(ctx, m.secret) {
+				return nil, exit.Error(exit.NotFound, nil, "Secret %s for %s (line %d) not found", m.secret, m.name, m.line)
+			}
+
+			sec, err = s.Store.Get(ctx, m.secret)
+			if err != nil {
+				return nil, exit.Error(exit.Decrypt, err, "Failed to decrypt %s for %s: %s", m.secret, m.name, err)
+			}
+			secs[m.secret] = sec
+		}
+
+		value, found := envMapValue(sec, m.key)
+		if !found {
+			return nil, exit.Error(exit.NotFound, nil, "Key %q of %s for %s (line %d) not found", m.key, m.secret, m.name, m.line)
+		}
+
+		vars = append(vars, envVar{name: m.name, value: value})
+	}
+
+	return vars, nil
+}
+
+// envMapValue returns the password for an empty key or the key password,
+// otherwise all values of the key joined by newlines.
+func envMapValue(sec gopass.Secret, key string) (string, bool) {
+	if key == "" || key == "password" {
+		return sec.Password(), true
+	}
+
+	values, found := sec.Values(key)
+	if !found {
+		return "", false
+	}
+
+	return strings.Join(values, "\n"), true
+}
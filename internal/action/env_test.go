@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 
@@ -322,3 +323,111 @@ func TestEnvDefaultModeUnchanged(t *testing.T) {
 	require.NoError(t, act.Env(ctx, gptest.CliCtx(ctx, t, "baz", "env")))
 	assert.Contains(t, buf.String(), fmt.Sprintf("BAZ=%s\n", pw))
 }
+
+func TestParseEnvMap(t *testing.T) {
+	t.Parallel()
+
+	mappings, err := parseEnvMap([]byte(`# database
+DB_PASSWORD=prod/db:password
+export DB_USER = prod/db:user
+API_TOKEN="services/x"
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []envMapping{
+		{name: "DB_PASSWORD", secret: "prod/db", key: "password", line: 2},
+		{name: "DB_USER", secret: "prod/db", key: "user", line: 3},
+		{name: "API_TOKEN", secret: "services/x", line: 4},
+	}, mappings)
+
+	for _, tc := range []struct {
+		in  string
+		err string
+	}{
+		{in: "DB_PASSWORD", err: "line 1: expected NAME=secret[:key]"},
+		{in: "1DB=prod/db", err: `line 1: invalid variable name "1DB"`},
+		{in: "DB=prod/db:", err: "line 1: missing key after ':' for DB"},
+		{in: "DB=", err: "line 1: missing secret for DB"},
+		{in: "DB=prod/db\nDB=prod/other", err: "line 2: DB is already defined in line 1"},
+	} {
+		_, err := parseEnvMap([]byte(tc.in))
+		require.EqualError(t, err, tc.err, tc.in)
+	}
+}
+
+func TestEnvMap(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires unix env utility")
+	}
+
+	u := gptest.NewUnitTester(t)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+	ctx = ctxutil.WithTerminal(ctx, false)
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	require.NotNil(t, act)
+	ctx = act.cfg.WithConfig(ctx)
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	out.Stderr = buf
+	stdout = buf
+	defer func() {
+		out.Stdout = os.Stdout
+		out.Stderr = os.Stderr
+		stdout = os.Stdout
+	}()
+
+	require.NoError(t, act.insertStdin(ctx, "prod/db", []byte("dbpw\nuser: app"), false))
+	require.NoError(t, act.insertStdin(ctx, "services/x", []byte("ignored\ntoken: tok"), false))
+	buf.Reset()
+
+	mapFile := filepath.Join(t.TempDir(), ".gopass-env")
+	require.NoError(t, os.WriteFile(mapFile, []byte("DB_PASSWORD=prod/db:password\nDB_USER=prod/db:user\nAPI_TOKEN=services/x:token\n"), 0o600))
+
+	t.Run("env", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.NoError(t, act.Env(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"map": mapFile}, "env")))
+		assert.Contains(t, buf.String(), "DB_PASSWORD=dbpw\n")
+		assert.Contains(t, buf.String(), "DB_USER=app\n")
+		assert.Contains(t, buf.String(), "API_TOKEN=tok\n")
+	})
+
+	t.Run("file", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.NoError(t, act.Env(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"map": mapFile, "file": "true"}, "env")))
+		assert.Contains(t, buf.String(), "API_TOKEN_FILE=")
+		assert.NotContains(t, buf.String(), "tok\n")
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.EqualError(t, act.Env(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"map": mapFile, "stdin": "true"}, "cat")),
+			"--stdin requires a single variable in the mapping file")
+
+		single := filepath.Join(t.TempDir(), "single")
+		require.NoError(t, os.WriteFile(single, []byte("API_TOKEN=services/x:token\n"), 0o600))
+		require.NoError(t, act.Env(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"map": single, "stdin": "true"}, "cat")))
+		assert.Equal(t, "tok", buf.String())
+	})
+
+	t.Run("fail closed", func(t *testing.T) {
+		defer buf.Reset()
+
+		missing := filepath.Join(t.TempDir(), "missing")
+		require.NoError(t, os.WriteFile(missing, []byte("DB_USER=prod/db:user\nAPI_KEY=services/x:key\n"), 0o600))
+		require.EqualError(t, act.Env(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"map": missing}, "env")),
+			`Key "key" of services/x for API_KEY (line 2) not found`)
+
+		require.NoError(t, os.WriteFile(missing, []byte("DB_USER=prod/db:user\nOTHER=prod/other\n"), 0o600))
+		require.EqualError(t, act.Env(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"map": missing}, "env")),
+			"Secret prod/other for OTHER (line 2) not found")
+
+		// the command never ran.
+		assert.NotContains(t, buf.String(), "DB_USER=")
+	})
+}
@@ -58,6 +58,8 @@ const (
 	Hook = 20
 	// Doctor is used when the doctor command finds failing checks.
 	Doctor = 21
+	// Policy is used when a password violates the password policy of the store.
+	Policy = 22
 )
 
 // exitCodeDescriptions lists every defined exit code together with a short
@@ -89,6 +91,7 @@ var exitCodeDescriptions = []struct {
 	{GPG, "GPG", "Miscellaneous GPG error (reserved)"},
 	{Hook, "Hook", "Hook execution failed"},
 	{Doctor, "Doctor", "Doctor found one or more failing checks"},
+	{Policy, "Policy", "Password violates the password policy of the store"},
 }
 
 // PrintExitCodes writes a human-readable table of all defined exit codes to w.
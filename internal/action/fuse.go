@@ -0,0 +1,80 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/action/exit"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/internal/secretfs"
+	"github.com/gopasspw/gopass/internal/tree"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/urfave/cli/v3"
+)
+
+// FuseMount mounts the store read-only on a directory until the context is
+// canceled or the file system is unmounted.
+func (s *fuseHandler) FuseMount(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+	ctx = ctxutil.WithInteractive(ctx, false)
+
+	dir := cmd.Args().First()
+	if dir == "" {
+		return exit.Error(exit.Usage, nil, "Usage: %s fuse mount [--prefix <folder>] <dir>", s.Name)
+	}
+
+	timeout := cmd.Int("cache-timeout")
+	if timeout < 0 {
+		return exit.Error(exit.Usage, nil, "--cache-timeout must not be negative")
+	}
+
+	srv, err := secretfs.Mount(ctx, dir, &fuseBackend{h: s}, secretfs.Options{
+		Prefix:       cmd.String("prefix"),
+		Keys:         cmd.Bool("keys"),
+		CacheTimeout: time.Duration(timeout) * time.Second,
+		Allow:        cmd.StringSlice("allow"),
+	})
+	if err != nil {
+		if errors.Is(err, secretfs.ErrUnsupported) {
+			return exit.Error(exit.Unknown, err, "%s", err)
+		}
+
+		return exit.Error(exit.IO, err, "%s", err)
+	}
+
+	out.Printf(ctx, "Mounted on %s. Press Ctrl+C to unmount", dir)
+
+	done := make(chan struct{})
+	go func() {
+		srv.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		if err := srv.Unmount(); err != nil {
+			return exit.Error(exit.IO, err, "Failed to unmount %s: %s", dir, err)
+		}
+		<-done
+	}
+
+	out.Printf(ctx, "Unmounted %s", dir)
+
+	return nil
+}
+
+// fuseBackend provides the secrets of the root store to the file system.
+type fuseBackend struct {
+	h *fuseHandler
+}
+
+func (b *fuseBackend) List(ctx context.Context) ([]string, error) {
+	return b.h.Store.List(ctx, tree.INF)
+}
+
+func (b *fuseBackend) Get(ctx context.Context, name string) (gopass.Secret, error) {
+	return b.h.Store.Get(ctx, name)
+}
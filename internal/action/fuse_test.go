@@ -0,0 +1,86 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/tests/gptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuseMount(t *testing.T) {
+	u := gptest.NewUnitTester(t)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	ctx = act.cfg.WithConfig(ctx)
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	defer func() {
+		out.Stdout = os.Stdout
+	}()
+
+	require.Error(t, act.FuseMount(ctx, gptest.CliCtx(ctx, t)))
+	require.Error(t, act.FuseMount(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"cache-timeout": "-1"}, t.TempDir())))
+
+	if runtime.GOOS != "linux" {
+		t.Skip("mounting is only tested on Linux")
+	}
+	if _, err := os.Stat("/dev/fuse"); err != nil {
+		t.Skip("FUSE is not available")
+	}
+
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- act.FuseMount(ctx, gptest.CliCtx(ctx, t, dir))
+	}()
+
+	// the file is read without the runtime poller. Registering it with epoll
+	// makes the kernel ask this process, which deadlocks if it happens before
+	// the mount is fully set up.
+	var content []byte
+	var mountErr error
+	require.Eventually(t, func() bool {
+		select {
+		case mountErr = <-done:
+			return true
+		default:
+		}
+
+		fd, err := syscall.Open(filepath.Join(dir, "foo"), syscall.O_RDONLY, 0)
+		if err != nil {
+			return false
+		}
+		defer syscall.Close(fd) //nolint:errcheck
+
+		buf := make([]byte, 4096)
+		n, err := syscall.Read(fd, buf)
+		content = buf[:max(n, 0)]
+
+		return err == nil
+	}, 5*time.Second, 50*time.Millisecond)
+	if mountErr != nil {
+		t.Skipf("failed to mount: %s", mountErr)
+	}
+	assert.Equal(t, "secret\nsecond\nthird\n", string(content))
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Contains(t, buf.String(), "Unmounted "+dir)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/gopasspw/gopass/internal/action/exit"
 	"github.com/gopasspw/gopass/internal/config"
 	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/internal/policy"
 	"github.com/gopasspw/gopass/internal/store"
 	"github.com/gopasspw/gopass/internal/tree"
 	"github.com/gopasspw/gopass/pkg/clipboard"
@@ -29,6 +30,10 @@ import (
 
 var reNumber = regexp.MustCompile(`^\d+$`)
 
+// policyRetries is the number of attempts to generate a password that
+// satisfies the password policy.
+const policyRetries = 10
+
 // Generate and save a password.
 func (s *generateHandler) Generate(ctx context.Context, cmd *cli.Command) error {
 	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
@@ -77,6 +82,14 @@ func (s *generateHandler) Generate(ctx context.Context, cmd *cli.Command) error
 		return err
 	}
 
+	// the password of a secret must satisfy the password policy of the store.
+	if key == "" {
+		ctx, password, err = s.generateForPolicy(ctx, cmd, length, name, password)
+		if err != nil {
+			return err
+		}
+	}
+
 	// display or copy to clipboard.
 	if err := s.generateCopyOrPrint(ctx, cmd, name, key, password); err != nil {
 		return err
@@ -240,6 +253,37 @@ func (s *generateHandler) generatePassword(ctx context.Context, cmd *cli.Command
 	}
 }
 
+// generateForPolicy makes sure a generated password satisfies the password
+// policy of the secret. Since generated passwords can violate the policy by
+// chance, e.g. lack a digit, they are generated again a few times unless
+// the user would have to be asked for the length again.
+func (s *generateHandler) generateForPolicy(ctx context.Context, cmd *cli.Command, length, name, password string) (context.Context, string, error) {
+	p, file, err := policy.Lookup(ctx, s.Store, name)
+	if err != nil {
+		return ctx, "", exit.Error(exit.Policy, err, "%s", err)
+	}
+	if p == nil {
+		return ctx, password, nil
+	}
+
+	if _, isCustom := config.DefaultPasswordLengthFromEnv(ctx); length != "" || isCustom {
+		for i := 0; i < policyRetries && len(p.Check(password)) > 0; i++ {
+			debug.Log("generated password violates the policy %s, retrying", file)
+			password, err = s.generatePassword(ctx, cmd, length, name)
+			if err != nil {
+				return ctx, "", err
+			}
+		}
+	}
+
+	ctx, err = policy.Apply(ctx, p, file, password, ctxutil.IsForce(ctx))
+	if err != nil {
+		return ctx, "", exit.Error(exit.Policy, err, "%s. Use a longer length, a different generator or --force to override it", err)
+	}
+
+	return ctx, password, nil
+}
+
 // getPwLengthFromEnvOrAskUser either determines the password length through an
 // environment variable or asks the user to set one.
 // This function assumes that if the length is set via the environment variable,
@@ -323,6 +367,25 @@ func (s *generateHandler) generatePasswordXKCD(ctx context.Context, cmd *cli.Com
 	if cmd.IsSet("xkcd-numbers") {
 		num = cmd.Bool("xkcd-numbers")
 	}
+	wordlist := config.String(ctx, "pwgen.xkcd-wordlist")
+	if cmd.IsSet("xkcd-wordlist") {
+		wordlist = cmd.String("xkcd-wordlist")
+	}
+	if wordlist == "" {
+		wordlist = lang
+	}
+	minBits := config.Int(ctx, "pwgen.xkcd-min-entropy")
+	if cmd.IsSet("xkcd-min-entropy") {
+		minBits = cmd.Int("xkcd-min-entropy")
+	}
+
+	d, err := xkcdgen.NewDiceware(wordlist)
+	if err != nil {
+		return "", exit.Error(exit.Usage, err, "failed to load wordlist: %s", err)
+	}
+	d.Delimiter = sep
+	d.Capitalize = capitalize
+	d.Numbers = num
 
 	pwlen := config.Int(ctx, "pwgen.xkcd-len")
 	switch {
@@ -333,6 +396,9 @@ func (s *generateHandler) generatePasswordXKCD(ctx context.Context, cmd *cli.Com
 			return "", exit.Error(exit.Usage, err, "password length must be a number: %s", err)
 		}
 		pwlen = iv
+	case minBits > 0:
+		// the minimum entropy decides on the number of words
+		pwlen = d.WordsFor(float64(minBits))
 	case pwlen < 1:
 		// no config value, nothing on the command line: ask the user
 		question := "How many words should be combined to a password?"
@@ -349,7 +415,19 @@ func (s *generateHandler) generatePasswordXKCD(ctx context.Context, cmd *cli.Com
 		return "", exit.Error(exit.Usage, nil, "password length must not be zero")
 	}
 
-	return xkcdgen.RandomLengthDelim(pwlen, sep, lang, capitalize, num)
+	if minBits > 0 && d.Entropy(pwlen) < float64(minBits) {
+		n := d.WordsFor(float64(minBits))
+		out.Warningf(ctx, "%d words do not provide %d bits of entropy, using %d words", pwlen, minBits, n)
+		pwlen = n
+	}
+
+	password, err := d.Generate(pwlen)
+	if err != nil {
+		return "", err
+	}
+	out.Noticef(ctx, "Passphrase entropy: %.1f bits (%d words from a list of %d)", d.Entropy(pwlen), pwlen, d.Size())
+
+	return password, nil
 }
 
 // generateSetPassword will update or create a secret.
@@ -152,6 +152,19 @@ func TestGenerate(t *testing.T) {
 		buf.Reset()
 	})
 
+	// generate --force --generator=xkcd --xkcd-wordlist=eff-short --xkcd-min-entropy=70 --print foobar
+	t.Run("generate xkcd with minimum entropy", func(t *testing.T) {
+		require.NoError(t, act.Generate(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"force": "true", "generator": "xkcd", "xkcd-wordlist": "eff-short", "xkcd-min-entropy": "70", "print": "true"}, "foobar")))
+		assert.Contains(t, buf.String(), "Passphrase entropy: 72.4 bits (7 words from a list of 1296)")
+		buf.Reset()
+
+		// a too short length is raised
+		require.NoError(t, act.Generate(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"force": "true", "generator": "xkcd", "xkcd-min-entropy": "70", "print": "true"}, "foobar", "3")))
+		assert.Contains(t, buf.String(), "3 words do not provide 70 bits of entropy, using 6 words")
+		assert.Contains(t, buf.String(), "Passphrase entropy: 77.5 bits (6 words from a list of 7776)")
+		buf.Reset()
+	})
+
 	// generate --force foobar 24 w/ autoclip and output redirection
 	t.Run("generate --force foobar 24", func(t *testing.T) {
 		ov := act.cfg.Get("generate.autoclip")
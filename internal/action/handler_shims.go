@@ -103,6 +103,10 @@ func (s *Action) CompleteGenerate(ctx context.Context, cmd *cli.Command) {
 	s.generate.CompleteGenerate(ctx, cmd)
 }
 
+func (s *Action) Rotate(ctx context.Context, cmd *cli.Command) error {
+	return s.generate.Rotate(ctx, cmd)
+}
+
 // ── mountHandler shims ─────────────────────────────────────────────────────
 
 func (s *Action) MountRemove(ctx context.Context, cmd *cli.Command) error {
@@ -283,11 +287,39 @@ func (s *Action) Env(ctx context.Context, cmd *cli.Command) error { return s.env
 
 func (s *Action) OTP(ctx context.Context, cmd *cli.Command) error { return s.otpH.OTP(ctx, cmd) }
 
+func (s *Action) OTPAdd(ctx context.Context, cmd *cli.Command) error { return s.otpH.OTPAdd(ctx, cmd) }
+
 // Internal methods accessed from tests.
 func (s *Action) otp(ctx context.Context, name, qrf string, clip, pw, recurse, chained, alsoClip bool) error {
 	return s.otpH.otp(ctx, name, qrf, clip, pw, recurse, chained, alsoClip)
 }
 
+// ── passkeyHandler shims ───────────────────────────────────────────────────
+
+func (s *Action) PasskeyCreate(ctx context.Context, cmd *cli.Command) error {
+	return s.passkeyH.PasskeyCreate(ctx, cmd)
+}
+
+func (s *Action) PasskeyAssert(ctx context.Context, cmd *cli.Command) error {
+	return s.passkeyH.PasskeyAssert(ctx, cmd)
+}
+
+func (s *Action) PasskeyList(ctx context.Context, cmd *cli.Command) error {
+	return s.passkeyH.PasskeyList(ctx, cmd)
+}
+
+func (s *Action) PasskeyExport(ctx context.Context, cmd *cli.Command) error {
+	return s.passkeyH.PasskeyExport(ctx, cmd)
+}
+
+func (s *Action) PasskeyImport(ctx context.Context, cmd *cli.Command) error {
+	return s.passkeyH.PasskeyImport(ctx, cmd)
+}
+
+// ── tuiHandler shims ───────────────────────────────────────────────────────
+
+func (s *Action) TUI(ctx context.Context, cmd *cli.Command) error { return s.tuiH.TUI(ctx, cmd) }
+
 // ── miscHandler shims ──────────────────────────────────────────────────────
 
 func (s *Action) AliasesPrint(ctx context.Context, cmd *cli.Command) error {
@@ -336,8 +368,8 @@ func (s *Action) ConfigComplete(ctx context.Context, cmd *cli.Command) {
 	s.misc.ConfigComplete(ctx, cmd)
 }
 
-func (s *Action) newGopassCompleter(ctx context.Context, cmd *cli.Command) *gopassCompleter {
-	return s.misc.newGopassCompleter(ctx, cmd)
+func (s *Action) newGopassCompleter(ctx context.Context, cmd *cli.Command, cwd string) *gopassCompleter {
+	return s.misc.newGopassCompleter(ctx, cmd, cwd)
 }
 
 func (s *Action) setConfigValue(ctx context.Context, store, key, value string) error {
@@ -357,3 +389,51 @@ func (s *Action) find(ctx context.Context, cmd *cli.Command, needle string, cb s
 func (s *Action) findSelection(ctx context.Context, cmd *cli.Command, choices []string, needle string, cb showFunc) error {
 	return s.search.findSelection(ctx, cmd, choices, needle, cb)
 }
+
+// ── nativeHostHandler shims ────────────────────────────────────────────────
+
+func (s *Action) NativeHost(ctx context.Context, cmd *cli.Command) error {
+	return s.nativeHost.NativeHost(ctx, cmd)
+}
+
+func (s *Action) NativeHostInstall(ctx context.Context, cmd *cli.Command) error {
+	return s.nativeHost.NativeHostInstall(ctx, cmd)
+}
+
+// ── serveHandler shims ─────────────────────────────────────────────────────
+
+func (s *Action) Serve(ctx context.Context, cmd *cli.Command) error {
+	return s.serveH.Serve(ctx, cmd)
+}
+
+func (s *Action) ServeTokenAdd(ctx context.Context, cmd *cli.Command) error {
+	return s.serveH.ServeTokenAdd(ctx, cmd)
+}
+
+func (s *Action) ServeTokenList(ctx context.Context, cmd *cli.Command) error {
+	return s.serveH.ServeTokenList(ctx, cmd)
+}
+
+func (s *Action) ServeTokenRemove(ctx context.Context, cmd *cli.Command) error {
+	return s.serveH.ServeTokenRemove(ctx, cmd)
+}
+
+// ── fuseHandler shims ──────────────────────────────────────────────────────
+
+func (s *Action) FuseMount(ctx context.Context, cmd *cli.Command) error {
+	return s.fuseH.FuseMount(ctx, cmd)
+}
+
+// ── renderHandler shims ────────────────────────────────────────────────────
+
+func (s *Action) Render(ctx context.Context, cmd *cli.Command) error {
+	return s.renderH.Render(ctx, cmd)
+}
+
+func (s *Action) RenderK8sSecret(ctx context.Context, cmd *cli.Command) error {
+	return s.renderH.RenderK8sSecret(ctx, cmd)
+}
+
+func (s *Action) RenderDockerEnv(ctx context.Context, cmd *cli.Command) error {
+	return s.renderH.RenderDockerEnv(ctx, cmd)
+}
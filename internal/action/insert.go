@@ -42,6 +42,9 @@ func (s *secretHandler) Insert(ctx context.Context, cmd *cli.Command) error {
 func (s *secretHandler) insert(ctx context.Context, cmd *cli.Command, name, key string, echo, multiline, force, appending bool, kvps map[string]string) error {
 	var content []byte
 
+	// --force also overrides the password policy.
+	ctx = ctxutil.WithForce(ctx, force)
+
 	// Check for custom commit message
 	commitMsg := "Insert user supplied password"
 	if cmd.IsSet("commit-message") {
@@ -98,6 +101,13 @@ func (s *secretHandler) insert(ctx context.Context, cmd *cli.Command, name, key
 		return exit.Error(exit.IO, err, "failed to ask for password: %s", err)
 	}
 
+	// warn about a weak password while the user can still choose another one.
+	if pw != "" && audit.Single(ctx, pw) && !force && ctxutil.IsInteractive(ctx) {
+		if !termio.AskForConfirmation(ctx, "Do you want to store this weak password anyway?") {
+			return exit.Error(exit.Aborted, nil, "not storing a weak password for %s", name)
+		}
+	}
+
 	return s.insertSingle(ctx, name, pw, kvps)
 }
 
@@ -110,6 +120,12 @@ func (s *secretHandler) insertStdin(ctx context.Context, name string, content []
 		if err != nil {
 			return err
 		}
+	} else if pw := sec.Password(); pw != "" {
+		var err error
+		ctx, err = s.enforcePolicy(ctx, name, pw)
+		if err != nil {
+			return err
+		}
 	}
 
 	if err := s.Store.Set(ctx, name, sec); err != nil {
@@ -153,7 +169,13 @@ func (s *secretHandler) insertSingle(ctx context.Context, name, pw string, kvps
 	// we only update the pw if the kvps were not set or if it's non-empty, because otherwise we were updating the kvps.
 	if pw != "" || len(kvps) == 0 {
 		sec.SetPassword(pw)
-		audit.Single(ctx, pw)
+	}
+
+	if pw != "" {
+		ctx, err = s.enforcePolicy(ctx, name, pw)
+		if err != nil {
+			return err
+		}
 	}
 
 	if err := s.Store.Set(ctx, name, sec); err != nil {
@@ -256,6 +278,14 @@ func (s *secretHandler) insertMultiline(ctx context.Context, cmd *cli.Command, n
 		out.Errorf(ctx, "WARNING: Invalid secret: %s of len %d", err, n)
 	}
 
+	// only a changed password is checked against the policy.
+	if pw := sec.Password(); pw != "" && pw != secrets.ParseAKV(buf).Password() {
+		ctx, err = s.enforcePolicy(ctx, name, pw)
+		if err != nil {
+			return err
+		}
+	}
+
 	if err := s.Store.Set(ctx, name, sec); err != nil {
 		if !errors.Is(err, store.ErrMeaninglessWrite) {
 			return exit.Error(exit.Encrypt, err, "failed to store secret %q: %s", name, err)
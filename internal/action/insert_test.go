@@ -2,13 +2,16 @@ package action
 
 import (
 	"bytes"
+	"context"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/fatih/color"
 	"github.com/gopasspw/gopass/internal/config"
 	"github.com/gopasspw/gopass/internal/out"
 	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/termio"
 	"github.com/gopasspw/gopass/tests/gptest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -177,3 +180,51 @@ func TestInsertStdin(t *testing.T) {
 	ibuf.Reset()
 	buf.Reset()
 }
+
+func TestInsertWeakPassword(t *testing.T) {
+	u := gptest.NewUnitTester(t)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithTerminal(ctx, false)
+	ctx = ctxutil.WithInteractive(ctx, true)
+	ctx = termio.WithPassPromptFunc(ctx, func(context.Context, string) (string, error) {
+		return "P@ssw0rd1990", nil
+	})
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	require.NotNil(t, act)
+	ctx = act.cfg.WithConfig(ctx)
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	color.NoColor = true
+	defer func() {
+		out.Stdout = os.Stdout
+		termio.Stdin = os.Stdin
+	}()
+
+	t.Run("decline weak password", func(t *testing.T) {
+		termio.Stdin = strings.NewReader("n\n")
+		require.Error(t, act.Insert(ctx, gptest.CliCtx(ctx, t, "weak")))
+		assert.Contains(t, buf.String(), "weak password (score 1/4)")
+		assert.Contains(t, buf.String(), `dictionary "P@ssw0rd" (common password "password", capitalized, l33t)`)
+		assert.False(t, act.Store.Exists(ctx, "weak"))
+		buf.Reset()
+	})
+
+	t.Run("accept weak password", func(t *testing.T) {
+		termio.Stdin = strings.NewReader("y\n")
+		require.NoError(t, act.Insert(ctx, gptest.CliCtx(ctx, t, "weak")))
+		assert.True(t, act.Store.Exists(ctx, "weak"))
+		buf.Reset()
+	})
+
+	t.Run("force skips the question", func(t *testing.T) {
+		termio.Stdin = strings.NewReader("")
+		require.NoError(t, act.Insert(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"force": "true"}, "weak2")))
+		assert.Contains(t, buf.String(), "weak password")
+		assert.True(t, act.Store.Exists(ctx, "weak2"))
+		buf.Reset()
+	})
+}
@@ -0,0 +1,201 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/gopasspw/gopass/internal/action/exit"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/hook"
+	"github.com/gopasspw/gopass/internal/nativehost"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/internal/policy"
+	"github.com/gopasspw/gopass/internal/tree"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/pwgen"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
+)
+
+// NativeHost answers requests of browser extensions on STDIN and STDOUT. It
+// is started by the browser.
+func (s *nativeHostHandler) NativeHost(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+
+	if f, ok := stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		return exit.Error(exit.Usage, nil, "%s native-host is started by the browser. Use '%s native-host install' to register it", s.Name, s.Name)
+	}
+
+	// STDOUT carries the messages to the browser, any other output would
+	// break the protocol.
+	ostdout := out.Stdout
+	out.Stdout = stderr
+	defer func() {
+		out.Stdout = ostdout
+	}()
+	ctx = ctxutil.WithInteractive(ctx, false)
+	ctx = ctxutil.WithTerminal(ctx, false)
+
+	inited, err := s.Store.IsInitialized(ctx)
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "Failed to initialize store: %s", err)
+	}
+	if !inited {
+		return exit.Error(exit.NotInitialized, nil, "password store not initialized")
+	}
+
+	if err := nativehost.New(&nativeHostBackend{h: s}, s.version).Serve(ctx, stdin, stdout); err != nil {
+		return exit.Error(exit.IO, err, "%s", err)
+	}
+
+	return nil
+}
+
+// NativeHostInstall installs the native messaging manifests.
+func (s *nativeHostHandler) NativeHostInstall(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+
+	browsers, err := nativeHostBrowsers(cmd.StringSlice("browser"))
+	if err != nil {
+		return err
+	}
+
+	if cmd.Bool("print") {
+		for _, b := range browsers {
+			buf, err := b.Manifest(nativehost.WrapperPath())
+			if err != nil {
+				return exit.Error(exit.Unknown, err, "%s", err)
+			}
+			out.Printf(ctx, "%s:\n%s", b.ManifestPath(), buf)
+		}
+
+		return nil
+	}
+
+	binary, err := os.Executable()
+	if err != nil {
+		return exit.Error(exit.IO, err, "failed to get the path of %s: %s", s.Name, err)
+	}
+
+	wrapper, err := nativehost.InstallWrapper(binary)
+	if err != nil {
+		return exit.Error(exit.IO, err, "%s", err)
+	}
+	debug.Log("installed wrapper at %s", wrapper)
+
+	for _, b := range browsers {
+		p, err := b.Install(wrapper)
+		if err != nil {
+			return exit.Error(exit.IO, err, "failed to install manifest for %s: %s", b.Name, err)
+		}
+		out.OKf(ctx, "Installed manifest for %s at %s", b.Name, p)
+
+		if runtime.GOOS == "windows" {
+			out.Noticef(ctx, "Register it with: reg add %q /ve /t REG_SZ /d %q /f", b.RegistryKey(), p)
+		}
+	}
+
+	return nil
+}
+
+// nativeHostBrowsers returns the selected browsers or all browsers that
+// have a profile in the home directory.
+func nativeHostBrowsers(names []string) ([]nativehost.Browser, error) {
+	if len(names) < 1 {
+		var browsers []nativehost.Browser
+		for _, b := range nativehost.Browsers {
+			if b.Installed() {
+				browsers = append(browsers, b)
+			}
+		}
+		if len(browsers) < 1 {
+			return nil, exit.Error(exit.NotFound, nil, "No supported browser found. Use --browser to select one of %v", nativehost.BrowserNames())
+		}
+
+		return browsers, nil
+	}
+
+	browsers := make([]nativehost.Browser, 0, len(names))
+	for _, name := range names {
+		b, found := nativehost.LookupBrowser(name)
+		if !found {
+			return nil, exit.Error(exit.Usage, nil, "Unknown browser %q. Supported: %v", name, nativehost.BrowserNames())
+		}
+		browsers = append(browsers, b)
+	}
+
+	return browsers, nil
+}
+
+// nativeHostBackend implements the store operations of the native
+// messaging host.
+type nativeHostBackend struct {
+	h *nativeHostHandler
+}
+
+func (b *nativeHostBackend) List(ctx context.Context) ([]string, error) {
+	return b.h.Store.List(ctx, tree.INF)
+}
+
+func (b *nativeHostBackend) Get(ctx context.Context, name string) (gopass.Secret, error) {
+	return b.h.Store.Get(ctx, name)
+}
+
+func (b *nativeHostBackend) Exists(ctx context.Context, name string) bool {
+	return b.h.Store.Exists(ctx, name)
+}
+
+func (b *nativeHostBackend) Create(ctx context.Context, name string, sec gopass.Secret) error {
+	ctx, err := policy.Enforce(ctx, b.h.Store, name, sec.Password(), false)
+	if err != nil {
+		return err
+	}
+
+	ctx = ctxutil.WithCommitMessage(ctx, "Created by browser extension")
+	if err := b.h.Store.Set(ctx, name, sec); err != nil {
+		return fmt.Errorf("failed to save secret %s: %w", name, err)
+	}
+
+	return hook.InvokeRoot(ctx, "create.post-hook", name, b.h.Store)
+}
+
+// Generate uses the password rules for the domain in the name, if any.
+// These also adjust the length to the limits of the domain. Passwords that
+// violate the password policy by chance are generated again.
+func (b *nativeHostBackend) Generate(ctx context.Context, name string, length int, symbols bool) (string, error) {
+	if length < 1 {
+		length, _ = config.DefaultPasswordLengthFromEnv(ctx)
+	}
+
+	gen := func() string {
+		return pwgen.GeneratePassword(length, symbols)
+	}
+	if domain, _ := hasPwRuleForSecret(ctx, name); domain != "" {
+		gen = func() string {
+			return pwgen.NewCrypticForDomain(ctx, length, domain).Password()
+		}
+	}
+
+	p, _, err := policy.Lookup(ctx, b.h.Store, name)
+	if err != nil {
+		return "", err
+	}
+
+	pw := gen()
+	for i := 0; p != nil && i < policyRetries && len(p.Check(pw)) > 0; i++ {
+		pw = gen()
+	}
+	if pw == "" {
+		return "", fmt.Errorf("failed to generate password for %s", name)
+	}
+
+	return pw, nil
+}
+
+func (b *nativeHostBackend) OTP(ctx context.Context, name string) (string, error) {
+	return b.h.otpFn(ctx, name)
+}
@@ -0,0 +1,127 @@
+package action
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/nativehost"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/gopasspw/gopass/tests/gptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNativeHost(t *testing.T) {
+	u := gptest.NewUnitTester(t)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	ctx = act.cfg.WithConfig(ctx)
+
+	sec := secrets.NewAKV()
+	sec.SetPassword("s3cr3t")
+	require.NoError(t, sec.Set("user", "alice"))
+	require.NoError(t, act.Store.Set(ctx, "websites/example.com/alice", sec))
+
+	req := &bytes.Buffer{}
+	for _, m := range []string{
+		`{"type":"queryHost","host":"https://login.example.com/"}`,
+		`{"type":"getLogin","entry":"websites/example.com/alice"}`,
+		`{"type":"create","entry_name":"websites/example.org/bob","login":"bob","generate":true,"length":24}`,
+	} {
+		require.NoError(t, binary.Write(req, binary.NativeEndian, uint32(len(m))))
+		req.WriteString(m)
+	}
+
+	resp := &bytes.Buffer{}
+	ostdin, ostdout := stdin, stdout
+	stdin, stdout = req, resp
+	defer func() {
+		stdin, stdout = ostdin, ostdout
+	}()
+
+	require.NoError(t, act.NativeHost(ctx, gptest.CliCtx(ctx, t)))
+
+	next := func(v any) {
+		t.Helper()
+
+		var size uint32
+		require.NoError(t, binary.Read(resp, binary.NativeEndian, &size))
+		require.NoError(t, json.Unmarshal(resp.Next(int(size)), v))
+	}
+
+	var names []string
+	next(&names)
+	assert.Equal(t, []string{"websites/example.com/alice"}, names)
+
+	var login map[string]string
+	next(&login)
+	assert.Equal(t, map[string]string{"username": "alice", "password": "s3cr3t"}, login)
+
+	next(&login)
+	assert.Equal(t, "bob", login["username"])
+	assert.Len(t, login["password"], 24)
+
+	created, err := act.Store.Get(ctx, "websites/example.org/bob")
+	require.NoError(t, err)
+	assert.Equal(t, login["password"], created.Password())
+	assert.Equal(t, 0, resp.Len())
+}
+
+func TestNativeHostInstall(t *testing.T) {
+	u := gptest.NewUnitTester(t)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	ctx = act.cfg.WithConfig(ctx)
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	defer func() {
+		out.Stdout = os.Stdout
+	}()
+
+	chromium, found := nativehost.LookupBrowser("chromium")
+	require.True(t, found)
+
+	t.Run("no browser", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.Error(t, act.NativeHostInstall(ctx, gptest.CliCtx(ctx, t)))
+	})
+
+	t.Run("print manifest", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Dir(chromium.ManifestPath())), 0o700))
+		require.NoError(t, act.NativeHostInstall(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"print": "true"})))
+		assert.Contains(t, buf.String(), chromium.ManifestPath())
+		assert.Contains(t, buf.String(), `"name": "com.justwatch.gopass"`)
+		assert.NoFileExists(t, chromium.ManifestPath())
+	})
+
+	t.Run("install for detected browsers", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.NoError(t, act.NativeHostInstall(ctx, gptest.CliCtx(ctx, t)))
+		assert.FileExists(t, chromium.ManifestPath())
+		assert.FileExists(t, nativehost.WrapperPath())
+
+		firefox, found := nativehost.LookupBrowser("firefox")
+		require.True(t, found)
+		assert.NoFileExists(t, firefox.ManifestPath())
+	})
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gopasspw/gopass/internal/action/exit"
@@ -14,11 +17,12 @@ import (
 	"github.com/gopasspw/gopass/pkg/clipboard"
 	"github.com/gopasspw/gopass/pkg/ctxutil"
 	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
 	"github.com/gopasspw/gopass/pkg/otp"
 	"github.com/gopasspw/gopass/pkg/termio"
 	"github.com/mattn/go-tty"
-	"github.com/pquerna/otp/hotp"
-	"github.com/pquerna/otp/totp"
+	gotp "github.com/pquerna/otp"
 	"github.com/urfave/cli/v3"
 )
 
@@ -43,6 +47,14 @@ func (s *otpHandler) OTP(ctx context.Context, cmd *cli.Command) error {
 	pw := cmd.Bool("password")
 	snip := cmd.Bool("snip")
 
+	if cmd.Bool("resync") {
+		if cmd.Args().Len() != 3 {
+			return exit.Error(exit.Usage, nil, "Usage: %s otp --resync <NAME> <TOKEN1> <TOKEN2>", s.Name)
+		}
+
+		return s.otpResync(ctx, name, cmd.Args().Get(1), cmd.Args().Get(2))
+	}
+
 	if snip {
 		qr, err := otp.ParseScreen(ctx)
 		if err != nil || len(qr) == 0 {
@@ -132,13 +144,11 @@ func (s *otpHandler) otp(ctx context.Context, name, qrf string, clip, pw, recurs
 		defer cleanupFn()
 	}
 
-	// only used for the HOTP case as a fallback
-	var counter uint64 = 1
-	if sv, found := sec.Get("counter"); found && sv != "" {
-		if iv, err := strconv.ParseUint(sv, 10, 64); iv != 0 && err == nil {
-			counter = iv
-		}
+	two, err := otp.Calculate(name, sec)
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "No OTP entry found for %s: %s", name, err)
 	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -146,40 +156,16 @@ func (s *otpHandler) otp(ctx context.Context, name, qrf string, clip, pw, recurs
 		default:
 		}
 
-		two, err := otp.Calculate(name, sec)
-		if err != nil {
-			return exit.Error(exit.Unknown, err, "No OTP entry found for %s: %s", name, err)
+		// only used for the HOTP case
+		var counter uint64
+		if two.Type() == "hotp" {
+			// using outerCtx here because we want to save the counter even if the user cancels.
+			counter = s.otpNextCounter(outerCtx, name, two, sec)
 		}
 
-		var token string
-		switch two.Type() {
-		case "totp":
-			token, err = totp.GenerateCodeCustom(two.Secret(), time.Now(), totp.ValidateOpts{
-				Period:    uint(two.Period()),
-				Skew:      1,
-				Digits:    two.Digits(),
-				Algorithm: two.Algorithm(),
-				Encoder:   two.Encoder(),
-			})
-			if err != nil {
-				return exit.Error(exit.Unknown, err, "Failed to compute OTP token for %s: %s", name, err)
-			}
-		case "hotp":
-			token, err = hotp.GenerateCodeCustom(two.Secret(), counter, hotp.ValidateOpts{
-				Digits:    two.Digits(),
-				Algorithm: two.Algorithm(),
-				Encoder:   two.Encoder(),
-			})
-			if err != nil {
-				return exit.Error(exit.Unknown, err, "Failed to compute OTP token for %s: %s", name, err)
-			}
-			counter++
-			_ = sec.Set("counter", strconv.Itoa(int(counter)))
-			// using outerCtx here because we want to save the counter even if the user cancels.
-			if err := s.Store.Set(outerCtx, name, sec); err != nil {
-				out.Errorf(outerCtx, "Failed to persist counter value: %s", err)
-			}
-			debug.Log("Saved counter as %d", counter)
+		token, err := otp.Generate(two, time.Now(), counter)
+		if err != nil {
+			return exit.Error(exit.Unknown, err, "Failed to compute OTP token for %s: %s", name, err)
 		}
 
 		now := time.Now()
@@ -245,6 +231,87 @@ func (s *otpHandler) otp(ctx context.Context, name, qrf string, clip, pw, recurs
 	}
 }
 
+// otpToken returns the current token of the secret. It increments the
+// counter of HOTP secrets.
+func (s *otpHandler) otpToken(ctx context.Context, name string) (string, error) {
+	sec, err := s.Store.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	two, err := otp.Calculate(name, sec)
+	if err != nil {
+		return "", fmt.Errorf("no OTP entry found: %w", err)
+	}
+
+	var counter uint64
+	if two.Type() == "hotp" {
+		counter = s.otpNextCounter(ctx, name, two, sec)
+	}
+
+	return otp.Generate(two, time.Now(), counter)
+}
+
+// otpNextCounter returns the counter of the next HOTP token and persists
+// its increment. The secret is read again right before it is written so
+// that an increment made in the meantime is not lost.
+func (s *otpHandler) otpNextCounter(ctx context.Context, name string, key *gotp.Key, sec gopass.Secret) uint64 {
+	if fresh, err := s.Store.Get(ctx, name); err == nil {
+		sec = fresh
+	} else {
+		debug.Log("failed to re-read %s: %s", name, err)
+	}
+
+	counter := otp.Counter(key, sec)
+	next, err := otp.SetCounter(sec, counter+1)
+	if err != nil {
+		out.Errorf(ctx, "Failed to set counter value: %s", err)
+
+		return counter
+	}
+
+	ctx = ctxutil.WithCommitMessage(ctx, fmt.Sprintf("Increment HOTP counter to %d", next))
+	if err := s.Store.Set(ctx, name, sec); err != nil && !errors.Is(err, store.ErrMeaninglessWrite) {
+		out.Errorf(ctx, "Failed to persist counter value: %s", err)
+	}
+	debug.Log("Saved counter as %d", next)
+
+	return counter
+}
+
+// otpResync sets the counter of a HOTP secret to the one following the two
+// consecutive tokens, e.g. when it was used elsewhere.
+func (s *otpHandler) otpResync(ctx context.Context, name, token1, token2 string) error {
+	sec, err := s.Store.Get(ctx, name)
+	if err != nil {
+		return exit.Error(exit.NotFound, err, "failed to retrieve secret %q: %s", name, err)
+	}
+
+	key, err := otp.Calculate(name, sec)
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "No OTP entry found for %s: %s", name, err)
+	}
+
+	counter, err := otp.Resync(key, otp.Counter(key, sec), token1, token2)
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "Failed to resync %s: %s", name, err)
+	}
+
+	// the counter may also go back, so it is set as is.
+	if err := sec.Set(otp.KeyCounter, strconv.FormatUint(counter, 10)); err != nil {
+		return exit.Error(exit.Unknown, err, "Failed to set counter value: %s", err)
+	}
+
+	ctx = ctxutil.WithCommitMessage(ctx, fmt.Sprintf("Resync HOTP counter to %d", counter))
+	if err := s.Store.Set(ctx, name, sec); err != nil && !errors.Is(err, store.ErrMeaninglessWrite) {
+		return exit.Error(exit.Encrypt, err, "Failed to persist counter value: %s", err)
+	}
+
+	out.OKf(ctx, "Resynchronized the HOTP counter of %s to %d", name, counter)
+
+	return nil
+}
+
 func (s *otpHandler) otpHandleError(ctx context.Context, name, qrf string, clip, pw, recurse, chained, alsoClip bool, err error) error {
 	if !errors.Is(err, store.ErrNotFound) || !recurse || !ctxutil.IsTerminal(ctx) {
 		return exit.Error(exit.Unknown, err, "failed to retrieve secret %q: %s", name, err)
@@ -260,3 +327,143 @@ func (s *otpHandler) otpHandleError(ctx context.Context, name, qrf string, clip,
 
 	return nil
 }
+
+// errOTPExists is returned by otpAdd if the secret already has an OTP.
+var errOTPExists = errors.New("secret already contains an OTP")
+
+// OTPAdd adds an OTP to a secret. The otpauth URL is either given as an
+// argument or read from a QR code image. An otpauth-migration URL, as
+// exported by Google Authenticator, creates one secret per account below
+// the given name.
+func (s *otpHandler) OTPAdd(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+	name := cmd.Args().First()
+	uri := cmd.Args().Get(1)
+
+	if fn := cmd.String("from-image"); fn != "" {
+		fh, err := os.Open(fn)
+		if err != nil {
+			return exit.Error(exit.IO, err, "failed to open %s: %s", fn, err)
+		}
+		defer fh.Close() //nolint:errcheck
+
+		uri, err = otp.DecodeQRImage(fh)
+		if err != nil {
+			return exit.Error(exit.IO, err, "failed to read QR code from %s: %s", fn, err)
+		}
+	}
+
+	if name == "" || uri == "" {
+		return exit.Error(exit.Usage, nil, "Usage: %s otp add <NAME> [<otpauth URL> | --from-image <FILE>]", s.Name)
+	}
+
+	force := cmd.Bool("force")
+
+	switch {
+	case strings.HasPrefix(uri, otp.MigrationScheme+"://"):
+		accounts, err := otp.ParseMigrationURL(uri)
+		if err != nil {
+			return exit.Error(exit.Usage, err, "%s", err)
+		}
+
+		var added int
+		for i, a := range accounts {
+			entry := path.Join(name, migrationEntry(a, i))
+			err := s.otpAdd(ctx, entry, a.URL(), force)
+			if errors.Is(err, errOTPExists) {
+				out.Warningf(ctx, "Skipping %s. It already contains an OTP. Use --force to overwrite it", entry)
+
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			added++
+		}
+		out.OKf(ctx, "Added %d of %d OTP accounts below %s", added, len(accounts), name)
+
+		return nil
+	case strings.HasPrefix(uri, "otpauth://"):
+		if _, err := gotp.NewKeyFromURL(uri); err != nil {
+			return exit.Error(exit.Usage, err, "invalid otpauth URL: %s", err)
+		}
+
+		err := s.otpAdd(ctx, name, uri, force)
+		if errors.Is(err, errOTPExists) {
+			return exit.Error(exit.Aborted, err, "%s already contains an OTP. Use --force to overwrite it", name)
+		}
+
+		return err
+	default:
+		return exit.Error(exit.Usage, nil, "not an otpauth:// or %s:// URL", otp.MigrationScheme)
+	}
+}
+
+// otpAdd stores the otpauth URL in the otpauth key of the secret, creating
+// the secret if necessary.
+func (s *otpHandler) otpAdd(ctx context.Context, name, uri string, force bool) error {
+	sec := gopass.Secret(secrets.New())
+	if s.Store.Exists(ctx, name) {
+		var err error
+		sec, err = s.Store.Get(ctx, name)
+		if err != nil {
+			return exit.Error(exit.Decrypt, err, "failed to read %s: %s", name, err)
+		}
+
+		if hasOTP(sec) && !force {
+			return errOTPExists
+		}
+	}
+
+	if err := sec.Set("otpauth", uri); err != nil {
+		return exit.Error(exit.Usage, err, "failed to set otpauth of %s: %s", name, err)
+	}
+
+	ctx = ctxutil.WithCommitMessage(ctx, "Add OTP secret")
+	if err := s.Store.Set(ctx, name, sec); err != nil {
+		if !errors.Is(err, store.ErrMeaninglessWrite) {
+			return exit.Error(exit.Encrypt, err, "failed to save %s: %s", name, err)
+		}
+		out.Warningf(ctx, "No need to write: the secret is already there and with the right value")
+
+		return nil
+	}
+
+	out.OKf(ctx, "Added OTP secret to %s", name)
+
+	return nil
+}
+
+// hasOTP returns true if the secret contains any OTP key or URL.
+func hasOTP(sec gopass.Secret) bool {
+	for _, k := range []string{"otpauth", "totp", "hotp", otp.KeyMOTP} {
+		if _, found := sec.Get(k); found {
+			return true
+		}
+	}
+
+	return strings.Contains(sec.Body(), "otpauth://")
+}
+
+// migrationEntry returns the name of the secret for an account of an
+// otpauth-migration URL, i.e. <issuer>/<account>. The URL may come from an
+// untrusted source, so both parts are turned into a single path element that
+// is neither hidden nor relative.
+func migrationEntry(a otp.MigrationAccount, i int) string {
+	clean := func(s string) string {
+		s = strings.NewReplacer("/", "-", `\`, "-").Replace(s)
+
+		return strings.TrimLeft(strings.TrimSpace(s), ".")
+	}
+
+	account := clean(a.AccountName())
+	if account == "" {
+		account = fmt.Sprintf("account-%d", i+1)
+	}
+
+	if issuer := clean(a.Issuer); issuer != "" {
+		return path.Join(issuer, account)
+	}
+
+	return account
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/gopasspw/gopass/internal/out"
 	"github.com/gopasspw/gopass/pkg/ctxutil"
 	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/gopasspw/gopass/pkg/otp"
 	"github.com/gopasspw/gopass/tests/gptest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -73,4 +74,157 @@ func TestOTP(t *testing.T) {
 		require.NoError(t, act.OTP(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"qr": fn}, "bar")))
 		assert.FileExists(t, fn)
 	})
+	t.Run("steam guard token", func(t *testing.T) {
+		defer buf.Reset()
+		sec := secrets.NewAKV()
+		sec.SetPassword("foo")
+		require.NoError(t, sec.Set("totp", "GJWTGMTNN5YWW2TNPJXWG2DHMIFA"))
+		require.NoError(t, sec.Set("encoder", "steam"))
+		require.NoError(t, act.Store.Set(ctx, "steam", sec))
+
+		require.NoError(t, act.OTP(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"password": "true"}, "steam")))
+		assert.Regexp(t, `^[2-9BCDFGHJKMNPQRTVWXY]{5}\n$`, buf.String())
+	})
+
+	t.Run("mOTP has no QR code", func(t *testing.T) {
+		defer buf.Reset()
+		sec := secrets.NewAKV()
+		sec.SetPassword("foo")
+		require.NoError(t, sec.Set("motp", "1234567890abcdef"))
+		require.NoError(t, sec.Set("motp-pin", "1234"))
+		require.NoError(t, act.Store.Set(ctx, "motp", sec))
+
+		require.NoError(t, act.OTP(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"password": "true"}, "motp")))
+		assert.Regexp(t, `^[0-9a-f]{6}\n$`, buf.String())
+
+		require.Error(t, act.OTP(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"qr": filepath.Join(u.Dir, "motp.png")}, "motp")))
+	})
+	t.Run("HOTP counter", func(t *testing.T) {
+		defer buf.Reset()
+		// RFC 4226, appendix D
+		sec := secrets.NewAKV()
+		sec.SetPassword("foo")
+		require.NoError(t, sec.Set("otpauth", "otpauth://hotp/gopass:test?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=gopass"))
+		require.NoError(t, act.Store.Set(ctx, "hotp", sec))
+
+		require.NoError(t, act.OTP(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"password": "true"}, "hotp")))
+		assert.Equal(t, "287082\n", buf.String())
+		buf.Reset()
+
+		// an increment made elsewhere is not lost
+		stored, err := act.Store.Get(ctx, "hotp")
+		require.NoError(t, err)
+		got, _ := stored.Get("counter")
+		assert.Equal(t, "2", got)
+		require.NoError(t, stored.Set("counter", "5"))
+		require.NoError(t, act.Store.Set(ctx, "hotp", stored))
+
+		require.NoError(t, act.otp(ctx, "hotp", "", false, true, false, false, false))
+		assert.Equal(t, "254676\n", buf.String())
+		buf.Reset()
+
+		stored, err = act.Store.Get(ctx, "hotp")
+		require.NoError(t, err)
+		got, _ = stored.Get("counter")
+		assert.Equal(t, "6", got)
+	})
+
+	t.Run("HOTP resync", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.Error(t, act.OTP(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"resync": "true"}, "hotp", "399871")))
+		require.Error(t, act.OTP(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"resync": "true"}, "hotp", "399871", "755224")))
+		require.Error(t, act.OTP(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"resync": "true"}, "bar", "399871", "520489")))
+
+		// tokens of the counters 8 and 9
+		require.NoError(t, act.OTP(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"resync": "true"}, "hotp", "399871", "520489")))
+		sec, err := act.Store.Get(ctx, "hotp")
+		require.NoError(t, err)
+		got, _ := sec.Get("counter")
+		assert.Equal(t, "10", got)
+	})
+
+	t.Run("add from URL", func(t *testing.T) {
+		defer buf.Reset()
+
+		uri := "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&issuer=Example"
+		require.Error(t, act.OTPAdd(ctx, gptest.CliCtx(ctx, t, "added")))
+		require.Error(t, act.OTPAdd(ctx, gptest.CliCtx(ctx, t, "added", "https://example.com")))
+		require.NoError(t, act.OTPAdd(ctx, gptest.CliCtx(ctx, t, "added", uri)))
+
+		sec, err := act.Store.Get(ctx, "added")
+		require.NoError(t, err)
+		got, _ := sec.Get("otpauth")
+		assert.Equal(t, uri, got)
+
+		// refuses to overwrite an existing OTP
+		require.Error(t, act.OTPAdd(ctx, gptest.CliCtx(ctx, t, "added", uri)))
+		require.NoError(t, act.OTPAdd(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"force": "true"}, "added", uri)))
+	})
+
+	t.Run("add from image", func(t *testing.T) {
+		defer buf.Reset()
+
+		fn := filepath.Join(u.Dir, "add.png")
+		require.NoError(t, act.OTP(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"qr": fn}, "added")))
+
+		require.NoError(t, act.OTPAdd(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"from-image": fn}, "fromimage")))
+		sec, err := act.Store.Get(ctx, "fromimage")
+		require.NoError(t, err)
+		got, _ := sec.Get("otpauth")
+		assert.Contains(t, got, "secret=JBSWY3DPEHPK3PXP")
+	})
+
+	t.Run("add from migration URL", func(t *testing.T) {
+		defer buf.Reset()
+
+		// Example:alice@example.com (TOTP) and bob (ACME Co, HOTP)
+		uri := "otpauth-migration://offline?data=CjYKCkhlbGxvId6tvu8SGUV4YW1wbGU6YWxpY2VAZXhhbXBsZS5jb20aB0V4YW1wbGUgASgBMAIKLAoUMTIzNDU2Nzg5MDEyMzQ1Njc4OTASA2JvYhoHQUNNRSBDbyACKAIwATgqEAEYASAAKMDEBw%3D%3D"
+
+		// an existing secret without OTP keeps its password
+		sec := secrets.NewAKV()
+		sec.SetPassword("hunter2")
+		require.NoError(t, act.Store.Set(ctx, "migrated/ACME Co/bob", sec))
+
+		require.NoError(t, act.OTPAdd(ctx, gptest.CliCtx(ctx, t, "migrated", uri)))
+
+		alice, err := act.Store.Get(ctx, "migrated/Example/alice@example.com")
+		require.NoError(t, err)
+		got, _ := alice.Get("otpauth")
+		assert.Contains(t, got, "otpauth://totp/")
+		assert.Contains(t, got, "secret=JBSWY3DPEHPK3PXP")
+
+		bob, err := act.Store.Get(ctx, "migrated/ACME Co/bob")
+		require.NoError(t, err)
+		assert.Equal(t, "hunter2", bob.Password())
+		got, _ = bob.Get("otpauth")
+		assert.Contains(t, got, "otpauth://hotp/")
+		assert.Contains(t, got, "counter=42")
+
+		// existing OTPs are skipped
+		require.NoError(t, act.OTPAdd(ctx, gptest.CliCtx(ctx, t, "migrated", uri)))
+	})
+}
+
+func TestMigrationEntry(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		issuer string
+		name   string
+		want   string
+	}{
+		{issuer: "Example", name: "Example:alice@example.com", want: "Example/alice@example.com"},
+		{name: "bob", want: "bob"},
+		{issuer: "ACME/Co", name: "a/b", want: "ACME-Co/a-b"},
+		{name: "", want: "account-3"},
+		{issuer: "..", name: "alice", want: "alice"},
+		{issuer: ".", name: "..", want: "account-3"},
+		{issuer: "..", name: "../../etc", want: "-..-etc"},
+		{issuer: ".git", name: "config", want: "git/config"},
+		{issuer: `..\..`, name: "alice", want: "-../alice"},
+	} {
+		got := migrationEntry(otp.MigrationAccount{Issuer: tc.issuer, Name: tc.name}, 2)
+		assert.Equal(t, tc.want, got, tc)
+	}
 }
@@ -0,0 +1,334 @@
+package action
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/action/exit"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/internal/tree"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/passkey"
+	"github.com/urfave/cli/v3"
+)
+
+// passkeyPrefix is the folder that new passkeys are stored in.
+const passkeyPrefix = "passkeys"
+
+// PasskeyCreate creates a new passkey and stores it as a secret.
+func (s *passkeyHandler) PasskeyCreate(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+	rp := cmd.Args().Get(0)
+	user := cmd.Args().Get(1)
+	if rp == "" || user == "" {
+		return exit.Error(exit.Usage, nil, "Usage: %s passkey create <rp> <user>", s.Name)
+	}
+
+	name, err := passkeyName(rp, user)
+	if err != nil {
+		return exit.Error(exit.Usage, err, "%s", err)
+	}
+
+	if s.Store.Exists(ctx, name) && !cmd.Bool("force") {
+		return exit.Error(exit.Aborted, nil, "Secret %s already exists. Use --force to overwrite it", name)
+	}
+
+	algs := make([]passkey.COSEAlgorithm, 0, len(cmd.StringSlice("algorithm")))
+	for _, a := range cmd.StringSlice("algorithm") {
+		alg, err := passkey.ParseCOSEAlgorithm(a)
+		if err != nil {
+			// unknown algorithms are skipped, they might be acceptable
+			// to the relying party but not supported by us.
+			debug.Log("skipping algorithm %q: %s", a, err)
+
+			continue
+		}
+		algs = append(algs, alg)
+	}
+	if len(algs) == 0 && len(cmd.StringSlice("algorithm")) > 0 {
+		return exit.Error(exit.Usage, nil, "None of the algorithms %v is supported", cmd.StringSlice("algorithm"))
+	}
+
+	cred, err := passkey.CreateCredential(rp, user, passkey.CredentialFlags{
+		UserPresent:  true,
+		UserVerified: cmd.Bool("user-verified"),
+	}, algs...)
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "failed to create passkey: %s", err)
+	}
+
+	sec, err := cred.Secret()
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "failed to encode passkey: %s", err)
+	}
+
+	ctx = ctxutil.WithCommitMessage(ctx, fmt.Sprintf("Add passkey for %s", rp))
+	if err := s.Store.Set(ctx, name, sec); err != nil {
+		return exit.Error(exit.Encrypt, err, "failed to save passkey to %s: %s", name, err)
+	}
+
+	pub, err := cred.PublicKey()
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "failed to encode public key: %s", err)
+	}
+
+	alg, err := cred.COSEAlgorithm()
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "failed to determine algorithm: %s", err)
+	}
+
+	authData, err := cred.RegistrationAuthData()
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "failed to encode authenticator data: %s", err)
+	}
+
+	out.OKf(ctx, "Created %s passkey %s", alg, name)
+
+	return jsonWrite(stdout, map[string]any{
+		"id":                 cred.ID,
+		"rpId":               cred.Rp,
+		"user":               cred.UserName,
+		"publicKey":          base64.RawURLEncoding.EncodeToString(pub),
+		"publicKeyAlgorithm": int(alg),
+		"authenticatorData":  base64.RawURLEncoding.EncodeToString(authData),
+	})
+}
+
+// PasskeyAssert signs a WebAuthn challenge with a stored passkey. The
+// incremented signature counter is saved before the assertion is printed,
+// so a counter value is never used twice by this copy of the store.
+func (s *passkeyHandler) PasskeyAssert(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+	name := cmd.Args().First()
+	challenge := cmd.String("challenge")
+	if name == "" || challenge == "" {
+		return exit.Error(exit.Usage, nil, "Usage: %s passkey assert <secret> --challenge <challenge> [--origin <origin>]", s.Name)
+	}
+
+	sec, err := s.Store.Get(ctx, name)
+	if err != nil {
+		return exit.Error(exit.Decrypt, err, "failed to read %s: %s", name, err)
+	}
+
+	cred, err := passkey.FromSecret(sec)
+	if err != nil {
+		return exit.Error(exit.NotFound, err, "failed to read passkey from %s: %s", name, err)
+	}
+
+	origin := cmd.String("origin")
+	if origin == "" {
+		origin = "https://" + cred.Rp
+	}
+
+	rsp, err := s.passkeyAssert(ctx, name, sec, cred, challenge, origin)
+	if err != nil {
+		return err
+	}
+
+	return jsonWrite(stdout, rsp.JSON(cred))
+}
+
+// passkeyAssert signs the challenge and saves the incremented counter. Only
+// the counter of the secret is updated, so its other keys are kept as they
+// are.
+func (s *passkeyHandler) passkeyAssert(ctx context.Context, name string, sec gopass.Secret, cred *passkey.Credential, challenge, origin string) (*passkey.Response, error) {
+	rsp, err := cred.GetAssertion(challenge, origin)
+	if err != nil {
+		return nil, exit.Error(exit.Unknown, err, "failed to sign challenge: %s", err)
+	}
+
+	if err := sec.Set(passkey.KeyCounter, strconv.FormatUint(uint64(cred.Counter), 10)); err != nil {
+		return nil, exit.Error(exit.Unknown, err, "failed to update passkey: %s", err)
+	}
+
+	ctx = ctxutil.WithCommitMessage(ctx, fmt.Sprintf("Increment passkey counter of %s to %d", name, cred.Counter))
+	if err := s.Store.Set(ctx, name, sec); err != nil {
+		return nil, exit.Error(exit.Encrypt, err, "failed to save passkey counter to %s: %s", name, err)
+	}
+
+	return rsp, nil
+}
+
+// passkeyName returns the name of the secret for a passkey. The relying
+// party and the user must not contain path separators or be relative path
+// elements, so imported or user supplied values can not point outside of
+// the passkeys folder.
+func passkeyName(rp, user string) (string, error) {
+	for _, p := range []string{rp, user} {
+		if p == "" || p == "." || p == ".." || strings.ContainsAny(p, `/\`) {
+			return "", fmt.Errorf("invalid relying party or user %q", p)
+		}
+	}
+
+	return path.Join(passkeyPrefix, rp, user), nil
+}
+
+// PasskeyList lists all passkeys grouped by relying party.
+func (s *passkeyHandler) PasskeyList(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+
+	names, creds, err := s.passkeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	byRp := make(map[string][]string, 8)
+	for i, cred := range creds {
+		byRp[cred.Rp] = append(byRp[cred.Rp], fmt.Sprintf("%s (%s, counter: %d)", cred.UserName, names[i], cred.Counter))
+	}
+
+	rps := make([]string, 0, len(byRp))
+	for rp := range byRp {
+		rps = append(rps, rp)
+	}
+	sort.Strings(rps)
+
+	for _, rp := range rps {
+		out.Printf(ctx, "%s", rp)
+		for _, cred := range byRp[rp] {
+			out.Printf(ctx, "  %s", cred)
+		}
+	}
+
+	return nil
+}
+
+// PasskeyExport prints the given passkeys, or all of them, in the FIDO
+// Credential Exchange Format (CXF).
+func (s *passkeyHandler) PasskeyExport(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+
+	var creds []*passkey.Credential
+	if cmd.Args().Len() > 0 {
+		for _, name := range cmd.Args().Slice() {
+			sec, err := s.Store.Get(ctx, name)
+			if err != nil {
+				return exit.Error(exit.Decrypt, err, "failed to read %s: %s", name, err)
+			}
+
+			cred, err := passkey.FromSecret(sec)
+			if err != nil {
+				return exit.Error(exit.NotFound, err, "failed to read passkey from %s: %s", name, err)
+			}
+			creds = append(creds, cred)
+		}
+	} else {
+		var err error
+		_, creds, err = s.passkeys(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	buf, err := passkey.MarshalCXF(creds, time.Now())
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "failed to export passkeys: %s", err)
+	}
+
+	out.Warningf(ctx, "The exported data contains %d unencrypted private keys", len(creds))
+	fmt.Fprintln(stdout, string(buf))
+
+	return nil
+}
+
+// PasskeyImport reads passkeys in the FIDO Credential Exchange Format (CXF)
+// from a file (or stdin) and stores them in passkeys/<rp>/<user>.
+func (s *passkeyHandler) PasskeyImport(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+	file := cmd.Args().First()
+	if file == "" {
+		return exit.Error(exit.Usage, nil, "Usage: %s passkey import <file|->", s.Name)
+	}
+
+	var buf []byte
+	var err error
+	if file == "-" {
+		buf, err = io.ReadAll(stdin)
+	} else {
+		buf, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return exit.Error(exit.IO, err, "failed to read %s: %s", file, err)
+	}
+
+	creds, err := passkey.UnmarshalCXF(buf)
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "failed to import passkeys: %s", err)
+	}
+
+	var imported int
+	for _, cred := range creds {
+		name, err := passkeyName(cred.Rp, cred.UserName)
+		if err != nil {
+			out.Warningf(ctx, "Skipping passkey. %s", err)
+
+			continue
+		}
+
+		if s.Store.Exists(ctx, name) && !cmd.Bool("force") {
+			out.Warningf(ctx, "Skipping %s. Secret already exists. Use --force to overwrite it", name)
+
+			continue
+		}
+
+		sec, err := cred.Secret()
+		if err != nil {
+			return exit.Error(exit.Unknown, err, "failed to encode passkey: %s", err)
+		}
+
+		ctx := ctxutil.WithCommitMessage(ctx, fmt.Sprintf("Import passkey for %s", cred.Rp))
+		if err := s.Store.Set(ctx, name, sec); err != nil {
+			return exit.Error(exit.Encrypt, err, "failed to save passkey to %s: %s", name, err)
+		}
+		imported++
+	}
+
+	out.OKf(ctx, "Imported %d of %d passkeys", imported, len(creds))
+
+	return nil
+}
+
+// passkeys returns the names and credentials of all passkeys.
+func (s *passkeyHandler) passkeys(ctx context.Context) ([]string, []*passkey.Credential, error) {
+	names, err := s.Store.List(ctx, tree.INF)
+	if err != nil {
+		return nil, nil, exit.Error(exit.List, err, "failed to list store: %s", err)
+	}
+
+	var found []string
+	var creds []*passkey.Credential
+	for _, name := range names {
+		if !strings.HasPrefix(name, passkeyPrefix+"/") {
+			continue
+		}
+
+		sec, err := s.Store.Get(ctx, name)
+		if err != nil {
+			debug.Log("failed to read %s: %s", name, err)
+
+			continue
+		}
+
+		cred, err := passkey.FromSecret(sec)
+		if err != nil {
+			debug.Log("skipping %s: %s", name, err)
+
+			continue
+		}
+
+		found = append(found, name)
+		creds = append(creds, cred)
+	}
+
+	return found, creds, nil
+}
@@ -0,0 +1,245 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/passkey"
+	"github.com/gopasspw/gopass/tests/gptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+func TestPasskey(t *testing.T) {
+	u := gptest.NewUnitTester(t)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+	ctx = ctxutil.WithInteractive(ctx, false)
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	require.NotNil(t, act)
+	ctx = act.cfg.WithConfig(ctx)
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	stdout = buf
+	defer func() {
+		out.Stdout = os.Stdout
+		stdout = os.Stdout
+	}()
+
+	name := "passkeys/example.com/alice"
+
+	t.Run("create", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.Error(t, act.PasskeyCreate(ctx, gptest.CliCtx(ctx, t, "example.com")))
+		require.NoError(t, act.PasskeyCreate(ctx, gptest.CliCtx(ctx, t, "example.com", "alice")))
+		assert.Contains(t, buf.String(), `"rpId": "example.com"`)
+
+		// refuses to overwrite
+		require.Error(t, act.PasskeyCreate(ctx, gptest.CliCtx(ctx, t, "example.com", "alice")))
+	})
+
+	t.Run("create with algorithm", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.Error(t, act.PasskeyCreate(ctx, cliCtxWithAlgorithms(ctx, t, []string{"ES384"}, "example.net", "carol")))
+
+		// the first supported algorithm is used
+		require.NoError(t, act.PasskeyCreate(ctx, cliCtxWithAlgorithms(ctx, t, []string{"-35", "-8", "-7"}, "example.net", "carol")))
+		// skip the success message
+		var created map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes()[bytes.IndexByte(buf.Bytes(), '{'):], &created))
+		assert.InDelta(t, float64(passkey.EdDSA), created["publicKeyAlgorithm"], 0)
+		assert.NotEmpty(t, created["authenticatorData"])
+
+		sec, err := act.Store.Get(ctx, "passkeys/example.net/carol")
+		require.NoError(t, err)
+		cred, err := passkey.FromSecret(sec)
+		require.NoError(t, err)
+		assert.Equal(t, "EdDSA", cred.Algorithm)
+
+		require.NoError(t, act.Store.Delete(ctx, "passkeys/example.net/carol"))
+	})
+
+	t.Run("assert", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.Error(t, act.PasskeyAssert(ctx, gptest.CliCtx(ctx, t, name)))
+		require.Error(t, act.PasskeyAssert(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"challenge": "Y2hhbGxlbmdl"}, "foo")))
+
+		require.NoError(t, act.PasskeyAssert(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"challenge": "Y2hhbGxlbmdl"}, name)))
+
+		var aj passkey.AssertionJSON
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &aj))
+		assert.Equal(t, "public-key", aj.Type)
+
+		sec, err := act.Store.Get(ctx, name)
+		require.NoError(t, err)
+		cred, err := passkey.FromSecret(sec)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), cred.Counter)
+		assert.Equal(t, cred.ID, aj.ID)
+
+		// verify the signature
+		authData, err := base64.RawURLEncoding.DecodeString(aj.Response.AuthenticatorData)
+		require.NoError(t, err)
+		clientData, err := base64.RawURLEncoding.DecodeString(aj.Response.ClientDataJSON)
+		require.NoError(t, err)
+		assert.Contains(t, string(clientData), `"origin":"https://example.com"`)
+		sig, err := base64.RawURLEncoding.DecodeString(aj.Response.Signature)
+		require.NoError(t, err)
+
+		clientDataHash := sha256.Sum256(clientData)
+		message := sha256.Sum256(append(authData, clientDataHash[:]...))
+		assert.True(t, ecdsa.VerifyASN1(cred.SecretKey.Public().(*ecdsa.PublicKey), message[:], sig))
+		assert.Equal(t, []byte{0, 0, 0, 1}, authData[len(authData)-4:])
+
+		// the counter keeps increasing
+		buf.Reset()
+		require.NoError(t, act.PasskeyAssert(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"challenge": "Y2hhbGxlbmdl"}, name)))
+		sec, err = act.Store.Get(ctx, name)
+		require.NoError(t, err)
+		cred, err = passkey.FromSecret(sec)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(2), cred.Counter)
+
+		// other keys of the secret are kept.
+		require.NoError(t, sec.Set("note", "keep me"))
+		require.NoError(t, act.Store.Set(ctx, name, sec))
+		buf.Reset()
+		require.NoError(t, act.PasskeyAssert(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"challenge": "Y2hhbGxlbmdl"}, name)))
+		sec, err = act.Store.Get(ctx, name)
+		require.NoError(t, err)
+		cred, err = passkey.FromSecret(sec)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(3), cred.Counter)
+		note, _ := sec.Get("note")
+		assert.Equal(t, "keep me", note)
+	})
+
+	t.Run("invalid names", func(t *testing.T) {
+		defer buf.Reset()
+
+		for _, args := range [][]string{{"..", "alice"}, {"example.com", ".."}, {"example.com", "../../evil"}, {"a/b", "alice"}} {
+			require.Error(t, act.PasskeyCreate(ctx, gptest.CliCtx(ctx, t, args...)), args)
+		}
+		assert.False(t, act.Store.Exists(ctx, "evil"))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.NoError(t, act.PasskeyCreate(ctx, gptest.CliCtx(ctx, t, "example.org", "bob")))
+		buf.Reset()
+
+		require.NoError(t, act.PasskeyList(ctx, gptest.CliCtx(ctx, t)))
+		assert.Equal(t, "example.com\n  alice (passkeys/example.com/alice, counter: 3)\nexample.org\n  bob (passkeys/example.org/bob, counter: 0)\n", buf.String())
+	})
+	t.Run("export and import", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.NoError(t, act.PasskeyExport(ctx, gptest.CliCtx(ctx, t)))
+		creds, err := passkey.UnmarshalCXF(buf.Bytes())
+		require.NoError(t, err)
+		require.Len(t, creds, 2)
+		assert.Equal(t, "alice", creds[0].UserName)
+		assert.Equal(t, uint32(3), creds[0].Counter)
+
+		buf.Reset()
+		require.NoError(t, act.PasskeyExport(ctx, gptest.CliCtx(ctx, t, "passkeys/example.org/bob")))
+		exported := append([]byte(nil), buf.Bytes()...)
+		creds, err = passkey.UnmarshalCXF(exported)
+		require.NoError(t, err)
+		require.Len(t, creds, 1)
+		assert.Equal(t, "bob", creds[0].UserName)
+
+		fn := filepath.Join(t.TempDir(), "passkeys.json")
+		require.NoError(t, os.WriteFile(fn, exported, 0o600))
+
+		require.Error(t, act.PasskeyImport(ctx, gptest.CliCtx(ctx, t)))
+
+		// existing passkeys are skipped
+		require.NoError(t, act.PasskeyImport(ctx, gptest.CliCtx(ctx, t, fn)))
+
+		// the imported passkey is identical to the exported one
+		require.NoError(t, act.Store.Delete(ctx, "passkeys/example.org/bob"))
+		require.NoError(t, act.PasskeyImport(ctx, gptest.CliCtx(ctx, t, fn)))
+		sec, err := act.Store.Get(ctx, "passkeys/example.org/bob")
+		require.NoError(t, err)
+		cred, err := passkey.FromSecret(sec)
+		require.NoError(t, err)
+		assert.Equal(t, creds[0].ID, cred.ID)
+		assert.Equal(t, creds[0].UserHandle, cred.UserHandle)
+		assert.True(t, creds[0].SecretKey.Equal(cred.SecretKey))
+	})
+
+	t.Run("import rejects names outside of the passkeys folder", func(t *testing.T) {
+		defer buf.Reset()
+
+		evil, err := passkey.CreateCredential("..", "../../evil", passkey.CredentialFlags{UserPresent: true})
+		require.NoError(t, err)
+		good, err := passkey.CreateCredential("example.edu", "dave", passkey.CredentialFlags{UserPresent: true})
+		require.NoError(t, err)
+		exported, err := passkey.MarshalCXF([]*passkey.Credential{evil, good}, time.Now())
+		require.NoError(t, err)
+
+		fn := filepath.Join(t.TempDir(), "evil.json")
+		require.NoError(t, os.WriteFile(fn, exported, 0o600))
+
+		require.NoError(t, act.PasskeyImport(ctx, gptest.CliCtx(ctx, t, fn)))
+		assert.Contains(t, buf.String(), "Imported 1 of 2 passkeys")
+		assert.True(t, act.Store.Exists(ctx, "passkeys/example.edu/dave"))
+		assert.False(t, act.Store.Exists(ctx, "evil"))
+		assert.False(t, act.Store.Exists(ctx, "passkeys/evil"))
+	})
+}
+
+// cliCtxWithAlgorithms builds a *cli.Command that has the --algorithm
+// StringSlice flag populated with the given values.
+func cliCtxWithAlgorithms(ctx context.Context, t *testing.T, algs []string, args ...string) *cli.Command {
+	t.Helper()
+
+	allArgs := make([]string, 0, len(algs)+len(args)+1)
+	allArgs = append(allArgs, "test")
+	for _, a := range algs {
+		allArgs = append(allArgs, "--algorithm="+a)
+	}
+	allArgs = append(allArgs, args...)
+
+	var captured *cli.Command
+
+	cmd := &cli.Command{
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{Name: "algorithm", Aliases: []string{"a"}},
+		},
+		Action: func(c context.Context, cmd *cli.Command) error {
+			captured = cmd
+
+			return nil
+		},
+	}
+
+	require.NoError(t, cmd.Run(ctx, allArgs))
+
+	if captured == nil {
+		return cmd
+	}
+
+	return captured
+}
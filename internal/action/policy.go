@@ -0,0 +1,21 @@
+package action
+
+import (
+	"context"
+
+	"github.com/gopasspw/gopass/internal/action/exit"
+	"github.com/gopasspw/gopass/internal/policy"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+)
+
+// enforcePolicy checks the password of a secret against the password policy
+// of its folder. If the context has the force flag set a violation is
+// recorded in the commit message instead.
+func (b *base) enforcePolicy(ctx context.Context, name, password string) (context.Context, error) {
+	ctx, err := policy.Enforce(ctx, b.Store, name, password, ctxutil.IsForce(ctx))
+	if err != nil {
+		return ctx, exit.Error(exit.Policy, err, "%s. Use --force to override it", err)
+	}
+
+	return ctx, nil
+}
@@ -0,0 +1,126 @@
+package action
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/internal/policy"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/gopasspw/gopass/tests/gptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	u := gptest.NewUnitTester(t)
+
+	ctx := t.Context()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+	ctx = ctxutil.WithInteractive(ctx, false)
+
+	// the commit messages are checked, so the store has to be a git repo.
+	gitInit := exec.Command("git", "init", "-q")
+	gitInit.Dir = u.StoreDir("")
+	require.NoError(t, gitInit.Run())
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	require.NotNil(t, act)
+	ctx = act.cfg.WithConfig(ctx)
+
+	require.NoError(t, act.cfg.Set("", "generate.autoclip", "false"))
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	out.Stderr = buf
+	defer func() {
+		out.Stdout = os.Stdout
+		out.Stderr = os.Stderr
+	}()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(u.StoreDir(""), "team"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(u.StoreDir(""), "team", ".gopass-policy.yml"), []byte("min_length: 16\nrequire: [digit]\nbanned: ['(?i)secret']\n"), 0o600))
+
+	lastCommit := func(t *testing.T) string {
+		t.Helper()
+
+		cmd := exec.Command("git", "log", "-1", "--format=%B")
+		cmd.Dir = u.StoreDir("")
+		msg, err := cmd.Output()
+		require.NoError(t, err)
+
+		return string(msg)
+	}
+
+	t.Run("insert", func(t *testing.T) {
+		defer buf.Reset()
+
+		// secrets outside of the folder are not affected
+		require.NoError(t, act.insertStdin(ctx, "other", []byte("short"), false))
+
+		require.ErrorContains(t, act.insertStdin(ctx, "team/db", []byte("short"), false), "shorter than 16 characters, no digit character")
+		require.ErrorContains(t, act.insertStdin(ctx, "team/db", []byte("my-secret-1234567890"), false), "banned pattern")
+		require.NoError(t, act.insertStdin(ctx, "team/db", []byte("kxq7zvMw!p2Lq-x9Y"), false))
+
+		require.NoError(t, act.insertStdin(ctxutil.WithForce(ctx, true), "team/db", []byte("short"), false))
+		assert.Contains(t, lastCommit(t), "Password policy team/.gopass-policy.yml overridden with --force: shorter than 16 characters, no digit character")
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		defer buf.Reset()
+
+		old := []byte("short\nuser: alice\n")
+		// an unchanged password is accepted
+		require.NoError(t, act.editUpdate(ctx, "team/db", old, []byte("short\nuser: bob\n"), false, "test"))
+		require.ErrorContains(t, act.editUpdate(ctx, "team/db", old, []byte("still-short\nuser: bob\n"), false, "test"), policy.ErrViolation.Error())
+		require.NoError(t, act.editUpdate(ctx, "team/db", old, []byte("kxq7zvMw!p2Lq-x9Y\nuser: bob\n"), false, "test"))
+	})
+
+	t.Run("generate", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.Error(t, act.Generate(ctx, gptest.CliCtx(ctx, t, "team/gen", "8")))
+		assert.False(t, act.Store.Exists(ctx, "team/gen"))
+
+		require.NoError(t, act.Generate(ctx, gptest.CliCtx(ctx, t, "team/gen", "24")))
+		sec, err := act.Store.Get(ctx, "team/gen")
+		require.NoError(t, err)
+		assert.Regexp(t, `[0-9]`, sec.Password())
+
+		require.NoError(t, act.Generate(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"force": "true"}, "team/gen", "8")))
+		assert.Contains(t, lastCommit(t), "overridden with --force: shorter than 16 characters")
+	})
+
+	t.Run("rotate", func(t *testing.T) {
+		defer buf.Reset()
+
+		old := secrets.NewAKV()
+		old.SetPassword("kxq7zvMw!p2Lq-x9Y")
+		require.NoError(t, act.Store.Set(ctx, "team/rot", old))
+
+		require.NoError(t, act.Rotate(ctx, gptest.CliCtx(ctx, t, "team/rot")))
+		sec, err := act.Store.Get(ctx, "team/rot")
+		require.NoError(t, err)
+		pending, found := sec.Get(rotatePendingKey)
+		require.True(t, found)
+		p, _, err := policy.Lookup(ctx, act.Store, "team/rot")
+		require.NoError(t, err)
+		assert.Empty(t, p.Check(pending))
+
+		// a pending password that no longer satisfies the policy is not confirmed
+		require.NoError(t, sec.Set(rotatePendingKey, "short"))
+		require.NoError(t, act.Store.Set(ctx, "team/rot", sec))
+		require.ErrorContains(t, act.Rotate(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"confirm": "true"}, "team/rot")), "shorter than 16 characters")
+
+		require.NoError(t, act.Rotate(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"confirm": "true", "force": "true"}, "team/rot")))
+		assert.Contains(t, lastCommit(t), "overridden with --force: shorter than 16 characters, no digit character")
+		sec, err = act.Store.Get(ctx, "team/rot")
+		require.NoError(t, err)
+		assert.Equal(t, "short", sec.Password())
+	})
+}
@@ -38,6 +38,17 @@ func (p *pathRestrictedStore) Get(ctx context.Context, name string) (gopass.Secr
 	return nil, fmt.Errorf("access denied: %q is not within an allowed path", name)
 }
 
+// templateStore decides which store view the template engine may access.
+func templateStore(ctx context.Context, store secretGetter, allowPaths []string) secretGetter {
+	if len(allowPaths) > 0 {
+		return &pathRestrictedStore{inner: store, allowPaths: allowPaths}
+	}
+
+	out.Warningf(ctx, "No --allow-path flag set. The template has unrestricted access to ALL secrets in the store. Only process templates from trusted sources.")
+
+	return store
+}
+
 // Process is a command to process a template and replace secrets contained in it.
 func (s *miscHandler) Process(ctx context.Context, cmd *cli.Command) error {
 	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
@@ -53,16 +64,7 @@ func (s *miscHandler) Process(ctx context.Context, cmd *cli.Command) error {
 		return exit.Error(exit.IO, err, "Failed to read file: %s", file)
 	}
 
-	// Decide which store view the template engine may access.
-	var store secretGetter
-	if len(allowPaths) > 0 {
-		store = &pathRestrictedStore{inner: s.Store, allowPaths: allowPaths}
-	} else {
-		out.Warningf(ctx, "No --allow-path flag set. The template has unrestricted access to ALL secrets in the store. Only process templates from trusted sources.")
-		store = s.Store
-	}
-
-	obuf, err := tpl.Execute(ctx, string(buf), file, nil, store)
+	obuf, err := tpl.Execute(ctx, string(buf), file, nil, templateStore(ctx, s.Store, allowPaths))
 	if err != nil {
 		return exit.Error(exit.IO, err, "Failed to process file: %s", file)
 	}
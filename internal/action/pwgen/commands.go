@@ -66,6 +66,16 @@ func GetCommands() []*cli.Command {
 					Aliases: []string{"xkcdnumbers", "xn"},
 					Usage:   "Add a random number to the end of the generated xkcd style password. This flag implies -xkcd",
 				},
+				&cli.StringFlag{
+					Name:    "xkcd-wordlist",
+					Aliases: []string{"xw"},
+					Usage:   "Wordlist to generate xkcd style passwords from: en, de, de_short, eff-large, eff-short or the path to a wordlist file. Overrides -xkcd-lang. This flag implies -xkcd",
+				},
+				&cli.IntFlag{
+					Name:    "xkcd-min-entropy",
+					Aliases: []string{"xe"},
+					Usage:   "Minimum entropy in bits of the generated xkcd style password. Sets the number of words unless a length is given. This flag implies -xkcd",
+				},
 				&cli.BoolFlag{
 					Name:    "memorable",
 					Aliases: []string{"m"},
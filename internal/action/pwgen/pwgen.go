@@ -6,6 +6,7 @@ package pwgen
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 
 	"github.com/gopasspw/gopass/internal/action/exit"
@@ -41,7 +42,7 @@ func Pwgen(ctx context.Context, cmd *cli.Command) error {
 		}
 	}
 
-	xkcdSet := cmd.Bool("xkcd") || cmd.Bool("xkcd-capitalize") || cmd.Bool("xkcd-numbers")
+	xkcdSet := cmd.Bool("xkcd") || cmd.Bool("xkcd-capitalize") || cmd.Bool("xkcd-numbers") || cmd.IsSet("xkcd-wordlist") || cmd.IsSet("xkcd-min-entropy")
 	memorableSet := cmd.Bool("memorable") || cmd.Bool("memorable-capitalize")
 
 	// --memorable and --xkcd both select a generator; rejecting the
@@ -70,6 +71,13 @@ func xkcdGen(ctx context.Context, cmd *cli.Command, length, num int) error {
 	if cmd.IsSet("xkcd-lang") {
 		lang = cmd.String("xkcd-lang")
 	}
+	wordlist := config.String(ctx, "pwgen.xkcd-wordlist")
+	if cmd.IsSet("xkcd-wordlist") {
+		wordlist = cmd.String("xkcd-wordlist")
+	}
+	if wordlist == "" {
+		wordlist = lang
+	}
 	if length < 1 {
 		length = config.Int(ctx, "pwgen.xkcd-len")
 		if length < 1 {
@@ -84,15 +92,36 @@ func xkcdGen(ctx context.Context, cmd *cli.Command, length, num int) error {
 	if cmd.IsSet("xkcd-numbers") {
 		numbers = cmd.Bool("xkcd-numbers")
 	}
+	minBits := config.Int(ctx, "pwgen.xkcd-min-entropy")
+	if cmd.IsSet("xkcd-min-entropy") {
+		minBits = cmd.Int("xkcd-min-entropy")
+	}
+
+	d, err := xkcdgen.NewDiceware(wordlist)
+	if err != nil {
+		return exit.Error(exit.Usage, err, "failed to load wordlist: %s", err)
+	}
+	d.Delimiter = sep
+	d.Capitalize = capitalize
+	d.Numbers = numbers
+
+	// without an explicit length the minimum entropy decides on the number
+	// of words. An explicit length is only raised if it is too short.
+	if minBits > 0 && (cmd.Args().Get(0) == "" || d.Entropy(length) < float64(minBits)) {
+		length = d.WordsFor(float64(minBits))
+	}
 
 	for range num {
-		s, err := xkcdgen.RandomLengthDelim(length, sep, lang, capitalize, numbers)
+		s, err := d.Generate(length)
 		if err != nil {
 			return err
 		}
 		out.Print(ctx, s)
 	}
 
+	// the entropy goes to stderr to keep the output usable in pipes.
+	fmt.Fprintf(out.Stderr, "Passphrase entropy: %.1f bits (%d words from a list of %d)\n", d.Entropy(length), length, d.Size())
+
 	return nil
 }
 
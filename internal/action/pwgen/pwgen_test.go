@@ -183,3 +183,35 @@ func boolFlag(t *testing.T, flags []cli.Flag, name string) *cli.BoolFlag {
 func hasUppercase(s string) bool {
 	return strings.ContainsAny(s, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
 }
+
+func TestPwgenXKCDEntropy(t *testing.T) {
+	u := gptest.NewUnitTester(t)
+	assert.NotNil(t, u)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+
+	buf := &bytes.Buffer{}
+	ebuf := &bytes.Buffer{}
+	out.Stdout = buf
+	out.Stderr = ebuf
+	defer func() {
+		out.Stdout = os.Stdout
+		out.Stderr = os.Stderr
+	}()
+
+	// --xkcd-min-entropy implies --xkcd and decides on the number of words
+	require.NoError(t, Pwgen(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"xkcd-wordlist": "eff-short", "xkcd-min-entropy": "70", "xkcd-sep": "."})))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 10)
+	assert.Len(t, strings.Split(lines[0], "."), 7)
+	assert.Equal(t, "Passphrase entropy: 72.4 bits (7 words from a list of 1296)\n", ebuf.String())
+
+	buf.Reset()
+	ebuf.Reset()
+	require.NoError(t, Pwgen(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"xkcd": "true", "xkcd-sep": "."}, "4", "1")))
+	assert.Len(t, strings.Split(strings.TrimSpace(buf.String()), "."), 4)
+	assert.Contains(t, ebuf.String(), "51.7 bits (4 words")
+
+	require.Error(t, Pwgen(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"xkcd-wordlist": "does-not-exist.txt"})))
+}
@@ -0,0 +1,414 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/action/exit"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/internal/render"
+	"github.com/gopasspw/gopass/internal/tpl"
+	"github.com/gopasspw/gopass/internal/tree"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/urfave/cli/v3"
+)
+
+// Render renders a template with secrets. The output is printed or written
+// atomically to a file, optionally every time the store changes.
+func (s *renderHandler) Render(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+
+	file := cmd.Args().First()
+	if file == "" {
+		return exit.Error(exit.Usage, nil, "Usage: %s render [--output <file>] [--watch] [--diff] <template>", s.Name)
+	}
+
+	output := cmd.String("output")
+	watch := cmd.Bool("watch")
+	showDiff := cmd.Bool("diff")
+
+	if (watch || showDiff) && output == "" {
+		return exit.Error(exit.Usage, nil, "--watch and --diff require --output")
+	}
+	if watch && showDiff {
+		return exit.Error(exit.Usage, nil, "Only one of --watch or --diff may be specified")
+	}
+
+	store := templateStore(ctx, s.Store, cmd.StringSlice("allow-path"))
+
+	if watch {
+		interval := time.Duration(cmd.Int("interval")) * time.Second
+		if interval <= 0 {
+			return exit.Error(exit.Usage, nil, "--interval must be positive")
+		}
+
+		return s.renderWatch(ctx, file, output, store, interval)
+	}
+
+	buf, err := renderTemplate(ctx, file, store)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case showDiff:
+		old, err := os.ReadFile(output)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return exit.Error(exit.IO, err, "Failed to read %s: %s", output, err)
+		}
+
+		changes := render.Diff(old, buf)
+		for _, c := range changes {
+			out.Printf(ctx, "%s", c)
+		}
+		if len(changes) < 1 {
+			out.OKf(ctx, "%s is up to date", output)
+		}
+
+		return nil
+	case output != "":
+		if err := render.WriteFile(output, buf); err != nil {
+			return exit.Error(exit.IO, err, "%s", err)
+		}
+		out.OKf(ctx, "Rendered %s to %s", file, output)
+
+		return nil
+	default:
+		fmt.Fprint(stdout, string(buf))
+
+		return nil
+	}
+}
+
+// renderWatch renders the template whenever the template or the store
+// changed, e.g. after a sync. Once the first rendering succeeded, failures are
+// reported and the last output is kept.
+//
+// Changes are detected by the files below the local paths of the stores and
+// the names of all secrets. Backends that keep secrets on a server, like
+// s3fs and webdavfs, only trigger a new rendering if secrets are added,
+// removed or renamed.
+func (s *renderHandler) renderWatch(ctx context.Context, file, output string, store secretGetter, interval time.Duration) error {
+	paths := []string{file}
+	for _, mp := range append([]string{""}, s.Store.MountPoints()...) {
+		if st := s.Store.Storage(ctx, mp); st != nil {
+			paths = append(paths, st.Path())
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last uint64
+	for first := true; ; first = false {
+		fp, err := s.renderFingerprint(ctx, paths)
+		switch {
+		case err != nil && first:
+			return exit.Error(exit.IO, err, "Failed to watch for changes: %s", err)
+		case err != nil:
+			out.Errorf(ctx, "Failed to check for changes: %s", err)
+		case first || fp != last:
+			last = fp
+			if err := s.renderUpdate(ctx, file, output, store); err != nil {
+				if first {
+					return err
+				}
+				out.Errorf(ctx, "%s", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *renderHandler) renderFingerprint(ctx context.Context, paths []string) (uint64, error) {
+	names, err := s.Store.List(ctx, tree.INF)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	return render.Fingerprint(names, paths...)
+}
+
+// renderUpdate renders the template and replaces the output if it changed.
+func (s *renderHandler) renderUpdate(ctx context.Context, file, output string, store secretGetter) error {
+	buf, err := renderTemplate(ctx, file, store)
+	if err != nil {
+		return err
+	}
+
+	if old, err := os.ReadFile(output); err == nil && bytes.Equal(old, buf) {
+		return nil
+	}
+
+	if err := render.WriteFile(output, buf); err != nil {
+		return exit.Error(exit.IO, err, "%s", err)
+	}
+	out.OKf(ctx, "Rendered %s to %s", file, output)
+
+	return nil
+}
+
+// renderTemplate reads and renders the template file.
+func renderTemplate(ctx context.Context, file string, store secretGetter) ([]byte, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, exit.Error(exit.IO, err, "Failed to read template %s: %s", file, err)
+	}
+
+	buf, err := tpl.Execute(ctx, string(content), file, nil, store)
+	if err != nil {
+		return nil, exit.Error(exit.Unknown, err, "Failed to render template %s: %s", file, err)
+	}
+
+	return buf, nil
+}
+
+// RenderK8sSecret prints a Kubernetes Secret manifest for a folder or checks
+// an existing manifest.
+func (s *renderHandler) RenderK8sSecret(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+
+	name := strings.Trim(cmd.Args().First(), "/")
+	if name == "" {
+		return exit.Error(exit.Usage, nil, "Usage: %s render k8s-secret [--name <name>] [--namespace <namespace>] <folder>", s.Name)
+	}
+
+	objName := cmd.String("name")
+	if objName == "" {
+		objName = render.K8sName(path.Base(name))
+	}
+	if objName == "" {
+		return exit.Error(exit.Usage, nil, "Can not derive a Secret name from %s, use --name", name)
+	}
+
+	buf, err := s.renderOutput(ctx, cmd, name, nil, func(data map[string]string) ([]byte, error) {
+		sec, err := render.NewK8sSecret(objName, cmd.String("namespace"), data)
+		if err != nil {
+			return nil, err
+		}
+
+		return sec.Bytes()
+	})
+	if err != nil {
+		return err
+	}
+
+	check := cmd.String("check")
+	if check == "" {
+		fmt.Fprint(stdout, string(buf))
+
+		return nil
+	}
+
+	want, err := render.ParseK8sSecret(buf)
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "Invalid output: %s", err)
+	}
+
+	have, err := readK8sSecret(check)
+	if err != nil {
+		return err
+	}
+
+	changes, err := k8sChanges(want, have)
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "%s", err)
+	}
+
+	return renderReport(ctx, check, changes)
+}
+
+// RenderDockerEnv prints an env file for Docker for a folder or checks an
+// existing env file.
+func (s *renderHandler) RenderDockerEnv(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+
+	name := strings.Trim(cmd.Args().First(), "/")
+	if name == "" {
+		return exit.Error(exit.Usage, nil, "Usage: %s render docker-env [--keep-case] <folder>", s.Name)
+	}
+
+	key := strings.ToUpper
+	if cmd.Bool("keep-case") {
+		key = nil
+	}
+
+	buf, err := s.renderOutput(ctx, cmd, name, key, render.DockerEnv)
+	if err != nil {
+		return err
+	}
+
+	check := cmd.String("check")
+	if check == "" {
+		fmt.Fprint(stdout, string(buf))
+
+		return nil
+	}
+
+	want, err := render.ParseDockerEnv(buf)
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "Invalid output: %s", err)
+	}
+
+	existing, err := os.ReadFile(check)
+	if err != nil {
+		return exit.Error(exit.IO, err, "Failed to read %s: %s", check, err)
+	}
+
+	have, err := render.ParseDockerEnv(existing)
+	if err != nil {
+		return exit.Error(exit.IO, err, "Failed to read %s: %s", check, err)
+	}
+
+	return renderReport(ctx, check, changeLines(render.Compare(want, have)))
+}
+
+// renderOutput renders the secrets below name either with gen or, if given,
+// with the template file. Templates may only access secrets below name.
+func (s *renderHandler) renderOutput(ctx context.Context, cmd *cli.Command, name string, key func(string) string, gen func(map[string]string) ([]byte, error)) ([]byte, error) {
+	isDir := s.Store.IsDir(ctx, name)
+	if !isDir && !s.Store.Exists(ctx, name) {
+		return nil, exit.Error(exit.NotFound, nil, "Secret %s not found", name)
+	}
+
+	if file := cmd.String("template"); file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, exit.Error(exit.IO, err, "Failed to read template %s: %s", file, err)
+		}
+
+		allow := name
+		if isDir {
+			allow += "/"
+		}
+
+		buf, err := tpl.Execute(ctx, string(content), file, nil, &pathRestrictedStore{inner: s.Store, allowPaths: []string{allow}})
+		if err != nil {
+			return nil, exit.Error(exit.Unknown, err, "Failed to render template %s: %s", file, err)
+		}
+
+		return buf, nil
+	}
+
+	secs, err := s.renderSecrets(ctx, name, isDir)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := render.Data(secs, key)
+	if err != nil {
+		return nil, exit.Error(exit.Unknown, err, "%s", err)
+	}
+
+	buf, err := gen(data)
+	if err != nil {
+		return nil, exit.Error(exit.Unknown, err, "%s", err)
+	}
+
+	return buf, nil
+}
+
+// renderSecrets decrypts the secret name or all secrets below the folder
+// name.
+func (s *renderHandler) renderSecrets(ctx context.Context, name string, isDir bool) (map[string]gopass.Secret, error) {
+	names := []string{name}
+	if isDir {
+		all, err := s.Store.List(ctx, tree.INF)
+		if err != nil {
+			return nil, exit.Error(exit.List, err, "failed to list store: %s", err)
+		}
+
+		names = names[:0]
+		for _, n := range all {
+			if strings.HasPrefix(n, name+"/") {
+				names = append(names, n)
+			}
+		}
+	}
+
+	secs := make(map[string]gopass.Secret, len(names))
+	for _, n := range names {
+		sec, err := s.Store.Get(ctx, n)
+		if err != nil {
+			return nil, exit.Error(exit.Decrypt, err, "Failed to decrypt %s: %s", n, err)
+		}
+		secs[n] = sec
+	}
+
+	return secs, nil
+}
+
+// renderReport prints the changes and fails if the file is out of date.
+func renderReport(ctx context.Context, file string, changes []string) error {
+	for _, c := range changes {
+		out.Printf(ctx, "%s", c)
+	}
+
+	if len(changes) > 0 {
+		return exit.Error(exit.Unknown, nil, "%s is out of date", file)
+	}
+
+	out.OKf(ctx, "%s is up to date", file)
+
+	return nil
+}
+
+func changeLines(changes []render.Change) []string {
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+
+	return lines
+}
+
+func readK8sSecret(file string) (*render.K8sSecret, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, exit.Error(exit.IO, err, "Failed to read %s: %s", file, err)
+	}
+
+	sec, err := render.ParseK8sSecret(buf)
+	if err != nil {
+		return nil, exit.Error(exit.IO, err, "Failed to read %s: %s", file, err)
+	}
+
+	return sec, nil
+}
+
+// k8sChanges returns the changes of the metadata and the keys of the
+// Secret. The values are never included.
+func k8sChanges(want, have *render.K8sSecret) ([]string, error) {
+	var lines []string
+	if want.Metadata.Name != have.Metadata.Name {
+		lines = append(lines, fmt.Sprintf("~ metadata.name: %q -> %q", have.Metadata.Name, want.Metadata.Name))
+	}
+	if want.Metadata.Namespace != have.Metadata.Namespace {
+		lines = append(lines, fmt.Sprintf("~ metadata.namespace: %q -> %q", have.Metadata.Namespace, want.Metadata.Namespace))
+	}
+
+	wv, err := want.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	hv, err := have.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(lines, changeLines(render.Compare(wv, hv))...), nil
+}
@@ -0,0 +1,180 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/gopasspw/gopass/tests/gptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	u := gptest.NewUnitTester(t)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+	ctx = ctxutil.WithTerminal(ctx, false)
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	ctx = act.cfg.WithConfig(ctx)
+
+	sec := secrets.NewAKV()
+	sec.SetPassword("s3cr3t")
+	require.NoError(t, sec.Set("user", "app"))
+	require.NoError(t, act.Store.Set(ctx, "prod/app/db_password", sec))
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	stdout = buf
+	defer func() {
+		out.Stdout = os.Stdout
+		stdout = os.Stdout
+	}()
+
+	dir := t.TempDir()
+
+	t.Run("k8s-secret", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.Error(t, act.RenderK8sSecret(ctx, gptest.CliCtx(ctx, t)))
+		require.Error(t, act.RenderK8sSecret(ctx, gptest.CliCtx(ctx, t, "missing")))
+
+		require.NoError(t, act.RenderK8sSecret(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"namespace": "prod"}, "prod/app")))
+		assert.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  namespace: prod
+type: Opaque
+data:
+  db_password: czNjcjN0
+  user: YXBw
+`, buf.String())
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.yaml"), buf.Bytes(), 0o600))
+	})
+
+	t.Run("k8s-secret check", func(t *testing.T) {
+		defer buf.Reset()
+
+		manifest := filepath.Join(dir, "secret.yaml")
+		require.NoError(t, act.RenderK8sSecret(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"namespace": "prod", "check": manifest}, "prod/app")))
+		assert.Contains(t, buf.String(), "is up to date")
+		buf.Reset()
+
+		sec.SetPassword("n3w")
+		require.NoError(t, act.Store.Set(ctx, "prod/app/db_password", sec))
+		require.Error(t, act.RenderK8sSecret(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"check": manifest}, "prod/app")))
+		assert.Contains(t, buf.String(), "~ db_password")
+		assert.Contains(t, buf.String(), `~ metadata.namespace: "prod" -> ""`)
+		assert.NotContains(t, buf.String(), "n3w")
+		assert.NotContains(t, buf.String(), "s3cr3t")
+	})
+
+	t.Run("k8s-secret template", func(t *testing.T) {
+		defer buf.Reset()
+
+		tmpl := filepath.Join(dir, "secret.tpl")
+		require.NoError(t, os.WriteFile(tmpl, []byte(`kind: Secret
+data:
+  password: {{ getpw "prod/app/db_password" | b64enc }}
+`), 0o600))
+		require.NoError(t, act.RenderK8sSecret(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"template": tmpl}, "prod/app")))
+		assert.Equal(t, "kind: Secret\ndata:\n  password: bjN3\n", buf.String())
+
+		// templates can only access the folder.
+		require.NoError(t, os.WriteFile(tmpl, []byte(`{{ getpw "foo" }}`), 0o600))
+		require.Error(t, act.RenderK8sSecret(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"template": tmpl}, "prod/app")))
+	})
+
+	t.Run("docker-env", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.NoError(t, act.RenderDockerEnv(ctx, gptest.CliCtx(ctx, t, "prod/app")))
+		assert.Equal(t, "DB_PASSWORD=n3w\nUSER=app\n", buf.String())
+		buf.Reset()
+
+		env := filepath.Join(dir, "app.env")
+		require.NoError(t, os.WriteFile(env, []byte("DB_PASSWORD=n3w\nLEGACY=1\n"), 0o600))
+		require.Error(t, act.RenderDockerEnv(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"check": env}, "prod/app")))
+		assert.Contains(t, buf.String(), "+ USER")
+		assert.Contains(t, buf.String(), "- LEGACY")
+		assert.NotContains(t, buf.String(), "DB_PASSWORD")
+	})
+
+	t.Run("template", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.Error(t, act.Render(ctx, gptest.CliCtx(ctx, t)))
+
+		tmpl := filepath.Join(dir, "app.conf.tpl")
+		require.NoError(t, os.WriteFile(tmpl, []byte("user={{ getval \"prod/app/db_password\" \"user\" }}\npassword={{ getpw \"prod/app/db_password\" }}\n"), 0o600))
+
+		require.NoError(t, act.Render(ctx, gptest.CliCtx(ctx, t, tmpl)))
+		assert.Equal(t, "user=app\npassword=n3w\n", buf.String())
+		buf.Reset()
+
+		// --diff and --watch need an output file.
+		require.Error(t, act.Render(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"diff": "true"}, tmpl)))
+		require.Error(t, act.Render(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"watch": "true"}, tmpl)))
+
+		conf := filepath.Join(dir, "conf", "app.conf")
+		require.NoError(t, act.Render(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"output": conf}, tmpl)))
+		got, err := os.ReadFile(conf)
+		require.NoError(t, err)
+		assert.Equal(t, "user=app\npassword=n3w\n", string(got))
+		fi, err := os.Stat(conf)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+		buf.Reset()
+
+		require.NoError(t, act.Render(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"output": conf, "diff": "true"}, tmpl)))
+		assert.Contains(t, buf.String(), "is up to date")
+		buf.Reset()
+
+		sec.SetPassword("n3w3r")
+		require.NoError(t, act.Store.Set(ctx, "prod/app/db_password", sec))
+		require.NoError(t, act.Render(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"output": conf, "diff": "true"}, tmpl)))
+		assert.Contains(t, buf.String(), "- line 2")
+		assert.Contains(t, buf.String(), "+ line 2")
+		assert.NotContains(t, buf.String(), "password")
+		assert.NotContains(t, buf.String(), "n3w")
+		buf.Reset()
+
+		// derived values are not shown either.
+		b64 := filepath.Join(dir, "b64.tpl")
+		require.NoError(t, os.WriteFile(b64, []byte("{{ getpw \"prod/app/db_password\" | b64enc }}\n"), 0o600))
+		require.NoError(t, act.Render(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"output": conf, "diff": "true"}, b64)))
+		assert.NotContains(t, buf.String(), "bjN3M3I=")
+		buf.Reset()
+
+		// watch renders the file until it is stopped.
+		watched := filepath.Join(dir, "watched.conf")
+		wctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		require.NoError(t, act.Render(wctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"output": watched, "watch": "true", "interval": "1"}, tmpl)))
+		got, err = os.ReadFile(watched)
+		require.NoError(t, err)
+		assert.Equal(t, "user=app\npassword=n3w3r\n", string(got))
+		buf.Reset()
+
+		// watch fails if the template can not be watched.
+		require.Error(t, act.Render(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"output": watched, "watch": "true"}, filepath.Join(dir, "missing.tpl"))))
+
+		// a missing secret fails and keeps the file.
+		require.NoError(t, os.WriteFile(tmpl, []byte("{{ getpw \"prod/app/missing\" }}\n"), 0o600))
+		require.Error(t, act.Render(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"output": conf}, tmpl)))
+		got, err = os.ReadFile(conf)
+		require.NoError(t, err)
+		assert.Equal(t, "user=app\npassword=n3w\n", string(got))
+	})
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/ergochat/readline"
+	"github.com/gopasspw/gopass/internal/config"
 	"github.com/gopasspw/gopass/internal/out"
 	"github.com/gopasspw/gopass/internal/tree"
 	"github.com/gopasspw/gopass/pkg/debug"
@@ -28,7 +30,18 @@ func (s *miscHandler) REPL(ctx context.Context, cmd *cli.Command) error {
 	out.Printf(ctx, "🌟 Welcome to gopass!")
 	out.Printf(ctx, "⚠ This is the built-in shell. Type 'help' for a list of commands.")
 
-	rl, err := readline.New("gopass> ")
+	sess := newREPLSession(replIdleTimeout(ctx), func() {
+		if err := s.Store.Lock(); err != nil {
+			debug.Log("Failed to lock stores: %s", err)
+		}
+	})
+
+	rl, err := readline.NewFromConfig(&readline.Config{
+		Prompt: sess.prompt(),
+		Stdin:  stdin,
+		// lines are added to the history manually to keep secret values out of it.
+		DisableAutoSaveHistory: true,
+	})
 	if err != nil {
 		return err
 	}
@@ -50,14 +63,19 @@ READ:
 		// the list of secrets may have changed, e.g. due to
 		// the user adding a new secret.
 		cfg := rl.GetConfig()
-		cfg.AutoComplete = s.newGopassCompleter(ctx, cmd)
+		cfg.AutoComplete = s.newGopassCompleter(ctx, cmd, sess.cwd)
 		if err := rl.SetConfig(cfg); err != nil {
 			debug.Log("Failed to set readline config: %s", err)
 
 			break
 		}
+		rl.SetPrompt(sess.prompt())
 
+		sess.idle()
 		line, err := rl.Readline()
+		if sess.busy() {
+			out.Noticef(ctx, "Locked after %s without input", sess.timeout)
+		}
 		if err != nil {
 			debug.Log("Readline error: %s", err)
 
@@ -72,6 +90,11 @@ READ:
 		if len(args) < 1 {
 			continue
 		}
+		if sess.record(cmd.Root(), args) {
+			if err := rl.SaveToHistory(line); err != nil {
+				debug.Log("Failed to save history: %s", err)
+			}
+		}
 		switch strings.ToLower(args[0]) {
 		case "quit":
 			break READ
@@ -82,10 +105,19 @@ READ:
 		case "clear":
 			rl.ClearScreen()
 
+			continue
+		case "cd":
+			s.replCd(ctx, sess, args[1:])
+
+			continue
+		case "pwd":
+			out.Printf(ctx, "/%s", sess.cwd)
+
 			continue
 		default:
 		}
 
+		args = sess.resolveArgs(cmd.Root(), args)
 		if err := cmd.Root().Run(ctx, append([]string{"gopass"}, args...)); err != nil {
 			continue
 		}
@@ -103,6 +135,35 @@ func (s *miscHandler) replLock(ctx context.Context) {
 	out.OKf(ctx, "Locked")
 }
 
+// replCd changes the current folder of the REPL session. Without an argument
+// it changes to the root of the store.
+func (s *miscHandler) replCd(ctx context.Context, sess *replSession, args []string) {
+	if len(args) > 1 {
+		out.Errorf(ctx, "Usage: cd [folder]")
+
+		return
+	}
+
+	dir := ""
+	if len(args) > 0 {
+		dir = strings.TrimSuffix(sess.resolve(args[0]), "/")
+	}
+
+	if dir != "" && !s.Store.IsDir(ctx, dir) {
+		out.Errorf(ctx, "No such folder: /%s", dir)
+
+		return
+	}
+
+	sess.cwd = dir
+}
+
+// replIdleTimeout returns the time without input after which the REPL
+// locks the stores. Zero or less disables the idle lock.
+func replIdleTimeout(ctx context.Context) time.Duration {
+	return time.Duration(config.AsIntWithDefault(config.String(ctx, "repl.idle-timeout"), 900)) * time.Second
+}
+
 // escapeEntry escapes special shell characters in a secret name so that
 // tab-completed values are safe to use on the REPL command line.
 // Spaces, quotes, backslashes and other special chars are backslash-escaped.
@@ -373,12 +434,22 @@ func (g *gopassCompleter) completeFromList(candidates []string, prefix string, n
 }
 
 // newGopassCompleter builds a gopassCompleter from the current app state.
-func (s *miscHandler) newGopassCompleter(ctx context.Context, cmd *cli.Command) *gopassCompleter {
+// Entries are completed relative to the current folder cwd.
+func (s *miscHandler) newGopassCompleter(ctx context.Context, cmd *cli.Command, cwd string) *gopassCompleter {
 	entries, err := s.Store.List(ctx, tree.INF)
 	if err != nil {
 		debug.Log("failed to list secrets: %s", err)
 		entries = nil
 	}
+	if cwd != "" {
+		rel := make([]string, 0, len(entries))
+		for _, e := range entries {
+			if name, found := strings.CutPrefix(e, cwd+"/"); found {
+				rel = append(rel, name)
+			}
+		}
+		entries = rel
+	}
 
 	gc := &gopassCompleter{
 		cmdSpecs: make(map[string]completionSpec),
@@ -0,0 +1,236 @@
+package action
+
+import (
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+// replNameArgs maps commands to the number of leading positional arguments
+// that are secret names and need to be resolved relative to the current
+// folder of a REPL session. -1 means all positional arguments.
+var replNameArgs = map[string]int{
+	"cat":      1,
+	"copy":     2,
+	"delete":   1,
+	"edit":     1,
+	"env":      1,
+	"generate": 1,
+	"history":  1,
+	"insert":   1,
+	"link":     2,
+	"list":     1,
+	"merge":    -1,
+	"move":     2,
+	"otp":      1,
+	"otp add":  1,
+	"rotate":   1,
+	"show":     1,
+	"sum":      1,
+}
+
+// replNoHistory lists commands whose arguments can contain secret values,
+// e.g. otpauth:// URLs or age identities. They are never added to the
+// REPL history.
+var replNoHistory = map[string]bool{
+	"age identities add": true,
+	"otp add":            true,
+}
+
+// replSession holds the state of a REPL session that lives between commands:
+// the current folder and the idle timer that locks the stores.
+type replSession struct {
+	cwd     string
+	timeout time.Duration
+	lockFn  func()
+
+	mu     sync.Mutex
+	timer  *time.Timer
+	locked bool
+}
+
+func newREPLSession(timeout time.Duration, lockFn func()) *replSession {
+	return &replSession{
+		timeout: timeout,
+		lockFn:  lockFn,
+	}
+}
+
+// idle starts the idle timer. It must be called before waiting for input.
+func (r *replSession) idle() {
+	if r.timeout <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.timer = time.AfterFunc(r.timeout, r.lockIdle)
+}
+
+func (r *replSession) lockIdle() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the session became busy while the timer fired.
+	if r.timer == nil {
+		return
+	}
+	r.timer = nil
+
+	r.lockFn()
+	r.locked = true
+}
+
+// busy stops the idle timer before a command is run. It reports whether
+// the session was locked while waiting for input.
+func (r *replSession) busy() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+
+	locked := r.locked
+	r.locked = false
+
+	return locked
+}
+
+// prompt returns the REPL prompt including the current folder.
+func (r *replSession) prompt() string {
+	if r.cwd == "" {
+		return "gopass> "
+	}
+
+	return "gopass:" + r.cwd + "> "
+}
+
+// resolve returns the full name of a secret or folder given relative to the
+// current folder. Names starting with a slash are relative to the root of
+// the store. A leading .. never leaves the root.
+func (r *replSession) resolve(name string) string {
+	if !strings.HasPrefix(name, "/") {
+		name = r.cwd + "/" + name
+	}
+
+	full := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if full != "" && strings.HasSuffix(name, "/") {
+		full += "/"
+	}
+
+	return full
+}
+
+// resolveArgs rewrites the secret names in args relative to the current folder.
+// A bare list, or ls, lists the current folder.
+func (r *replSession) resolveArgs(root *cli.Command, args []string) []string {
+	cmd, name, pos := replCommand(root, args)
+
+	n, found := replNameArgs[name]
+	if !found {
+		return args
+	}
+
+	res := slices.Clone(args)
+	resolved := 0
+	for i := pos; i < len(res); i++ {
+		if res[i] == "--" {
+			break
+		}
+		if strings.HasPrefix(res[i], "-") && len(res[i]) > 1 {
+			if flagTakesValue(cmd, res[i]) {
+				i++
+			}
+
+			continue
+		}
+		if n >= 0 && resolved >= n {
+			break
+		}
+		res[i] = r.resolve(res[i])
+		resolved++
+	}
+
+	if name == "list" && resolved == 0 && r.cwd != "" {
+		res = append(res, r.cwd)
+	}
+
+	return res
+}
+
+// record reports whether a line with the given args may be added to the
+// REPL history. Lines that can contain secret values are never recorded.
+func (r *replSession) record(root *cli.Command, args []string) bool {
+	_, name, _ := replCommand(root, args)
+	if replNoHistory[name] {
+		return false
+	}
+
+	for _, arg := range args {
+		switch {
+		case name == "otp" && arg == "--resync":
+			return false
+		case strings.HasPrefix(strings.ToLower(arg), "otpauth://"):
+			return false
+		case strings.HasPrefix(strings.ToUpper(arg), "AGE-SECRET-KEY-"):
+			return false
+		}
+	}
+
+	return true
+}
+
+// replCommand finds the (sub-)command invoked by args. It returns the command,
+// its full name (e.g. "otp add") and the index of the first argument after
+// the command name. Unknown commands are shown by the root command, so their
+// name is "show".
+func replCommand(root *cli.Command, args []string) (*cli.Command, string, int) {
+	if len(args) < 1 {
+		return root, "", 0
+	}
+
+	cmd := root.Command(args[0])
+	if cmd == nil {
+		return root, "show", 0
+	}
+
+	names := []string{cmd.Name}
+	pos := 1
+	for pos < len(args) {
+		sub := cmd.Command(args[pos])
+		if sub == nil {
+			break
+		}
+		cmd = sub
+		names = append(names, sub.Name)
+		pos++
+	}
+
+	return cmd, strings.Join(names, " "), pos
+}
+
+// flagTakesValue reports whether the flag token consumes the next argument.
+func flagTakesValue(cmd *cli.Command, tok string) bool {
+	if strings.Contains(tok, "=") {
+		return false
+	}
+
+	name := strings.TrimLeft(tok, "-")
+	for _, f := range cmd.Flags {
+		if !slices.Contains(f.Names(), name) {
+			continue
+		}
+		_, isBool := f.(*cli.BoolFlag)
+
+		return !isBool
+	}
+
+	return false
+}
@@ -0,0 +1,191 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/gopasspw/gopass/tests/gptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+func TestREPLSessionResolve(t *testing.T) {
+	t.Parallel()
+
+	sess := newREPLSession(0, nil)
+	sess.cwd = "web/shop"
+
+	for in, want := range map[string]string{
+		"login":       "web/shop/login",
+		"sub/":        "web/shop/sub/",
+		"../mail":     "web/mail",
+		"../../../x":  "x",
+		"/root/entry": "root/entry",
+		"/":           "",
+		".":           "web/shop",
+	} {
+		assert.Equal(t, want, sess.resolve(in), in)
+	}
+}
+
+func TestREPLSessionResolveArgs(t *testing.T) {
+	t.Parallel()
+
+	root := &cli.Command{
+		Name: "gopass",
+		Commands: []*cli.Command{
+			{
+				Name: "show",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "clip", Aliases: []string{"c"}},
+					&cli.StringFlag{Name: "revision"},
+				},
+			},
+			{Name: "move", Aliases: []string{"mv"}},
+			{Name: "list", Aliases: []string{"ls"}},
+			{Name: "generate"},
+			{Name: "env"},
+			{
+				Name: "otp",
+				Commands: []*cli.Command{
+					{Name: "add"},
+				},
+			},
+			{Name: "config"},
+		},
+	}
+
+	sess := newREPLSession(0, nil)
+	sess.cwd = "web"
+
+	for _, tc := range []struct {
+		in   []string
+		want []string
+	}{
+		{[]string{"show", "-c", "login"}, []string{"show", "-c", "web/login"}},
+		{[]string{"show", "--revision", "HEAD", "login", "user"}, []string{"show", "--revision", "HEAD", "web/login", "user"}},
+		{[]string{"mv", "a", "../b"}, []string{"mv", "web/a", "b"}},
+		{[]string{"ls"}, []string{"ls", "web"}},
+		{[]string{"list", "sub"}, []string{"list", "web/sub"}},
+		{[]string{"generate", "login", "24"}, []string{"generate", "web/login", "24"}},
+		{[]string{"env", "login", "--", "cmd", "arg"}, []string{"env", "web/login", "--", "cmd", "arg"}},
+		{[]string{"otp", "add", "login", "otpauth://x"}, []string{"otp", "add", "web/login", "otpauth://x"}},
+		{[]string{"config", "core.autosync"}, []string{"config", "core.autosync"}},
+		{[]string{"login"}, []string{"web/login"}},
+	} {
+		assert.Equal(t, tc.want, sess.resolveArgs(root, tc.in), tc.in)
+	}
+
+	sess.cwd = ""
+	assert.Equal(t, []string{"ls"}, sess.resolveArgs(root, []string{"ls"}))
+	assert.Equal(t, []string{"show", "login"}, sess.resolveArgs(root, []string{"show", "/login"}))
+}
+
+func TestREPLSessionRecord(t *testing.T) {
+	t.Parallel()
+
+	root := &cli.Command{
+		Name: "gopass",
+		Commands: []*cli.Command{
+			{Name: "show"},
+			{
+				Name: "otp",
+				Commands: []*cli.Command{
+					{Name: "add"},
+				},
+			},
+			{
+				Name: "age",
+				Commands: []*cli.Command{
+					{
+						Name: "identities",
+						Commands: []*cli.Command{
+							{Name: "add"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	sess := newREPLSession(0, nil)
+
+	assert.True(t, sess.record(root, []string{"show", "foo"}))
+	assert.True(t, sess.record(root, []string{"otp", "foo"}))
+	assert.False(t, sess.record(root, []string{"otp", "add", "foo", "otpauth://totp/foo?secret=ABC"}))
+	assert.False(t, sess.record(root, []string{"otp", "foo", "--resync", "123456", "654321"}))
+	assert.False(t, sess.record(root, []string{"age", "identities", "add"}))
+	assert.False(t, sess.record(root, []string{"insert", "AGE-SECRET-KEY-1ABC"}))
+}
+
+func TestREPLSessionIdleLock(t *testing.T) {
+	t.Parallel()
+
+	var locks atomic.Int32
+	sess := newREPLSession(20*time.Millisecond, func() { locks.Add(1) })
+
+	sess.idle()
+	assert.Eventually(t, func() bool { return locks.Load() == 1 }, time.Second, 5*time.Millisecond)
+	assert.True(t, sess.busy())
+	assert.False(t, sess.busy())
+
+	// a busy session is never locked.
+	sess.idle()
+	assert.False(t, sess.busy())
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), locks.Load())
+}
+
+func TestREPLCurrentFolder(t *testing.T) {
+	u := gptest.NewUnitTester(t)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+	ctx = ctxutil.WithInteractive(ctx, false)
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	require.NotNil(t, act)
+	ctx = act.cfg.WithConfig(ctx)
+
+	sec := secrets.NewAKV()
+	sec.SetPassword("sub-password")
+	require.NoError(t, act.Store.Set(ctx, "sub/bar", sec))
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	out.Stderr = buf
+	stdout = buf
+	stdin = bytes.NewBufferString("cd sub\npwd\nshow bar\ncd nope\ncd ..\npwd\nquit\n")
+	color.NoColor = true
+	defer func() {
+		out.Stdout = os.Stdout
+		out.Stderr = os.Stderr
+		stdout = os.Stdout
+		stdin = os.Stdin
+	}()
+
+	app := &cli.Command{
+		Name:     "gopass",
+		Commands: act.GetCommands(),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return act.Show(ctx, cmd)
+		},
+	}
+
+	require.NoError(t, act.REPL(ctx, app))
+	assert.Contains(t, buf.String(), "/sub\n")
+	assert.Contains(t, buf.String(), "sub-password")
+	assert.Contains(t, buf.String(), "No such folder: /sub/nope")
+	assert.Contains(t, buf.String(), "/\n")
+}
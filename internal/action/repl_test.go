@@ -163,7 +163,7 @@ func TestNewGopassCompleter(t *testing.T) {
 		stdout = os.Stdout
 	}()
 
-	gc := act.newGopassCompleter(ctx, gptest.CliCtx(ctx, t))
+	gc := act.newGopassCompleter(ctx, gptest.CliCtx(ctx, t), "")
 	require.NotNil(t, gc)
 	assert.NotEmpty(t, gc.entries)
 }
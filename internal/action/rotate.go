@@ -0,0 +1,193 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path"
+	"runtime"
+
+	"github.com/gopasspw/gopass/internal/action/exit"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/internal/policy"
+	"github.com/gopasspw/gopass/internal/store"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/pwgen"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	// rotatePendingKey holds the new password of a secret until its change
+	// is confirmed.
+	rotatePendingKey = "password-pending"
+	// rotateChangeURLKey holds the URL to change the password of a secret.
+	rotateChangeURLKey = "password-change-url"
+)
+
+// openURLFn opens an URL in the default browser.
+var openURLFn = func(ctx context.Context, u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "open", u)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.CommandContext(ctx, "xdg-open", u)
+	}
+
+	return cmd.Start()
+}
+
+// Rotate assists with changing the password of a secret. It generates a new
+// password that satisfies the password rules of the domain and stores it
+// next to the current one. Once the password was changed on the website
+// --confirm replaces the current password, --abort discards the new one.
+func (s *generateHandler) Rotate(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+	ctx = WithClip(ctx, cmd.Bool("clip"))
+	ctx = ctxutil.WithForce(ctx, cmd.Bool("force"))
+
+	name := cmd.Args().First()
+	if name == "" {
+		return exit.Error(exit.Usage, nil, "Usage: %s rotate [--confirm|--abort] <NAME>", s.Name)
+	}
+	if cmd.Bool("confirm") && cmd.Bool("abort") {
+		return exit.Error(exit.Usage, nil, "--confirm and --abort are mutually exclusive")
+	}
+
+	ctx = config.WithMount(ctx, s.Store.MountPoint(name))
+
+	sec, err := s.Store.Get(ctx, name)
+	if err != nil {
+		return exit.Error(exit.NotFound, err, "failed to retrieve secret %q: %s", name, err)
+	}
+
+	switch {
+	case cmd.Bool("confirm"):
+		return s.rotateConfirm(ctx, name, sec)
+	case cmd.Bool("abort"):
+		return s.rotateAbort(ctx, name, sec)
+	default:
+		return s.rotateStart(ctx, cmd, name, sec)
+	}
+}
+
+func (s *generateHandler) rotateStart(ctx context.Context, cmd *cli.Command, name string, sec gopass.Secret) error {
+	if _, found := sec.Get(rotatePendingKey); found && !cmd.Bool("force") {
+		return exit.Error(exit.Aborted, nil, "A password rotation of %s is already pending. Use --confirm, --abort or --force", name)
+	}
+
+	domain, _ := hasPwRuleForSecret(ctx, name)
+	if domain != "" {
+		out.Noticef(ctx, "Using password rules for %s ...", domain)
+	} else {
+		domain = path.Base(name)
+		debug.Log("no password rules found for %s, using the defaults", name)
+	}
+
+	length, _ := config.DefaultPasswordLengthFromEnv(ctx)
+	gen := func() string {
+		return pwgen.NewCrypticForDomain(ctx, length, domain).Password()
+	}
+	password := gen()
+	if password == "" {
+		return exit.Error(exit.Unknown, nil, "failed to generate password for %s", domain)
+	}
+
+	p, file, err := policy.Lookup(ctx, s.Store, name)
+	if err != nil {
+		return exit.Error(exit.Policy, err, "%s", err)
+	}
+	if p != nil {
+		for i := 0; i < policyRetries && len(p.Check(password)) > 0; i++ {
+			debug.Log("generated password violates the policy %s, retrying", file)
+			password = gen()
+		}
+
+		ctx, err = policy.Apply(ctx, p, file, password, ctxutil.IsForce(ctx))
+		if err != nil {
+			return exit.Error(exit.Policy, err, "%s. Use --force to override it", err)
+		}
+	}
+
+	// the new password is stored before it is shown, so that it is not lost
+	// if the website accepted it.
+	_ = sec.Set(rotatePendingKey, password)
+	ctx = ctxutil.WithCommitMessage(ctx, "Start password rotation")
+	if err := s.Store.Set(ctx, name, sec); err != nil {
+		return exit.Error(exit.Encrypt, err, "failed to save new password of %q: %s", name, err)
+	}
+
+	if err := s.generateCopyOrPrint(ctx, cmd, name, rotatePendingKey, password); err != nil {
+		return err
+	}
+
+	if u := rotateChangeURL(ctx, name, sec); u != "" {
+		out.Printf(ctx, "Change the password at %s", u)
+		if cmd.Bool("open") {
+			if err := openURLFn(ctx, u); err != nil {
+				out.Errorf(ctx, "Failed to open %s: %s", u, err)
+			}
+		}
+	} else {
+		out.Noticef(ctx, "No URL to change the password of %s is known", name)
+	}
+
+	out.Noticef(ctx, "Run '%s rotate --confirm %s' once the password was changed or '%s rotate --abort %s' to keep the current one", s.Name, name, s.Name, name)
+
+	return nil
+}
+
+func (s *generateHandler) rotateConfirm(ctx context.Context, name string, sec gopass.Secret) error {
+	password, found := sec.Get(rotatePendingKey)
+	if !found {
+		return exit.Error(exit.NotFound, nil, "No password rotation of %s is pending", name)
+	}
+
+	// the policy may have changed since the rotation was started.
+	ctx, err := s.enforcePolicy(ctx, name, password)
+	if err != nil {
+		return err
+	}
+
+	sec.SetPassword(password)
+	sec.Del(rotatePendingKey)
+
+	ctx = ctxutil.WithCommitMessage(ctx, "Confirm password rotation")
+	if err := s.Store.Set(ctx, name, sec); err != nil && !errors.Is(err, store.ErrMeaninglessWrite) {
+		return exit.Error(exit.Encrypt, err, "failed to save new password of %q: %s", name, err)
+	}
+
+	out.OKf(ctx, "New password of %s confirmed", name)
+
+	return nil
+}
+
+func (s *generateHandler) rotateAbort(ctx context.Context, name string, sec gopass.Secret) error {
+	if !sec.Del(rotatePendingKey) {
+		return exit.Error(exit.NotFound, nil, "No password rotation of %s is pending", name)
+	}
+
+	ctx = ctxutil.WithCommitMessage(ctx, "Abort password rotation")
+	if err := s.Store.Set(ctx, name, sec); err != nil {
+		return exit.Error(exit.Encrypt, err, "failed to restore password of %q: %s", name, err)
+	}
+
+	out.OKf(ctx, "Password rotation of %s aborted, keeping the current password", name)
+
+	return nil
+}
+
+// rotateChangeURL returns the URL to change the password of a secret. An URL
+// stored in the secret takes precedence over the well-known change URLs.
+func rotateChangeURL(ctx context.Context, name string, sec gopass.Secret) string {
+	if u, found := sec.Get(rotateChangeURLKey); found && u != "" {
+		return u
+	}
+
+	return hasChangeURL(ctx, name)
+}
@@ -0,0 +1,128 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/gopasspw/gopass/tests/gptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotate(t *testing.T) {
+	u := gptest.NewUnitTester(t)
+
+	ctx := t.Context()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+	ctx = ctxutil.WithInteractive(ctx, false)
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	require.NotNil(t, act)
+	ctx = act.cfg.WithConfig(ctx)
+
+	require.NoError(t, act.cfg.Set("", "generate.autoclip", "false"))
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	out.Stderr = buf
+	defer func() {
+		out.Stdout = os.Stdout
+		out.Stderr = os.Stderr
+	}()
+
+	var opened []string
+	oldOpen := openURLFn
+	openURLFn = func(_ context.Context, u string) error {
+		opened = append(opened, u)
+
+		return nil
+	}
+	defer func() {
+		openURLFn = oldOpen
+	}()
+
+	name := "websites/apple.com/alice"
+	sec := secrets.NewAKV()
+	sec.SetPassword("old")
+	require.NoError(t, act.Store.Set(ctx, name, sec))
+
+	pending := func(t *testing.T) (string, string, bool) {
+		t.Helper()
+
+		sec, err := act.Store.Get(ctx, name)
+		require.NoError(t, err)
+		pw, found := sec.Get(rotatePendingKey)
+
+		return sec.Password(), pw, found
+	}
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.Error(t, act.Rotate(ctx, gptest.CliCtx(ctx, t)))
+		require.Error(t, act.Rotate(ctx, gptest.CliCtx(ctx, t, "does/not/exist")))
+		require.Error(t, act.Rotate(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"confirm": "true", "abort": "true"}, name)))
+		require.Error(t, act.Rotate(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"confirm": "true"}, name)))
+		require.Error(t, act.Rotate(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"abort": "true"}, name)))
+	})
+
+	t.Run("start and abort", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.NoError(t, act.Rotate(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"open": "true"}, name)))
+		assert.Contains(t, buf.String(), "Using password rules for apple.com")
+		assert.Contains(t, buf.String(), "Change the password at https://appleid.apple.com/account/manage")
+		assert.Equal(t, []string{"https://appleid.apple.com/account/manage"}, opened)
+
+		pw, newPw, found := pending(t)
+		assert.Equal(t, "old", pw)
+		require.True(t, found)
+		// apple.com requires digits, lower and upper case letters
+		assert.Regexp(t, `[0-9]`, newPw)
+		assert.Regexp(t, `[a-z]`, newPw)
+		assert.Regexp(t, `[A-Z]`, newPw)
+
+		// a pending password is not replaced without --force
+		require.Error(t, act.Rotate(ctx, gptest.CliCtx(ctx, t, name)))
+		require.NoError(t, act.Rotate(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"force": "true"}, name)))
+		_, forcedPw, _ := pending(t)
+		assert.NotEqual(t, newPw, forcedPw)
+
+		require.NoError(t, act.Rotate(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"abort": "true"}, name)))
+		pw, _, found = pending(t)
+		assert.Equal(t, "old", pw)
+		assert.False(t, found)
+	})
+
+	t.Run("start and confirm", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.NoError(t, act.Rotate(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"print": "true"}, name)))
+		_, newPw, found := pending(t)
+		require.True(t, found)
+		assert.Contains(t, buf.String(), newPw)
+
+		require.NoError(t, act.Rotate(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"confirm": "true"}, name)))
+		pw, _, found := pending(t)
+		assert.Equal(t, newPw, pw)
+		assert.False(t, found)
+	})
+
+	t.Run("change URL from the secret", func(t *testing.T) {
+		defer buf.Reset()
+
+		sec := secrets.NewAKV()
+		sec.SetPassword("old")
+		require.NoError(t, sec.Set(rotateChangeURLKey, "https://example.org/password"))
+		require.NoError(t, act.Store.Set(ctx, "example", sec))
+
+		require.NoError(t, act.Rotate(ctx, gptest.CliCtx(ctx, t, "example")))
+		assert.Contains(t, buf.String(), "Change the password at https://example.org/password")
+	})
+}
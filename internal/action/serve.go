@@ -0,0 +1,234 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/action/exit"
+	"github.com/gopasspw/gopass/internal/apiserver"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/internal/policy"
+	"github.com/gopasspw/gopass/internal/tree"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/urfave/cli/v3"
+)
+
+// Serve runs the API server on a Unix socket.
+func (s *serveHandler) Serve(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+	ctx = ctxutil.WithInteractive(ctx, false)
+
+	socket := cmd.String("socket")
+	if socket == "" {
+		return exit.Error(exit.Usage, nil, "Usage: %s serve --socket <path>", s.Name)
+	}
+
+	tokens, err := apiserver.LoadTokens(apiserver.TokensFile())
+	if err != nil {
+		return exit.Error(exit.Config, err, "%s", err)
+	}
+	if len(tokens) < 1 {
+		return exit.Error(exit.Usage, nil, "No API tokens found. Create one with '%s serve token add <name>'", s.Name)
+	}
+
+	inited, err := s.Store.IsInitialized(ctx)
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "Failed to initialize store: %s", err)
+	}
+	if !inited {
+		return exit.Error(exit.NotInitialized, nil, "password store not initialized")
+	}
+
+	l, err := apiserver.Listen(socket)
+	if err != nil {
+		return exit.Error(exit.IO, err, "%s", err)
+	}
+
+	idle := time.Duration(config.AsIntWithDefault(config.String(ctx, "serve.idle-timeout"), 900)) * time.Second
+	out.Printf(ctx, "Listening on %s", socket)
+
+	if err := apiserver.New(&serveStore{h: s}, tokens, idle).Serve(ctx, l); err != nil {
+		return exit.Error(exit.IO, err, "%s", err)
+	}
+
+	out.Printf(ctx, "Stopped")
+
+	return nil
+}
+
+// ServeTokenAdd creates a new API token and prints it.
+func (s *serveHandler) ServeTokenAdd(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+
+	name := cmd.Args().First()
+	if name == "" {
+		return exit.Error(exit.NoName, nil, "Usage: %s serve token add [--prefix <folder>] <name>", s.Name)
+	}
+
+	fn := apiserver.TokensFile()
+	tokens, err := apiserver.LoadTokens(fn)
+	if err != nil {
+		return exit.Error(exit.Config, err, "%s", err)
+	}
+	if slices.ContainsFunc(tokens, func(t apiserver.Token) bool { return t.Name == name }) {
+		return exit.Error(exit.Usage, nil, "Token %s already exists", name)
+	}
+
+	tok, secret, err := apiserver.NewToken(name, cmd.StringSlice("prefix"))
+	if err != nil {
+		return exit.Error(exit.Unknown, err, "%s", err)
+	}
+
+	if err := apiserver.SaveTokens(fn, append(tokens, tok)); err != nil {
+		return exit.Error(exit.IO, err, "%s", err)
+	}
+
+	out.OKf(ctx, "Created token %s for %s", name, tokenScope(tok))
+	out.Noticef(ctx, "The token is only shown once:")
+	out.Printf(ctx, "%s", secret)
+
+	return nil
+}
+
+// ServeTokenList lists the API tokens.
+func (s *serveHandler) ServeTokenList(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+
+	tokens, err := apiserver.LoadTokens(apiserver.TokensFile())
+	if err != nil {
+		return exit.Error(exit.Config, err, "%s", err)
+	}
+
+	for _, t := range tokens {
+		out.Printf(ctx, "%s - %s (created %s)", t.Name, tokenScope(t), t.Created.Format(time.DateOnly))
+	}
+
+	return nil
+}
+
+// ServeTokenRemove removes an API token. A running server keeps accepting
+// it until it is restarted.
+func (s *serveHandler) ServeTokenRemove(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+
+	name := cmd.Args().First()
+	if name == "" {
+		return exit.Error(exit.NoName, nil, "Usage: %s serve token remove <name>", s.Name)
+	}
+
+	fn := apiserver.TokensFile()
+	tokens, err := apiserver.LoadTokens(fn)
+	if err != nil {
+		return exit.Error(exit.Config, err, "%s", err)
+	}
+
+	n := len(tokens)
+	tokens = slices.DeleteFunc(tokens, func(t apiserver.Token) bool { return t.Name == name })
+	if len(tokens) == n {
+		return exit.Error(exit.NotFound, nil, "Token %s not found", name)
+	}
+
+	if err := apiserver.SaveTokens(fn, tokens); err != nil {
+		return exit.Error(exit.IO, err, "%s", err)
+	}
+
+	out.OKf(ctx, "Removed token %s", name)
+
+	return nil
+}
+
+func tokenScope(t apiserver.Token) string {
+	if t.Unrestricted() {
+		return "the whole store"
+	}
+
+	return strings.Join(t.Prefixes, ", ")
+}
+
+// serveStore implements gopass.Store on top of the root store for the API
+// server.
+type serveStore struct {
+	h *serveHandler
+}
+
+var _ gopass.Store = &serveStore{}
+
+func (s *serveStore) String() string {
+	return s.h.Store.String()
+}
+
+func (s *serveStore) List(ctx context.Context) ([]string, error) {
+	return s.h.Store.List(ctx, tree.INF)
+}
+
+func (s *serveStore) Get(ctx context.Context, name, revision string) (gopass.Secret, error) {
+	if revision == "" || revision == "latest" {
+		return s.h.Store.Get(ctx, name)
+	}
+
+	_, sec, err := s.h.Store.GetRevision(ctx, name, revision)
+
+	return sec, err
+}
+
+// Set enforces the password policy of the secret.
+func (s *serveStore) Set(ctx context.Context, name string, sec gopass.Byter) error {
+	pw := secrets.ParseAKV(sec.Bytes()).Password()
+	if p, ok := sec.(interface{ Password() string }); ok {
+		pw = p.Password()
+	}
+
+	ctx, err := policy.Enforce(ctx, s.h.Store, name, pw, false)
+	if err != nil {
+		return err
+	}
+
+	return s.h.Store.Set(ctxutil.WithCommitMessage(ctx, "Saved via API"), name, sec)
+}
+
+func (s *serveStore) Revisions(ctx context.Context, name string) ([]string, error) {
+	revs, err := s.h.Store.ListRevisions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, 0, len(revs))
+	for _, r := range revs {
+		hashes = append(hashes, r.Hash)
+	}
+
+	return hashes, nil
+}
+
+func (s *serveStore) Remove(ctx context.Context, name string) error {
+	return s.h.Store.Delete(ctxutil.WithCommitMessage(ctx, "Removed via API"), name)
+}
+
+func (s *serveStore) RemoveAll(ctx context.Context, prefix string) error {
+	return s.h.Store.Prune(ctxutil.WithCommitMessage(ctx, "Removed via API"), prefix)
+}
+
+// Rename never overwrites an existing secret. The server serializes all
+// writes, so the secret can not be created between the check and the move.
+func (s *serveStore) Rename(ctx context.Context, src, dest string) error {
+	if s.h.Store.Exists(ctx, dest) {
+		return fmt.Errorf("secret %s already exists", dest)
+	}
+
+	return s.h.Store.Move(ctxutil.WithCommitMessage(ctx, "Renamed via API"), src, dest)
+}
+
+func (s *serveStore) Sync(ctx context.Context) error {
+	return s.h.syncFn(ctx, "", false)
+}
+
+// Close does nothing, the store is closed when gopass exits.
+func (s *serveStore) Close(ctx context.Context) error {
+	return nil
+}
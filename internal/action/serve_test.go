@@ -0,0 +1,183 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/apiserver"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/gopasspw/gopass/tests/gptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeTokens(t *testing.T) {
+	u := gptest.NewUnitTester(t)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	ctx = act.cfg.WithConfig(ctx)
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	defer func() {
+		out.Stdout = os.Stdout
+	}()
+
+	t.Run("add", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.Error(t, act.ServeTokenAdd(ctx, gptest.CliCtx(ctx, t)))
+		require.NoError(t, act.ServeTokenAdd(ctx, gptest.CliCtx(ctx, t, "scripts")))
+		assert.Contains(t, buf.String(), "Created token scripts for the whole store")
+		assert.Contains(t, buf.String(), "gopass_")
+		require.Error(t, act.ServeTokenAdd(ctx, gptest.CliCtx(ctx, t, "scripts")))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.NoError(t, act.ServeTokenList(ctx, gptest.CliCtx(ctx, t)))
+		assert.Contains(t, buf.String(), "scripts - the whole store")
+		assert.NotContains(t, buf.String(), "gopass_")
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		defer buf.Reset()
+
+		require.NoError(t, act.ServeTokenRemove(ctx, gptest.CliCtx(ctx, t, "scripts")))
+		require.Error(t, act.ServeTokenRemove(ctx, gptest.CliCtx(ctx, t, "scripts")))
+
+		tokens, err := apiserver.LoadTokens(apiserver.TokensFile())
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+}
+
+func TestServe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test client does not support unix sockets on windows")
+	}
+
+	u := gptest.NewUnitTester(t)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	ctx = act.cfg.WithConfig(ctx)
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	defer func() {
+		out.Stdout = os.Stdout
+	}()
+
+	sec := secrets.NewAKV()
+	sec.SetPassword("s3cr3t")
+	require.NoError(t, act.Store.Set(ctx, "ci/deploy", sec))
+
+	// socket paths are limited to about 100 characters.
+	dir, err := os.MkdirTemp("", "gopass-serve")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "gopass.sock")
+
+	require.Error(t, act.Serve(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"socket": socket})), "no tokens")
+
+	tok, secret, err := apiserver.NewToken("ci", []string{"ci"})
+	require.NoError(t, err)
+	require.NoError(t, apiserver.SaveTokens(apiserver.TokensFile(), []apiserver.Token{tok}))
+
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := gptest.CliCtxWithFlags(ctx, t, map[string]string{"socket": socket})
+	done := make(chan error, 1)
+	go func() {
+		done <- act.Serve(sctx, cmd)
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	do := func(method, path, body string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(t.Context(), method, "http://gopass"+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+secret)
+
+		var resp *http.Response
+		require.Eventually(t, func() bool {
+			resp, err = client.Do(req) //nolint:bodyclose
+			return err == nil
+		}, 5*time.Second, 10*time.Millisecond)
+
+		return resp
+	}
+
+	resp := do(http.MethodGet, "/v1/secrets/ci/deploy", "")
+	var got apiserver.Secret
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, "s3cr3t", got.Password)
+
+	resp = do(http.MethodPut, "/v1/secrets/ci/new", `{"password":"n3w"}`)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// rename never overwrites existing secrets.
+	resp = do(http.MethodPost, "/v1/rename", `{"from":"ci/new","to":"ci/deploy"}`)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	resp = do(http.MethodPost, "/v1/rename", `{"from":"ci/new","to":"ci/renamed"}`)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	renamed, err := act.Store.Get(ctx, "ci/renamed")
+	require.NoError(t, err)
+	assert.Equal(t, "n3w", renamed.Password())
+
+	// writes enforce the password policy of the folder.
+	require.NoError(t, os.WriteFile(filepath.Join(u.StoreDir(""), "ci", ".gopass-policy.yml"), []byte("min_length: 16\n"), 0o600))
+	resp = do(http.MethodPut, "/v1/secrets/ci/weak", `{"password":"short"}`)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.False(t, act.Store.Exists(ctx, "ci/weak"))
+
+	resp = do(http.MethodGet, "/v1/secrets/ci/missing", "")
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop")
+	}
+}
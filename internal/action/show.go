@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/gopasspw/gopass/internal/action/exit"
+	"github.com/gopasspw/gopass/internal/autotype"
 	"github.com/gopasspw/gopass/internal/config"
 	"github.com/gopasspw/gopass/internal/hook"
 	"github.com/gopasspw/gopass/internal/notify"
@@ -45,7 +46,8 @@ func clipFlagValue(cmd *cli.Command) string {
 func isTrailingFlag(arg string) bool {
 	return arg == "-c" || arg == "--clip" ||
 		strings.HasPrefix(arg, "-c=") || strings.HasPrefix(arg, "--clip=") ||
-		arg == "-C" || arg == "--alsoclip"
+		arg == "-C" || arg == "--alsoclip" ||
+		arg == "--type"
 }
 
 func isShowFuzzySearchEnabled(ctx context.Context, cmd *cli.Command) bool {
@@ -73,6 +75,10 @@ func showParseTrailingFlags(ctx context.Context, cmd *cli.Command) context.Conte
 			ctx = WithAlsoClip(ctx, true)
 			ctx = WithClip(ctx, true)
 
+			continue
+		case arg == "--type":
+			ctx = WithAutotype(ctx, true)
+
 			continue
 		default:
 			continue
@@ -122,6 +128,10 @@ func showParseArgs(ctx context.Context, cmd *cli.Command) context.Context {
 		ctx = WithQRBody(ctx, cmd.Bool("qrbody"))
 	}
 
+	if cmd.IsSet("type") {
+		ctx = WithAutotype(ctx, cmd.Bool("type"))
+	}
+
 	if cmd.IsSet("password") {
 		ctx = WithPasswordOnly(ctx, cmd.Bool("password"))
 	}
@@ -269,6 +279,10 @@ func (s *secretHandler) showHandleOutputChars(ctx context.Context, pw string, ch
 
 // showHandleOutput displays a secret.
 func (s *secretHandler) showHandleOutput(ctx context.Context, name string, sec gopass.Secret) error {
+	if IsAutotype(ctx) {
+		return s.showAutotype(ctx, name, sec)
+	}
+
 	pw, body, err := s.showGetContent(ctx, sec)
 	if err != nil {
 		return err
@@ -329,6 +343,54 @@ func (s *secretHandler) showHandleOutput(ctx context.Context, name string, sec g
 	return nil
 }
 
+// showAutotype types the key, the autotype sequence or the password of the
+// secret into the focused window after a countdown.
+func (s *secretHandler) showAutotype(ctx context.Context, name string, sec gopass.Secret) error {
+	var steps []autotype.Step
+
+	switch {
+	case HasKey(ctx):
+		v, found := sec.Get(GetKey(ctx))
+		if !found {
+			return exit.Error(exit.NotFound, store.ErrNoKey, "%v", store.ErrNoKey)
+		}
+		steps = []autotype.Step{{Text: v}}
+	default:
+		seq, found := sec.Get("autotype")
+		if !found {
+			seq = autotype.DefaultSequence
+		}
+		var err error
+		steps, err = autotype.Sequence(seq, sec)
+		if err != nil {
+			return exit.Error(exit.NotFound, err, "invalid autotype sequence for %s: %s", name, err)
+		}
+	}
+
+	b, err := autotype.New(config.String(ctx, "autotype.backend"))
+	if err != nil {
+		return exit.Error(exit.Unsupported, err, "%s", err)
+	}
+
+	delay := config.AsIntWithDefault(config.String(ctx, "autotype.delay"), 3)
+	if delay > 0 {
+		out.Printf(ctx, "Typing %s with %s, focus the target window", name, b.Name())
+	}
+	if err := autotype.Countdown(ctx, delay, func(remaining int) {
+		out.Printf(ctx, "%d ...", remaining)
+	}); err != nil {
+		return exit.Error(exit.Aborted, err, "Aborted")
+	}
+
+	if err := autotype.Type(ctx, b, steps); err != nil {
+		return exit.Error(exit.Unknown, err, "%s", err)
+	}
+
+	debug.Log("typed %s with %s", name, b.Name())
+
+	return nil
+}
+
 func (s *secretHandler) showGetContent(ctx context.Context, sec gopass.Secret) (string, string, error) {
 	// YAML key.
 	if HasKey(ctx) {
@@ -420,7 +482,7 @@ func showSafeContent(ctx context.Context, sec gopass.Secret) string {
 }
 
 func isUnsafeKey(ctx context.Context, key string, sec gopass.Secret) bool {
-	duks := []string{"hotp", "otpauth", "password", "totp"}
+	duks := []string{"hotp", "motp", "motp-pin", "otpauth", "password", rotatePendingKey, "totp"}
 	if slices.Contains(duks, key) {
 		return true
 	}
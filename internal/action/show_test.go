@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/fatih/color"
@@ -122,6 +124,23 @@ func TestShowMulti(t *testing.T) {
 		buf.Reset()
 	})
 
+	t.Run("show entry with a pending password from rotate", func(t *testing.T) {
+		sec := secrets.NewAKV()
+		sec.SetPassword("123")
+		require.NoError(t, sec.Set(rotatePendingKey, "n3w-pending"))
+		require.NoError(t, act.Store.Set(ctx, "rotate/pending", sec))
+		buf.Reset()
+
+		require.NoError(t, act.Show(ctx, gptest.CliCtx(ctx, t, "rotate/pending")))
+		assert.Contains(t, buf.String(), rotatePendingKey+": *****")
+		assert.NotContains(t, buf.String(), "n3w-pending")
+		buf.Reset()
+
+		preview, err := (&tuiBackend{h: act.tuiH}).Preview(ctx, "rotate/pending")
+		require.NoError(t, err)
+		assert.NotContains(t, preview, "n3w-pending")
+	})
+
 	t.Run("copy a key with the clip flag without showing any output", func(t *testing.T) {
 		sec := secrets.NewAKV()
 		sec.SetPassword("123")
@@ -544,6 +563,81 @@ func TestShowAutoClip(t *testing.T) {
 	})
 }
 
+func TestShowAutotype(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake xdotool is a shell script")
+	}
+
+	// a fake xdotool that logs its arguments and stdin.
+	bin := t.TempDir()
+	log := filepath.Join(bin, "log")
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "xdotool"), []byte("#!/bin/sh\necho \"$@\" >> "+log+"\ncat >> "+log+"\n"), 0o755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("DISPLAY", ":0")
+
+	u := gptest.NewUnitTester(t)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+	ctx = ctxutil.WithInteractive(ctx, false)
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	require.NotNil(t, act)
+	require.NoError(t, act.cfg.Set("", "autotype.delay", "0"))
+	ctx = act.cfg.WithConfig(ctx)
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	stdout = buf
+	defer func() {
+		stdout = os.Stdout
+		out.Stdout = os.Stdout
+	}()
+
+	sec := secrets.NewAKV()
+	sec.SetPassword("s3cret")
+	require.NoError(t, sec.Set("username", "alice"))
+	require.NoError(t, act.Store.Set(ctx, "login", sec))
+	require.NoError(t, sec.Set("autotype", "{username}{TAB}{password}{ENTER}"))
+	require.NoError(t, act.Store.Set(ctx, "login-seq", sec))
+
+	typed := func(t *testing.T) string {
+		t.Helper()
+
+		b, err := os.ReadFile(log)
+		require.NoError(t, err)
+		require.NoError(t, os.Remove(log))
+
+		return string(b)
+	}
+
+	t.Run("password", func(t *testing.T) {
+		c := gptest.CliCtxWithFlags(ctx, t, map[string]string{"type": "true"}, "login")
+		require.NoError(t, act.Show(ctx, c))
+		assert.Equal(t, "type --clearmodifiers --file -\ns3cret", typed(t))
+		assert.NotContains(t, buf.String(), "s3cret")
+	})
+
+	t.Run("key", func(t *testing.T) {
+		c := gptest.CliCtxWithFlags(ctx, t, map[string]string{"type": "true"}, "login", "username")
+		require.NoError(t, act.Show(ctx, c))
+		assert.Equal(t, "type --clearmodifiers --file -\nalice", typed(t))
+	})
+
+	t.Run("sequence", func(t *testing.T) {
+		c := gptest.CliCtxWithFlags(ctx, t, map[string]string{"type": "true"}, "login-seq")
+		require.NoError(t, act.Show(ctx, c))
+		assert.Equal(t, "type --clearmodifiers --file -\nalicekey --clearmodifiers Tab\ntype --clearmodifiers --file -\ns3cretkey --clearmodifiers Return\n", typed(t))
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		c := gptest.CliCtxWithFlags(ctx, t, map[string]string{"type": "true"}, "login", "pin")
+		require.Error(t, act.Show(ctx, c))
+	})
+}
+
 func TestShowHandleRevision(t *testing.T) {
 	u := gptest.NewUnitTester(t)
 
@@ -20,6 +20,7 @@ import (
 	"github.com/gopasspw/gopass/internal/tree"
 	"github.com/gopasspw/gopass/pkg/ctxutil"
 	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/otp"
 	"github.com/urfave/cli/v3"
 	"github.com/xhit/go-str2duration/v2"
 )
@@ -203,7 +204,12 @@ func (s *syncHandler) syncMount(ctx context.Context, mp string, isAutosync bool)
 
 	out.Printf(ctxno, "\n   "+color.GreenString("%s pull and push ... ", sub.Storage().Name()))
 
-	switch err := sub.Storage().Push(ctx, "", ""); {
+	err = sub.Storage().Push(ctx, "", "")
+	if errors.Is(err, backend.ErrConflict) {
+		err = syncResolveConflicts(ctx, sub, err)
+	}
+
+	switch {
 	case err == nil:
 		debug.Log("Push succeeded")
 		out.Printf(ctxno, color.GreenString("OK"))
@@ -211,6 +217,15 @@ func (s *syncHandler) syncMount(ctx context.Context, mp string, isAutosync bool)
 		out.Printf(ctx, "Skipped (no remote)")
 		debug.Log("Failed to push %q to its remote: %s", name, err)
 
+		return err
+	case errors.Is(err, backend.ErrConflict):
+		out.Errorf(ctx, "Failed to sync %q: %s", name, err)
+		if vcs := vcsCommand(sub.Storage().Name()); vcs != "" {
+			out.Noticef(ctx, "Resolve the conflicts with 'gopass %s --store=%s' and run 'gopass sync' again", vcs, mp)
+		} else {
+			out.Noticef(ctx, "Resolve the conflicts in %s and run 'gopass sync' again", sub.Storage().Path())
+		}
+
 		return err
 	case errors.Is(err, backend.ErrNotSupported):
 		out.Printf(ctxno, "Skipped (not supported)")
@@ -243,6 +258,40 @@ func (s *syncHandler) syncMount(ctx context.Context, mp string, isAutosync bool)
 	return nil
 }
 
+// syncResolveConflicts resolves the conflicts left by a failed sync which
+// only differ in the counter of a HOTP secret, e.g. after two users
+// generated a token at the same time. The higher counter wins. If all
+// conflicts could be resolved the push is retried.
+func syncResolveConflicts(ctx context.Context, sub *leaf.Store, err error) error {
+	resolved, rerr := sub.ResolveConflicts(ctx, otp.ResolveCounterConflict)
+	for _, name := range resolved {
+		out.Noticef(ctx, "Resolved conflicting HOTP counter of %s", name)
+	}
+	if rerr != nil {
+		debug.Log("failed to resolve conflicts: %s", rerr)
+
+		return err
+	}
+
+	return sub.Storage().Push(ctx, "", "")
+}
+
+// vcsCommands maps storage backends to the gopass command that runs their
+// VCS.
+var vcsCommands = map[string]string{
+	"gitfs":    "git",
+	"cryptfs":  "git",
+	"jjfs":     "jj",
+	"fossilfs": "fossil",
+}
+
+// vcsCommand returns the name of the command that runs the VCS of the
+// given storage backend, e.g. gitfs -> git, or an empty string if there is
+// none.
+func vcsCommand(storage string) string {
+	return vcsCommands[storage]
+}
+
 func syncImportKeys(ctx context.Context, sub *leaf.Store, name string) error {
 	// import keys.
 	if err := sub.ImportMissingPublicKeys(ctx); err != nil {
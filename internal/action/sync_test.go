@@ -9,6 +9,7 @@ import (
 	"github.com/gopasspw/gopass/internal/out"
 	"github.com/gopasspw/gopass/pkg/ctxutil"
 	"github.com/gopasspw/gopass/tests/gptest"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -40,3 +41,17 @@ func TestSync(t *testing.T) {
 		require.NoError(t, act.Sync(ctx, gptest.CliCtxWithFlags(ctx, t, map[string]string{"store": "root"})))
 	})
 }
+
+func TestVCSCommand(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]string{
+		"gitfs":    "git",
+		"jjfs":     "jj",
+		"fossilfs": "fossil",
+		"cryptfs":  "git",
+		"s3fs":     "",
+	} {
+		assert.Equal(t, want, vcsCommand(in))
+	}
+}
@@ -0,0 +1,177 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gopasspw/gopass/internal/action/exit"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/hook"
+	"github.com/gopasspw/gopass/internal/tree"
+	"github.com/gopasspw/gopass/internal/tui"
+	"github.com/gopasspw/gopass/internal/username"
+	"github.com/gopasspw/gopass/pkg/clipboard"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/urfave/cli/v3"
+)
+
+// newScreen is overridden in tests to use a simulated terminal.
+var newScreen = tcell.NewScreen
+
+// TUI shows a full-screen terminal UI to browse the store.
+func (s *tuiHandler) TUI(ctx context.Context, cmd *cli.Command) error {
+	ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+
+	if !ctxutil.IsTerminal(ctx) || !ctxutil.IsInteractive(ctx) {
+		return exit.Error(exit.Usage, nil, "%s tui needs an interactive terminal", s.Name)
+	}
+
+	screen, err := newScreen()
+	if err != nil {
+		return exit.Error(exit.IO, err, "failed to open terminal: %s", err)
+	}
+	if err := screen.Init(); err != nil {
+		return exit.Error(exit.IO, err, "failed to initialize terminal: %s", err)
+	}
+	defer screen.Fini()
+
+	opts := tui.Options{
+		IdleTimeout: time.Duration(config.Int(ctx, "tui.idle-timeout")) * time.Second,
+	}
+
+	if err := tui.New(&tuiBackend{h: s, cmd: cmd}, screen, opts).Run(ctx); err != nil {
+		return exit.Error(exit.Unknown, err, "%s", err)
+	}
+
+	return nil
+}
+
+// tuiBackend implements the store operations of the TUI.
+type tuiBackend struct {
+	h   *tuiHandler
+	cmd *cli.Command
+}
+
+// quiet hides the output of the store operations since it would garble
+// the screen.
+func quiet(ctx context.Context) context.Context {
+	return ctxutil.WithHidden(ctx, true)
+}
+
+func (b *tuiBackend) Tree(ctx context.Context) (*tree.Root, error) {
+	return b.h.Store.Tree(quiet(ctx))
+}
+
+// Preview returns the content of the secret like show does with
+// show.safecontent enabled. The password is always masked.
+func (b *tuiBackend) Preview(ctx context.Context, name string) (string, error) {
+	ctx = quiet(ctx)
+
+	sec, err := b.h.Store.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if sec.Password() != "" {
+		sb.WriteString("password: " + randAsterisk() + "\n")
+	}
+	sb.WriteString(showSafeContent(ctx, sec))
+
+	return sb.String(), nil
+}
+
+func (b *tuiBackend) Copy(ctx context.Context, name string, field tui.Field) (string, error) {
+	ctx = quiet(ctx)
+
+	var content string
+	switch field {
+	case tui.Password:
+		sec, err := b.h.Store.Get(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		content = sec.Password()
+	case tui.Username:
+		sec, err := b.h.Store.Get(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		content = username.Get(name, sec)
+	case tui.OTP:
+		token, err := b.h.otpFn(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		content = token
+	}
+
+	if content == "" {
+		return "", fmt.Errorf("%s is empty", field)
+	}
+
+	timeout := config.Int(ctx, "core.cliptimeout")
+	if err := clipboard.CopyTo(ctx, fmt.Sprintf("%s of %s", field, name), []byte(content), timeout); err != nil {
+		return "", err
+	}
+
+	if timeout < 1 {
+		return fmt.Sprintf("Copied %s of %s to clipboard.", field, name), nil
+	}
+
+	return fmt.Sprintf("Copied %s of %s to clipboard. Will clear in %d seconds.", field, name, timeout), nil
+}
+
+// Edit runs the editor like gopass edit does. The screen is suspended
+// while the editor is running.
+func (b *tuiBackend) Edit(ctx context.Context, name string) error {
+	if err := hook.Invoke(ctx, "edit.pre-hook", name); err != nil {
+		return fmt.Errorf("edit.pre-hook failed: %w", err)
+	}
+
+	if err := b.h.editFn(ctx, b.cmd, name); err != nil {
+		return err
+	}
+
+	return hook.InvokeRoot(ctx, "edit.post-hook", name, b.h.Store)
+}
+
+func (b *tuiBackend) Move(ctx context.Context, from, to string) error {
+	ctx = ctxutil.WithCommitMessage(quiet(ctx), fmt.Sprintf("Move %s to %s", from, to))
+
+	return b.h.Store.Move(ctx, from, to)
+}
+
+func (b *tuiBackend) Delete(ctx context.Context, name string) error {
+	ctx = ctxutil.WithCommitMessage(quiet(ctx), fmt.Sprintf("Delete %s", name))
+	if err := b.h.Store.Delete(ctx, name); err != nil {
+		return err
+	}
+
+	return hook.InvokeRoot(ctx, "delete.post-hook", name, b.h.Store)
+}
+
+func (b *tuiBackend) Lock(context.Context) error {
+	return b.h.Store.Lock()
+}
+
+// Unlock decrypts the first secret, so the crypto backend asks for the
+// credentials dropped by Lock.
+func (b *tuiBackend) Unlock(ctx context.Context) error {
+	ctx = quiet(ctx)
+
+	names, err := b.h.Store.List(ctx, tree.INF)
+	if err != nil {
+		return err
+	}
+	if len(names) < 1 {
+		return nil
+	}
+
+	_, err = b.h.Store.Get(ctx, names[0])
+
+	return err
+}
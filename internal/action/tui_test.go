@@ -0,0 +1,116 @@
+package action
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/gopasspw/gopass/tests/gptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTUI(t *testing.T) {
+	u := gptest.NewUnitTester(t)
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithTerminal(ctx, true)
+	ctx = ctxutil.WithInteractive(ctx, true)
+
+	act, err := newMock(ctx, u.StoreDir(""))
+	require.NoError(t, err)
+	ctx = act.cfg.WithConfig(ctx)
+
+	sec := secrets.NewAKV()
+	sec.SetPassword("s3cr3t-pw")
+	require.NoError(t, sec.Set("username", "alice"))
+	require.NoError(t, act.Store.Set(ctx, "websites/example.com/alice", sec))
+
+	screen := tcell.NewSimulationScreen("UTF-8")
+	oldScreen := newScreen
+	newScreen = func() (tcell.Screen, error) { return screen, nil }
+	defer func() { newScreen = oldScreen }()
+
+	text := func() string {
+		w, h := screen.Size()
+		var sb strings.Builder
+		for y := range h {
+			for x := range w {
+				r, _, _, _ := screen.GetContent(x, y)
+				sb.WriteRune(r)
+			}
+			sb.WriteString("\n")
+		}
+
+		return sb.String()
+	}
+	waitFor := func(s string) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			return strings.Contains(text(), s)
+		}, 5*time.Second, 10*time.Millisecond, "screen does not contain %q:\n%s", s, text())
+	}
+	keys := func(s string) {
+		for _, r := range s {
+			screen.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- act.TUI(ctx, gptest.CliCtx(ctx, t))
+	}()
+
+	waitFor("▸ websites/")
+
+	// the password is masked in the preview.
+	keys("/alice")
+	waitFor("username: alice")
+	waitFor("password: *****")
+	assert.NotContains(t, text(), "s3cr3t-pw")
+	screen.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+
+	// move the secret.
+	keys("m")
+	waitFor("Move websites/example.com/alice to: websites/example.com/alice")
+	for range len("alice") {
+		screen.InjectKey(tcell.KeyBackspace2, 0, tcell.ModNone)
+	}
+	keys("bob")
+	screen.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	waitFor("Moved websites/example.com/alice to websites/example.com/bob.")
+	assert.True(t, act.Store.Exists(ctx, "websites/example.com/bob"))
+	assert.False(t, act.Store.Exists(ctx, "websites/example.com/alice"))
+
+	// delete it.
+	screen.InjectKey(tcell.KeyEscape, 0, tcell.ModNone)
+	keys("/bob")
+	waitFor("filter: bob")
+	screen.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	keys("dy")
+	screen.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	waitFor("Deleted websites/example.com/bob.")
+	assert.False(t, act.Store.Exists(ctx, "websites/example.com/bob"))
+
+	// lock and unlock again.
+	screen.InjectKey(tcell.KeyEscape, 0, tcell.ModNone)
+	keys("L")
+	waitFor("gopass is locked")
+	screen.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	waitFor("foo")
+
+	keys("q")
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("TUI did not quit")
+	}
+
+	// a terminal is required.
+	require.Error(t, act.TUI(ctxutil.WithTerminal(ctx, false), gptest.CliCtx(ctx, t)))
+}
@@ -2,6 +2,7 @@ package action
 
 import (
 	"context"
+	"io"
 	"os"
 	"time"
 
@@ -20,14 +21,39 @@ func (s *miscHandler) Unclip(ctx context.Context, cmd *cli.Command) error {
 	name := os.Getenv("GOPASS_UNCLIP_NAME")
 	checksum := os.Getenv("GOPASS_UNCLIP_CHECKSUM")
 
-	time.Sleep(time.Second * time.Duration(timeout))
-
 	mp := s.Store.MountPoint(name)
 	ctx = config.WithMount(ctx, mp)
 
+	if cmd.Bool("serve") {
+		return s.unclipServe(ctx, name, timeout)
+	}
+
+	time.Sleep(time.Second * time.Duration(timeout))
+
 	if err := clipboard.Clear(ctx, name, checksum, force); err != nil {
 		return exit.Error(exit.IO, err, "Failed to clear clipboard: %s", err)
 	}
 
 	return nil
 }
+
+// unclipServe owns the clipboard with the content read from stdin until the
+// timeout expired and clears it afterwards, unless it was replaced in the
+// meantime. Once the content can be pasted it signals the parent process on
+// file descriptor 3.
+func (s *miscHandler) unclipServe(ctx context.Context, name string, timeout int) error {
+	ready := os.NewFile(3, "ready")
+
+	content, err := io.ReadAll(stdin)
+	if err != nil {
+		_ = ready.Close()
+
+		return exit.Error(exit.IO, err, "Failed to read clipboard content: %s", err)
+	}
+
+	if err := clipboard.Serve(ctx, name, content, timeout, ready); err != nil {
+		return exit.Error(exit.IO, err, "Failed to serve clipboard: %s", err)
+	}
+
+	return nil
+}
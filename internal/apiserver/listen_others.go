@@ -0,0 +1,18 @@
+//go:build !windows
+
+package apiserver
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenUnix creates the socket with a umask that only allows the current
+// user to access it. Otherwise other users could connect before the
+// permissions are restricted.
+func listenUnix(socket string) (net.Listener, error) {
+	old := syscall.Umask(0o077)
+	defer syscall.Umask(old)
+
+	return net.Listen("unix", socket)
+}
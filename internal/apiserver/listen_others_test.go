@@ -0,0 +1,32 @@
+//go:build !windows
+
+package apiserver
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenUnixMode(t *testing.T) {
+	// the umask is process wide, so this test must not run in parallel.
+	old := syscall.Umask(0o022)
+	defer syscall.Umask(old)
+
+	dir, err := os.MkdirTemp("", "gopass-serve")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "gopass.sock")
+	l, err := listenUnix(socket)
+	require.NoError(t, err)
+	defer l.Close()
+
+	fi, err := os.Stat(socket)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0), fi.Mode().Perm()&0o077)
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package apiserver
+
+import "net"
+
+// listenUnix creates the socket. Windows has no umask, access to the socket
+// is controlled by the permissions of its directory.
+func listenUnix(socket string) (net.Listener, error) {
+	return net.Listen("unix", socket)
+}
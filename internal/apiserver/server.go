@@ -0,0 +1,452 @@
+// Package apiserver implements a local HTTP API for gopass.Store. It allows
+// tools that are not written in Go to access the password store without
+// starting gopass for every request.
+//
+// The server listens on a Unix socket that is only accessible by the current
+// user. Every request must carry a bearer token that limits the secrets it can
+// access to a set of path prefixes.
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/policy"
+	"github.com/gopasspw/gopass/internal/store"
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+// maxBody limits the size of request bodies.
+const maxBody = 16 << 20
+
+var (
+	errForbidden  = errors.New("token does not grant access to this secret")
+	errBadRequest = errors.New("bad request")
+)
+
+// Server serves the API for a store.
+type Server struct {
+	store  gopass.Store
+	tokens []Token
+	idle   time.Duration
+
+	mu     sync.Mutex
+	active int
+	last   time.Time
+
+	// storeMu serializes writes to the store. Requests are handled
+	// concurrently but the store is not safe for concurrent writes.
+	storeMu sync.RWMutex
+}
+
+// New returns a new server for the store that accepts the given tokens. It
+// shuts down after it didn't receive any request for the idle duration. An
+// idle duration of zero disables the shutdown.
+func New(s gopass.Store, tokens []Token, idle time.Duration) *Server {
+	return &Server{
+		store:  s,
+		tokens: tokens,
+		idle:   idle,
+		last:   time.Now(),
+	}
+}
+
+// Secret is the representation of a secret in requests and responses.
+type Secret struct {
+	Name     string              `json:"name,omitempty"`
+	Password string              `json:"password"`
+	Values   map[string][]string `json:"values,omitempty"`
+	Body     string              `json:"body,omitempty"`
+}
+
+// Rename is the body of a rename request.
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Listen creates the Unix socket at path. The socket is only accessible by
+// the current user. A stale socket left behind by a crashed server is
+// replaced.
+func Listen(socket string) (net.Listener, error) {
+	if fi, err := os.Lstat(socket); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", socket)
+		}
+		if c, err := net.Dial("unix", socket); err == nil {
+			_ = c.Close()
+
+			return nil, fmt.Errorf("%s is in use by another server", socket)
+		}
+		debug.Log("removing stale socket %s", socket)
+		if err := os.Remove(socket); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(socket), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create socket dir: %w", err)
+	}
+
+	l, err := listenUnix(socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", socket, err)
+	}
+
+	if err := os.Chmod(socket, 0o600); err != nil {
+		_ = l.Close()
+
+		return nil, fmt.Errorf("failed to restrict access to %s: %w", socket, err)
+	}
+
+	return l, nil
+}
+
+// Serve answers requests on l until the context is canceled or the server
+// was idle for too long.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		s.waitIdle(ctx, done)
+
+		sctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_ = srv.Shutdown(sctx)
+	}()
+
+	if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+
+	return nil
+}
+
+// waitIdle returns when the context is canceled, done is closed or the
+// server didn't handle any request for the idle duration.
+func (s *Server) waitIdle(ctx context.Context, done <-chan struct{}) {
+	if s.idle <= 0 {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+
+		return
+	}
+
+	ticker := time.NewTicker(min(s.idle/2, time.Second))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			idle := s.active == 0 && time.Since(s.last) >= s.idle
+			s.mu.Unlock()
+
+			if idle {
+				debug.Log("shutting down after %s without requests", s.idle)
+
+				return
+			}
+		}
+	}
+}
+
+// Handler returns the HTTP handler of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /v1/secrets", s.with(s.shared(s.list)))
+	mux.Handle("GET /v1/secrets/{name...}", s.with(s.shared(s.get)))
+	mux.Handle("PUT /v1/secrets/{name...}", s.with(s.exclusive(s.set)))
+	mux.Handle("DELETE /v1/secrets/{name...}", s.with(s.exclusive(s.remove)))
+	mux.Handle("GET /v1/revisions/{name...}", s.with(s.shared(s.revisions)))
+	mux.Handle("POST /v1/rename", s.with(s.exclusive(s.rename)))
+	mux.Handle("POST /v1/sync", s.with(s.exclusive(s.sync)))
+
+	return mux
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, t Token) error
+
+// with authenticates the request, keeps track of the activity of the server
+// and turns errors into error responses.
+func (s *Server) with(h handlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.active++
+		s.mu.Unlock()
+
+		defer func() {
+			s.mu.Lock()
+			s.active--
+			s.last = time.Now()
+			s.mu.Unlock()
+		}()
+
+		secret, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		t, valid := lookupToken(s.tokens, secret)
+		if !found || !valid {
+			debug.Log("%s %s: invalid token", r.Method, r.URL.Path)
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid token"})
+
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+		if err := h(w, r, t); err != nil {
+			debug.Log("%s %s with token %s failed: %s", r.Method, r.URL.Path, t.Name, err)
+			writeJSON(w, statusOf(err), errorResponse{Error: err.Error()})
+		}
+	})
+}
+
+// shared runs h while no request is modifying the store.
+func (s *Server) shared(h handlerFunc) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, t Token) error {
+		s.storeMu.RLock()
+		defer s.storeMu.RUnlock()
+
+		return h(w, r, t)
+	}
+}
+
+// exclusive runs h while no other request accesses the store. This also
+// makes checks like the one for an existing destination of a rename atomic
+// with the following change.
+func (s *Server) exclusive(h handlerFunc) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, t Token) error {
+		s.storeMu.Lock()
+		defer s.storeMu.Unlock()
+
+		return h(w, r, t)
+	}
+}
+
+func statusOf(err error) int {
+	var mbe *http.MaxBytesError
+
+	switch {
+	case errors.Is(err, errForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, errBadRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, policy.ErrViolation):
+		return http.StatusUnprocessableEntity
+	case errors.As(err, &mbe):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		debug.Log("failed to write response: %s", err)
+	}
+}
+
+func readJSON(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return err
+		}
+
+		return fmt.Errorf("%w: %w", errBadRequest, err)
+	}
+
+	return nil
+}
+
+// checkName makes sure the name is a clean relative path the token grants
+// access to.
+func checkName(name string, t Token) error {
+	if name == "" || name != path.Clean(name) || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return fmt.Errorf("%w: invalid secret name %q", errBadRequest, name)
+	}
+
+	if !t.Allows(name) {
+		return fmt.Errorf("%w: %s", errForbidden, name)
+	}
+
+	return nil
+}
+
+// list returns the names of all secrets the token grants access to.
+func (s *Server) list(w http.ResponseWriter, r *http.Request, t Token) error {
+	names, err := s.store.List(r.Context())
+	if err != nil {
+		return err
+	}
+
+	names = slices.DeleteFunc(names, func(name string) bool { return !t.Allows(name) })
+	if names == nil {
+		names = []string{}
+	}
+
+	writeJSON(w, http.StatusOK, names)
+
+	return nil
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, t Token) error {
+	name := r.PathValue("name")
+	if err := checkName(name, t); err != nil {
+		return err
+	}
+
+	revision := r.URL.Query().Get("revision")
+	if revision == "" {
+		revision = "latest"
+	}
+
+	sec, err := s.store.Get(r.Context(), name, revision)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string][]string, len(sec.Keys()))
+	for _, k := range sec.Keys() {
+		if v, found := sec.Values(k); found {
+			values[k] = v
+		}
+	}
+
+	writeJSON(w, http.StatusOK, Secret{
+		Name:     name,
+		Password: sec.Password(),
+		Values:   values,
+		Body:     sec.Body(),
+	})
+
+	return nil
+}
+
+// set creates or replaces a secret.
+func (s *Server) set(w http.ResponseWriter, r *http.Request, t Token) error {
+	name := r.PathValue("name")
+	if err := checkName(name, t); err != nil {
+		return err
+	}
+
+	var m Secret
+	if err := readJSON(r, &m); err != nil {
+		return err
+	}
+
+	if err := s.store.Set(r.Context(), name, secrets.NewAKVWithData(m.Password, m.Values, m.Body, false)); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (s *Server) remove(w http.ResponseWriter, r *http.Request, t Token) error {
+	name := r.PathValue("name")
+	if err := checkName(name, t); err != nil {
+		return err
+	}
+
+	if err := s.store.Remove(r.Context(), name); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (s *Server) revisions(w http.ResponseWriter, r *http.Request, t Token) error {
+	name := r.PathValue("name")
+	if err := checkName(name, t); err != nil {
+		return err
+	}
+
+	revs, err := s.store.Revisions(r.Context(), name)
+	if err != nil {
+		return err
+	}
+	if revs == nil {
+		revs = []string{}
+	}
+
+	writeJSON(w, http.StatusOK, revs)
+
+	return nil
+}
+
+// rename moves a secret or a folder. The token must grant access to both
+// locations.
+func (s *Server) rename(w http.ResponseWriter, r *http.Request, t Token) error {
+	var m Rename
+	if err := readJSON(r, &m); err != nil {
+		return err
+	}
+
+	for _, name := range []string{m.From, m.To} {
+		if err := checkName(name, t); err != nil {
+			return err
+		}
+	}
+
+	if err := s.store.Rename(r.Context(), m.From, m.To); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// sync synchronizes the whole store, so it is only allowed for tokens
+// without restrictions.
+func (s *Server) sync(w http.ResponseWriter, r *http.Request, t Token) error {
+	if !t.Unrestricted() {
+		return fmt.Errorf("%w: sync requires a token without prefixes", errForbidden)
+	}
+
+	if err := s.store.Sync(r.Context()); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
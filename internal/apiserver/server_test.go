@@ -0,0 +1,286 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/policy"
+	"github.com/gopasspw/gopass/internal/store"
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/apimock"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+
+	mock := apimock.New()
+	for _, name := range []string{"ci/deploy", "prod/db"} {
+		sec := secrets.NewAKV()
+		sec.SetPassword("pw-" + name)
+		require.NoError(t, sec.Set("user", "bob"))
+		require.NoError(t, mock.Set(ctx, name, sec))
+	}
+
+	all, allSecret, err := NewToken("all", nil)
+	require.NoError(t, err)
+	ci, ciSecret, err := NewToken("ci", []string{"ci"})
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(New(mock, []Token{all, ci}, 0).Handler())
+	defer ts.Close()
+
+	do := func(token, method, path, body string) (int, string) {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(ctx, method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+
+		buf, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(buf)
+	}
+
+	t.Run("authentication", func(t *testing.T) {
+		code, _ := do("", http.MethodGet, "/v1/secrets", "")
+		assert.Equal(t, http.StatusUnauthorized, code)
+
+		code, _ = do("gopass_invalid", http.MethodGet, "/v1/secrets", "")
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("list is scoped", func(t *testing.T) {
+		code, body := do(allSecret, http.MethodGet, "/v1/secrets", "")
+		assert.Equal(t, http.StatusOK, code)
+		assert.JSONEq(t, `["ci/deploy","prod/db"]`, body)
+
+		code, body = do(ciSecret, http.MethodGet, "/v1/secrets", "")
+		assert.Equal(t, http.StatusOK, code)
+		assert.JSONEq(t, `["ci/deploy"]`, body)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		code, body := do(ciSecret, http.MethodGet, "/v1/secrets/ci/deploy", "")
+		require.Equal(t, http.StatusOK, code, body)
+
+		var sec Secret
+		require.NoError(t, json.Unmarshal([]byte(body), &sec))
+		assert.Equal(t, "ci/deploy", sec.Name)
+		assert.Equal(t, "pw-ci/deploy", sec.Password)
+		assert.Equal(t, []string{"bob"}, sec.Values["user"])
+
+		code, _ = do(ciSecret, http.MethodGet, "/v1/secrets/prod/db", "")
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		code, body := do(ciSecret, http.MethodPut, "/v1/secrets/ci/new", `{"password":"s3cr3t","values":{"url":["https://example.com"]},"body":"notes\n"}`)
+		require.Equal(t, http.StatusNoContent, code, body)
+
+		sec, err := mock.Get(ctx, "ci/new", "latest")
+		require.NoError(t, err)
+		assert.Equal(t, "s3cr3t", sec.Password())
+		url, _ := sec.Get("url")
+		assert.Equal(t, "https://example.com", url)
+		assert.Equal(t, "notes\n", sec.Body())
+
+		code, _ = do(ciSecret, http.MethodPut, "/v1/secrets/prod/new", `{"password":"s3cr3t"}`)
+		assert.Equal(t, http.StatusForbidden, code)
+
+		code, _ = do(ciSecret, http.MethodPut, "/v1/secrets/ci/broken", `{"password":`)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("rename needs access to both names", func(t *testing.T) {
+		code, _ := do(ciSecret, http.MethodPost, "/v1/rename", `{"from":"ci/new","to":"prod/new"}`)
+		assert.Equal(t, http.StatusForbidden, code)
+
+		code, body := do(ciSecret, http.MethodPost, "/v1/rename", `{"from":"ci/new","to":"ci/renamed"}`)
+		require.Equal(t, http.StatusNoContent, code, body)
+
+		_, err := mock.Get(ctx, "ci/renamed", "latest")
+		require.NoError(t, err)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		code, _ := do(ciSecret, http.MethodDelete, "/v1/secrets/prod/db", "")
+		assert.Equal(t, http.StatusForbidden, code)
+
+		code, body := do(ciSecret, http.MethodDelete, "/v1/secrets/ci/renamed", "")
+		require.Equal(t, http.StatusNoContent, code, body)
+
+		_, err := mock.Get(ctx, "ci/renamed", "latest")
+		require.Error(t, err)
+	})
+
+	t.Run("sync requires an unrestricted token", func(t *testing.T) {
+		code, _ := do(ciSecret, http.MethodPost, "/v1/sync", "")
+		assert.Equal(t, http.StatusForbidden, code)
+
+		// the mock store does not implement sync.
+		code, _ = do(allSecret, http.MethodPost, "/v1/sync", "")
+		assert.Equal(t, http.StatusInternalServerError, code)
+	})
+}
+
+// overlapStore records if writes to the store overlap.
+type overlapStore struct {
+	gopass.Store
+
+	writing atomic.Int32
+	overlap atomic.Bool
+}
+
+func (o *overlapStore) Set(ctx context.Context, name string, sec gopass.Byter) error {
+	if o.writing.Add(1) > 1 {
+		o.overlap.Store(true)
+	}
+	defer o.writing.Add(-1)
+
+	time.Sleep(time.Millisecond)
+
+	return o.Store.Set(ctx, name, sec)
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	t.Parallel()
+
+	st := &overlapStore{Store: apimock.New()}
+	tok, secret, err := NewToken("all", nil)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(New(st, []Token{tok}, 0).Handler())
+	defer ts.Close()
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodPut, fmt.Sprintf("%s/v1/secrets/ci/%d", ts.URL, i), strings.NewReader(`{"password":"s3cr3t"}`))
+			assert.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+secret)
+
+			resp, err := ts.Client().Do(req)
+			if assert.NoError(t, err) {
+				assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+				_ = resp.Body.Close()
+			}
+		})
+	}
+	wg.Wait()
+
+	assert.False(t, st.overlap.Load(), "writes to the store overlapped")
+
+	names, err := st.List(t.Context())
+	require.NoError(t, err)
+	assert.Len(t, names, 20)
+}
+
+func TestCheckName(t *testing.T) {
+	t.Parallel()
+
+	tok, _, err := NewToken("ci", []string{"ci"})
+	require.NoError(t, err)
+
+	require.NoError(t, checkName("ci/deploy", tok))
+	for _, name := range []string{"", "ci/../prod/db", "/ci/deploy", "../ci", "ci//deploy"} {
+		require.ErrorIs(t, checkName(name, tok), errBadRequest, name)
+	}
+	require.ErrorIs(t, checkName("prod/db", tok), errForbidden)
+}
+
+func TestStatusOf(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, http.StatusNotFound, statusOf(fmt.Errorf("failed to read: %w", store.ErrNotFound)))
+	assert.Equal(t, http.StatusForbidden, statusOf(fmt.Errorf("%w: prod", errForbidden)))
+	assert.Equal(t, http.StatusBadRequest, statusOf(fmt.Errorf("%w: broken", errBadRequest)))
+	assert.Equal(t, http.StatusUnprocessableEntity, statusOf(fmt.Errorf("%w: password too short", policy.ErrViolation)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, statusOf(&http.MaxBytesError{Limit: maxBody}))
+	assert.Equal(t, http.StatusInternalServerError, statusOf(io.ErrUnexpectedEOF))
+}
+
+func TestServeSocket(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not supported on windows")
+	}
+
+	// socket paths are limited to about 100 characters.
+	dir, err := os.MkdirTemp("", "gopass-serve")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "run", "gopass.sock")
+
+	l, err := Listen(socket)
+	require.NoError(t, err)
+
+	fi, err := os.Stat(socket)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+	_, err = Listen(socket)
+	require.Error(t, err, "socket in use")
+
+	tok, secret, err := NewToken("all", nil)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- New(apimock.New(), []Token{tok}, 200*time.Millisecond).Serve(context.Background(), l)
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://gopass/v1/secrets", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+secret)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, resp.Body.Close())
+	client.CloseIdleConnections()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down when idle")
+	}
+
+	_, err = os.Stat(socket)
+	assert.True(t, os.IsNotExist(err), "socket removed")
+}
@@ -0,0 +1,137 @@
+package apiserver
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/appdir"
+)
+
+// tokenPrefix makes tokens easy to recognize, e.g. by secret scanners.
+const tokenPrefix = "gopass_"
+
+// Token grants access to the secrets below its prefixes. A token without
+// prefixes grants access to the whole store. Only the hash of the token is
+// stored.
+type Token struct {
+	Name     string    `json:"name"`
+	Hash     string    `json:"hash"`
+	Prefixes []string  `json:"prefixes,omitempty"`
+	Created  time.Time `json:"created"`
+}
+
+// NewToken returns a new token for the prefixes and its secret value. The
+// secret is not stored anywhere, it must be shown to the user right away.
+func NewToken(name string, prefixes []string) (Token, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return Token{}, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	secret := tokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	var clean []string
+	for _, p := range prefixes {
+		if p = strings.Trim(p, "/"); p != "" && !slices.Contains(clean, p) {
+			clean = append(clean, p)
+		}
+	}
+
+	return Token{
+		Name:     name,
+		Hash:     hashToken(secret),
+		Prefixes: clean,
+		Created:  time.Now().UTC(),
+	}, secret, nil
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Unrestricted returns true if the token grants access to the whole store.
+func (t Token) Unrestricted() bool {
+	return len(t.Prefixes) < 1
+}
+
+// Allows returns true if the secret or folder is below one of the prefixes
+// of the token. Prefixes match whole path elements, i.e. the prefix ci
+// matches ci/deploy but not cinema.
+func (t Token) Allows(name string) bool {
+	if t.Unrestricted() {
+		return true
+	}
+
+	name = strings.Trim(name, "/")
+
+	return slices.ContainsFunc(t.Prefixes, func(p string) bool {
+		return name == p || strings.HasPrefix(name, p+"/")
+	})
+}
+
+// lookupToken returns the token with the given secret value.
+func lookupToken(tokens []Token, secret string) (Token, bool) {
+	hash := hashToken(secret)
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Hash), []byte(hash)) == 1 {
+			return t, true
+		}
+	}
+
+	return Token{}, false
+}
+
+// TokensFile returns the location of the tokens file.
+func TokensFile() string {
+	return filepath.Join(appdir.UserConfig(), "serve-tokens.json")
+}
+
+// LoadTokens reads the tokens from the file. A missing file contains no
+// tokens.
+func LoadTokens(path string) ([]Token, error) {
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens: %w", err)
+	}
+
+	var tokens []Token
+	if err := json.Unmarshal(buf, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return tokens, nil
+}
+
+// SaveTokens writes the tokens to the file. It is only readable by the
+// current user.
+func SaveTokens(path string, tokens []Token) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config dir: %w", err)
+	}
+
+	buf, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode tokens: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(buf, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write tokens: %w", err)
+	}
+
+	return nil
+}
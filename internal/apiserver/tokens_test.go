@@ -0,0 +1,63 @@
+package apiserver
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenAllows(t *testing.T) {
+	t.Parallel()
+
+	tok, secret, err := NewToken("ci", []string{"/ci/", "shared", "ci"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, tokenPrefix))
+	assert.NotContains(t, tok.Hash, secret)
+	assert.Equal(t, []string{"ci", "shared"}, tok.Prefixes)
+	assert.False(t, tok.Unrestricted())
+
+	for name, allowed := range map[string]bool{
+		"ci":             true,
+		"ci/deploy":      true,
+		"shared/db/prod": true,
+		"cinema":         false,
+		"prod/ci":        false,
+		"":               false,
+	} {
+		assert.Equal(t, allowed, tok.Allows(name), name)
+	}
+
+	all, _, err := NewToken("all", nil)
+	require.NoError(t, err)
+	assert.True(t, all.Unrestricted())
+	assert.True(t, all.Allows("prod/db"))
+}
+
+func TestLoadSaveTokens(t *testing.T) {
+	t.Parallel()
+
+	fn := filepath.Join(t.TempDir(), "config", "serve-tokens.json")
+
+	tokens, err := LoadTokens(fn)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	tok, secret, err := NewToken("editor", []string{"notes"})
+	require.NoError(t, err)
+	require.NoError(t, SaveTokens(fn, []Token{tok}))
+
+	tokens, err = LoadTokens(fn)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, "editor", tokens[0].Name)
+
+	found, ok := lookupToken(tokens, secret)
+	assert.True(t, ok)
+	assert.Equal(t, "editor", found.Name)
+
+	_, ok = lookupToken(tokens, secret+"x")
+	assert.False(t, ok)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/gopasspw/gopass/pkg/debug"
 	"github.com/gopasspw/gopass/pkg/fsutil"
 	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/pwgen"
 	"github.com/gopasspw/gopass/pkg/termio"
 	"github.com/muesli/crunchy"
 )
@@ -36,6 +37,23 @@ type validator struct {
 	Name        string
 	Description string
 	Validate    func(string, gopass.Secret) error
+	// Grade is used instead of Validate if set. It returns the severity
+	// and the message of the finding.
+	Grade func(string, gopass.Secret) (string, string)
+}
+
+// StrengthSeverity maps the score of the password strength estimate to the
+// severity of the finding. Passwords that can be guessed within seconds are
+// errors, those that only resist an online attack are warnings.
+func StrengthSeverity(score int) string {
+	switch {
+	case score <= 1:
+		return "error"
+	case score == 2:
+		return "warning"
+	default:
+		return "none"
+	}
 }
 
 // DefaultExpiration is the default expiration time for secrets.
@@ -64,6 +82,15 @@ func New(ctx context.Context, s secretGetter) *Auditor {
 				return cv.Check(sec.Password())
 			},
 		},
+		{
+			Name:        "strength",
+			Description: "Estimates the password strength from dictionary words, keyboard patterns, sequences, repeats and dates",
+			Grade: func(_ string, sec gopass.Secret) (string, string) {
+				st := pwgen.Estimate(sec.Password())
+
+				return StrengthSeverity(st.Score), st.Explain()
+			},
+		},
 		{
 			Name:        "equals-name",
 			Description: "Checks for passwords the match the secret name",
@@ -209,6 +236,13 @@ func (a *Auditor) auditSecret(ctx context.Context, secret string) {
 	var wg sync.WaitGroup
 	for _, v := range a.v {
 		wg.Go(func() {
+			if v.Grade != nil {
+				severity, msg := v.Grade(secret, sec)
+				a.r.AddFinding(secret, v.Name, msg, severity)
+
+				return
+			}
+
 			if err := v.Validate(secret, sec); err != nil {
 				a.r.AddFinding(secret, v.Name, err.Error(), "warning")
 
@@ -64,6 +64,18 @@ func TestAuditSecret(t *testing.T) {
 	a.auditSecret(ctx, secret)
 
 	assert.Contains(t, a.r.secrets, secret)
+
+	f := a.r.secrets[secret].Findings["strength"]
+	assert.Equal(t, "error", f.Severity)
+	assert.Contains(t, f.Message, `dictionary "password" (common password "password")`)
+}
+
+func TestStrengthSeverity(t *testing.T) {
+	t.Parallel()
+
+	for score, want := range []string{"error", "error", "warning", "none", "none"} {
+		assert.Equal(t, want, StrengthSeverity(score), score)
+	}
 }
 
 func TestCheckHIBP(t *testing.T) {
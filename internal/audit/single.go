@@ -3,15 +3,34 @@ package audit
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/pkg/pwgen"
 	"github.com/muesli/crunchy"
 )
 
-// Single runs a password strength audit on a single password.
-func Single(ctx context.Context, password string) {
+// Single runs a password strength audit on a single password. It prints the
+// guessable patterns found in a weak password and returns true if the
+// password is weak.
+func Single(ctx context.Context, password string) bool {
+	var weak bool
+
 	validator := crunchy.NewValidator()
 	if err := validator.Check(password); err != nil {
 		out.Printf(ctx, fmt.Sprintf("Warning: %s", err))
+		weak = true
+	}
+
+	st := pwgen.Estimate(password)
+	if StrengthSeverity(st.Score) == "none" {
+		return weak
 	}
+
+	out.Printf(ctx, "Warning: weak password (score %d/%d), estimated crack time %s", st.Score, pwgen.MaxScore, pwgen.HumanizeDuration(st.CrackTime))
+	if p := st.Patterns(); len(p) > 0 {
+		out.Printf(ctx, "  found %s", strings.Join(p, ", "))
+	}
+
+	return true
 }
@@ -0,0 +1,188 @@
+// Package autotype types secrets as keystrokes into the focused window for
+// applications that do not support pasting from the clipboard, e.g. remote
+// desktop or VM consoles.
+//
+// The keystrokes are sent by an external command: xdotool on X11, wtype on
+// Wayland or ydotool, which works everywhere but needs the ydotoold daemon.
+package autotype
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+var (
+	// ErrNoBackend is returned if none of the supported commands is
+	// installed.
+	ErrNoBackend = errors.New("no autotype backend found, please install xdotool (X11), wtype (Wayland) or ydotool")
+
+	// lookPath and runCommand are indirections over os/exec so tests can
+	// substitute them.
+	lookPath   = exec.LookPath
+	runCommand = func(ctx context.Context, stdin string, name string, args ...string) error {
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Stdin = bytes.NewBufferString(stdin)
+		cmd.Stderr = os.Stderr
+
+		return cmd.Run()
+	}
+
+	sleep = func(ctx context.Context, d time.Duration) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+			return nil
+		}
+	}
+)
+
+// Backend sends keystrokes to the focused window.
+type Backend interface {
+	// Name returns the name of the command.
+	Name() string
+	// Text types the text.
+	Text(ctx context.Context, text string) error
+	// Key presses and releases a special key.
+	Key(ctx context.Context, key Key) error
+}
+
+// backends are all supported backends.
+var backends = []Backend{xdotool{}, wtype{}, ydotool{}}
+
+// Names returns the names of all supported backends.
+func Names() []string {
+	names := make([]string, 0, len(backends))
+	for _, b := range backends {
+		names = append(names, b.Name())
+	}
+
+	return names
+}
+
+// New returns the backend with the given name. If name is empty the first
+// installed backend that supports the current session is returned: wtype on
+// Wayland, xdotool on X11 and ydotool as a fallback.
+func New(name string) (Backend, error) {
+	if name != "" {
+		for _, b := range backends {
+			if b.Name() != name {
+				continue
+			}
+			if _, err := lookPath(b.Name()); err != nil {
+				return nil, fmt.Errorf("autotype backend %s is not installed: %w", name, err)
+			}
+
+			return b, nil
+		}
+
+		return nil, fmt.Errorf("unknown autotype backend %q, supported: %v", name, Names())
+	}
+
+	order := []Backend{ydotool{}}
+	switch {
+	case os.Getenv("WAYLAND_DISPLAY") != "":
+		order = []Backend{wtype{}, ydotool{}}
+	case os.Getenv("DISPLAY") != "":
+		order = []Backend{xdotool{}, ydotool{}}
+	}
+
+	for _, b := range order {
+		if _, err := lookPath(b.Name()); err == nil {
+			debug.Log("using autotype backend %s", b.Name())
+
+			return b, nil
+		}
+	}
+
+	return nil, ErrNoBackend
+}
+
+// Countdown calls tick with the remaining seconds once per second and
+// returns once the countdown is over or the context is canceled.
+func Countdown(ctx context.Context, seconds int, tick func(remaining int)) error {
+	for i := seconds; i > 0; i-- {
+		tick(i)
+		if err := sleep(ctx, time.Second); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Type runs the sequence with the backend.
+func Type(ctx context.Context, b Backend, steps []Step) error {
+	for _, s := range steps {
+		var err error
+
+		switch {
+		case s.Delay > 0:
+			err = sleep(ctx, time.Duration(s.Delay)*time.Millisecond)
+		case s.Key != "":
+			err = b.Key(ctx, s.Key)
+		default:
+			err = b.Text(ctx, s.Text)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to type with %s: %w", b.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// The backends pass the text on stdin so it does not show up in the process
+// list.
+
+// keysyms are the X11 names of the special keys used by xdotool and wtype.
+var keysyms = map[Key]string{KeyTab: "Tab", KeyEnter: "Return", KeySpace: "space"}
+
+type xdotool struct{}
+
+func (xdotool) Name() string { return "xdotool" }
+
+func (x xdotool) Text(ctx context.Context, text string) error {
+	return runCommand(ctx, text, x.Name(), "type", "--clearmodifiers", "--file", "-")
+}
+
+func (x xdotool) Key(ctx context.Context, key Key) error {
+	return runCommand(ctx, "", x.Name(), "key", "--clearmodifiers", keysyms[key])
+}
+
+type wtype struct{}
+
+func (wtype) Name() string { return "wtype" }
+
+func (w wtype) Text(ctx context.Context, text string) error {
+	return runCommand(ctx, text, w.Name(), "-")
+}
+
+func (w wtype) Key(ctx context.Context, key Key) error {
+	return runCommand(ctx, "", w.Name(), "-k", keysyms[key])
+}
+
+type ydotool struct{}
+
+func (ydotool) Name() string { return "ydotool" }
+
+func (y ydotool) Text(ctx context.Context, text string) error {
+	return runCommand(ctx, text, y.Name(), "type", "--file", "-")
+}
+
+func (y ydotool) Key(ctx context.Context, key Key) error {
+	// ydotool expects Linux input event codes, see
+	// linux/input-event-codes.h.
+	codes := map[Key]string{KeyTab: "15", KeyEnter: "28", KeySpace: "57"}
+	code := codes[key]
+
+	return runCommand(ctx, "", y.Name(), "key", code+":1", code+":0")
+}
@@ -0,0 +1,145 @@
+package autotype
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type call struct {
+	stdin string
+	args  string
+}
+
+// fakeCommands replaces the command execution and reports the calls.
+func fakeCommands(t *testing.T, installed ...string) *[]call {
+	t.Helper()
+
+	calls := &[]call{}
+
+	oldLookPath, oldRun, oldSleep := lookPath, runCommand, sleep
+	t.Cleanup(func() {
+		lookPath, runCommand, sleep = oldLookPath, oldRun, oldSleep
+	})
+
+	lookPath = func(name string) (string, error) {
+		for _, i := range installed {
+			if i == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+
+		return "", errors.New("not found")
+	}
+	runCommand = func(_ context.Context, stdin string, name string, args ...string) error {
+		*calls = append(*calls, call{stdin: stdin, args: name + " " + strings.Join(args, " ")})
+
+		return nil
+	}
+	sleep = func(_ context.Context, d time.Duration) error {
+		*calls = append(*calls, call{args: "sleep " + d.String()})
+
+		return nil
+	}
+
+	return calls
+}
+
+func TestNew(t *testing.T) { //nolint:paralleltest
+	fakeCommands(t, "xdotool", "ydotool")
+
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("DISPLAY", ":0")
+
+	b, err := New("")
+	require.NoError(t, err)
+	assert.Equal(t, "xdotool", b.Name())
+
+	// wtype is not installed.
+	t.Setenv("WAYLAND_DISPLAY", "wayland-0")
+	b, err = New("")
+	require.NoError(t, err)
+	assert.Equal(t, "ydotool", b.Name())
+
+	_, err = New("wtype")
+	require.Error(t, err)
+
+	_, err = New("foo")
+	require.Error(t, err)
+
+	b, err = New("xdotool")
+	require.NoError(t, err)
+	assert.Equal(t, "xdotool", b.Name())
+
+	fakeCommands(t)
+	_, err = New("")
+	require.ErrorIs(t, err, ErrNoBackend)
+}
+
+func TestType(t *testing.T) { //nolint:paralleltest
+	steps := []Step{{Text: "alice"}, {Key: KeyTab}, {Delay: 100}, {Text: "s3cret"}, {Key: KeyEnter}}
+
+	for _, tc := range []struct {
+		backend Backend
+		want    []call
+	}{
+		{
+			backend: xdotool{},
+			want: []call{
+				{stdin: "alice", args: "xdotool type --clearmodifiers --file -"},
+				{args: "xdotool key --clearmodifiers Tab"},
+				{args: "sleep 100ms"},
+				{stdin: "s3cret", args: "xdotool type --clearmodifiers --file -"},
+				{args: "xdotool key --clearmodifiers Return"},
+			},
+		},
+		{
+			backend: wtype{},
+			want: []call{
+				{stdin: "alice", args: "wtype -"},
+				{args: "wtype -k Tab"},
+				{args: "sleep 100ms"},
+				{stdin: "s3cret", args: "wtype -"},
+				{args: "wtype -k Return"},
+			},
+		},
+		{
+			backend: ydotool{},
+			want: []call{
+				{stdin: "alice", args: "ydotool type --file -"},
+				{args: "ydotool key 15:1 15:0"},
+				{args: "sleep 100ms"},
+				{stdin: "s3cret", args: "ydotool type --file -"},
+				{args: "ydotool key 28:1 28:0"},
+			},
+		},
+	} {
+		t.Run(tc.backend.Name(), func(t *testing.T) {
+			calls := fakeCommands(t)
+
+			require.NoError(t, Type(t.Context(), tc.backend, steps))
+			assert.Equal(t, tc.want, *calls)
+		})
+	}
+}
+
+func TestCountdown(t *testing.T) { //nolint:paralleltest
+	calls := fakeCommands(t)
+
+	var ticks []int
+	require.NoError(t, Countdown(t.Context(), 3, func(i int) {
+		ticks = append(ticks, i)
+	}))
+	assert.Equal(t, []int{3, 2, 1}, ticks)
+	assert.Len(t, *calls, 3)
+
+	sleep = func(context.Context, time.Duration) error {
+		return context.Canceled
+	}
+	require.ErrorIs(t, Countdown(t.Context(), 3, func(int) {}), context.Canceled)
+}
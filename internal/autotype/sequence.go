@@ -0,0 +1,126 @@
+package autotype
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+// DefaultSequence is typed if the secret has no autotype key.
+const DefaultSequence = "{password}"
+
+// Key is a special key that is pressed during a sequence.
+type Key string
+
+// Special keys supported in sequences.
+const (
+	KeyTab   Key = "TAB"
+	KeyEnter Key = "ENTER"
+	KeySpace Key = "SPACE"
+)
+
+// Step is a single step of an autotype sequence. Exactly one of Text, Key
+// or Delay is set.
+type Step struct {
+	Text  string
+	Key   Key
+	Delay int // milliseconds
+}
+
+// Sequence parses an autotype sequence like
+// "{username}{TAB}{password}{ENTER}" and resolves the placeholders against
+// the secret.
+//
+// Placeholders are either one of the special keys TAB, ENTER and SPACE, a
+// pause like {DELAY 500} (in milliseconds) or the name of a key of the
+// secret. {password} is the first line of the secret. Text outside of
+// braces is typed as is. Use {{} and {}} to type literal braces.
+func Sequence(seq string, sec gopass.Secret) ([]Step, error) {
+	var steps []Step
+
+	text := func(s string) {
+		if s == "" {
+			return
+		}
+		if n := len(steps); n > 0 && steps[n-1].Key == "" && steps[n-1].Delay == 0 {
+			steps[n-1].Text += s
+
+			return
+		}
+		steps = append(steps, Step{Text: s})
+	}
+
+	for seq != "" {
+		start := strings.IndexByte(seq, '{')
+		if start < 0 {
+			text(seq)
+
+			break
+		}
+		text(seq[:start])
+		seq = seq[start+1:]
+
+		// {{} and {}} are literal braces.
+		if strings.HasPrefix(seq, "{}") || strings.HasPrefix(seq, "}}") {
+			text(seq[:1])
+			seq = seq[2:]
+
+			continue
+		}
+
+		end := strings.IndexByte(seq, '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated placeholder in autotype sequence")
+		}
+		placeholder := seq[:end]
+		seq = seq[end+1:]
+
+		step, err := resolve(placeholder, sec)
+		if err != nil {
+			return nil, err
+		}
+		if step.Key == "" && step.Delay == 0 {
+			text(step.Text)
+
+			continue
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+func resolve(placeholder string, sec gopass.Secret) (Step, error) {
+	switch k := Key(placeholder); k {
+	case KeyTab, KeyEnter, KeySpace:
+		return Step{Key: k}, nil
+	}
+
+	if ms, found := strings.CutPrefix(placeholder, "DELAY "); found {
+		d, err := strconv.Atoi(strings.TrimSpace(ms))
+		if err != nil || d < 1 {
+			return Step{}, fmt.Errorf("invalid delay %q in autotype sequence", ms)
+		}
+
+		return Step{Delay: d}, nil
+	}
+
+	if placeholder == "password" {
+		if pw := sec.Password(); pw != "" {
+			return Step{Text: pw}, nil
+		}
+
+		return Step{}, fmt.Errorf("secret has no password")
+	}
+
+	if v, found := sec.Get(placeholder); found {
+		return Step{Text: v}, nil
+	}
+	if v, found := sec.Get(strings.ToLower(placeholder)); found {
+		return Step{Text: v}, nil
+	}
+
+	return Step{}, fmt.Errorf("secret has no key %q", placeholder)
+}
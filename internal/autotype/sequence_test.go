@@ -0,0 +1,77 @@
+package autotype
+
+import (
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequence(t *testing.T) {
+	t.Parallel()
+
+	sec := secrets.ParseAKV([]byte("s3cret\nusername: alice\nPIN: 1234\nempty: \n"))
+
+	for _, tc := range []struct {
+		name string
+		seq  string
+		want []Step
+	}{
+		{
+			name: "default",
+			seq:  DefaultSequence,
+			want: []Step{{Text: "s3cret"}},
+		},
+		{
+			name: "login",
+			seq:  "{username}{TAB}{password}{ENTER}",
+			want: []Step{{Text: "alice"}, {Key: KeyTab}, {Text: "s3cret"}, {Key: KeyEnter}},
+		},
+		{
+			name: "literal text is merged",
+			seq:  "user={username} pin={PIN}{SPACE}",
+			want: []Step{{Text: "user=alice pin=1234"}, {Key: KeySpace}},
+		},
+		{
+			name: "delay",
+			seq:  "{username}{DELAY 250}{password}",
+			want: []Step{{Text: "alice"}, {Delay: 250}, {Text: "s3cret"}},
+		},
+		{
+			name: "escaped braces",
+			seq:  "{{}{username}{}}",
+			want: []Step{{Text: "{alice}"}},
+		},
+		{
+			name: "empty value",
+			seq:  "{empty}{TAB}",
+			want: []Step{{Key: KeyTab}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			steps, err := Sequence(tc.seq, sec)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, steps)
+		})
+	}
+}
+
+func TestSequenceErrors(t *testing.T) {
+	t.Parallel()
+
+	sec := secrets.ParseAKV([]byte("\nusername: alice\n"))
+
+	for _, seq := range []string{
+		"{username",
+		"{missing}",
+		"{password}",
+		"{DELAY x}",
+		"{DELAY -1}",
+	} {
+		_, err := Sequence(seq, sec)
+		assert.Error(t, err, seq)
+	}
+}
@@ -9,8 +9,13 @@ import (
 	"github.com/gopasspw/gopass/pkg/debug"
 )
 
-// ErrNotSupported is returned by backends for unsupported calls.
-var ErrNotSupported = fmt.Errorf("not supported")
+var (
+	// ErrNotSupported is returned by backends for unsupported calls.
+	ErrNotSupported = fmt.Errorf("not supported")
+	// ErrConflict is returned by remote backends if an entry was changed
+	// concurrently since it was last read.
+	ErrConflict = fmt.Errorf("entry was modified concurrently")
+)
 
 // StorageBackend is a type of storage backend.
 type StorageBackend int
@@ -26,6 +31,12 @@ const (
 	JJFS
 	// CryptFS is a filename encrypting storage.
 	CryptFS
+	// S3FS is an S3-compatible object storage.
+	S3FS
+	// WebDAVFS is a WebDAV based storage.
+	WebDAVFS
+	// SQLiteFS is a SQLite based storage.
+	SQLiteFS
 )
 
 func (s StorageBackend) String() string {
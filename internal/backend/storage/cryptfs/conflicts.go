@@ -0,0 +1,155 @@
+package cryptfs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// conflictResolver is implemented by the sub storages that can resolve the
+// conflicts left by a failed sync, i.e. gitfs.
+type conflictResolver interface {
+	Conflicts(context.Context) ([]string, error)
+	ConflictVersions(context.Context, string) ([]byte, []byte, error)
+	ResolveConflict(context.Context, string, []byte) error
+}
+
+func (c *Crypt) conflictResolver() (conflictResolver, error) {
+	cr, ok := c.sub.(conflictResolver)
+	if !ok {
+		return nil, backend.ErrNotSupported
+	}
+
+	return cr, nil
+}
+
+// Conflicts lists the entries with unresolved merge conflicts. Concurrent
+// changes always conflict on the mapping file as well. Since both sides only
+// add or remove names, it is merged right away.
+func (c *Crypt) Conflicts(ctx context.Context) ([]string, error) {
+	cr, err := c.conflictResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := cr.Conflicts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if slices.Contains(files, mappingFile) {
+		if err := c.mergeMappings(ctx, cr, files); err != nil {
+			return nil, fmt.Errorf("failed to merge %s: %w", mappingFile, err)
+		}
+	}
+
+	names := make(map[string]string, len(c.mappings))
+	for name, h := range c.mappings {
+		// linked entries share a hash, report the conflict only once
+		if other, ok := names[h]; ok && other < name {
+			continue
+		}
+		names[h] = name
+	}
+
+	conflicts := make([]string, 0, len(files))
+	for _, file := range files {
+		if file == mappingFile {
+			continue
+		}
+		name, ok := names[file]
+		if !ok {
+			debug.Log("no mapping for conflicting file %s", file)
+			name = file
+		}
+		conflicts = append(conflicts, name)
+	}
+	sort.Strings(conflicts)
+
+	return conflicts, nil
+}
+
+// mergeMappings combines both versions of the mapping file. Names whose
+// files were removed by the merge are dropped.
+func (c *Crypt) mergeMappings(ctx context.Context, cr conflictResolver, conflicts []string) error {
+	ours, theirs, err := cr.ConflictVersions(ctx, mappingFile)
+	if err != nil {
+		return err
+	}
+
+	merged, err := c.decryptMappings(ctx, ours)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt our version: %w", err)
+	}
+	theirMappings, err := c.decryptMappings(ctx, theirs)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt their version: %w", err)
+	}
+	for name, h := range theirMappings {
+		merged[name] = h
+	}
+	for name, h := range merged {
+		if !c.sub.Exists(ctx, h) && !slices.Contains(conflicts, h) {
+			debug.Log("dropping mapping of removed entry %s", name)
+			delete(merged, name)
+		}
+	}
+
+	ciphertext, err := c.encryptMappings(ctx, merged)
+	if err != nil {
+		return err
+	}
+	if err := cr.ResolveConflict(ctx, mappingFile, ciphertext); err != nil {
+		return err
+	}
+	c.mappings = merged
+	debug.Log("merged %d mappings", len(merged))
+
+	return nil
+}
+
+// ConflictVersions returns our and their version of an entry with an
+// unresolved merge conflict.
+func (c *Crypt) ConflictVersions(ctx context.Context, name string) ([]byte, []byte, error) {
+	cr, err := c.conflictResolver()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	h, ok := c.mappings[name]
+	if !ok {
+		return nil, nil, os.ErrNotExist
+	}
+
+	return cr.ConflictVersions(ctx, h)
+}
+
+// ResolveConflict writes the resolved content of a conflicting entry and
+// marks it as resolved.
+func (c *Crypt) ResolveConflict(ctx context.Context, name string, content []byte) error {
+	cr, err := c.conflictResolver()
+	if err != nil {
+		return err
+	}
+
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	h, ok := c.mappings[name]
+	if !ok {
+		return os.ErrNotExist
+	}
+
+	return cr.ResolveConflict(ctx, h, content)
+}
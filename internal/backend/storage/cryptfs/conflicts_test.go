@@ -0,0 +1,112 @@
+package cryptfs
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/backend/crypto/age"
+	"github.com/gopasspw/gopass/internal/backend/storage/gitfs"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveConflict(t *testing.T) {
+	ctx := t.Context()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+	ctx = ctxutil.WithAgePassphrase(ctx, password)
+
+	td := t.TempDir()
+	t.Setenv("GOPASS_HOMEDIR", td)
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	defer func() { out.Stdout = os.Stdout }()
+
+	a, err := age.New(ctx, false, "")
+	require.NoError(t, err)
+	recp, err := a.GenerateIdentity(ctx, "", "", password)
+	require.NoError(t, err)
+
+	remote := filepath.Join(td, "remote.git")
+	require.NoError(t, exec.Command("git", "init", "--bare", remote).Run())
+
+	set := func(c *Crypt, name, content string) {
+		t.Helper()
+
+		require.NoError(t, c.Set(ctx, name, []byte(content)))
+		require.NoError(t, c.Add(ctx, name))
+		require.NoError(t, c.Commit(ctx, "update "+name))
+	}
+
+	g1, err := gitfs.Clone(ctx, remote, filepath.Join(td, "one"), "One", "one@example.org")
+	require.NoError(t, err)
+	require.NoError(t, g1.Set(ctx, ".age-recipients", []byte(recp)))
+	require.NoError(t, g1.Add(ctx, ".age-recipients"))
+	c1, err := newCrypt(ctx, g1)
+	require.NoError(t, err)
+	set(c1, "entry", "counter: 1\n")
+	require.NoError(t, c1.Push(ctx, "", ""))
+
+	g2, err := gitfs.Clone(ctx, remote, filepath.Join(td, "two"), "Two", "two@example.org")
+	require.NoError(t, err)
+	c2, err := newCrypt(ctx, g2)
+	require.NoError(t, err)
+
+	// both change the entry and add another one concurrently
+	set(c1, "entry", "counter: 2\n")
+	set(c1, "one", "added by one\n")
+	require.NoError(t, c1.Push(ctx, "", ""))
+
+	set(c2, "entry", "counter: 3\n")
+	set(c2, "two", "added by two\n")
+	require.ErrorIs(t, c2.Push(ctx, "", ""), backend.ErrConflict)
+
+	// the mapping file is merged, only the entry is left
+	conflicts, err := c2.Conflicts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"entry"}, conflicts)
+
+	ours, theirs, err := c2.ConflictVersions(ctx, "entry")
+	require.NoError(t, err)
+	assert.Equal(t, "counter: 3\n", string(ours))
+	assert.Equal(t, "counter: 2\n", string(theirs))
+
+	require.NoError(t, c2.ResolveConflict(ctx, "entry", ours))
+	conflicts, err = c2.Conflicts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+	require.NoError(t, c2.Push(ctx, "", ""))
+
+	require.NoError(t, c1.Pull(ctx, "", ""))
+	c1, err = newCrypt(ctx, g1)
+	require.NoError(t, err)
+	names, err := c1.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"entry", "one", "two"}, names)
+	content, err := c1.Get(ctx, "entry")
+	require.NoError(t, err)
+	assert.Equal(t, "counter: 3\n", string(content))
+}
+
+func TestConflictsNotSupported(t *testing.T) {
+	ctx := t.Context()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+	ctx = ctxutil.WithAgePassphrase(ctx, password)
+
+	td := t.TempDir()
+	t.Setenv("GOPASS_HOMEDIR", td)
+
+	sub, err := backend.InitStorage(ctx, backend.FS, filepath.Join(td, "fs"))
+	require.NoError(t, err)
+	c, err := newCrypt(ctx, sub)
+	require.NoError(t, err)
+
+	_, err = c.Conflicts(ctx)
+	require.ErrorIs(t, err, backend.ErrNotSupported)
+}
@@ -0,0 +1,27 @@
+package cryptfs
+
+import (
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/backend/storagetest"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+)
+
+func TestConformance(t *testing.T) {
+	ctx := t.Context()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+	ctx = ctxutil.WithAgePassphrase(ctx, password)
+
+	td := t.TempDir()
+	t.Setenv("GOPASS_HOMEDIR", td)
+
+	storagetest.Run(ctx, t, storagetest.Options{
+		New: func(t *testing.T) backend.Storage {
+			crypt, _ := newTestCryptFS(ctx, t, t.TempDir())
+
+			return crypt
+		},
+		History: true,
+	})
+}
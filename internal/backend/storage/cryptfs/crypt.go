@@ -85,28 +85,46 @@ func (c *Crypt) loadMappings(ctx context.Context) error {
 }
 
 func (c *Crypt) saveMappings(ctx context.Context) error {
-	plaintext, err := json.MarshalIndent(c.mappings, "", "  ")
+	ciphertext, err := c.encryptMappings(ctx, c.mappings)
 	if err != nil {
 		return err
 	}
 
+	return c.sub.Set(ctx, mappingFile, ciphertext)
+}
+
+func (c *Crypt) encryptMappings(ctx context.Context, mappings map[string]string) ([]byte, error) {
+	plaintext, err := json.MarshalIndent(mappings, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
 	recipientsFile := c.crypto.IDFile()
 	content, err := c.sub.Get(ctx, recipientsFile)
 	if err != nil {
-		return fmt.Errorf("failed to read recipients file %s: %w", recipientsFile, err)
+		return nil, fmt.Errorf("failed to read recipients file %s: %w", recipientsFile, err)
 	}
 
 	recipients := strings.Split(strings.TrimSpace(string(content)), "\n")
 	if len(recipients) == 0 || (len(recipients) == 1 && recipients[0] == "") {
-		return fmt.Errorf("no recipients found in %s", recipientsFile)
+		return nil, fmt.Errorf("no recipients found in %s", recipientsFile)
 	}
 
-	ciphertext, err := c.crypto.Encrypt(ctx, plaintext, recipients)
+	return c.crypto.Encrypt(ctx, plaintext, recipients)
+}
+
+func (c *Crypt) decryptMappings(ctx context.Context, ciphertext []byte) (map[string]string, error) {
+	plaintext, err := c.crypto.Decrypt(ctx, ciphertext)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return c.sub.Set(ctx, mappingFile, ciphertext)
+	mappings := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &mappings); err != nil {
+		return nil, err
+	}
+
+	return mappings, nil
 }
 
 // String implements fmt.Stringer.
@@ -193,8 +211,24 @@ func (c *Crypt) Fsck(ctx context.Context) error {
 	return c.sub.Fsck(ctx)
 }
 
+// Prune removes all secrets below the given prefix. The files in the
+// underlying storage are flat, so this works on the mappings.
 func (c *Crypt) Prune(ctx context.Context, prefix string) error {
-	return c.sub.Prune(ctx, prefix)
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	for name, h := range c.mappings {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if err := c.sub.Delete(ctx, h); err != nil {
+			return err
+		}
+		delete(c.mappings, name)
+	}
+
+	return c.saveMappings(ctx)
 }
 
 // Link creates a symlink.
@@ -495,7 +529,9 @@ func (c *Crypt) Move(ctx context.Context, from, to string, del bool) error {
 	}
 
 	// update mapping
-	delete(c.mappings, from)
+	if del {
+		delete(c.mappings, from)
+	}
 	c.mappings[to] = toH
 	if err := c.saveMappings(ctx); err != nil {
 		// try to rollback
@@ -505,6 +541,10 @@ func (c *Crypt) Move(ctx context.Context, from, to string, del bool) error {
 		return err
 	}
 
+	if !del {
+		return nil
+	}
+
 	// delete old
 	if err := c.sub.Delete(ctx, fromH); err != nil {
 		// this is not ideal, we have two copies now.
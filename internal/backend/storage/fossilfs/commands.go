@@ -0,0 +1,53 @@
+package fossilfs
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gopasspw/gopass/internal/action/exit"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/urfave/cli/v3"
+)
+
+// Commands returns the commands that are available for the fossilfs backend.
+func (l loader) Commands(i cli.BeforeFunc, s func(string) (string, error)) []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:  "fossil",
+			Usage: "Run a fossil command inside a password store: gopass fossil [--store=<store>] <fossil-command>",
+			Description: "" +
+				"If the password store is a fossil repository, execute a fossil command " +
+				"specified by fossil-command-args.",
+			Hidden: true,
+			Before: i,
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+				store := cmd.String("store")
+
+				path, err := s(store)
+				if err != nil {
+					return exit.Error(exit.Unknown, err, "failed to get sub store %s: %s", store, err)
+				}
+
+				args := cmd.Args().Slice()
+				out.Noticef(ctx, "Running 'fossil %s' in %s...", strings.Join(args, " "), path)
+				fossilCmd := exec.CommandContext(ctx, "fossil", args...)
+				fossilCmd.Dir = path
+				fossilCmd.Stdout = os.Stdout
+				fossilCmd.Stderr = os.Stderr
+				fossilCmd.Stdin = os.Stdin
+
+				return fossilCmd.Run()
+			},
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "store",
+					Usage: "Store to operate on",
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,57 @@
+package fossilfs
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/backend/storagetest"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	if _, err := exec.LookPath("fossil"); err != nil {
+		t.Skip("fossil not installed")
+	}
+
+	ctx := config.NewContextInMemory()
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	defer func() {
+		out.Stdout = os.Stdout
+	}()
+
+	storagetest.Run(ctx, t, storagetest.Options{
+		New: func(t *testing.T) backend.Storage {
+			dir := filepath.Join(t.TempDir(), "store")
+			require.NoError(t, os.MkdirAll(dir, 0o700))
+
+			f, err := Init(ctx, dir, "", "")
+			require.NoError(t, err)
+
+			return f
+		},
+		History: true,
+		Remote: func(t *testing.T) string {
+			repo := filepath.Join(t.TempDir(), "remote.fossil")
+			cmd := exec.CommandContext(ctx, "fossil", "init", repo)
+			require.NoError(t, cmd.Run())
+
+			return "file://" + repo
+		},
+		Clone: func(t *testing.T, url string) backend.Storage {
+			f, err := Clone(ctx, url, filepath.Join(t.TempDir(), "clone"))
+			require.NoError(t, err)
+
+			return f
+		},
+		// fossil rejects pushes from repositories with a different project code
+		CloneOrigin: true,
+	})
+}
@@ -300,6 +300,12 @@ func (f *Fossil) PushPull(ctx context.Context, op, remote, branch string) error
 		return store.ErrGitNotInit
 	}
 
+	if !f.hasRemote(ctx) {
+		debug.Log("No remote configured for %s", f.fs.Path())
+
+		return store.ErrGitNoRemote
+	}
+
 	if uf := f.ListUntrackedFiles(ctx); len(uf) > 0 {
 		out.Warningf(ctx, "Found untracked files: %+v", uf)
 	}
@@ -316,7 +322,54 @@ func (f *Fossil) PushPull(ctx context.Context, op, remote, branch string) error
 		}
 	}
 
-	return f.Cmd(ctx, "fossilUpdate", "update")
+	return f.update(ctx)
+}
+
+// hasRemote returns true if a default remote is configured. fossil remote
+// prints "off" otherwise.
+func (f *Fossil) hasRemote(ctx context.Context) bool {
+	stdout, _, err := f.captureCmd(ctx, "fossilRemote", "remote")
+	if err != nil {
+		return false
+	}
+
+	url := strings.TrimSpace(string(stdout))
+
+	return url != "" && url != "off"
+}
+
+// update merges the changes received from the remote into the checkout.
+// fossil update succeeds even if it runs into conflicts, so we need to
+// look at its output to detect them.
+func (f *Fossil) update(ctx context.Context) error {
+	stdout, stderr, err := f.captureCmd(ctx, "fossilUpdate", "update")
+	if err != nil {
+		debug.Log("Command failed: %s\n%s", string(stdout), string(stderr))
+
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(stderr)))
+	}
+
+	// e.g. "***** 1 merge conflicts in foo.txt" followed by a
+	// "WARNING: 1 merge conflicts" summary.
+	var conflicts []string
+	hasConflicts := false
+	for line := range strings.SplitSeq(string(stdout), "\n") {
+		if !strings.Contains(line, "merge conflict") {
+			continue
+		}
+		hasConflicts = true
+		if _, file, found := strings.Cut(line, " conflicts in "); found {
+			conflicts = append(conflicts, strings.TrimSpace(file))
+		}
+	}
+	if !hasConflicts {
+		return nil
+	}
+	if len(conflicts) < 1 {
+		return fmt.Errorf("%w: unresolved merge conflicts", backend.ErrConflict)
+	}
+
+	return fmt.Errorf("%w: unresolved conflicts in %s", backend.ErrConflict, strings.Join(conflicts, ", "))
 }
 
 // Push pushes to the fossil remote.
@@ -374,6 +427,10 @@ func (f *Fossil) RemoveRemote(ctx context.Context, remote string) error {
 
 // Revisions will list all available revisions of the named entity.
 func (f *Fossil) Revisions(ctx context.Context, name string) ([]backend.Revision, error) {
+	if !f.IsInitialized() {
+		return nil, store.ErrGitNotInit
+	}
+
 	args := []string{
 		"finfo",
 		"-W",
@@ -437,7 +494,7 @@ func (f *Fossil) Revisions(ctx context.Context, name string) ([]backend.Revision
 			Hash:       rev,
 			Date:       ts,
 			Body:       body,
-			Subject:    subject,
+			Subject:    strings.TrimSpace(subject),
 			AuthorName: author,
 		}
 		revs = append(revs, r)
@@ -448,6 +505,10 @@ func (f *Fossil) Revisions(ctx context.Context, name string) ([]backend.Revision
 
 // GetRevision will return the content of any revision of the named entity.
 func (f *Fossil) GetRevision(ctx context.Context, name, revision string) ([]byte, error) {
+	if !f.IsInitialized() {
+		return nil, store.ErrGitNotInit
+	}
+
 	name = strings.TrimSpace(name)
 	revision = strings.TrimSpace(revision)
 	args := []string{
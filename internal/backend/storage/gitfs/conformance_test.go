@@ -0,0 +1,48 @@
+package gitfs
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/backend/storagetest"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	ctx := config.NewContextInMemory()
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	defer func() {
+		out.Stdout = os.Stdout
+	}()
+
+	storagetest.Run(ctx, t, storagetest.Options{
+		New: func(t *testing.T) backend.Storage {
+			g, err := Init(ctx, t.TempDir(), "Dead Beef", "dead.beef@example.org")
+			require.NoError(t, err)
+
+			return g
+		},
+		History: true,
+		Remote: func(t *testing.T) string {
+			dir := filepath.Join(t.TempDir(), "remote.git")
+			cmd := exec.CommandContext(ctx, "git", "init", "--bare", dir)
+			require.NoError(t, cmd.Run())
+
+			return dir
+		},
+		Clone: func(t *testing.T, url string) backend.Storage {
+			g, err := Clone(ctx, url, filepath.Join(t.TempDir(), "clone"), "Dead Beef", "dead.beef@example.org")
+			require.NoError(t, err)
+
+			return g
+		},
+	})
+}
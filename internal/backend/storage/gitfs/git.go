@@ -282,6 +282,67 @@ func (g *Git) ListUntrackedFiles(ctx context.Context) []string {
 	return uf
 }
 
+// listUnmergedFiles lists files with unresolved merge conflicts.
+func (g *Git) listUnmergedFiles(ctx context.Context) []string {
+	stdout, _, err := g.captureCmd(ctx, "gitDiff", "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil
+	}
+
+	uf := []string{}
+	for f := range strings.SplitSeq(string(stdout), "\n") {
+		if f == "" {
+			continue
+		}
+		uf = append(uf, f)
+	}
+
+	return uf
+}
+
+// Conflicts lists the entries with unresolved merge conflicts.
+func (g *Git) Conflicts(ctx context.Context) ([]string, error) {
+	if !g.IsInitialized() {
+		return nil, store.ErrGitNotInit
+	}
+
+	return g.listUnmergedFiles(ctx), nil
+}
+
+// ConflictVersions returns our and their version of an entry with an
+// unresolved merge conflict.
+func (g *Git) ConflictVersions(ctx context.Context, name string) ([]byte, []byte, error) {
+	// stage 2 is our version, stage 3 the one being merged in.
+	// See: https://git-scm.com/docs/git-show#_examples
+	ours, err := g.GetRevision(ctx, name, ":2")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get our version of %s: %w", name, err)
+	}
+	theirs, err := g.GetRevision(ctx, name, ":3")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get their version of %s: %w", name, err)
+	}
+
+	return ours, theirs, nil
+}
+
+// ResolveConflict writes the resolved content of a conflicting entry and
+// marks it as resolved. Once no conflicts are left the merge is committed.
+func (g *Git) ResolveConflict(ctx context.Context, name string, content []byte) error {
+	if err := g.fs.Set(ctx, name, content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := g.Add(ctx, name); err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+
+	if len(g.listUnmergedFiles(ctx)) > 0 {
+		return nil
+	}
+
+	return g.Cmd(ctx, "gitCommit", "commit", "--no-edit")
+}
+
 // Commit creates a new git commit with the given commit message.
 func (g *Git) Commit(ctx context.Context, msg string) error {
 	if !g.IsInitialized() {
@@ -382,6 +443,9 @@ func (g *Git) PushPull(ctx context.Context, op, remote, branch string) error {
 	}
 
 	if err := g.Cmd(ctx, "gitPush", "pull", remote, branch); err != nil {
+		if uf := g.listUnmergedFiles(ctx); len(uf) > 0 {
+			return fmt.Errorf("%w: unresolved conflicts in %s", backend.ErrConflict, strings.Join(uf, ", "))
+		}
 		if op == "pull" {
 			return err
 		}
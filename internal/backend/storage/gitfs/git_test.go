@@ -3,10 +3,12 @@ package gitfs
 import (
 	"bytes"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 
 	"github.com/blang/semver/v4"
+	"github.com/gopasspw/gopass/internal/backend"
 	"github.com/gopasspw/gopass/internal/config"
 	"github.com/gopasspw/gopass/internal/out"
 	"github.com/gopasspw/gopass/pkg/ctxutil"
@@ -175,3 +177,58 @@ func TestInitWithoutSetupRemoteCreatesCommit(t *testing.T) {
 	// Without the flag the initial commit should have been created.
 	assert.False(t, git.HasStagedChanges(ctx), "expected no staged changes after automatic commit")
 }
+
+func TestResolveConflict(t *testing.T) {
+	td := t.TempDir()
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithAlwaysYes(ctx, true)
+
+	buf := &bytes.Buffer{}
+	out.Stdout = buf
+	defer func() { out.Stdout = os.Stdout }()
+
+	remote := filepath.Join(td, "remote.git")
+	require.NoError(t, exec.Command("git", "init", "--bare", remote).Run())
+
+	g1, err := Clone(ctx, remote, filepath.Join(td, "one"), "One", "one@example.org")
+	require.NoError(t, err)
+	require.NoError(t, g1.Set(ctx, "entry", []byte("counter: 1\n")))
+	require.NoError(t, g1.Add(ctx, "entry"))
+	require.NoError(t, g1.Commit(ctx, "add entry"))
+	require.NoError(t, g1.Push(ctx, "", ""))
+
+	g2, err := Clone(ctx, remote, filepath.Join(td, "two"), "Two", "two@example.org")
+	require.NoError(t, err)
+
+	// both change the entry concurrently
+	require.NoError(t, g1.Set(ctx, "entry", []byte("counter: 2\n")))
+	require.NoError(t, g1.Add(ctx, "entry"))
+	require.NoError(t, g1.Commit(ctx, "increment to 2"))
+	require.NoError(t, g1.Push(ctx, "", ""))
+
+	require.NoError(t, g2.Set(ctx, "entry", []byte("counter: 3\n")))
+	require.NoError(t, g2.Add(ctx, "entry"))
+	require.NoError(t, g2.Commit(ctx, "increment to 3"))
+	require.ErrorIs(t, g2.Push(ctx, "", ""), backend.ErrConflict)
+
+	conflicts, err := g2.Conflicts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"entry"}, conflicts)
+
+	ours, theirs, err := g2.ConflictVersions(ctx, "entry")
+	require.NoError(t, err)
+	assert.Equal(t, "counter: 3\n", string(ours))
+	assert.Equal(t, "counter: 2\n", string(theirs))
+
+	require.NoError(t, g2.ResolveConflict(ctx, "entry", ours))
+	conflicts, err = g2.Conflicts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+	require.NoError(t, g2.Push(ctx, "", ""))
+
+	require.NoError(t, g1.Pull(ctx, "", ""))
+	content, err := g1.Get(ctx, "entry")
+	require.NoError(t, err)
+	assert.Equal(t, "counter: 3\n", string(content))
+}
@@ -0,0 +1,47 @@
+// Package names normalizes entry names for storage backends that do not
+// map secrets to files in a local directory, e.g. s3fs, webdavfs and sqlitefs.
+package names
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Clean returns the normalized, slash separated name of an entry. The name
+// is resolved relative to the root of the store so it can never point
+// outside of it.
+func Clean(name string) (string, error) {
+	name = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+	if name == "" || name == "." {
+		return "", fmt.Errorf("invalid name %q", name)
+	}
+
+	return name, nil
+}
+
+// Dir returns the normalized, slash separated name of a directory without
+// leading or trailing slashes. The root is the empty string.
+func Dir(name string) string {
+	return strings.Trim(path.Clean("/"+filepath.ToSlash(name)), "/")
+}
+
+// InHiddenDir returns true if any parent directory of name starts with a
+// dot and that directory is not part of the requested prefix.
+func InHiddenDir(name, prefix string) bool {
+	dirs := strings.Split(name, "/")
+	dirs = dirs[:len(dirs)-1]
+	for i, d := range dirs {
+		if !strings.HasPrefix(d, ".") {
+			continue
+		}
+		if strings.HasPrefix(prefix, strings.Join(dirs[:i+1], "/")) {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,50 @@
+package names
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClean(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]string{
+		"foo":              "foo",
+		"/foo/bar":         "foo/bar",
+		"foo//bar/":        "foo/bar",
+		"../../etc/passwd": "etc/passwd",
+		"foo/../bar":       "bar",
+	} {
+		got, err := Clean(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "/", ".", ".."} {
+		_, err := Clean(in)
+		require.Error(t, err, in)
+	}
+}
+
+func TestDir(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, Dir(""))
+	assert.Empty(t, Dir("/"))
+	assert.Empty(t, Dir("../.."))
+	assert.Equal(t, "foo/bar", Dir("/foo/bar/"))
+	assert.Equal(t, "bar", Dir("foo/../bar"))
+}
+
+func TestInHiddenDir(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, InHiddenDir("foo/bar", ""))
+	assert.False(t, InHiddenDir(".hidden", ""))
+	assert.True(t, InHiddenDir(".git/config", ""))
+	assert.True(t, InHiddenDir("foo/.cache/bar", "foo"))
+	assert.False(t, InHiddenDir(".public-keys/0xDEADBEEF", ".public-keys"))
+	assert.False(t, InHiddenDir("foo/.cache/bar", "foo/.cache/"))
+}
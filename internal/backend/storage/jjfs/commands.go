@@ -0,0 +1,53 @@
+package jjfs
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gopasspw/gopass/internal/action/exit"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/urfave/cli/v3"
+)
+
+// Commands returns the commands that are available for the jjfs backend.
+func (l loader) Commands(i cli.BeforeFunc, s func(string) (string, error)) []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:  "jj",
+			Usage: "Run a jj command inside a password store: gopass jj [--store=<store>] <jj-command>",
+			Description: "" +
+				"If the password store is a jj repository, execute a jj command " +
+				"specified by jj-command-args.",
+			Hidden: true,
+			Before: i,
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				ctx = ctxutil.WithGlobalFlags(ctx, cmd)
+				store := cmd.String("store")
+
+				path, err := s(store)
+				if err != nil {
+					return exit.Error(exit.Unknown, err, "failed to get sub store %s: %s", store, err)
+				}
+
+				args := cmd.Args().Slice()
+				out.Noticef(ctx, "Running 'jj %s' in %s...", strings.Join(args, " "), path)
+				jjCmd := exec.CommandContext(ctx, "jj", args...)
+				jjCmd.Dir = path
+				jjCmd.Stdout = os.Stdout
+				jjCmd.Stderr = os.Stderr
+				jjCmd.Stdin = os.Stdin
+
+				return jjCmd.Run()
+			},
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "store",
+					Usage: "Store to operate on",
+				},
+			},
+		},
+	}
+}
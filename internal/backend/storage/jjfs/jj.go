@@ -6,7 +6,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -21,6 +23,31 @@ import (
 	"github.com/gopasspw/gopass/pkg/fsutil"
 )
 
+const (
+	defaultRemote = "origin"
+	// fallbackBranch is the bookmark that is pushed to a remote that has
+	// neither a tracked bookmark nor a HEAD, e.g. a new empty repository.
+	fallbackBranch = "main"
+)
+
+type contextKey int
+
+const (
+	ctxKeyPathOverride contextKey = iota
+)
+
+func withPathOverride(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, ctxKeyPathOverride, path)
+}
+
+func getPathOverride(ctx context.Context, def string) string {
+	if sv, ok := ctx.Value(ctxKeyPathOverride).(string); ok && sv != "" {
+		return sv
+	}
+
+	return def
+}
+
 // JJFS is a cli based jj backend.
 type JJFS struct {
 	fs *fs.Store
@@ -39,6 +66,24 @@ func (j *JJFS) LinkTarget(ctx context.Context, name string) (string, bool, error
 	return j.fs.LinkTarget(ctx, name)
 }
 
+// Clone clones an existing git repository into a new colocated jj repo.
+func Clone(ctx context.Context, repo, path, userName, userEmail string) (*JJFS, error) {
+	j := &JJFS{
+		fs: fs.New(path),
+	}
+
+	if err := j.Cmd(withPathOverride(ctx, filepath.Dir(path)), "Clone", "git", "clone", "--colocate", repo, path); err != nil {
+		return nil, err
+	}
+
+	if err := j.InitConfig(ctx, userName, userEmail); err != nil {
+		return j, fmt.Errorf("failed to configure jj: %w", err)
+	}
+	out.Printf(ctx, "jj configured at %s", j.fs.Path())
+
+	return j, nil
+}
+
 // Init initializes this store's jj repo.
 func Init(ctx context.Context, path, userName, userEmail string) (*JJFS, error) {
 	j := &JJFS{
@@ -52,10 +97,24 @@ func Init(ctx context.Context, path, userName, userEmail string) (*JJFS, error)
 		out.Printf(ctx, "jj initialized at %s", j.fs.Path())
 	}
 
+	if !ctxutil.IsGitInit(ctx) {
+		return j, nil
+	}
+
+	if err := j.InitConfig(ctx, userName, userEmail); err != nil {
+		return j, fmt.Errorf("failed to configure jj: %w", err)
+	}
+
 	if err := j.Add(ctx, j.fs.Path()); err != nil {
 		return j, fmt.Errorf("failed to add %q to jj: %w", j.fs.Path(), err)
 	}
 
+	if !j.HasStagedChanges(ctx) {
+		debug.Log("No staged changes")
+
+		return j, nil
+	}
+
 	if err := j.Commit(ctx, "Add current content of password store"); err != nil {
 		return j, fmt.Errorf("failed to commit changes to jj: %w", err)
 	}
@@ -68,9 +127,13 @@ func (j *JJFS) captureCmd(ctx context.Context, name string, args ...string) ([]b
 	bufErr := &bytes.Buffer{}
 
 	cmd := exec.CommandContext(ctx, "jj", args[0:]...)
-	cmd.Dir = j.fs.Path()
+	cmd.Dir = getPathOverride(ctx, j.fs.Path())
 	cmd.Stdout = bufOut
 	cmd.Stderr = bufErr
+	if ctxutil.HasCommitTimestamp(ctx) {
+		// jj uses this for the author and committer timestamps of new commits
+		cmd.Env = append(os.Environ(), "JJ_TIMESTAMP="+ctxutil.GetCommitTimestamp(ctx).Format(time.RFC3339))
+	}
 
 	debug.Log("store.%s: %s %+v (%s)", name, cmd.Path, cmd.Args, j.fs.Path())
 	err := cmd.Run()
@@ -121,7 +184,8 @@ func (j *JJFS) IsInitialized() bool {
 	return fsutil.IsDir(j.fs.Path() + "/.jj")
 }
 
-// Add adds the listed files to the jj index.
+// Add starts tracking the listed files. jj snapshots the working copy on
+// every command, so there is no separate staging step.
 func (j *JJFS) Add(ctx context.Context, files ...string) error {
 	if !j.IsInitialized() {
 		return store.ErrGitNotInit
@@ -132,13 +196,13 @@ func (j *JJFS) Add(ctx context.Context, files ...string) error {
 	}
 
 	args := make([]string, 0, 2+len(files))
-	args = append(args, "git", "add")
+	args = append(args, "file", "track")
 	args = append(args, files...)
 
-	return j.Cmd(ctx, "jjGitAdd", args...)
+	return j.Cmd(ctx, "jjFileTrack", args...)
 }
 
-// TryAdd calls Add and returns nil if the git repo was not initialized.
+// TryAdd calls Add and returns nil if the jj repo was not initialized.
 func (j *JJFS) TryAdd(ctx context.Context, files ...string) error {
 	err := j.Add(ctx, files...)
 	if err == nil {
@@ -153,16 +217,21 @@ func (j *JJFS) TryAdd(ctx context.Context, files ...string) error {
 	return err
 }
 
-// Commit creates a new jj commit with the given commit message.
+// Commit describes the working copy with the given commit message and
+// starts a new, empty change on top of it.
 func (j *JJFS) Commit(ctx context.Context, msg string) error {
 	if !j.IsInitialized() {
 		return store.ErrGitNotInit
 	}
 
-	return j.Cmd(ctx, "jjCommit", "describe", "-m", msg)
+	if !j.HasStagedChanges(ctx) {
+		return store.ErrGitNothingToCommit
+	}
+
+	return j.Cmd(ctx, "jjCommit", "commit", "-m", msg)
 }
 
-// TryCommit calls commit and returns nil if there was nothing to commit or if the git repo was not initialized.
+// TryCommit calls commit and returns nil if there was nothing to commit or if the jj repo was not initialized.
 func (j *JJFS) TryCommit(ctx context.Context, msg string) error {
 	err := j.Commit(ctx, msg)
 	if err == nil {
@@ -182,35 +251,201 @@ func (j *JJFS) TryCommit(ctx context.Context, msg string) error {
 	return err
 }
 
-// Push pushes to the git remote.
-func (j *JJFS) Push(ctx context.Context, _, _ string) error {
+func (j *JJFS) hasRemote(ctx context.Context, remote string) bool {
+	stdout, _, err := j.captureCmd(ctx, "jjGitRemoteList", "git", "remote", "list")
+	if err != nil {
+		return false
+	}
+
+	for line := range strings.SplitSeq(string(stdout), "\n") {
+		if name, _, _ := strings.Cut(line, " "); name == remote {
+			return true
+		}
+	}
+
+	return false
+}
+
+// defaultBranch returns the bookmark that is pushed to and pulled from if
+// none is given. jj has no notion of a current branch like git, so this is
+// the bookmark tracking the remote, e.g. the default branch of a cloned
+// repository, or else the branch the HEAD of the remote points to.
+func (j *JJFS) defaultBranch(ctx context.Context, remote string) string {
+	stdout, _, err := j.captureCmd(ctx, "jjBookmarkList", "bookmark", "list", "--tracked", "--remote", remote, "--template", `name ++ "\n"`)
+	if err == nil {
+		if b := singleBookmark(stdout); b != "" {
+			return b
+		}
+	}
+
+	if b := j.remoteHead(ctx, remote); b != "" {
+		return b
+	}
+
+	debug.Log("No default branch found for %s, using %s", remote, fallbackBranch)
+
+	return fallbackBranch
+}
+
+// singleBookmark returns the bookmark listed in the output of jj bookmark
+// list if there is exactly one. Tracked bookmarks are listed once for the
+// local bookmark and once for every remote.
+func singleBookmark(buf []byte) string {
+	var found string
+	for _, name := range strings.Fields(string(buf)) {
+		if found != "" && name != found {
+			return ""
+		}
+		found = name
+	}
+
+	return found
+}
+
+// remoteHead returns the branch the HEAD of the remote points to. The
+// repository is colocated, so git knows the remotes of jj.
+func (j *JJFS) remoteHead(ctx context.Context, remote string) string {
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--symref", remote, "HEAD")
+	cmd.Dir = j.fs.Path()
+
+	stdout, err := cmd.Output()
+	if err != nil {
+		debug.Log("failed to read HEAD of %s: %s", remote, err)
+
+		return ""
+	}
+
+	return parseSymref(stdout)
+}
+
+// parseSymref returns the branch from the output of git ls-remote --symref,
+// e.g. "ref: refs/heads/master\tHEAD".
+func parseSymref(buf []byte) string {
+	for line := range strings.SplitSeq(string(buf), "\n") {
+		ref, found := strings.CutPrefix(line, "ref: refs/heads/")
+		if !found {
+			continue
+		}
+
+		if branch, _, found := strings.Cut(ref, "\t"); found {
+			return branch
+		}
+	}
+
+	return ""
+}
+
+// revExists returns true if the revset resolves to at least one commit.
+func (j *JJFS) revExists(ctx context.Context, revset string) bool {
+	stdout, _, err := j.captureCmd(ctx, "jjLog", "log", "--no-graph", "--revisions", "present("+revset+")", "--template", "commit_id")
+
+	return err == nil && len(bytes.TrimSpace(stdout)) > 0
+}
+
+// conflicts returns an error wrapping backend.ErrConflict if the working
+// copy or any of its ancestors contain unresolved conflicts.
+func (j *JJFS) conflicts(ctx context.Context) error {
+	stdout, _, err := j.captureCmd(ctx, "jjConflicts", "log", "--no-graph", "--revisions", "conflicts() & ::@", "--template", "change_id.short() ++ \"\\n\"")
+	if err != nil {
+		return err
+	}
+
+	if ids := strings.Fields(string(stdout)); len(ids) > 0 {
+		return fmt.Errorf("%w: unresolved conflicts in %s", backend.ErrConflict, strings.Join(ids, ", "))
+	}
+
+	return nil
+}
+
+// PushPull fetches from the remote, rebases the local changes on top of
+// the remote bookmark and, unless op is pull, pushes the result.
+// optional arguments: remote and branch.
+func (j *JJFS) PushPull(ctx context.Context, op, remote, branch string) error {
 	if ctxutil.IsNoNetwork(ctx) {
 		debug.Log("Skipping network ops. NoNetwork=true")
 
 		return nil
 	}
 	if !j.IsInitialized() {
+		debug.Log("JJ in %s is not initialized. Can not push/pull", j.Path())
+
 		return store.ErrGitNotInit
 	}
 
-	return j.Cmd(ctx, "jjGitPush", "git", "push")
+	if remote == "" {
+		remote = defaultRemote
+	}
+
+	if !j.hasRemote(ctx, remote) {
+		debug.Log("No remote %q found", remote)
+
+		return store.ErrGitNoRemote
+	}
+
+	if err := j.Cmd(ctx, "jjGitFetch", "git", "fetch", "--remote", remote); err != nil {
+		if op == "pull" {
+			return err
+		}
+		out.Warningf(ctx, "Failed to fetch before jj push: %s", err)
+	}
+
+	if branch == "" {
+		branch = j.defaultBranch(ctx, remote)
+	}
+
+	// jj records conflicts in the rebased commits instead of failing, so
+	// we need to check for them explicitly before pushing.
+	tracking := branch + "@" + remote
+	isNew := !j.revExists(ctx, tracking)
+	if !isNew {
+		if err := j.Cmd(ctx, "jjRebase", "rebase", "--branch", "@", "--destination", tracking); err != nil {
+			return err
+		}
+	}
+	if err := j.conflicts(ctx); err != nil {
+		return err
+	}
+
+	if op == "pull" {
+		return nil
+	}
+
+	// @ is the empty change created by the last commit.
+	if err := j.Cmd(ctx, "jjBookmarkSet", "bookmark", "set", branch, "--revision", "@-"); err != nil {
+		return err
+	}
+
+	args := []string{"git", "push", "--remote", remote, "--bookmark", branch}
+	if isNew {
+		args = append(args, "--allow-new")
+	}
+
+	return j.Cmd(ctx, "jjGitPush", args...)
 }
 
-// Pull pulls from the git remote.
-func (j *JJFS) Pull(ctx context.Context, _, _ string) error {
+// Push pushes to the git remote.
+func (j *JJFS) Push(ctx context.Context, remote, branch string) error {
 	if ctxutil.IsNoNetwork(ctx) {
 		debug.Log("Skipping network ops. NoNetwork=true")
 
 		return nil
 	}
-	if !j.IsInitialized() {
-		return store.ErrGitNotInit
+
+	return j.PushPull(ctx, "push", remote, branch)
+}
+
+// Pull pulls from the git remote.
+func (j *JJFS) Pull(ctx context.Context, remote, branch string) error {
+	if ctxutil.IsNoNetwork(ctx) {
+		debug.Log("Skipping network ops. NoNetwork=true")
+
+		return nil
 	}
 
-	return j.Cmd(ctx, "jjGitPull", "git", "fetch")
+	return j.PushPull(ctx, "pull", remote, branch)
 }
 
-// TryPush calls Push and returns nil if the git repo was not initialized.
+// TryPush calls Push and returns nil if the jj repo was not initialized.
 func (j *JJFS) TryPush(ctx context.Context, remote, branch string) error {
 	err := j.Push(ctx, remote, branch)
 	if err == nil {
@@ -239,9 +474,11 @@ func (j *JJFS) Revisions(ctx context.Context, name string) ([]backend.Revision,
 
 	args := []string{
 		"log",
-		"--revisions", "@",
+		"--no-graph",
+		"--revisions", "::@",
 		"--template",
-		"commit_id \"\x1f\" author \"\x1f\" committer.timestamp() \"\x1f\" description \"\x1e\"",
+		"commit_id ++ \"\x1f\" ++ author.name() ++ \"\x1f\" ++ author.email() ++ \"\x1f\" ++ " +
+			"author.timestamp().utc().format(\"%s\") ++ \"\x1f\" ++ description ++ \"\x1e\"",
 		"--",
 		name,
 	}
@@ -272,13 +509,19 @@ func (j *JJFS) Revisions(ctx context.Context, name string) ([]backend.Revision,
 		}
 
 		if len(p) > 2 {
-			if iv, err := strconv.ParseInt(p[2], 10, 64); err == nil {
+			r.AuthorEmail = p[2]
+		}
+
+		if len(p) > 3 {
+			if iv, err := strconv.ParseInt(p[3], 10, 64); err == nil {
 				r.Date = time.Unix(iv, 0)
 			}
 		}
 
-		if len(p) > 3 {
-			r.Subject = p[3]
+		if len(p) > 4 {
+			subject, body, _ := strings.Cut(strings.TrimSpace(p[4]), "\n")
+			r.Subject = subject
+			r.Body = strings.TrimSpace(body)
 		}
 
 		revs = append(revs, r)
@@ -298,8 +541,9 @@ func (j *JJFS) GetRevision(ctx context.Context, name, revision string) ([]byte,
 	name = strings.TrimSpace(name)
 	revision = strings.TrimSpace(revision)
 	args := []string{
-		"show",
+		"file", "show",
 		"--revision", revision,
+		"--",
 		name,
 	}
 	stdout, stderr, err := j.captureCmd(ctx, "GetRevision", args...)
@@ -324,9 +568,9 @@ func (j *JJFS) Status(ctx context.Context) ([]byte, error) {
 	return stdout, nil
 }
 
-// Compact will run git gc.
+// Compact will run jj util gc.
 func (j *JJFS) Compact(ctx context.Context) error {
-	return j.Cmd(ctx, "jjGitGC", "git", "gc", "--aggressive")
+	return j.Cmd(ctx, "jjUtilGC", "util", "gc")
 }
 
 // ListUntrackedFiles lists untracked files.
@@ -348,14 +592,14 @@ func (j *JJFS) ListUntrackedFiles(ctx context.Context) []string {
 	return uf
 }
 
-// HasStagedChanges returns true if there are any staged changes which can be committed.
+// HasStagedChanges returns true if the working copy has any changes which can be committed.
 func (j *JJFS) HasStagedChanges(ctx context.Context) bool {
-	stdout, _, err := j.captureCmd(ctx, "jjStatus", "status", "--no-patch")
+	stdout, _, err := j.captureCmd(ctx, "jjDiff", "diff", "--summary")
 	if err != nil {
 		return false
 	}
 
-	return len(strings.TrimSpace(string(stdout))) > 0
+	return len(bytes.TrimSpace(stdout)) > 0
 }
 
 // AddRemote adds a new remote.
@@ -368,8 +612,20 @@ func (j *JJFS) RemoveRemote(ctx context.Context, remote string) error {
 	return j.Cmd(ctx, "jjGitRemoteRemove", "git", "remote", "remove", remote)
 }
 
-// InitConfig initializes the git config.
+// InitConfig sets the author of new commits in the repo config.
 func (j *JJFS) InitConfig(ctx context.Context, name, email string) error {
+	if name != "" {
+		if err := j.Cmd(ctx, "jjConfigSet", "config", "set", "--repo", "user.name", name); err != nil {
+			return fmt.Errorf("failed to set jj config user.name: %w", err)
+		}
+	}
+
+	if email != "" && strings.Contains(email, "@") {
+		if err := j.Cmd(ctx, "jjConfigSet", "config", "set", "--repo", "user.email", email); err != nil {
+			return fmt.Errorf("failed to set jj config user.email: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,61 @@
+package jjfs
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/backend/storage/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleBookmark(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, singleBookmark(nil))
+	assert.Equal(t, "master", singleBookmark([]byte("master\nmaster\n")))
+	assert.Equal(t, "trunk", singleBookmark([]byte("trunk\n")))
+	assert.Empty(t, singleBookmark([]byte("main\nmain\nfeature\nfeature\n")))
+}
+
+func TestParseSymref(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "master", parseSymref([]byte("ref: refs/heads/master\tHEAD\n0123456789abcdef\tHEAD\n")))
+	assert.Equal(t, "trunk", parseSymref([]byte("ref: refs/heads/trunk\tHEAD\n")))
+	assert.Empty(t, parseSymref([]byte("0123456789abcdef\tHEAD\n")))
+	assert.Empty(t, parseSymref(nil))
+}
+
+func TestRemoteHead(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	ctx := t.Context()
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	require.NoError(t, exec.CommandContext(ctx, "git", "init", "--bare", "--initial-branch=trunk", remote).Run())
+
+	// the repository is colocated, so git knows the remotes.
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(cmd.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.org", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.org")
+		buf, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(buf))
+	}
+	git("init", "--initial-branch=trunk")
+	git("remote", "add", "origin", remote)
+
+	git("commit", "--allow-empty", "-m", "init")
+	git("push", "origin", "trunk")
+
+	j := &JJFS{fs: fs.New(dir)}
+	assert.Equal(t, "trunk", j.remoteHead(ctx, "origin"))
+	assert.Empty(t, j.remoteHead(ctx, "missing"))
+}
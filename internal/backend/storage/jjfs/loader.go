@@ -6,6 +6,7 @@ import (
 
 	"github.com/gopasspw/gopass/internal/backend"
 	"github.com/gopasspw/gopass/pkg/fsutil"
+	"github.com/gopasspw/gopass/pkg/termio"
 )
 
 func init() {
@@ -27,11 +28,11 @@ func (l loader) New(ctx context.Context, path string) (backend.Storage, error) {
 }
 
 func (l loader) Init(ctx context.Context, path string) (backend.Storage, error) {
-	return Init(ctx, path, "", "")
+	return Init(ctx, path, termio.DetectName(ctx, nil), termio.DetectEmail(ctx, nil))
 }
 
 func (l loader) Clone(ctx context.Context, repo, path string) (backend.Storage, error) {
-	return nil, backend.ErrNotSupported
+	return Clone(ctx, repo, path, termio.DetectName(ctx, nil), termio.DetectEmail(ctx, nil))
 }
 
 func (l loader) Handles(ctx context.Context, path string) error {
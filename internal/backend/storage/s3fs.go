@@ -0,0 +1,3 @@
+package storage
+
+import _ "github.com/gopasspw/gopass/internal/backend/storage/s3fs" // register s3fs backend
@@ -0,0 +1,256 @@
+package s3fs
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// errNotFound is returned if the requested object does not exist.
+var errNotFound = errors.New("object not found")
+
+// apiError is an error returned by the S3 API.
+type apiError struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *apiError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3 request failed: %s", http.StatusText(e.StatusCode))
+	}
+
+	return fmt.Sprintf("s3 request failed: %s: %s (%d)", e.Code, e.Message, e.StatusCode)
+}
+
+// client is a minimal S3 REST client. It only implements the handful of
+// object and bucket operations required by the storage backend.
+type client struct {
+	hc       *http.Client
+	endpoint *url.URL
+	region   string
+	bucket   string
+	creds    credentials
+	// pathStyle selects path style (endpoint/bucket/key) instead of
+	// virtual-hosted style (bucket.endpoint/key) addressing.
+	pathStyle bool
+	now       func() time.Time
+}
+
+func newClient(s Settings, creds credentials) (*client, error) {
+	endpoint := s.Endpoint
+	pathStyle := true
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", s.Bucket, s.region())
+		pathStyle = false
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint %q: unsupported scheme", endpoint)
+	}
+
+	return &client{
+		hc:        &http.Client{Timeout: 60 * time.Second},
+		endpoint:  u,
+		region:    s.region(),
+		bucket:    s.Bucket,
+		creds:     creds,
+		pathStyle: pathStyle,
+		now:       time.Now,
+	}, nil
+}
+
+func (c *client) url(key string, query url.Values) *url.URL {
+	u := *c.endpoint
+	p := strings.TrimSuffix(u.Path, "/")
+	if c.pathStyle {
+		p += "/" + c.bucket
+	}
+	p += "/" + key
+
+	u.Path = p
+	u.RawPath = canonicalURI(&url.URL{Path: p})
+	u.RawQuery = query.Encode()
+
+	return &u
+}
+
+// do performs a signed request against the given object key. An empty key
+// addresses the bucket itself. Non-2xx responses are turned into errors.
+func (c *client) do(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	u := c.url(key, query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if body != nil {
+		sum := md5.Sum(body) //nolint:gosec
+		req.Header.Set("Content-Md5", base64.StdEncoding.EncodeToString(sum[:]))
+		req.Header.Set("X-Amz-Content-Sha256", hexSHA256(body))
+	}
+	if c.creds.AccessKey != "" {
+		signV4(req, c.creds, c.region, c.now())
+	}
+
+	debug.V(3).Log("s3 %s %s", method, u.Redacted())
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	ae := &apiError{StatusCode: resp.StatusCode}
+	if buf, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024)); err == nil && len(buf) > 0 {
+		_ = xml.Unmarshal(buf, ae)
+	}
+	if resp.StatusCode == http.StatusNotFound && ae.Code != "NoSuchBucket" {
+		return nil, fmt.Errorf("%s: %w", key, errNotFound)
+	}
+
+	return nil, ae
+}
+
+// doXML performs a request and decodes the XML response into v.
+func (c *client) doXML(ctx context.Context, method, key string, query url.Values, v any) error {
+	resp, err := c.do(ctx, method, key, query, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	return xml.NewDecoder(resp.Body).Decode(v)
+}
+
+type listObject struct {
+	Key  string `xml:"Key"`
+	ETag string `xml:"ETag"`
+}
+
+type listResult struct {
+	IsTruncated           bool         `xml:"IsTruncated"`
+	Contents              []listObject `xml:"Contents"`
+	NextContinuationToken string       `xml:"NextContinuationToken"`
+}
+
+// list returns all object keys starting with the given prefix.
+func (c *client) list(ctx context.Context, prefix string, limit int) ([]string, error) {
+	keys := make([]string, 0, 100)
+	token := ""
+	for {
+		q := url.Values{}
+		q.Set("list-type", "2")
+		q.Set("prefix", prefix)
+		if limit > 0 {
+			q.Set("max-keys", fmt.Sprintf("%d", limit))
+		}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+
+		var res listResult
+		if err := c.doXML(ctx, http.MethodGet, "", q, &res); err != nil {
+			return nil, err
+		}
+		for _, o := range res.Contents {
+			keys = append(keys, o.Key)
+		}
+
+		if !res.IsTruncated || res.NextContinuationToken == "" || (limit > 0 && len(keys) >= limit) {
+			return keys, nil
+		}
+		token = res.NextContinuationToken
+	}
+}
+
+type objectVersion struct {
+	Key          string    `xml:"Key"`
+	VersionID    string    `xml:"VersionId"`
+	IsLatest     bool      `xml:"IsLatest"`
+	LastModified time.Time `xml:"LastModified"`
+	ETag         string    `xml:"ETag"`
+}
+
+type versionsResult struct {
+	IsTruncated         bool            `xml:"IsTruncated"`
+	NextKeyMarker       string          `xml:"NextKeyMarker"`
+	NextVersionIDMarker string          `xml:"NextVersionIdMarker"`
+	Versions            []objectVersion `xml:"Version"`
+}
+
+// versions returns all versions of exactly the given key. Delete markers are
+// omitted.
+func (c *client) versions(ctx context.Context, key string) ([]objectVersion, error) {
+	vs := make([]objectVersion, 0, 10)
+	keyMarker, versionMarker := "", ""
+	for {
+		q := url.Values{}
+		q.Set("versions", "")
+		q.Set("prefix", key)
+		if keyMarker != "" {
+			q.Set("key-marker", keyMarker)
+			q.Set("version-id-marker", versionMarker)
+		}
+
+		var res versionsResult
+		if err := c.doXML(ctx, http.MethodGet, "", q, &res); err != nil {
+			return nil, err
+		}
+		for _, v := range res.Versions {
+			if v.Key != key {
+				continue
+			}
+			vs = append(vs, v)
+		}
+
+		if !res.IsTruncated || res.NextKeyMarker == "" {
+			return vs, nil
+		}
+		keyMarker, versionMarker = res.NextKeyMarker, res.NextVersionIDMarker
+	}
+}
+
+type versioningConfiguration struct {
+	Status string `xml:"Status"`
+}
+
+// versioning returns the versioning status of the bucket, i.e. Enabled,
+// Suspended or the empty string if it was never enabled.
+func (c *client) versioning(ctx context.Context) (string, error) {
+	q := url.Values{}
+	q.Set("versioning", "")
+
+	var res versioningConfiguration
+	if err := c.doXML(ctx, http.MethodGet, "", q, &res); err != nil {
+		return "", err
+	}
+
+	return res.Status, nil
+}
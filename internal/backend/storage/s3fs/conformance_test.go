@@ -0,0 +1,19 @@
+package s3fs
+
+import (
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/backend/storagetest"
+	"github.com/gopasspw/gopass/internal/config"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(config.NewContextInMemory(), t, storagetest.Options{
+		New: func(t *testing.T) backend.Storage {
+			_, srv := newFakeS3(t, "gopass")
+
+			return newTestStore(t, srv.URL, "")
+		},
+	})
+}
@@ -0,0 +1,337 @@
+package s3fs
+
+import (
+	"crypto/md5" //nolint:gosec
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a minimal in-process stand-in for an S3-compatible server. It
+// implements just enough of the API for the s3fs backend and verifies the
+// request signatures.
+type fakeS3 struct {
+	sync.Mutex
+
+	t          *testing.T
+	bucket     string
+	creds      credentials
+	versioning bool
+	objects    map[string][]*fakeVersion
+	nextID     int
+}
+
+type fakeVersion struct {
+	id       string
+	data     []byte
+	etag     string
+	meta     http.Header
+	modified time.Time
+	deleted  bool
+}
+
+func newFakeS3(t *testing.T, bucket string) (*fakeS3, *httptest.Server) {
+	t.Helper()
+
+	f := &fakeS3{
+		t:          t,
+		bucket:     bucket,
+		creds:      credentials{AccessKey: "AKIDTEST", SecretKey: "secret"},
+		versioning: true,
+		objects:    map[string][]*fakeVersion{},
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	t.Setenv("AWS_ACCESS_KEY_ID", f.creds.AccessKey)
+	t.Setenv("AWS_SECRET_ACCESS_KEY", f.creds.SecretKey)
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	return f, srv
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	if !f.verifySignature(r) {
+		f.error(w, http.StatusForbidden, "SignatureDoesNotMatch")
+
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		f.error(w, http.StatusNotFound, "NoSuchBucket")
+
+		return
+	}
+
+	q := r.URL.Query()
+	if key == "" {
+		switch {
+		case q.Has("versioning"):
+			f.getVersioning(w)
+		case q.Has("versions"):
+			f.listVersions(w, q.Get("prefix"))
+		case q.Get("list-type") == "2":
+			f.list(w, q)
+		default:
+			f.error(w, http.StatusNotImplemented, "NotImplemented")
+		}
+
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		f.get(w, r, key, q.Get("versionId"))
+	case http.MethodPut:
+		f.put(w, r, key)
+	case http.MethodDelete:
+		f.delete(w, key)
+	default:
+		f.error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (f *fakeS3) verifySignature(r *http.Request) bool {
+	got := r.Header.Get("Authorization")
+	ts, err := time.Parse(amzDateFormat, r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		f.t.Logf("invalid date: %s", err)
+
+		return false
+	}
+
+	req := r.Clone(r.Context())
+	req.Header.Del("Authorization")
+	req.URL.Host = r.Host
+	signV4(req, f.creds, defaultRegion, ts)
+
+	if want := req.Header.Get("Authorization"); got != want {
+		f.t.Logf("signature mismatch for %s %s:\ngot:  %s\nwant: %s", r.Method, r.URL, got, want)
+
+		return false
+	}
+
+	return true
+}
+
+func (f *fakeS3) error(w http.ResponseWriter, code int, s3code string) {
+	w.WriteHeader(code)
+	_, _ = fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", s3code, s3code)
+}
+
+func (f *fakeS3) latest(key string) *fakeVersion {
+	vs := f.objects[key]
+	if len(vs) < 1 || vs[len(vs)-1].deleted {
+		return nil
+	}
+
+	return vs[len(vs)-1]
+}
+
+func (f *fakeS3) get(w http.ResponseWriter, r *http.Request, key, versionID string) {
+	v := f.latest(key)
+	if versionID != "" {
+		v = nil
+		for _, cand := range f.objects[key] {
+			if cand.id == versionID && !cand.deleted {
+				v = cand
+			}
+		}
+	}
+	if v == nil {
+		f.error(w, http.StatusNotFound, "NoSuchKey")
+
+		return
+	}
+
+	for k, vs := range v.meta {
+		w.Header()[k] = vs
+	}
+	w.Header().Set("ETag", v.etag)
+	w.Header().Set("X-Amz-Version-Id", v.id)
+	w.Header().Set("Content-Length", strconv.Itoa(len(v.data)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(v.data)
+}
+
+func (f *fakeS3) put(w http.ResponseWriter, r *http.Request, key string) {
+	cur := f.latest(key)
+	if im := r.Header.Get("If-Match"); im != "" && (cur == nil || cur.etag != im) {
+		f.error(w, http.StatusPreconditionFailed, "PreconditionFailed")
+
+		return
+	}
+	if r.Header.Get("If-None-Match") == "*" && cur != nil {
+		f.error(w, http.StatusPreconditionFailed, "PreconditionFailed")
+
+		return
+	}
+
+	var data []byte
+	if src := r.Header.Get("X-Amz-Copy-Source"); src != "" {
+		src, _ = url.PathUnescape(src)
+		_, srcKey, _ := strings.Cut(strings.TrimPrefix(src, "/"), "/")
+		sv := f.latest(srcKey)
+		if sv == nil {
+			f.error(w, http.StatusNotFound, "NoSuchKey")
+
+			return
+		}
+		data = sv.data
+	} else {
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			f.error(w, http.StatusBadRequest, "IncompleteBody")
+
+			return
+		}
+		data = buf
+	}
+
+	meta := http.Header{}
+	for k, vs := range r.Header {
+		if strings.HasPrefix(k, metaPrefix) {
+			meta[k] = vs
+		}
+	}
+
+	sum := md5.Sum(data) //nolint:gosec
+	v := &fakeVersion{
+		id:       f.newID(),
+		data:     data,
+		etag:     `"` + hex.EncodeToString(sum[:]) + `"`,
+		meta:     meta,
+		modified: time.Now().UTC().Add(time.Duration(f.nextID) * time.Second),
+	}
+	f.add(key, v)
+
+	w.Header().Set("ETag", v.etag)
+}
+
+func (f *fakeS3) delete(w http.ResponseWriter, key string) {
+	if f.latest(key) != nil {
+		f.add(key, &fakeVersion{id: f.newID(), deleted: true, modified: time.Now().UTC()})
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeS3) newID() string {
+	f.nextID++
+	if !f.versioning {
+		return "null"
+	}
+
+	return fmt.Sprintf("v%04d", f.nextID)
+}
+
+func (f *fakeS3) add(key string, v *fakeVersion) {
+	if !f.versioning {
+		f.objects[key] = []*fakeVersion{v}
+
+		return
+	}
+	f.objects[key] = append(f.objects[key], v)
+}
+
+func (f *fakeS3) keys(prefix string) []string {
+	keys := make([]string, 0, len(f.objects))
+	for k := range f.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	return keys
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, q url.Values) {
+	maxKeys := 2 // small pages to exercise pagination
+	if mk, err := strconv.Atoi(q.Get("max-keys")); err == nil && mk < maxKeys {
+		maxKeys = mk
+	}
+	start, _ := strconv.Atoi(q.Get("continuation-token"))
+
+	type content struct {
+		Key  string `xml:"Key"`
+		ETag string `xml:"ETag"`
+	}
+	res := struct {
+		XMLName               xml.Name  `xml:"ListBucketResult"`
+		IsTruncated           bool      `xml:"IsTruncated"`
+		Contents              []content `xml:"Contents"`
+		NextContinuationToken string    `xml:"NextContinuationToken,omitempty"`
+	}{}
+
+	live := make([]string, 0, len(f.objects))
+	for _, k := range f.keys(q.Get("prefix")) {
+		if f.latest(k) != nil {
+			live = append(live, k)
+		}
+	}
+	for i := start; i < len(live); i++ {
+		if len(res.Contents) >= maxKeys {
+			res.IsTruncated = true
+			res.NextContinuationToken = strconv.Itoa(i)
+
+			break
+		}
+		res.Contents = append(res.Contents, content{Key: live[i], ETag: f.latest(live[i]).etag})
+	}
+
+	_ = xml.NewEncoder(w).Encode(res)
+}
+
+func (f *fakeS3) listVersions(w http.ResponseWriter, prefix string) {
+	type version struct {
+		Key          string    `xml:"Key"`
+		VersionID    string    `xml:"VersionId"`
+		IsLatest     bool      `xml:"IsLatest"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+	}
+	res := struct {
+		XMLName       xml.Name  `xml:"ListVersionsResult"`
+		Versions      []version `xml:"Version"`
+		DeleteMarkers []version `xml:"DeleteMarker"`
+	}{}
+
+	for _, k := range f.keys(prefix) {
+		vs := f.objects[k]
+		for i, v := range vs {
+			ov := version{Key: k, VersionID: v.id, IsLatest: i == len(vs)-1, LastModified: v.modified, ETag: v.etag}
+			if v.deleted {
+				res.DeleteMarkers = append(res.DeleteMarkers, ov)
+
+				continue
+			}
+			res.Versions = append(res.Versions, ov)
+		}
+	}
+
+	_ = xml.NewEncoder(w).Encode(res)
+}
+
+func (f *fakeS3) getVersioning(w http.ResponseWriter) {
+	status := ""
+	if f.versioning {
+		status = "Enabled"
+	}
+	_, _ = fmt.Fprintf(w, "<VersioningConfiguration><Status>%s</Status></VersioningConfiguration>", status)
+}
@@ -0,0 +1,90 @@
+package s3fs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/fsutil"
+)
+
+func init() {
+	backend.StorageRegistry.Register(backend.S3FS, name, &loader{})
+}
+
+type loader struct{}
+
+// New opens an existing store. The bucket settings are read from the
+// settings file in the local store directory.
+func (l loader) New(ctx context.Context, path string) (backend.Storage, error) {
+	path = fsutil.ExpandHomedir(path)
+
+	s, err := loadSettings(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load s3 settings from %s: %w", path, err)
+	}
+
+	be, err := New(path, s)
+	if err != nil {
+		return nil, err
+	}
+	debug.Log("Using Storage Backend: %s", be.String())
+
+	return be, nil
+}
+
+// Init initializes a new store using the bucket configured in s3.bucket.
+func (l loader) Init(ctx context.Context, path string) (backend.Storage, error) {
+	return l.init(ctx, path, settingsFromConfig(ctx))
+}
+
+// Clone connects to an existing store. The repo is expected to be of the form
+// s3://bucket/prefix.
+func (l loader) Clone(ctx context.Context, repo, path string) (backend.Storage, error) {
+	s, err := settingsFromURL(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.init(ctx, path, s)
+}
+
+func (l loader) init(ctx context.Context, path string, s Settings) (backend.Storage, error) {
+	path = fsutil.ExpandHomedir(path)
+
+	be, err := New(path, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := be.Fsck(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := saveSettings(path, s); err != nil {
+		return nil, fmt.Errorf("failed to save s3 settings to %s: %w", path, err)
+	}
+	out.Printf(ctx, "s3fs configured for s3://%s/%s at %s", s.Bucket, s.prefix(), path)
+
+	return be, nil
+}
+
+// Handles returns nil if the path contains an s3fs settings file.
+func (l loader) Handles(ctx context.Context, path string) error {
+	if !hasSettings(path) {
+		return fmt.Errorf("no %s found in %s", SettingsFile, path)
+	}
+
+	return nil
+}
+
+// Priority returns the priority of this backend.
+func (l loader) Priority() int {
+	return 8
+}
+
+func (l loader) String() string {
+	return name
+}
@@ -0,0 +1,172 @@
+// This file contains the rcs interface implementation for the s3fs backend.
+// Every write is immediately visible to all other clients, so there is no
+// staging area and nothing to push or pull. The history of a secret is
+// provided by S3 object versioning, the commit message and author are
+// recorded as object metadata at write time.
+package s3fs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/backend"
+)
+
+// Add does nothing. Objects are visible as soon as they are written.
+func (s *Store) Add(ctx context.Context, args ...string) error {
+	return nil
+}
+
+// TryAdd does nothing.
+func (s *Store) TryAdd(ctx context.Context, args ...string) error {
+	return nil
+}
+
+// Commit does nothing. The commit message is recorded on write.
+func (s *Store) Commit(ctx context.Context, msg string) error {
+	return nil
+}
+
+// TryCommit does nothing.
+func (s *Store) TryCommit(ctx context.Context, msg string) error {
+	return nil
+}
+
+// Push does nothing.
+func (s *Store) Push(ctx context.Context, origin, branch string) error {
+	return nil
+}
+
+// TryPush does nothing.
+func (s *Store) TryPush(ctx context.Context, origin, branch string) error {
+	return nil
+}
+
+// Pull does nothing. Reads always go to the bucket.
+func (s *Store) Pull(ctx context.Context, origin, branch string) error {
+	return nil
+}
+
+// InitConfig does nothing.
+func (s *Store) InitConfig(context.Context, string, string) error {
+	return nil
+}
+
+// AddRemote is not supported.
+func (s *Store) AddRemote(ctx context.Context, remote, url string) error {
+	return backend.ErrNotSupported
+}
+
+// RemoveRemote is not supported.
+func (s *Store) RemoveRemote(ctx context.Context, remote string) error {
+	return backend.ErrNotSupported
+}
+
+// Revisions returns the object versions of the named entity, newest first.
+func (s *Store) Revisions(ctx context.Context, name string) ([]backend.Revision, error) {
+	key, err := s.key(name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.client.versions(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions of %s: %w", name, err)
+	}
+
+	revs := make([]backend.Revision, 0, len(versions))
+	for _, v := range versions {
+		rev := backend.Revision{
+			Hash: v.VersionID,
+			Date: v.LastModified,
+		}
+		if err := s.revisionMetadata(ctx, key, &rev); err != nil {
+			return nil, fmt.Errorf("failed to read metadata of %s@%s: %w", name, v.VersionID, err)
+		}
+		revs = append(revs, rev)
+	}
+	slices.SortStableFunc(revs, func(a, b backend.Revision) int {
+		return b.Date.Compare(a.Date)
+	})
+
+	return revs, nil
+}
+
+// revisionMetadata fills the commit message and author from the metadata
+// recorded on write.
+func (s *Store) revisionMetadata(ctx context.Context, key string, rev *backend.Revision) error {
+	resp, err := s.client.do(ctx, http.MethodHead, key, versionQuery(rev.Hash), nil, nil)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	get := func(k string) string {
+		v := resp.Header.Get(metaPrefix + k)
+		if uv, err := url.QueryUnescape(v); err == nil {
+			return uv
+		}
+
+		return v
+	}
+
+	rev.Subject = get(metaSubject)
+	rev.Body = get(metaBody)
+	rev.AuthorName = get(metaAuthorName)
+	rev.AuthorEmail = get(metaAuthorEmail)
+	if ts, err := time.Parse(time.RFC3339, get(metaDate)); err == nil {
+		rev.Date = ts
+	}
+
+	return nil
+}
+
+// GetRevision returns the content of the given object version.
+func (s *Store) GetRevision(ctx context.Context, name, revision string) ([]byte, error) {
+	if revision == "HEAD" || revision == "latest" {
+		return s.Get(ctx, name)
+	}
+
+	key, err := s.key(name)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.do(ctx, http.MethodGet, key, versionQuery(revision), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s@%s: %w", name, revision, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	return io.ReadAll(resp.Body)
+}
+
+// Status returns a short summary of the bucket configuration.
+func (s *Store) Status(ctx context.Context) ([]byte, error) {
+	status, err := s.client.versioning(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status == "" {
+		status = "Disabled"
+	}
+
+	return fmt.Appendf(nil, "Bucket: %s\nPrefix: %s\nVersioning: %s\n", s.settings.Bucket, s.settings.prefix(), status), nil
+}
+
+// Compact does nothing. Use bucket lifecycle rules to expire old versions.
+func (s *Store) Compact(context.Context) error {
+	return nil
+}
+
+func versionQuery(id string) url.Values {
+	q := url.Values{}
+	q.Set("versionId", id)
+
+	return q
+}
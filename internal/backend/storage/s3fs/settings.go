@@ -0,0 +1,134 @@
+package s3fs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/pkg/fsutil"
+)
+
+const (
+	// SettingsFile is the name of the file in the local store directory
+	// that records which bucket backs this store. It doubles as the marker
+	// used to detect this backend.
+	SettingsFile = ".gopass-s3.json"
+
+	defaultRegion = "us-east-1"
+)
+
+// Settings describe the location of a store inside an S3-compatible
+// object store. Credentials are never persisted, they are read from the
+// environment.
+type Settings struct {
+	// Endpoint is the base URL of the S3 API, e.g. http://localhost:9000.
+	// If empty AWS S3 with virtual-hosted style addressing is used.
+	Endpoint string `json:"endpoint,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Bucket   string `json:"bucket"`
+	// Prefix is prepended to every object key. It allows storing several
+	// stores in the same bucket.
+	Prefix string `json:"prefix,omitempty"`
+}
+
+func (s Settings) region() string {
+	if s.Region == "" {
+		return defaultRegion
+	}
+
+	return s.Region
+}
+
+// prefix returns the normalized key prefix, either empty or ending in a
+// single slash.
+func (s Settings) prefix() string {
+	p := strings.Trim(s.Prefix, "/")
+	if p == "" {
+		return ""
+	}
+
+	return p + "/"
+}
+
+func (s Settings) validate() error {
+	if s.Bucket == "" {
+		return fmt.Errorf("no bucket configured. Please set s3.bucket")
+	}
+
+	return nil
+}
+
+// settingsFromConfig reads the settings from the gopass config.
+func settingsFromConfig(ctx context.Context) Settings {
+	return Settings{
+		Endpoint: config.String(ctx, "s3.endpoint"),
+		Region:   config.String(ctx, "s3.region"),
+		Bucket:   config.String(ctx, "s3.bucket"),
+		Prefix:   config.String(ctx, "s3.prefix"),
+	}
+}
+
+// settingsFromURL parses a location of the form s3://bucket/prefix. Any
+// setting not contained in the URL is taken from the config.
+func settingsFromURL(ctx context.Context, repo string) (Settings, error) {
+	s := settingsFromConfig(ctx)
+
+	u, err := url.Parse(repo)
+	if err != nil {
+		return s, fmt.Errorf("invalid location %q: %w", repo, err)
+	}
+	if u.Scheme != "s3" {
+		return s, fmt.Errorf("invalid location %q: expected s3://bucket/prefix", repo)
+	}
+
+	s.Bucket = u.Host
+	s.Prefix = strings.Trim(u.Path, "/")
+
+	return s, nil
+}
+
+func loadSettings(path string) (Settings, error) {
+	var s Settings
+
+	buf, err := os.ReadFile(filepath.Join(path, SettingsFile))
+	if err != nil {
+		return s, err
+	}
+
+	if err := json.Unmarshal(buf, &s); err != nil {
+		return s, fmt.Errorf("failed to parse %s: %w", SettingsFile, err)
+	}
+
+	return s, s.validate()
+}
+
+func saveSettings(path string, s Settings) error {
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		return err
+	}
+
+	buf, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(path, SettingsFile), buf, 0o600)
+}
+
+func hasSettings(path string) bool {
+	return fsutil.IsFile(filepath.Join(fsutil.ExpandHomedir(path), SettingsFile))
+}
+
+// credentialsFromEnv reads the standard AWS credential environment variables.
+func credentialsFromEnv() credentials {
+	return credentials{
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
@@ -0,0 +1,417 @@
+// Package s3fs implements a storage backend that keeps the encrypted secrets
+// in an S3-compatible object store. Concurrent modifications are detected
+// using conditional requests based on ETags and the revision history is
+// provided by object versioning.
+package s3fs
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/backend/storage/internal/names"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/internal/store"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/termio"
+)
+
+const (
+	name = "s3fs"
+
+	metaPrefix      = "X-Amz-Meta-"
+	metaSubject     = "Gopass-Subject"
+	metaBody        = "Gopass-Body"
+	metaAuthorName  = "Gopass-Author-Name"
+	metaAuthorEmail = "Gopass-Author-Email"
+	metaDate        = "Gopass-Date"
+)
+
+// Store is an S3 based store.
+type Store struct {
+	path     string
+	settings Settings
+	client   *client
+	version  semver.Version
+
+	// etags records the ETag of every object last seen by this instance.
+	// It is used to make writes conditional on the object not having
+	// changed in the meantime.
+	sync.Mutex
+	etags map[string]string
+}
+
+// New creates a new store for the given local path and bucket settings.
+func New(path string, s Settings) (*Store, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	c, err := newClient(s, credentialsFromEnv())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		path:     path,
+		settings: s,
+		client:   c,
+		version:  debug.ModuleVersion("github.com/gopasspw/gopass/internal/backend/storage/s3fs"),
+		etags:    make(map[string]string, 32),
+	}, nil
+}
+
+// key returns the object key for the given secret name.
+func (s *Store) key(name string) (string, error) {
+	name, err := names.Clean(name)
+	if err != nil {
+		return "", err
+	}
+
+	return s.settings.prefix() + name, nil
+}
+
+// dirKey returns the key prefix for the given directory name.
+func (s *Store) dirKey(name string) string {
+	name = names.Dir(name)
+	if name == "" {
+		return s.settings.prefix()
+	}
+
+	return s.settings.prefix() + name + "/"
+}
+
+func (s *Store) getETag(key string) (string, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	etag, found := s.etags[key]
+
+	return etag, found
+}
+
+func (s *Store) setETag(key, etag string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if etag == "" {
+		delete(s.etags, key)
+
+		return
+	}
+	s.etags[key] = etag
+}
+
+// Get retrieves the named content.
+func (s *Store) Get(ctx context.Context, name string) ([]byte, error) {
+	key, err := s.key(name)
+	if err != nil {
+		return nil, err
+	}
+	debug.V(3).Log("Reading %s from s3://%s/%s", name, s.settings.Bucket, key)
+
+	resp, err := s.client.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", name, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	s.setETag(key, resp.Header.Get("ETag"))
+
+	return buf, nil
+}
+
+// Set writes the given content. The write is conditional on the object not
+// having changed since this instance last saw it. If it did
+// backend.ErrConflict is returned.
+func (s *Store) Set(ctx context.Context, name string, value []byte) error {
+	key, err := s.key(name)
+	if err != nil {
+		return err
+	}
+
+	etag, found := s.getETag(key)
+	if !found {
+		etag, err = s.head(ctx, key)
+		if err != nil && !errors.Is(err, errNotFound) {
+			return fmt.Errorf("failed to stat %s: %w", name, err)
+		}
+	}
+
+	// if we ever try to write a secret that is identical (in ciphertext) to the secret in store,
+	// we might want to act differently. For single part uploads the ETag is the MD5 sum.
+	sum := md5.Sum(value) //nolint:gosec
+	if etag != "" && strings.Trim(etag, `"`) == hex.EncodeToString(sum[:]) {
+		return store.ErrMeaninglessWrite
+	}
+
+	hdr := s.commitMetadata(ctx)
+	hdr.Set("Content-Type", "application/octet-stream")
+	if etag != "" {
+		hdr.Set("If-Match", etag)
+	} else {
+		hdr.Set("If-None-Match", "*")
+	}
+	debug.V(3).Log("Writing %s to s3://%s/%s (if-match: %q)", name, s.settings.Bucket, key, etag)
+
+	resp, err := s.client.do(ctx, http.MethodPut, key, nil, hdr, value)
+	if err != nil {
+		if isConflict(err) {
+			s.setETag(key, "")
+
+			return fmt.Errorf("failed to write %s: %w", name, backend.ErrConflict)
+		}
+
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	_ = resp.Body.Close()
+	s.setETag(key, resp.Header.Get("ETag"))
+
+	return nil
+}
+
+// commitMetadata records the commit message and author as object metadata
+// so that they show up in the revision history.
+func (s *Store) commitMetadata(ctx context.Context) http.Header {
+	hdr := http.Header{}
+
+	set := func(k, v string) {
+		if v == "" {
+			return
+		}
+		hdr.Set(metaPrefix+k, url.QueryEscape(v))
+	}
+
+	set(metaSubject, ctxutil.GetCommitMessage(ctx))
+	set(metaBody, ctxutil.GetCommitMessageBody(ctx))
+
+	author := ctxutil.GetUsername(ctx)
+	if author == "" {
+		author = termio.DetectName(ctx, nil)
+	}
+	set(metaAuthorName, author)
+
+	email := ctxutil.GetEmail(ctx)
+	if email == "" {
+		email = termio.DetectEmail(ctx, nil)
+	}
+	set(metaAuthorEmail, email)
+
+	if ctxutil.HasCommitTimestamp(ctx) {
+		set(metaDate, ctxutil.GetCommitTimestamp(ctx).UTC().Format(time.RFC3339))
+	}
+
+	return hdr
+}
+
+func isConflict(err error) bool {
+	var ae *apiError
+	if !errors.As(err, &ae) {
+		return false
+	}
+
+	return ae.StatusCode == http.StatusPreconditionFailed || ae.StatusCode == http.StatusConflict
+}
+
+// head returns the current ETag of the given key.
+func (s *Store) head(ctx context.Context, key string) (string, error) {
+	resp, err := s.client.do(ctx, http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	_ = resp.Body.Close()
+
+	return resp.Header.Get("ETag"), nil
+}
+
+// Move moves the named entity to the new location. S3 has no rename, so the
+// object is copied server-side and the source is removed afterwards.
+func (s *Store) Move(ctx context.Context, from, to string, del bool) error {
+	fromKey, err := s.key(from)
+	if err != nil {
+		return err
+	}
+	toKey, err := s.key(to)
+	if err != nil {
+		return err
+	}
+	debug.V(3).Log("Copying %q (%q) to %q (%q)", from, fromKey, to, toKey)
+
+	hdr := s.commitMetadata(ctx)
+	hdr.Set("X-Amz-Copy-Source", canonicalURI(&url.URL{Path: "/" + s.settings.Bucket + "/" + fromKey}))
+	hdr.Set("X-Amz-Metadata-Directive", "REPLACE")
+
+	resp, err := s.client.do(ctx, http.MethodPut, toKey, nil, hdr, nil)
+	if err != nil {
+		return fmt.Errorf("failed to copy %q to %q: %w", from, to, err)
+	}
+	_ = resp.Body.Close()
+	// the ETag of a copy is returned in the response body. Re-read it
+	// on the next access instead.
+	s.setETag(toKey, "")
+
+	if !del {
+		return nil
+	}
+
+	return s.Delete(ctx, from)
+}
+
+// Delete removes the named entity.
+func (s *Store) Delete(ctx context.Context, name string) error {
+	key, err := s.key(name)
+	if err != nil {
+		return err
+	}
+	debug.V(3).Log("Deleting %s from s3://%s/%s", name, s.settings.Bucket, key)
+
+	if !s.Exists(ctx, name) {
+		return fmt.Errorf("failed to delete %s: %w", name, errNotFound)
+	}
+
+	resp, err := s.client.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", name, err)
+	}
+	_ = resp.Body.Close()
+	s.setETag(key, "")
+
+	return nil
+}
+
+// Exists checks if the named entity exists.
+func (s *Store) Exists(ctx context.Context, name string) bool {
+	key, err := s.key(name)
+	if err != nil {
+		return false
+	}
+
+	etag, err := s.head(ctx, key)
+	if err != nil {
+		debug.V(2).Log("Checking if %q exists at s3://%s/%s: %s", name, s.settings.Bucket, key, err)
+
+		return false
+	}
+	s.setETag(key, etag)
+
+	return true
+}
+
+// List returns a list of all entities with the given prefix.
+// e.g. foo, far/bar baz/.bang. Like the fs backend it skips hidden
+// directories unless they are explicitly requested.
+func (s *Store) List(ctx context.Context, prefix string) ([]string, error) {
+	prefix = strings.TrimPrefix(filepath.ToSlash(prefix), "/")
+	debug.V(2).Log("Listing s3://%s/%s%s", s.settings.Bucket, s.settings.prefix(), prefix)
+
+	keys, err := s.client.list(ctx, s.settings.prefix()+prefix, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+
+	files := make([]string, 0, len(keys))
+	for _, k := range keys {
+		name := strings.TrimPrefix(k, s.settings.prefix())
+		if name == "" || strings.HasSuffix(name, "/") || names.InHiddenDir(name, prefix) {
+			continue
+		}
+		files = append(files, name)
+	}
+	slices.Sort(files)
+
+	return files, nil
+}
+
+// IsDir returns true if the named entity is a directory, i.e. if there is
+// at least one object below it.
+func (s *Store) IsDir(ctx context.Context, name string) bool {
+	keys, err := s.client.list(ctx, s.dirKey(name), 1)
+	if err != nil {
+		debug.V(2).Log("%s: %s", name, err)
+
+		return false
+	}
+	isDir := len(keys) > 0
+	debug.V(2).Log("%s is a directory? %t", name, isDir)
+
+	return isDir
+}
+
+// Prune removes all objects below the named directory.
+func (s *Store) Prune(ctx context.Context, prefix string) error {
+	dir := s.dirKey(prefix)
+	debug.Log("Pruning %s from s3://%s/%s", prefix, s.settings.Bucket, dir)
+
+	keys, err := s.client.list(ctx, dir, 0)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+
+	for _, k := range keys {
+		resp, err := s.client.do(ctx, http.MethodDelete, k, nil, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to delete %s: %w", k, err)
+		}
+		_ = resp.Body.Close()
+		s.setETag(k, "")
+	}
+
+	return nil
+}
+
+// Link is not supported. Object stores have no notion of symlinks.
+func (s *Store) Link(ctx context.Context, from, to string) error {
+	return backend.ErrNotSupported
+}
+
+// Name returns the name of this backend.
+func (s *Store) Name() string {
+	return name
+}
+
+// Version returns the version of this backend.
+func (s *Store) Version(context.Context) semver.Version {
+	return s.version
+}
+
+// String implements fmt.Stringer.
+func (s *Store) String() string {
+	return fmt.Sprintf("%s(%s,bucket:%s,prefix:%s)", name, s.version.String(), s.settings.Bucket, s.settings.prefix())
+}
+
+// Path returns the local path of this storage. It only contains the
+// settings file.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Fsck checks that the bucket is reachable and has versioning enabled.
+func (s *Store) Fsck(ctx context.Context) error {
+	status, err := s.client.versioning(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check bucket %s: %w", s.settings.Bucket, err)
+	}
+
+	if status != "Enabled" {
+		out.Warningf(ctx, "Versioning is not enabled for bucket %s. History will not be available.", s.settings.Bucket)
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package s3fs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/store"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T, endpoint, prefix string) *Store {
+	t.Helper()
+
+	s, err := New(t.TempDir(), Settings{
+		Endpoint: endpoint,
+		Bucket:   "gopass",
+		Prefix:   prefix,
+	})
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestSetGetDelete(t *testing.T) {
+	_, srv := newFakeS3(t, "gopass")
+	ctx := config.NewContextInMemory()
+	s := newTestStore(t, srv.URL, "team/")
+
+	assert.False(t, s.Exists(ctx, "foo/bar"))
+	_, err := s.Get(ctx, "foo/bar")
+	require.Error(t, err)
+
+	require.NoError(t, s.Set(ctx, "foo/bar", []byte("secret")))
+	assert.True(t, s.Exists(ctx, "foo/bar"))
+
+	buf, err := s.Get(ctx, "foo/bar")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(buf))
+
+	require.ErrorIs(t, s.Set(ctx, "foo/bar", []byte("secret")), store.ErrMeaninglessWrite)
+	require.NoError(t, s.Set(ctx, "foo/bar", []byte("other secret")))
+
+	// names with characters that need escaping
+	require.NoError(t, s.Set(ctx, "web/example.com+1 (ü)", []byte("escaped")))
+	buf, err = s.Get(ctx, "web/example.com+1 (ü)")
+	require.NoError(t, err)
+	assert.Equal(t, "escaped", string(buf))
+
+	require.NoError(t, s.Delete(ctx, "foo/bar"))
+	assert.False(t, s.Exists(ctx, "foo/bar"))
+	require.Error(t, s.Delete(ctx, "foo/bar"))
+
+	// keys can not escape the prefix
+	k, err := s.key("../../etc/passwd")
+	require.NoError(t, err)
+	assert.Equal(t, "team/etc/passwd", k)
+}
+
+func TestConcurrentModification(t *testing.T) {
+	_, srv := newFakeS3(t, "gopass")
+	ctx := config.NewContextInMemory()
+
+	alice := newTestStore(t, srv.URL, "")
+	bob := newTestStore(t, srv.URL, "")
+
+	require.NoError(t, alice.Set(ctx, "shared", []byte("v1")))
+
+	_, err := alice.Get(ctx, "shared")
+	require.NoError(t, err)
+	_, err = bob.Get(ctx, "shared")
+	require.NoError(t, err)
+
+	require.NoError(t, alice.Set(ctx, "shared", []byte("v2 from alice")))
+	require.ErrorIs(t, bob.Set(ctx, "shared", []byte("v2 from bob")), backend.ErrConflict)
+
+	// after re-reading the change bob can write again
+	buf, err := bob.Get(ctx, "shared")
+	require.NoError(t, err)
+	assert.Equal(t, "v2 from alice", string(buf))
+	require.NoError(t, bob.Set(ctx, "shared", []byte("v3 from bob")))
+}
+
+func TestListIsDirPrune(t *testing.T) {
+	_, srv := newFakeS3(t, "gopass")
+	ctx := config.NewContextInMemory()
+	s := newTestStore(t, srv.URL, "prefix")
+
+	for _, n := range []string{"foo/bar", "foo/baz", "foo/zab/zab", "zzz", ".gpg-id", ".hidden/secret", "foo/.hidden/secret"} {
+		require.NoError(t, s.Set(ctx, n, []byte(n)))
+	}
+	// outside of the prefix
+	other := newTestStore(t, srv.URL, "other")
+	require.NoError(t, other.Set(ctx, "foo/bar", []byte("other")))
+
+	files, err := s.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{".gpg-id", "foo/bar", "foo/baz", "foo/zab/zab", "zzz"}, files)
+
+	files, err = s.List(ctx, "foo/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo/bar", "foo/baz", "foo/zab/zab"}, files)
+
+	files, err = s.List(ctx, ".hidden")
+	require.NoError(t, err)
+	assert.Equal(t, []string{".hidden/secret"}, files)
+
+	assert.True(t, s.IsDir(ctx, "foo"))
+	assert.True(t, s.IsDir(ctx, "foo/zab"))
+	assert.False(t, s.IsDir(ctx, "foo/bar"))
+	assert.False(t, s.IsDir(ctx, "bar"))
+
+	require.NoError(t, s.Prune(ctx, "foo"))
+	files, err = s.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{".gpg-id", "zzz"}, files)
+	assert.True(t, other.Exists(ctx, "foo/bar"))
+}
+
+func TestMove(t *testing.T) {
+	_, srv := newFakeS3(t, "gopass")
+	ctx := config.NewContextInMemory()
+	s := newTestStore(t, srv.URL, "")
+
+	require.NoError(t, s.Set(ctx, "src", []byte("content")))
+
+	require.NoError(t, s.Move(ctx, "src", "dst/copy", false))
+	assert.True(t, s.Exists(ctx, "src"))
+
+	require.NoError(t, s.Move(ctx, "src", "dst/moved", true))
+	assert.False(t, s.Exists(ctx, "src"))
+
+	for _, n := range []string{"dst/copy", "dst/moved"} {
+		buf, err := s.Get(ctx, n)
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(buf))
+	}
+}
+
+func TestRevisions(t *testing.T) {
+	_, srv := newFakeS3(t, "gopass")
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithUsername(ctx, "John Doe")
+	ctx = ctxutil.WithEmail(ctx, "john@example.org")
+	s := newTestStore(t, srv.URL, "")
+
+	for i, msg := range []string{"first", "second", "third: with ünicode"} {
+		ctx := ctxutil.WithCommitMessage(ctx, msg)
+		ctx = ctxutil.WithCommitTimestamp(ctx, time.Date(2024, 1, i+1, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, s.Set(ctx, "foo", []byte(msg)))
+	}
+
+	revs, err := s.Revisions(ctx, "foo")
+	require.NoError(t, err)
+	require.Len(t, revs, 3)
+	assert.Equal(t, "third: with ünicode", revs[0].Subject)
+	assert.Equal(t, "first", revs[2].Subject)
+	assert.Equal(t, "John Doe", revs[0].AuthorName)
+	assert.Equal(t, "john@example.org", revs[0].AuthorEmail)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), revs[2].Date)
+
+	buf, err := s.GetRevision(ctx, "foo", revs[2].Hash)
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(buf))
+
+	buf, err = s.GetRevision(ctx, "foo", "latest")
+	require.NoError(t, err)
+	assert.Equal(t, "third: with ünicode", string(buf))
+
+	_, err = s.GetRevision(ctx, "foo", "invalid")
+	require.Error(t, err)
+}
+
+func TestLoader(t *testing.T) {
+	f, srv := newFakeS3(t, "gopass")
+
+	cfg := config.NewInMemory()
+	require.NoError(t, cfg.SetEnv("s3.endpoint", srv.URL))
+	require.NoError(t, cfg.SetEnv("s3.bucket", "gopass"))
+	ctx := cfg.WithConfig(context.Background())
+	ctx = ctxutil.WithHidden(ctx, true)
+
+	td := t.TempDir()
+	l := loader{}
+	require.Error(t, l.Handles(ctx, td))
+
+	st, err := l.Init(ctx, td)
+	require.NoError(t, err)
+	require.NoError(t, l.Handles(ctx, td))
+	require.NoError(t, st.Set(ctx, "foo", []byte("bar")))
+
+	st, err = l.New(ctx, td)
+	require.NoError(t, err)
+	assert.Equal(t, name, st.Name())
+	assert.True(t, st.Exists(ctx, "foo"))
+
+	// clone into a different prefix
+	td2 := t.TempDir()
+	st, err = l.Clone(ctx, "s3://gopass/team", td2)
+	require.NoError(t, err)
+	assert.False(t, st.Exists(ctx, "foo"))
+	require.NoError(t, st.Set(ctx, "foo", []byte("bar")))
+	assert.Contains(t, f.objects, "team/foo")
+
+	_, err = l.Clone(ctx, "https://example.com/foo", td2)
+	require.Error(t, err)
+
+	_, err = l.New(ctx, t.TempDir())
+	require.Error(t, err)
+}
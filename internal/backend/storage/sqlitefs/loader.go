@@ -0,0 +1,61 @@
+package sqlitefs
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/fsutil"
+)
+
+func init() {
+	backend.StorageRegistry.Register(backend.SQLiteFS, name, &loader{})
+}
+
+type loader struct{}
+
+// New opens an existing store.
+func (l loader) New(ctx context.Context, path string) (backend.Storage, error) {
+	path = fsutil.ExpandHomedir(path)
+	if err := l.Handles(ctx, path); err != nil {
+		return nil, err
+	}
+
+	be, err := New(path)
+	if err != nil {
+		return nil, err
+	}
+	debug.Log("Using Storage Backend: %s", be.String())
+
+	return be, nil
+}
+
+// Init creates a new, empty database.
+func (l loader) Init(ctx context.Context, path string) (backend.Storage, error) {
+	return New(fsutil.ExpandHomedir(path))
+}
+
+// Clone is not supported. The database is a local file.
+func (l loader) Clone(ctx context.Context, repo, path string) (backend.Storage, error) {
+	return nil, backend.ErrNotSupported
+}
+
+// Handles returns nil if the path contains a sqlitefs database.
+func (l loader) Handles(ctx context.Context, path string) error {
+	if !fsutil.IsFile(filepath.Join(fsutil.ExpandHomedir(path), DBFile)) {
+		return fmt.Errorf("no %s found in %s", DBFile, path)
+	}
+
+	return nil
+}
+
+// Priority returns the priority of this backend.
+func (l loader) Priority() int {
+	return 10
+}
+
+func (l loader) String() string {
+	return name
+}
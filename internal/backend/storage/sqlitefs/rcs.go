@@ -0,0 +1,231 @@
+// This file contains the rcs interface implementation for the sqlitefs
+// backend. Every write is recorded in the revisions table right away. Commit
+// groups all pending revisions under one commit carrying the message and the
+// author, similar to git. There is no remote to push to or pull from.
+package sqlitefs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/backend/storage/internal/names"
+	"github.com/gopasspw/gopass/internal/store"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/termio"
+)
+
+// now returns the timestamp to record for changes.
+func now(ctx context.Context) int64 {
+	return ctxutil.GetCommitTimestamp(ctx).UTC().Unix()
+}
+
+// addRevision records a new revision of name. A nil value records a
+// deletion. Must be called within a transaction.
+func addRevision(ctx context.Context, tx *sql.Tx, name string, value []byte) error {
+	if _, err := tx.ExecContext(ctx, "INSERT INTO revisions (name, content, date) VALUES (?, ?, ?)", name, value, now(ctx)); err != nil {
+		return fmt.Errorf("failed to record revision of %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Add does nothing. All changes are tracked automatically.
+func (s *Store) Add(ctx context.Context, args ...string) error {
+	return nil
+}
+
+// TryAdd does nothing.
+func (s *Store) TryAdd(ctx context.Context, args ...string) error {
+	return nil
+}
+
+// Commit records all pending revisions under a new commit with the given
+// message.
+func (s *Store) Commit(ctx context.Context, msg string) error {
+	subject, body, _ := strings.Cut(strings.TrimSpace(msg), "\n")
+
+	author := ctxutil.GetUsername(ctx)
+	if author == "" {
+		author = termio.DetectName(ctx, nil)
+	}
+	email := ctxutil.GetEmail(ctx)
+	if email == "" {
+		email = termio.DetectEmail(ctx, nil)
+	}
+
+	return s.tx(ctx, func(tx *sql.Tx) error {
+		var pending int
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM revisions WHERE commit_id IS NULL").Scan(&pending); err != nil {
+			return err
+		}
+		if pending < 1 {
+			return store.ErrGitNothingToCommit
+		}
+
+		res, err := tx.ExecContext(ctx, "INSERT INTO commits (subject, body, author_name, author_email, date) VALUES (?, ?, ?, ?, ?)",
+			subject, strings.TrimSpace(body), author, email, now(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to create commit: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE revisions SET commit_id = ? WHERE commit_id IS NULL", id); err != nil {
+			return fmt.Errorf("failed to commit %d revisions: %w", pending, err)
+		}
+		debug.Log("Committed %d revisions as %d: %s", pending, id, subject)
+
+		return nil
+	})
+}
+
+// TryCommit calls commit and returns nil if there was nothing to commit.
+func (s *Store) TryCommit(ctx context.Context, msg string) error {
+	if err := s.Commit(ctx, msg); err != nil && !errors.Is(err, store.ErrGitNothingToCommit) {
+		return err
+	}
+
+	return nil
+}
+
+// Push does nothing. There is no remote.
+func (s *Store) Push(ctx context.Context, origin, branch string) error {
+	return nil
+}
+
+// TryPush does nothing.
+func (s *Store) TryPush(ctx context.Context, origin, branch string) error {
+	return nil
+}
+
+// Pull does nothing. There is no remote.
+func (s *Store) Pull(ctx context.Context, origin, branch string) error {
+	return nil
+}
+
+// InitConfig does nothing.
+func (s *Store) InitConfig(context.Context, string, string) error {
+	return nil
+}
+
+// AddRemote is not supported.
+func (s *Store) AddRemote(ctx context.Context, remote, url string) error {
+	return backend.ErrNotSupported
+}
+
+// RemoveRemote is not supported.
+func (s *Store) RemoveRemote(ctx context.Context, remote string) error {
+	return backend.ErrNotSupported
+}
+
+// Revisions returns the revisions of the named entity, newest first.
+// Deletions are not included.
+func (s *Store) Revisions(ctx context.Context, name string) ([]backend.Revision, error) {
+	name, err := names.Clean(name)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+SELECT r.id, COALESCE(c.subject, ''), COALESCE(c.body, ''),
+       COALESCE(c.author_name, ''), COALESCE(c.author_email, ''), COALESCE(c.date, r.date)
+FROM revisions r LEFT JOIN commits c ON r.commit_id = c.id
+WHERE r.name = ? AND r.content IS NOT NULL
+ORDER BY r.id DESC`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions of %s: %w", name, err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	revs := []backend.Revision{}
+	for rows.Next() {
+		var id, date int64
+		var rev backend.Revision
+		if err := rows.Scan(&id, &rev.Subject, &rev.Body, &rev.AuthorName, &rev.AuthorEmail, &date); err != nil {
+			return nil, err
+		}
+		rev.Hash = strconv.FormatInt(id, 10)
+		rev.Date = time.Unix(date, 0)
+		revs = append(revs, rev)
+	}
+
+	return revs, rows.Err()
+}
+
+// GetRevision returns the content of the named entity at the given revision.
+func (s *Store) GetRevision(ctx context.Context, name, revision string) ([]byte, error) {
+	if revision == "HEAD" || revision == "latest" {
+		return s.Get(ctx, name)
+	}
+
+	name, err := names.Clean(name)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.ParseInt(revision, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision %q: %w", revision, err)
+	}
+
+	var buf []byte
+	if err := s.db.QueryRowContext(ctx, "SELECT content FROM revisions WHERE id = ? AND name = ? AND content IS NOT NULL", id, name).Scan(&buf); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get %s@%s: %w", name, revision, errNotFound)
+		}
+
+		return nil, fmt.Errorf("failed to get %s@%s: %w", name, revision, err)
+	}
+
+	return buf, nil
+}
+
+// Status returns a short summary of the database content.
+func (s *Store) Status(ctx context.Context) ([]byte, error) {
+	var entries, revisions, pending int
+	if err := s.db.QueryRowContext(ctx, `
+SELECT (SELECT COUNT(*) FROM entries),
+       (SELECT COUNT(*) FROM revisions),
+       (SELECT COUNT(*) FROM revisions WHERE commit_id IS NULL)`).Scan(&entries, &revisions, &pending); err != nil {
+		return nil, err
+	}
+
+	return fmt.Appendf(nil, "Entries: %d\nRevisions: %d\nUncommitted changes: %d\n", entries, revisions, pending), nil
+}
+
+// Compact removes the history of deleted entries and reclaims unused space.
+// The history of existing entries is kept.
+func (s *Store) Compact(ctx context.Context) error {
+	if err := s.tx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM revisions WHERE name NOT IN (SELECT name FROM entries)")
+		if err != nil {
+			return fmt.Errorf("failed to remove revisions of deleted entries: %w", err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			debug.Log("Removed %d revisions of deleted entries", n)
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM commits WHERE id NOT IN (SELECT commit_id FROM revisions WHERE commit_id IS NOT NULL)"); err != nil {
+			return fmt.Errorf("failed to remove empty commits: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, "VACUUM"); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+
+	return nil
+}
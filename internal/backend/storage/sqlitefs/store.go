@@ -0,0 +1,389 @@
+// Package sqlitefs implements a storage backend that keeps the encrypted
+// secrets and their revision history in a single SQLite database. It is
+// intended for very large stores where walking the directory tree of the fs
+// backend on every List becomes too slow.
+//
+// It uses the pure Go SQLite driver from modernc.org/sqlite, so it works in
+// binaries built with CGO_ENABLED=0.
+package sqlitefs
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/backend/storage/internal/names"
+	"github.com/gopasspw/gopass/internal/store"
+	"github.com/gopasspw/gopass/pkg/debug"
+	_ "modernc.org/sqlite" // register the pure Go sqlite driver
+)
+
+const (
+	name = "sqlitefs"
+
+	// DBFile is the name of the database file in the store directory.
+	DBFile = "gopass.sqlite"
+)
+
+// errNotFound is returned if the requested entry does not exist.
+var errNotFound = errors.New("entry not found")
+
+// schema creates the tables. entries holds the current content of every
+// entry. revisions records every write and delete, revisions that have not
+// been committed yet have no commit_id.
+const schema = `
+CREATE TABLE IF NOT EXISTS entries (
+	name    TEXT PRIMARY KEY,
+	content BLOB NOT NULL
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS commits (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	subject      TEXT NOT NULL,
+	body         TEXT NOT NULL,
+	author_name  TEXT NOT NULL,
+	author_email TEXT NOT NULL,
+	date         INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS revisions (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	name      TEXT NOT NULL,
+	content   BLOB,
+	date      INTEGER NOT NULL,
+	commit_id INTEGER REFERENCES commits(id)
+);
+
+CREATE INDEX IF NOT EXISTS revisions_name ON revisions (name, id);
+CREATE INDEX IF NOT EXISTS revisions_pending ON revisions (commit_id) WHERE commit_id IS NULL;
+`
+
+// Store is a SQLite based store.
+type Store struct {
+	path    string
+	db      *sql.DB
+	version semver.Version
+}
+
+// New opens (and if necessary creates) the database in the given directory.
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	fn := filepath.Join(dir, DBFile)
+	db, err := sql.Open("sqlite", "file:"+fn+"?_txlock=immediate&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", fn, err)
+	}
+	// SQLite only supports one writer at a time. Serializing all access
+	// avoids SQLITE_BUSY errors on concurrent writes.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("failed to initialize %s: %w", fn, err)
+	}
+	if err := os.Chmod(fn, 0o600); err != nil {
+		debug.Log("failed to restrict permissions of %s: %s", fn, err)
+	}
+
+	return &Store{
+		path:    dir,
+		db:      db,
+		version: debug.ModuleVersion("github.com/gopasspw/gopass/internal/backend/storage/sqlitefs"),
+	}, nil
+}
+
+// prefixRange returns the bounds for a range query matching all names that
+// start with prefix. An empty upper bound means unbounded.
+func prefixRange(prefix string) (string, string) {
+	if prefix == "" {
+		return "", ""
+	}
+
+	// increment the last byte. Names are valid UTF-8 so there is never a
+	// 0xff byte that would overflow.
+	upper := []byte(prefix)
+	upper[len(upper)-1]++
+
+	return prefix, string(upper)
+}
+
+// Get retrieves the named content.
+func (s *Store) Get(ctx context.Context, name string) ([]byte, error) {
+	name, err := names.Clean(name)
+	if err != nil {
+		return nil, err
+	}
+	debug.V(3).Log("Reading %s from %s", name, s.path)
+
+	var buf []byte
+	if err := s.db.QueryRowContext(ctx, "SELECT content FROM entries WHERE name = ?", name).Scan(&buf); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get %s: %w", name, errNotFound)
+		}
+
+		return nil, fmt.Errorf("failed to get %s: %w", name, err)
+	}
+
+	return buf, nil
+}
+
+// Set writes the given content and records a new revision.
+func (s *Store) Set(ctx context.Context, name string, value []byte) error {
+	name, err := names.Clean(name)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	debug.V(3).Log("Writing %s to %s", name, s.path)
+
+	return s.tx(ctx, func(tx *sql.Tx) error {
+		var cur []byte
+		err := tx.QueryRowContext(ctx, "SELECT content FROM entries WHERE name = ?", name).Scan(&cur)
+		switch {
+		case err == nil:
+			// if we ever try to write a secret that is identical (in ciphertext) to the secret in store,
+			// we might want to act differently.
+			if bytes.Equal(cur, value) {
+				return store.ErrMeaninglessWrite
+			}
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+
+		return put(ctx, tx, name, value)
+	})
+}
+
+// put writes an entry and records the revision. Must be called within a
+// transaction.
+func put(ctx context.Context, tx *sql.Tx, name string, value []byte) error {
+	if _, err := tx.ExecContext(ctx, "INSERT INTO entries (name, content) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET content = excluded.content", name, value); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return addRevision(ctx, tx, name, value)
+}
+
+// remove deletes an entry and records the deletion. Must be called within
+// a transaction.
+func remove(ctx context.Context, tx *sql.Tx, name string) error {
+	res, err := tx.ExecContext(ctx, "DELETE FROM entries WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", name, err)
+	}
+	if n, _ := res.RowsAffected(); n < 1 {
+		return fmt.Errorf("failed to delete %s: %w", name, errNotFound)
+	}
+
+	return addRevision(ctx, tx, name, nil)
+}
+
+// Move moves the named entity to the new location. If del is false the
+// entry is copied instead.
+func (s *Store) Move(ctx context.Context, from, to string, del bool) error {
+	from, err := names.Clean(from)
+	if err != nil {
+		return err
+	}
+	to, err = names.Clean(to)
+	if err != nil {
+		return err
+	}
+	debug.V(3).Log("Moving %q to %q (delete: %t)", from, to, del)
+
+	return s.tx(ctx, func(tx *sql.Tx) error {
+		var buf []byte
+		if err := tx.QueryRowContext(ctx, "SELECT content FROM entries WHERE name = ?", from).Scan(&buf); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("failed to move %s: %w", from, errNotFound)
+			}
+
+			return err
+		}
+
+		if err := put(ctx, tx, to, buf); err != nil {
+			return err
+		}
+
+		if !del {
+			return nil
+		}
+
+		return remove(ctx, tx, from)
+	})
+}
+
+// Delete removes the named entity.
+func (s *Store) Delete(ctx context.Context, name string) error {
+	name, err := names.Clean(name)
+	if err != nil {
+		return err
+	}
+	debug.V(3).Log("Deleting %s from %s", name, s.path)
+
+	return s.tx(ctx, func(tx *sql.Tx) error {
+		return remove(ctx, tx, name)
+	})
+}
+
+// Exists checks if the named entity exists.
+func (s *Store) Exists(ctx context.Context, name string) bool {
+	name, err := names.Clean(name)
+	if err != nil {
+		return false
+	}
+
+	var one int
+	if err := s.db.QueryRowContext(ctx, "SELECT 1 FROM entries WHERE name = ?", name).Scan(&one); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			debug.Log("failed to check if %s exists: %s", name, err)
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// List returns a list of all entities with the given prefix.
+// e.g. foo, far/bar baz/.bang. Like the fs backend it skips hidden
+// directories unless they are explicitly requested.
+func (s *Store) List(ctx context.Context, prefix string) ([]string, error) {
+	prefix = strings.TrimPrefix(filepath.ToSlash(prefix), "/")
+	debug.V(2).Log("Listing %s/%s", s.path, prefix)
+
+	// a range query instead of LIKE so the primary key index can be used.
+	lower, upper := prefixRange(prefix)
+	query := "SELECT name FROM entries WHERE name >= ? ORDER BY name"
+	args := []any{lower}
+	if upper != "" {
+		query = "SELECT name FROM entries WHERE name >= ? AND name < ? ORDER BY name"
+		args = append(args, upper)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	files := []string{}
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		if names.InHiddenDir(n, prefix) {
+			continue
+		}
+		files = append(files, n)
+	}
+
+	return files, rows.Err()
+}
+
+// IsDir returns true if the named entity is a directory, i.e. if there is
+// at least one entry below it.
+func (s *Store) IsDir(ctx context.Context, name string) bool {
+	dir := names.Dir(name)
+	if dir == "" {
+		return true
+	}
+	lower, upper := prefixRange(dir + "/")
+
+	var one int
+	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM entries WHERE name >= ? AND name < ? LIMIT 1", lower, upper).Scan(&one)
+	isDir := err == nil
+	debug.V(2).Log("%s is a directory? %t", name, isDir)
+
+	return isDir
+}
+
+// Prune removes all entries below the named directory.
+func (s *Store) Prune(ctx context.Context, prefix string) error {
+	dir := names.Dir(prefix)
+	if dir == "" {
+		return fmt.Errorf("refusing to prune the store root")
+	}
+	debug.Log("Pruning %s from %s", dir, s.path)
+
+	lower, upper := prefixRange(dir + "/")
+
+	return s.tx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO revisions (name, content, date) SELECT name, NULL, ? FROM entries WHERE name >= ? AND name < ?", now(ctx), lower, upper); err != nil {
+			return fmt.Errorf("failed to record deletion of %s: %w", dir, err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM entries WHERE name >= ? AND name < ?", lower, upper); err != nil {
+			return fmt.Errorf("failed to prune %s: %w", dir, err)
+		}
+
+		return nil
+	})
+}
+
+// Link is not supported.
+func (s *Store) Link(ctx context.Context, from, to string) error {
+	return backend.ErrNotSupported
+}
+
+// Name returns the name of this backend.
+func (s *Store) Name() string {
+	return name
+}
+
+// Version returns the version of this backend.
+func (s *Store) Version(context.Context) semver.Version {
+	return s.version
+}
+
+// String implements fmt.Stringer.
+func (s *Store) String() string {
+	return fmt.Sprintf("%s(%s,path:%s)", name, s.version.String(), s.path)
+}
+
+// Path returns the path to the directory containing the database.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Fsck runs the SQLite integrity check.
+func (s *Store) Fsck(ctx context.Context) error {
+	var res string
+	if err := s.db.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&res); err != nil {
+		return fmt.Errorf("failed to check %s: %w", s.path, err)
+	}
+	if res != "ok" {
+		return fmt.Errorf("database %s is corrupt: %s", filepath.Join(s.path, DBFile), res)
+	}
+
+	return nil
+}
+
+// tx runs fn in a transaction.
+func (s *Store) tx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,251 @@
+package sqlitefs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/store"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	s, err := New(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = s.db.Close()
+	})
+
+	return s
+}
+
+func TestSetGetDelete(t *testing.T) {
+	t.Parallel()
+
+	ctx := config.NewContextInMemory()
+	s := newTestStore(t)
+
+	assert.False(t, s.Exists(ctx, "foo/bar"))
+	_, err := s.Get(ctx, "foo/bar")
+	require.Error(t, err)
+
+	require.NoError(t, s.Set(ctx, "foo/bar", []byte("secret")))
+	assert.True(t, s.Exists(ctx, "foo/bar"))
+
+	buf, err := s.Get(ctx, "foo/bar")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(buf))
+
+	require.ErrorIs(t, s.Set(ctx, "foo/bar", []byte("secret")), store.ErrMeaninglessWrite)
+	require.NoError(t, s.Set(ctx, "foo/bar", []byte("other secret")))
+
+	require.NoError(t, s.Set(ctx, "empty", nil))
+	buf, err = s.Get(ctx, "empty")
+	require.NoError(t, err)
+	assert.Empty(t, buf)
+
+	require.NoError(t, s.Delete(ctx, "foo/bar"))
+	assert.False(t, s.Exists(ctx, "foo/bar"))
+	require.Error(t, s.Delete(ctx, "foo/bar"))
+
+	require.NoError(t, s.Fsck(ctx))
+
+	fi, err := os.Stat(filepath.Join(s.Path(), DBFile))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+}
+
+func TestListIsDirPrune(t *testing.T) {
+	t.Parallel()
+
+	ctx := config.NewContextInMemory()
+	s := newTestStore(t)
+
+	for _, n := range []string{"foo/bar", "foo/baz", "foo/zab/zab", "foo0", "zzz", ".gpg-id", ".hidden/secret", "foo/.hidden/secret"} {
+		require.NoError(t, s.Set(ctx, n, []byte(n)))
+	}
+
+	files, err := s.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{".gpg-id", "foo/bar", "foo/baz", "foo/zab/zab", "foo0", "zzz"}, files)
+
+	files, err = s.List(ctx, "foo/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo/bar", "foo/baz", "foo/zab/zab"}, files)
+
+	files, err = s.List(ctx, "foo/ba")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo/bar", "foo/baz"}, files)
+
+	files, err = s.List(ctx, ".hidden")
+	require.NoError(t, err)
+	assert.Equal(t, []string{".hidden/secret"}, files)
+
+	assert.True(t, s.IsDir(ctx, "foo"))
+	assert.True(t, s.IsDir(ctx, "foo/zab"))
+	assert.False(t, s.IsDir(ctx, "foo/bar"))
+	assert.False(t, s.IsDir(ctx, "fo"))
+	assert.False(t, s.IsDir(ctx, "bar"))
+
+	require.NoError(t, s.Prune(ctx, "foo"))
+	files, err = s.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{".gpg-id", "foo0", "zzz"}, files)
+	require.Error(t, s.Prune(ctx, ""))
+}
+
+func TestMove(t *testing.T) {
+	t.Parallel()
+
+	ctx := config.NewContextInMemory()
+	s := newTestStore(t)
+
+	require.NoError(t, s.Set(ctx, "src", []byte("content")))
+
+	require.NoError(t, s.Move(ctx, "src", "dst/copy", false))
+	assert.True(t, s.Exists(ctx, "src"))
+
+	require.NoError(t, s.Move(ctx, "src", "dst/moved", true))
+	assert.False(t, s.Exists(ctx, "src"))
+	require.Error(t, s.Move(ctx, "src", "dst/moved", true))
+
+	for _, n := range []string{"dst/copy", "dst/moved"} {
+		buf, err := s.Get(ctx, n)
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(buf))
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	t.Parallel()
+
+	ctx := config.NewContextInMemory()
+	s := newTestStore(t)
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			assert.NoError(t, s.Set(ctx, fmt.Sprintf("entry-%02d", i), []byte("content")))
+		})
+	}
+	wg.Wait()
+
+	files, err := s.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, files, 20)
+}
+
+func TestRevisions(t *testing.T) {
+	t.Parallel()
+
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithUsername(ctx, "John Doe")
+	ctx = ctxutil.WithEmail(ctx, "john@example.org")
+	s := newTestStore(t)
+
+	require.ErrorIs(t, s.Commit(ctx, "nothing"), store.ErrGitNothingToCommit)
+	require.NoError(t, s.TryCommit(ctx, "nothing"))
+
+	for i, msg := range []string{"first", "second", "third"} {
+		ctx := ctxutil.WithCommitTimestamp(ctx, time.Date(2024, 1, i+1, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, s.Set(ctx, "foo", []byte(msg)))
+		require.NoError(t, s.Commit(ctx, msg+"\n\nbody of "+msg))
+	}
+	// uncommitted changes show up as well
+	require.NoError(t, s.Set(ctx, "foo", []byte("pending")))
+
+	revs, err := s.Revisions(ctx, "foo")
+	require.NoError(t, err)
+	require.Len(t, revs, 4)
+	assert.Empty(t, revs[0].Subject)
+	assert.Equal(t, "third", revs[1].Subject)
+	assert.Equal(t, "body of third", revs[1].Body)
+	assert.Equal(t, "first", revs[3].Subject)
+	assert.Equal(t, "John Doe", revs[1].AuthorName)
+	assert.Equal(t, "john@example.org", revs[1].AuthorEmail)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), revs[3].Date.UTC())
+
+	buf, err := s.GetRevision(ctx, "foo", revs[3].Hash)
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(buf))
+
+	buf, err = s.GetRevision(ctx, "foo", "latest")
+	require.NoError(t, err)
+	assert.Equal(t, "pending", string(buf))
+
+	_, err = s.GetRevision(ctx, "foo", "invalid")
+	require.Error(t, err)
+	_, err = s.GetRevision(ctx, "bar", revs[3].Hash)
+	require.Error(t, err)
+
+	st, err := s.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Entries: 1\nRevisions: 4\nUncommitted changes: 1\n", string(st))
+
+	revs, err = s.Revisions(ctx, "bar")
+	require.NoError(t, err)
+	assert.Empty(t, revs)
+}
+
+func TestCompact(t *testing.T) {
+	t.Parallel()
+
+	ctx := config.NewContextInMemory()
+	s := newTestStore(t)
+
+	require.NoError(t, s.Set(ctx, "keep", []byte("v1")))
+	require.NoError(t, s.Set(ctx, "keep", []byte("v2")))
+	require.NoError(t, s.Set(ctx, "gone", []byte("v1")))
+	require.NoError(t, s.Commit(ctx, "add"))
+	require.NoError(t, s.Delete(ctx, "gone"))
+	require.NoError(t, s.Commit(ctx, "remove"))
+
+	require.NoError(t, s.Compact(ctx))
+
+	revs, err := s.Revisions(ctx, "keep")
+	require.NoError(t, err)
+	assert.Len(t, revs, 2)
+
+	revs, err = s.Revisions(ctx, "gone")
+	require.NoError(t, err)
+	assert.Empty(t, revs)
+
+	st, err := s.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Entries: 1\nRevisions: 2\nUncommitted changes: 0\n", string(st))
+}
+
+func TestLoader(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	td := t.TempDir()
+	l := loader{}
+
+	require.Error(t, l.Handles(ctx, td))
+	_, err := l.New(ctx, td)
+	require.Error(t, err)
+
+	st, err := l.Init(ctx, td)
+	require.NoError(t, err)
+	require.NoError(t, l.Handles(ctx, td))
+	require.NoError(t, st.Set(ctx, "foo", []byte("bar")))
+
+	st, err = l.New(ctx, td)
+	require.NoError(t, err)
+	assert.Equal(t, name, st.Name())
+	assert.True(t, st.Exists(ctx, "foo"))
+
+	_, err = l.Clone(ctx, "https://example.com/foo", t.TempDir())
+	require.ErrorIs(t, err, backend.ErrNotSupported)
+}
@@ -0,0 +1,157 @@
+package webdavfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gopasspw/gopass/internal/backend/storage/fs"
+	"github.com/gopasspw/gopass/internal/store"
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/fsutil"
+)
+
+const (
+	cacheDir   = ".webdav-cache"
+	cacheIndex = ".webdav-cache.json"
+)
+
+// cache is a local mirror of the (encrypted) entries last read from or
+// written to the server. It is used to serve reads while offline and to
+// avoid downloading unchanged entries again. It also remembers the ETag of
+// each entry so that writes can be made conditional on the entry not having
+// changed since it was last seen.
+type cache struct {
+	sync.Mutex
+
+	fs    *fs.Store
+	index string
+	etags map[string]string
+}
+
+func newCache(path string) (*cache, error) {
+	dir := filepath.Join(path, cacheDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir %s: %w", dir, err)
+	}
+
+	c := &cache{
+		fs:    fs.New(dir),
+		index: filepath.Join(path, cacheIndex),
+		etags: make(map[string]string, 32),
+	}
+
+	buf, err := os.ReadFile(c.index)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		return c, nil
+	}
+	if err := json.Unmarshal(buf, &c.etags); err != nil {
+		debug.Log("ignoring invalid cache index %s: %s", c.index, err)
+		c.etags = make(map[string]string, 32)
+	}
+
+	return c, nil
+}
+
+// get returns the cached content and ETag of the named entry.
+func (c *cache) get(ctx context.Context, name string) ([]byte, string, bool) {
+	c.Lock()
+	etag, found := c.etags[name]
+	c.Unlock()
+
+	if !found {
+		return nil, "", false
+	}
+
+	buf, err := c.fs.Get(ctx, name)
+	if err != nil {
+		return nil, "", false
+	}
+
+	return buf, etag, true
+}
+
+// etag returns the last seen ETag of the named entry.
+func (c *cache) etag(name string) (string, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	etag, found := c.etags[name]
+
+	return etag, found
+}
+
+// set records the content and ETag of the named entry.
+func (c *cache) set(ctx context.Context, name, etag string, content []byte) {
+	if err := c.fs.Set(ctx, name, content); err != nil && !errors.Is(err, store.ErrMeaninglessWrite) {
+		debug.Log("failed to cache %s: %s", name, err)
+
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.etags[name] = etag
+	c.save()
+}
+
+// remove drops the named entry from the cache.
+func (c *cache) remove(ctx context.Context, name string) {
+	if c.fs.Exists(ctx, name) {
+		if err := c.fs.Delete(ctx, name); err != nil {
+			debug.Log("failed to remove %s from cache: %s", name, err)
+		}
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.etags, name)
+	c.save()
+}
+
+// list returns all cached entries with the given prefix.
+func (c *cache) list(ctx context.Context, prefix string) ([]string, error) {
+	return c.fs.List(ctx, prefix)
+}
+
+// isDir returns true if the named entry is a cached directory.
+func (c *cache) isDir(ctx context.Context, name string) bool {
+	return c.fs.IsDir(ctx, name)
+}
+
+// save writes the ETag index. Must be called with the lock held.
+func (c *cache) save() {
+	buf, err := json.Marshal(c.etags)
+	if err != nil {
+		debug.Log("failed to marshal cache index: %s", err)
+
+		return
+	}
+
+	if err := os.WriteFile(c.index, buf, 0o600); err != nil {
+		debug.Log("failed to write cache index %s: %s", c.index, err)
+	}
+}
+
+// size returns the number of cached entries.
+func (c *cache) size() int {
+	c.Lock()
+	defer c.Unlock()
+
+	return len(c.etags)
+}
+
+// exists returns true if the cache directory exists.
+func (c *cache) exists() bool {
+	return fsutil.IsDir(c.fs.Path())
+}
@@ -0,0 +1,228 @@
+package webdavfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+var (
+	// errNotFound is returned if the requested resource does not exist.
+	errNotFound = errors.New("resource not found")
+	// errPrecondition is returned if a conditional request failed.
+	errPrecondition = errors.New("precondition failed")
+)
+
+// statusError is returned for unexpected HTTP status codes.
+type statusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("webdav %s %s failed: %s", e.Method, e.Path, http.StatusText(e.StatusCode))
+}
+
+// client is a minimal WebDAV client. It only implements the subset of
+// RFC 4918 required by the storage backend.
+type client struct {
+	hc       *http.Client
+	base     *url.URL
+	username string
+	password string
+}
+
+func newClient(s Settings, password string) (*client, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %q: %w", s.URL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid url %q: unsupported scheme", s.URL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/"
+
+	return &client{
+		hc:       &http.Client{Timeout: 60 * time.Second},
+		base:     u,
+		username: s.Username,
+		password: password,
+	}, nil
+}
+
+// url returns the absolute URL of the resource at the given relative path.
+func (c *client) url(p string) *url.URL {
+	u := *c.base
+	u.Path = path.Join(c.base.Path, p)
+	if strings.HasSuffix(p, "/") || p == "" {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/"
+	}
+	u.RawPath = ""
+
+	return &u
+}
+
+// do performs a request against the resource at the given relative path.
+// Non-2xx responses are turned into errors except for the ones listed in
+// allowed.
+func (c *client) do(ctx context.Context, method, p string, header http.Header, body []byte, allowed ...int) (*http.Response, error) {
+	u := c.url(p)
+
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), rd)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	debug.V(3).Log("webdav %s %s", method, u.Redacted())
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	for _, code := range allowed {
+		if resp.StatusCode == code {
+			return resp, nil
+		}
+	}
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	_ = resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%s: %w", u.Path, errNotFound)
+	case http.StatusPreconditionFailed:
+		return nil, fmt.Errorf("%s: %w", u.Path, errPrecondition)
+	default:
+		return nil, &statusError{Method: method, Path: u.Path, StatusCode: resp.StatusCode}
+	}
+}
+
+// resource is a single entry of a PROPFIND response.
+type resource struct {
+	Path  string
+	IsDir bool
+	ETag  string
+}
+
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Status string `xml:"status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+				ETag string `xml:"getetag"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getetag/></d:prop></d:propfind>`
+
+// propfind returns the resource at p and, with depth 1, its direct children.
+// The paths of the returned resources are relative to the base URL.
+func (c *client) propfind(ctx context.Context, p string, depth int) ([]resource, error) {
+	hdr := http.Header{}
+	hdr.Set("Depth", fmt.Sprintf("%d", depth))
+	hdr.Set("Content-Type", "application/xml; charset=utf-8")
+
+	resp, err := c.do(ctx, "PROPFIND", p, hdr, []byte(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("failed to parse PROPFIND response for %s: %w", p, err)
+	}
+
+	res := make([]resource, 0, len(ms.Responses))
+	for _, r := range ms.Responses {
+		rel, err := c.relPath(r.Href)
+		if err != nil {
+			debug.Log("ignoring invalid href %q: %s", r.Href, err)
+
+			continue
+		}
+
+		rs := resource{Path: rel}
+		for _, ps := range r.Propstat {
+			if !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			rs.IsDir = ps.Prop.ResourceType.Collection != nil
+			rs.ETag = ps.Prop.ETag
+		}
+		res = append(res, rs)
+	}
+
+	return res, nil
+}
+
+// relPath converts an href from a PROPFIND response into a path relative to
+// the base URL. Collections are returned without a trailing slash.
+func (c *client) relPath(href string) (string, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+
+	p := u.Path
+	if !strings.HasPrefix(p+"/", c.base.Path) {
+		return "", fmt.Errorf("outside of %s", c.base.Path)
+	}
+
+	return strings.Trim(strings.TrimPrefix(p, c.base.Path), "/"), nil
+}
+
+// mkcolAll creates the collection at p and all of its parents.
+func (c *client) mkcolAll(ctx context.Context, p string) error {
+	p = strings.Trim(p, "/")
+	if p == "" || p == "." {
+		return nil
+	}
+
+	parts := strings.Split(p, "/")
+	for i := range parts {
+		dir := strings.Join(parts[:i+1], "/") + "/"
+		// 405 Method Not Allowed is returned if the collection already exists.
+		resp, err := c.do(ctx, "MKCOL", dir, nil, nil, http.StatusMethodNotAllowed)
+		if err != nil {
+			return fmt.Errorf("failed to create collection %s: %w", dir, err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package webdavfs
+
+import (
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/backend/storagetest"
+	"github.com/gopasspw/gopass/internal/config"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(config.NewContextInMemory(), t, storagetest.Options{
+		New: func(t *testing.T) backend.Storage {
+			_, srv := newFakeDAV(t)
+			mkcol(t, srv.URL, "dav/gopass")
+
+			return newTestStore(t, srv.URL+"/dav/gopass")
+		},
+	})
+}
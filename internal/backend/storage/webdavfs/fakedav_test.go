@@ -0,0 +1,92 @@
+package webdavfs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+const (
+	testUser     = "alice"
+	testPassword = "app-password"
+)
+
+// fakeDAV wraps the in-memory WebDAV server from x/net. It adds basic auth
+// and implements If-Match and If-None-Match for PUT requests, which the x/net
+// handler ignores but real servers like Nextcloud honour.
+type fakeDAV struct {
+	dav  *webdav.Handler
+	down bool
+}
+
+func newFakeDAV(t *testing.T) (*fakeDAV, *httptest.Server) {
+	t.Helper()
+
+	f := &fakeDAV{
+		dav: &webdav.Handler{
+			FileSystem: webdav.NewMemFS(),
+			LockSystem: webdav.NewMemLS(),
+		},
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	t.Setenv("GOPASS_WEBDAV_PASSWORD", testPassword)
+
+	return f, srv
+}
+
+func (f *fakeDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.down {
+		// simulate an unreachable server by dropping the connection.
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			panic("hijacking not supported")
+		}
+		conn, _, err := hj.Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+
+		return
+	}
+
+	if u, p, ok := r.BasicAuth(); !ok || u != testUser || p != testPassword {
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	if r.Method == http.MethodPut && !f.preconditionsMet(r) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+
+		return
+	}
+
+	f.dav.ServeHTTP(w, r)
+}
+
+func (f *fakeDAV) preconditionsMet(r *http.Request) bool {
+	im, inm := r.Header.Get("If-Match"), r.Header.Get("If-None-Match")
+	if im == "" && inm == "" {
+		return true
+	}
+
+	rec := httptest.NewRecorder()
+	f.dav.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, r.URL.EscapedPath(), nil))
+	etag := ""
+	if rec.Code == http.StatusOK {
+		etag = rec.Header().Get("ETag")
+	}
+
+	if im != "" && im != etag {
+		return false
+	}
+	if inm == "*" && etag != "" {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,97 @@
+package webdavfs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/fsutil"
+)
+
+func init() {
+	backend.StorageRegistry.Register(backend.WebDAVFS, name, &loader{})
+}
+
+type loader struct{}
+
+// New opens an existing store. The server settings are read from the
+// settings file in the local store directory.
+func (l loader) New(ctx context.Context, path string) (backend.Storage, error) {
+	path = fsutil.ExpandHomedir(path)
+
+	s, err := loadSettings(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webdav settings from %s: %w", path, err)
+	}
+
+	be, err := New(path, s)
+	if err != nil {
+		return nil, err
+	}
+	debug.Log("Using Storage Backend: %s", be.String())
+
+	return be, nil
+}
+
+// Init initializes a new store using the collection configured in webdav.url.
+func (l loader) Init(ctx context.Context, path string) (backend.Storage, error) {
+	return l.init(ctx, path, settingsFromConfig(ctx))
+}
+
+// Clone connects to an existing store. The repo is expected to be the URL of
+// the collection.
+func (l loader) Clone(ctx context.Context, repo, path string) (backend.Storage, error) {
+	s := settingsFromConfig(ctx)
+	s.URL = repo
+
+	be, err := l.init(ctx, path, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := be.Pull(ctx, "", ""); err != nil {
+		return nil, err
+	}
+
+	return be, nil
+}
+
+func (l loader) init(ctx context.Context, path string, s Settings) (*Store, error) {
+	path = fsutil.ExpandHomedir(path)
+
+	be, err := New(path, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := be.Fsck(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := saveSettings(path, s); err != nil {
+		return nil, fmt.Errorf("failed to save webdav settings to %s: %w", path, err)
+	}
+	out.Printf(ctx, "webdavfs configured for %s at %s", be.client.base.Redacted(), path)
+
+	return be, nil
+}
+
+// Handles returns nil if the path contains a webdavfs settings file.
+func (l loader) Handles(ctx context.Context, path string) error {
+	if !hasSettings(path) {
+		return fmt.Errorf("no %s found in %s", SettingsFile, path)
+	}
+
+	return nil
+}
+
+// Priority returns the priority of this backend.
+func (l loader) Priority() int {
+	return 9
+}
+
+func (l loader) String() string {
+	return name
+}
@@ -0,0 +1,120 @@
+// This file contains the rcs interface implementation for the webdavfs
+// backend. Every write is immediately visible to all other clients, so there
+// is no staging area and nothing to push. Pull refreshes the offline cache.
+// WebDAV has no portable versioning, so there is no history.
+package webdavfs
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// Add does nothing. Entries are visible as soon as they are written.
+func (s *Store) Add(ctx context.Context, args ...string) error {
+	return nil
+}
+
+// TryAdd does nothing.
+func (s *Store) TryAdd(ctx context.Context, args ...string) error {
+	return nil
+}
+
+// Commit does nothing.
+func (s *Store) Commit(ctx context.Context, msg string) error {
+	return nil
+}
+
+// TryCommit does nothing.
+func (s *Store) TryCommit(ctx context.Context, msg string) error {
+	return nil
+}
+
+// Push does nothing.
+func (s *Store) Push(ctx context.Context, origin, branch string) error {
+	return nil
+}
+
+// TryPush does nothing.
+func (s *Store) TryPush(ctx context.Context, origin, branch string) error {
+	return nil
+}
+
+// Pull refreshes the offline cache. Every entry on the server is fetched
+// (unchanged ones are served from the cache) and cached entries that no
+// longer exist on the server are dropped.
+func (s *Store) Pull(ctx context.Context, origin, branch string) error {
+	remote, err := s.List(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", s.settings.URL, err)
+	}
+
+	for _, e := range remote {
+		if _, err := s.Get(ctx, e); err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", e, err)
+		}
+	}
+
+	local, err := s.cache.list(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to list cached entries: %w", err)
+	}
+	for _, e := range local {
+		if slices.Contains(remote, e) {
+			continue
+		}
+		debug.Log("Removing stale cache entry %s", e)
+		s.cache.remove(ctx, e)
+	}
+	debug.Log("Cached %d entries from %s", len(remote), s.client.base.Redacted())
+
+	return nil
+}
+
+// InitConfig does nothing.
+func (s *Store) InitConfig(context.Context, string, string) error {
+	return nil
+}
+
+// AddRemote is not supported.
+func (s *Store) AddRemote(ctx context.Context, remote, url string) error {
+	return backend.ErrNotSupported
+}
+
+// RemoveRemote is not supported.
+func (s *Store) RemoveRemote(ctx context.Context, remote string) error {
+	return backend.ErrNotSupported
+}
+
+// Revisions is not supported. Only the latest revision is returned.
+func (s *Store) Revisions(context.Context, string) ([]backend.Revision, error) {
+	return []backend.Revision{
+		{
+			Hash: "latest",
+			Date: time.Now(),
+		},
+	}, backend.ErrNotSupported
+}
+
+// GetRevision only supports getting the latest revision.
+func (s *Store) GetRevision(ctx context.Context, name string, revision string) ([]byte, error) {
+	if revision == "HEAD" || revision == "latest" {
+		return s.Get(ctx, name)
+	}
+
+	return []byte(""), backend.ErrNotSupported
+}
+
+// Status returns the server URL and the size of the offline cache.
+func (s *Store) Status(context.Context) ([]byte, error) {
+	return fmt.Appendf(nil, "URL: %s\nCached entries: %d\n", s.client.base.Redacted(), s.cache.size()), nil
+}
+
+// Compact does nothing.
+func (s *Store) Compact(context.Context) error {
+	return nil
+}
@@ -0,0 +1,79 @@
+package webdavfs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/pkg/fsutil"
+)
+
+// SettingsFile is the name of the file in the local store directory that
+// records which WebDAV collection backs this store. It doubles as the
+// marker used to detect this backend.
+const SettingsFile = ".gopass-webdav.json"
+
+// Settings describe the location of a store on a WebDAV server. The password
+// is never persisted, it is read from the environment.
+type Settings struct {
+	// URL is the collection containing the store, e.g.
+	// https://cloud.example.org/remote.php/dav/files/alice/gopass.
+	URL      string `json:"url"`
+	Username string `json:"username,omitempty"`
+}
+
+func (s Settings) validate() error {
+	if s.URL == "" {
+		return fmt.Errorf("no url configured. Please set webdav.url")
+	}
+
+	return nil
+}
+
+// settingsFromConfig reads the settings from the gopass config.
+func settingsFromConfig(ctx context.Context) Settings {
+	return Settings{
+		URL:      config.String(ctx, "webdav.url"),
+		Username: config.String(ctx, "webdav.username"),
+	}
+}
+
+func loadSettings(path string) (Settings, error) {
+	var s Settings
+
+	buf, err := os.ReadFile(filepath.Join(path, SettingsFile))
+	if err != nil {
+		return s, err
+	}
+
+	if err := json.Unmarshal(buf, &s); err != nil {
+		return s, fmt.Errorf("failed to parse %s: %w", SettingsFile, err)
+	}
+
+	return s, s.validate()
+}
+
+func saveSettings(path string, s Settings) error {
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		return err
+	}
+
+	buf, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(path, SettingsFile), buf, 0o600)
+}
+
+func hasSettings(path string) bool {
+	return fsutil.IsFile(filepath.Join(fsutil.ExpandHomedir(path), SettingsFile))
+}
+
+// passwordFromEnv returns the WebDAV password, usually an app password.
+func passwordFromEnv() string {
+	return os.Getenv("GOPASS_WEBDAV_PASSWORD")
+}
@@ -0,0 +1,505 @@
+// Package webdavfs implements a storage backend that keeps the encrypted
+// secrets on a WebDAV server, e.g. a self-hosted Nextcloud. Concurrent
+// modifications are detected using conditional requests based on ETags and a
+// local cache of the encrypted entries allows reading while offline.
+package webdavfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/backend/storage/internal/names"
+	"github.com/gopasspw/gopass/internal/store"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+const name = "webdavfs"
+
+// Store is a WebDAV based store.
+type Store struct {
+	path     string
+	settings Settings
+	client   *client
+	cache    *cache
+	version  semver.Version
+}
+
+// New creates a new store for the given local path and server settings. The
+// local path holds the settings file and the offline cache.
+func New(path string, s Settings) (*Store, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	c, err := newClient(s, passwordFromEnv())
+	if err != nil {
+		return nil, err
+	}
+
+	ca, err := newCache(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		path:     path,
+		settings: s,
+		client:   c,
+		cache:    ca,
+		version:  debug.ModuleVersion("github.com/gopasspw/gopass/internal/backend/storage/webdavfs"),
+	}, nil
+}
+
+// cleanDir returns the normalized directory name with a trailing slash or
+// the empty string for the root.
+func cleanDir(name string) string {
+	name = names.Dir(name)
+	if name == "" {
+		return ""
+	}
+
+	return name + "/"
+}
+
+// isOffline returns true if the error indicates that the server could not
+// be reached at all, as opposed to the server rejecting the request.
+func isOffline(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, errNotFound) || errors.Is(err, errPrecondition) {
+		return false
+	}
+	var se *statusError
+
+	return !errors.As(err, &se)
+}
+
+// offline returns true if the network should not be used.
+func offline(ctx context.Context) bool {
+	return ctxutil.IsNoNetwork(ctx)
+}
+
+// Get retrieves the named content. Unchanged entries are served from the
+// local cache. If the server is unreachable the cached copy is returned.
+func (s *Store) Get(ctx context.Context, name string) ([]byte, error) {
+	name, err := names.Clean(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, etag, found := s.cache.get(ctx, name)
+	if offline(ctx) {
+		if !found {
+			return nil, fmt.Errorf("failed to get %s: not cached and network disabled", name)
+		}
+
+		return cached, nil
+	}
+
+	hdr := http.Header{}
+	if found && etag != "" {
+		hdr.Set("If-None-Match", etag)
+	}
+	debug.V(3).Log("Reading %s from %s (if-none-match: %q)", name, s.settings.URL, etag)
+
+	resp, err := s.client.do(ctx, http.MethodGet, name, hdr, nil, http.StatusNotModified)
+	if err != nil {
+		if found && isOffline(err) {
+			debug.Log("Server unreachable, using cached copy of %s: %s", name, err)
+
+			return cached, nil
+		}
+		if errors.Is(err, errNotFound) {
+			s.cache.remove(ctx, name)
+		}
+
+		return nil, fmt.Errorf("failed to get %s: %w", name, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode == http.StatusNotModified {
+		debug.V(3).Log("%s not modified, using cached copy", name)
+
+		return cached, nil
+	}
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	s.cache.set(ctx, name, resp.Header.Get("ETag"), buf)
+
+	return buf, nil
+}
+
+// Set writes the given content. The write is conditional on the entry not
+// having changed since this client last saw it. If it did
+// backend.ErrConflict is returned.
+func (s *Store) Set(ctx context.Context, name string, value []byte) error {
+	name, err := names.Clean(name)
+	if err != nil {
+		return err
+	}
+	if offline(ctx) {
+		return fmt.Errorf("failed to write %s: network disabled", name)
+	}
+
+	cached, etag, found := s.cache.get(ctx, name)
+	if !found {
+		etag, err = s.head(ctx, name)
+		if err != nil && !errors.Is(err, errNotFound) {
+			return fmt.Errorf("failed to stat %s: %w", name, err)
+		}
+	}
+
+	// if we ever try to write a secret that is identical (in ciphertext) to the secret in store,
+	// we might want to act differently.
+	if found && bytes.Equal(cached, value) {
+		return store.ErrMeaninglessWrite
+	}
+
+	if err := s.client.mkcolAll(ctx, path.Dir(name)); err != nil {
+		return err
+	}
+
+	hdr := http.Header{}
+	hdr.Set("Content-Type", "application/octet-stream")
+	if etag != "" {
+		hdr.Set("If-Match", etag)
+	} else {
+		hdr.Set("If-None-Match", "*")
+	}
+	debug.V(3).Log("Writing %s to %s (if-match: %q)", name, s.settings.URL, etag)
+
+	resp, err := s.client.do(ctx, http.MethodPut, name, hdr, value)
+	if err != nil {
+		if errors.Is(err, errPrecondition) {
+			return fmt.Errorf("failed to write %s: %w", name, backend.ErrConflict)
+		}
+
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	_ = resp.Body.Close()
+
+	etag = resp.Header.Get("ETag")
+	if etag == "" {
+		// not all servers return the new ETag on PUT
+		etag, _ = s.head(ctx, name)
+	}
+	s.cache.set(ctx, name, etag, value)
+
+	return nil
+}
+
+// head returns the current ETag of the named entry.
+func (s *Store) head(ctx context.Context, name string) (string, error) {
+	resp, err := s.client.do(ctx, http.MethodHead, name, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	_ = resp.Body.Close()
+
+	return resp.Header.Get("ETag"), nil
+}
+
+// Move moves the named entity to the new location. If del is false the
+// entry is copied instead.
+func (s *Store) Move(ctx context.Context, from, to string, del bool) error {
+	from, err := names.Clean(from)
+	if err != nil {
+		return err
+	}
+	to, err = names.Clean(to)
+	if err != nil {
+		return err
+	}
+	if offline(ctx) {
+		return fmt.Errorf("failed to move %s: network disabled", from)
+	}
+
+	if err := s.client.mkcolAll(ctx, path.Dir(to)); err != nil {
+		return err
+	}
+
+	method := "COPY"
+	if del {
+		method = "MOVE"
+	}
+	debug.V(3).Log("%s %q to %q", method, from, to)
+
+	hdr := http.Header{}
+	hdr.Set("Destination", s.client.url(to).String())
+	hdr.Set("Overwrite", "T")
+
+	resp, err := s.client.do(ctx, method, from, hdr, nil)
+	if err != nil {
+		return fmt.Errorf("failed to move %q to %q: %w", from, to, err)
+	}
+	_ = resp.Body.Close()
+
+	// the ETag of the destination is not returned and may differ from the
+	// source, so it has to be looked up.
+	s.cache.remove(ctx, to)
+	if buf, _, found := s.cache.get(ctx, from); found {
+		if etag, err := s.head(ctx, to); err == nil {
+			s.cache.set(ctx, to, etag, buf)
+		}
+	}
+
+	if !del {
+		return nil
+	}
+	s.cache.remove(ctx, from)
+
+	return s.removeEmptyParents(ctx, from)
+}
+
+// Delete removes the named entity.
+func (s *Store) Delete(ctx context.Context, name string) error {
+	name, err := names.Clean(name)
+	if err != nil {
+		return err
+	}
+	if offline(ctx) {
+		return fmt.Errorf("failed to delete %s: network disabled", name)
+	}
+	debug.V(3).Log("Deleting %s from %s", name, s.settings.URL)
+
+	resp, err := s.client.do(ctx, http.MethodDelete, name, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", name, err)
+	}
+	_ = resp.Body.Close()
+	s.cache.remove(ctx, name)
+
+	return s.removeEmptyParents(ctx, name)
+}
+
+// removeEmptyParents removes the empty collections above name, mirroring
+// the behaviour of the fs backend.
+func (s *Store) removeEmptyParents(ctx context.Context, name string) error {
+	for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		res, err := s.client.propfind(ctx, dir+"/", 1)
+		if err != nil {
+			if errors.Is(err, errNotFound) {
+				continue
+			}
+
+			return fmt.Errorf("failed to list %s: %w", dir, err)
+		}
+		if len(res) > 1 {
+			return nil
+		}
+
+		debug.V(3).Log("Removing empty collection %s", dir)
+		resp, err := s.client.do(ctx, http.MethodDelete, dir+"/", nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to delete %s: %w", dir, err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	return nil
+}
+
+// Exists checks if the named entity exists.
+func (s *Store) Exists(ctx context.Context, name string) bool {
+	name, err := names.Clean(name)
+	if err != nil {
+		return false
+	}
+
+	if offline(ctx) {
+		_, found := s.cache.etag(name)
+
+		return found
+	}
+
+	if _, err := s.head(ctx, name); err != nil {
+		debug.V(2).Log("Checking if %q exists at %s: %s", name, s.settings.URL, err)
+		if isOffline(err) {
+			_, found := s.cache.etag(name)
+
+			return found
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// List returns a list of all entities with the given prefix.
+// e.g. foo, far/bar baz/.bang. Like the fs backend it skips hidden
+// directories unless they are explicitly requested.
+func (s *Store) List(ctx context.Context, prefix string) ([]string, error) {
+	prefix = strings.TrimPrefix(filepath.ToSlash(prefix), "/")
+	if offline(ctx) {
+		return s.cache.list(ctx, prefix)
+	}
+
+	// start at the deepest collection covered by the prefix.
+	start := ""
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		start = prefix[:i+1]
+	}
+	debug.V(2).Log("Listing %s%s", s.settings.URL, start)
+
+	files := []string{}
+	if err := s.walk(ctx, start, prefix, &files); err != nil {
+		if errors.Is(err, errNotFound) {
+			return files, nil
+		}
+		if isOffline(err) {
+			debug.Log("Server unreachable, listing cached entries: %s", err)
+
+			return s.cache.list(ctx, prefix)
+		}
+
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+	slices.Sort(files)
+
+	return files, nil
+}
+
+// walk recursively collects all entries below dir that match prefix. Only
+// Depth 1 requests are used since many servers disable Depth infinity.
+func (s *Store) walk(ctx context.Context, dir, prefix string, files *[]string) error {
+	res, err := s.client.propfind(ctx, dir, 1)
+	if err != nil {
+		return err
+	}
+
+	self := strings.TrimSuffix(dir, "/")
+	for _, r := range res {
+		if r.Path == self {
+			continue
+		}
+		if r.IsDir {
+			if names.InHiddenDir(r.Path+"/x", prefix) {
+				continue
+			}
+			if !strings.HasPrefix(r.Path+"/", prefix) && !strings.HasPrefix(prefix, r.Path+"/") {
+				continue
+			}
+			if err := s.walk(ctx, r.Path+"/", prefix, files); err != nil {
+				return err
+			}
+
+			continue
+		}
+		if strings.HasPrefix(r.Path, prefix) {
+			*files = append(*files, r.Path)
+		}
+	}
+
+	return nil
+}
+
+// IsDir returns true if the named entity is a collection.
+func (s *Store) IsDir(ctx context.Context, name string) bool {
+	dir := cleanDir(name)
+	if offline(ctx) {
+		return s.cache.isDir(ctx, dir)
+	}
+
+	res, err := s.client.propfind(ctx, dir, 0)
+	if err != nil {
+		debug.V(2).Log("%s: %s", name, err)
+		if isOffline(err) {
+			return s.cache.isDir(ctx, dir)
+		}
+
+		return false
+	}
+	isDir := len(res) > 0 && res[0].IsDir
+	debug.V(2).Log("%s is a directory? %t", name, isDir)
+
+	return isDir
+}
+
+// Prune removes the named collection and everything below it.
+func (s *Store) Prune(ctx context.Context, prefix string) error {
+	dir := cleanDir(prefix)
+	if dir == "" {
+		return fmt.Errorf("refusing to prune the store root")
+	}
+	if offline(ctx) {
+		return fmt.Errorf("failed to prune %s: network disabled", prefix)
+	}
+	debug.Log("Pruning %s from %s", dir, s.settings.URL)
+
+	cached, err := s.cache.list(ctx, dir)
+	if err != nil {
+		debug.Log("failed to list cached entries below %s: %s", dir, err)
+	}
+
+	resp, err := s.client.do(ctx, http.MethodDelete, dir, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to prune %s: %w", prefix, err)
+	}
+	_ = resp.Body.Close()
+
+	for _, e := range cached {
+		s.cache.remove(ctx, e)
+	}
+
+	return s.removeEmptyParents(ctx, strings.TrimSuffix(dir, "/"))
+}
+
+// Link is not supported. WebDAV has no portable notion of symlinks.
+func (s *Store) Link(ctx context.Context, from, to string) error {
+	return backend.ErrNotSupported
+}
+
+// Name returns the name of this backend.
+func (s *Store) Name() string {
+	return name
+}
+
+// Version returns the version of this backend.
+func (s *Store) Version(context.Context) semver.Version {
+	return s.version
+}
+
+// String implements fmt.Stringer.
+func (s *Store) String() string {
+	return fmt.Sprintf("%s(%s,url:%s)", name, s.version.String(), s.client.base.Redacted())
+}
+
+// Path returns the local path of this storage. It contains the settings
+// file and the offline cache.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Fsck checks that the server is reachable and the URL points to a
+// collection.
+func (s *Store) Fsck(ctx context.Context) error {
+	if !s.cache.exists() {
+		return fmt.Errorf("cache directory missing in %s", s.path)
+	}
+
+	res, err := s.client.propfind(ctx, "", 0)
+	if err != nil {
+		return fmt.Errorf("failed to check %s: %w", s.settings.URL, err)
+	}
+	if len(res) < 1 || !res[0].IsDir {
+		return fmt.Errorf("%s is not a collection", s.settings.URL)
+	}
+
+	return nil
+}
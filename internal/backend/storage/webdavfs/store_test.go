@@ -0,0 +1,261 @@
+package webdavfs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/store"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T, url string) *Store {
+	t.Helper()
+
+	s, err := New(t.TempDir(), Settings{
+		URL:      url,
+		Username: testUser,
+	})
+	require.NoError(t, err)
+
+	return s
+}
+
+// mkcol creates the collection p on the server.
+func mkcol(t *testing.T, url, p string) {
+	t.Helper()
+
+	c, err := newClient(Settings{URL: url, Username: testUser}, testPassword)
+	require.NoError(t, err)
+	require.NoError(t, c.mkcolAll(context.Background(), p))
+}
+
+func TestSetGetDelete(t *testing.T) {
+	_, srv := newFakeDAV(t)
+	ctx := config.NewContextInMemory()
+	s := newTestStore(t, srv.URL+"/dav/gopass")
+
+	require.Error(t, s.Fsck(ctx))
+	mkcol(t, srv.URL, "dav/gopass")
+	require.NoError(t, s.Fsck(ctx))
+
+	assert.False(t, s.Exists(ctx, "foo/bar"))
+	_, err := s.Get(ctx, "foo/bar")
+	require.Error(t, err)
+
+	require.NoError(t, s.Set(ctx, "foo/bar", []byte("secret")))
+	assert.True(t, s.Exists(ctx, "foo/bar"))
+
+	buf, err := s.Get(ctx, "foo/bar")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(buf))
+
+	require.ErrorIs(t, s.Set(ctx, "foo/bar", []byte("secret")), store.ErrMeaninglessWrite)
+	require.NoError(t, s.Set(ctx, "foo/bar", []byte("other secret")))
+
+	// names with characters that need escaping
+	require.NoError(t, s.Set(ctx, "web/example.com+1 (ü)", []byte("escaped")))
+	buf, err = s.Get(ctx, "web/example.com+1 (ü)")
+	require.NoError(t, err)
+	assert.Equal(t, "escaped", string(buf))
+
+	require.NoError(t, s.Delete(ctx, "foo/bar"))
+	assert.False(t, s.Exists(ctx, "foo/bar"))
+	assert.False(t, s.IsDir(ctx, "foo"))
+	require.Error(t, s.Delete(ctx, "foo/bar"))
+
+	// names can not escape the collection
+	require.NoError(t, s.Set(ctx, "../../etc/passwd", []byte("root")))
+	assert.True(t, s.Exists(ctx, "etc/passwd"))
+}
+
+func TestUnauthorized(t *testing.T) {
+	_, srv := newFakeDAV(t)
+	ctx := config.NewContextInMemory()
+
+	t.Setenv("GOPASS_WEBDAV_PASSWORD", "wrong")
+	s := newTestStore(t, srv.URL)
+
+	require.Error(t, s.Fsck(ctx))
+	require.Error(t, s.Set(ctx, "foo", []byte("bar")))
+}
+
+func TestConcurrentModification(t *testing.T) {
+	_, srv := newFakeDAV(t)
+	ctx := config.NewContextInMemory()
+
+	alice := newTestStore(t, srv.URL)
+	bob := newTestStore(t, srv.URL)
+
+	require.NoError(t, alice.Set(ctx, "shared", []byte("v1")))
+
+	_, err := bob.Get(ctx, "shared")
+	require.NoError(t, err)
+
+	require.NoError(t, alice.Set(ctx, "shared", []byte("v2 from alice")))
+	require.ErrorIs(t, bob.Set(ctx, "shared", []byte("v2 from bob")), backend.ErrConflict)
+
+	// after re-reading the change bob can write again
+	buf, err := bob.Get(ctx, "shared")
+	require.NoError(t, err)
+	assert.Equal(t, "v2 from alice", string(buf))
+	require.NoError(t, bob.Set(ctx, "shared", []byte("v3 from bob")))
+
+	// creating an entry that someone else created in the meantime
+	require.NoError(t, alice.Set(ctx, "new", []byte("from alice")))
+	bob2 := newTestStore(t, srv.URL)
+	bob2.cache.set(ctx, "new", "", []byte("stale"))
+	require.ErrorIs(t, bob2.Set(ctx, "new", []byte("from bob")), backend.ErrConflict)
+}
+
+func TestListIsDirPrune(t *testing.T) {
+	_, srv := newFakeDAV(t)
+	ctx := config.NewContextInMemory()
+	s := newTestStore(t, srv.URL)
+
+	for _, n := range []string{"foo/bar", "foo/baz", "foo/zab/zab", "zzz", ".gpg-id", ".hidden/secret", "foo/.hidden/secret"} {
+		require.NoError(t, s.Set(ctx, n, []byte(n)))
+	}
+
+	files, err := s.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{".gpg-id", "foo/bar", "foo/baz", "foo/zab/zab", "zzz"}, files)
+
+	files, err = s.List(ctx, "foo/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo/bar", "foo/baz", "foo/zab/zab"}, files)
+
+	files, err = s.List(ctx, "foo/ba")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo/bar", "foo/baz"}, files)
+
+	files, err = s.List(ctx, ".hidden")
+	require.NoError(t, err)
+	assert.Equal(t, []string{".hidden/secret"}, files)
+
+	files, err = s.List(ctx, "nope/")
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	assert.True(t, s.IsDir(ctx, "foo"))
+	assert.True(t, s.IsDir(ctx, "foo/zab"))
+	assert.False(t, s.IsDir(ctx, "foo/bar"))
+	assert.False(t, s.IsDir(ctx, "bar"))
+
+	require.NoError(t, s.Prune(ctx, "foo"))
+	files, err = s.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{".gpg-id", "zzz"}, files)
+	assert.False(t, s.IsDir(ctx, "foo"))
+	require.Error(t, s.Prune(ctx, ""))
+}
+
+func TestMove(t *testing.T) {
+	_, srv := newFakeDAV(t)
+	ctx := config.NewContextInMemory()
+	s := newTestStore(t, srv.URL)
+
+	require.NoError(t, s.Set(ctx, "src/secret", []byte("content")))
+
+	require.NoError(t, s.Move(ctx, "src/secret", "dst/copy", false))
+	assert.True(t, s.Exists(ctx, "src/secret"))
+
+	require.NoError(t, s.Move(ctx, "src/secret", "dst/moved", true))
+	assert.False(t, s.Exists(ctx, "src/secret"))
+	assert.False(t, s.IsDir(ctx, "src"))
+
+	for _, n := range []string{"dst/copy", "dst/moved"} {
+		buf, err := s.Get(ctx, n)
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(buf))
+	}
+
+	// the moved entry can be updated without a conflict
+	require.NoError(t, s.Set(ctx, "dst/moved", []byte("updated")))
+}
+
+func TestOfflineCache(t *testing.T) {
+	f, srv := newFakeDAV(t)
+	ctx := config.NewContextInMemory()
+	s := newTestStore(t, srv.URL)
+
+	require.NoError(t, s.Set(ctx, "foo/bar", []byte("secret")))
+	other := newTestStore(t, srv.URL)
+	require.NoError(t, other.Set(ctx, "foo/baz", []byte("not cached")))
+
+	f.down = true
+
+	buf, err := s.Get(ctx, "foo/bar")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(buf))
+	assert.True(t, s.Exists(ctx, "foo/bar"))
+	assert.True(t, s.IsDir(ctx, "foo"))
+
+	files, err := s.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo/bar"}, files)
+
+	_, err = s.Get(ctx, "foo/baz")
+	require.Error(t, err)
+	require.Error(t, s.Set(ctx, "foo/bar", []byte("offline write")))
+
+	// the network can be disabled explicitly
+	f.down = false
+	buf, err = s.Get(ctxutil.WithNoNetwork(ctx, true), "foo/bar")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(buf))
+	require.Error(t, s.Set(ctxutil.WithNoNetwork(ctx, true), "foo/bar", []byte("offline write")))
+
+	// pull refreshes the cache
+	require.NoError(t, other.Delete(ctx, "foo/bar"))
+	require.NoError(t, s.Pull(ctx, "", ""))
+	files, err = s.cache.list(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo/baz"}, files)
+}
+
+func TestLoader(t *testing.T) {
+	_, srv := newFakeDAV(t)
+
+	cfg := config.NewInMemory()
+	require.NoError(t, cfg.SetEnv("webdav.url", srv.URL+"/gopass/"))
+	require.NoError(t, cfg.SetEnv("webdav.username", testUser))
+	ctx := cfg.WithConfig(context.Background())
+	ctx = ctxutil.WithHidden(ctx, true)
+
+	// the collection must exist
+	td := t.TempDir()
+	l := loader{}
+	_, err := l.Init(ctx, td)
+	require.Error(t, err)
+	require.Error(t, l.Handles(ctx, td))
+
+	mkcol(t, srv.URL, "gopass")
+
+	st, err := l.Init(ctx, td)
+	require.NoError(t, err)
+	require.NoError(t, l.Handles(ctx, td))
+	require.NoError(t, st.Set(ctx, "foo", []byte("bar")))
+
+	st, err = l.New(ctx, td)
+	require.NoError(t, err)
+	assert.Equal(t, name, st.Name())
+	assert.True(t, st.Exists(ctx, "foo"))
+
+	// clone populates the offline cache
+	td2 := t.TempDir()
+	st, err = l.Clone(ctx, srv.URL+"/gopass", td2)
+	require.NoError(t, err)
+	buf, err := st.Get(ctxutil.WithNoNetwork(ctx, true), "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(buf))
+
+	_, err = l.Clone(ctx, "ftp://example.com/foo", t.TempDir())
+	require.Error(t, err)
+
+	_, err = l.New(ctx, t.TempDir())
+	require.Error(t, err)
+}
@@ -0,0 +1,241 @@
+// Package storagetest provides a conformance test suite for implementations
+// of backend.Storage and the RCS operations embedded in it. Every storage
+// backend should run it from its own tests so that commands like history,
+// show --revision and sync behave the same regardless of the backend.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Options describes the capabilities of the backend under test.
+type Options struct {
+	// New returns a new, empty and initialized storage.
+	New func(t *testing.T) backend.Storage
+	// History is set for backends that record the revisions of each entry.
+	History bool
+	// Remote returns the URL of a new, empty remote. It is set for backends
+	// that support remotes and requires Clone to be set as well.
+	Remote func(t *testing.T) string
+	// Clone returns a new checkout of the given remote.
+	Clone func(t *testing.T, url string) backend.Storage
+	// CloneOrigin is set for backends whose remotes only accept changes from
+	// checkouts of the same repository (e.g. fossil). The sync test then
+	// clones both of its checkouts from the remote instead of using New.
+	CloneOrigin bool
+}
+
+// Run runs the conformance tests against the backend described by opts.
+func Run(ctx context.Context, t *testing.T, opts Options) {
+	t.Helper()
+
+	t.Run("storage", func(t *testing.T) {
+		testStorage(ctx, t, opts.New(t))
+	})
+
+	t.Run("rcs", func(t *testing.T) {
+		testRCS(ctx, t, opts.New(t))
+	})
+
+	if opts.History {
+		t.Run("history", func(t *testing.T) {
+			testHistory(ctx, t, opts.New(t))
+		})
+	}
+
+	if opts.Remote != nil && opts.Clone != nil {
+		t.Run("remotes", func(t *testing.T) {
+			testRemotes(ctx, t, opts.New(t), opts.Remote(t))
+		})
+		t.Run("sync", func(t *testing.T) {
+			testSync(ctx, t, opts)
+		})
+	}
+}
+
+func testStorage(ctx context.Context, t *testing.T, s backend.Storage) {
+	t.Helper()
+
+	assert.NotEmpty(t, s.Name())
+	assert.NotEmpty(t, s.String())
+
+	assert.False(t, s.Exists(ctx, "conformance/foo.txt"))
+	_, err := s.Get(ctx, "conformance/foo.txt")
+	require.Error(t, err)
+
+	require.NoError(t, s.Set(ctx, "conformance/foo.txt", []byte("foo")))
+	require.NoError(t, s.Set(ctx, "conformance/sub/bar.txt", []byte("bar")))
+	require.NoError(t, s.Set(ctx, "other.txt", []byte("other")))
+
+	assert.True(t, s.Exists(ctx, "conformance/foo.txt"))
+	buf, err := s.Get(ctx, "conformance/foo.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(buf))
+
+	require.NoError(t, s.Set(ctx, "conformance/foo.txt", []byte("foo2")))
+	buf, err = s.Get(ctx, "conformance/foo.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "foo2", string(buf))
+
+	ls, err := s.List(ctx, "conformance")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"conformance/foo.txt", "conformance/sub/bar.txt"}, ls)
+
+	assert.True(t, s.IsDir(ctx, "conformance"))
+	assert.True(t, s.IsDir(ctx, "conformance/sub"))
+	assert.False(t, s.IsDir(ctx, "conformance/foo.txt"))
+	assert.False(t, s.IsDir(ctx, "missing"))
+
+	// copy
+	require.NoError(t, s.Move(ctx, "conformance/foo.txt", "conformance/copy.txt", false))
+	assert.True(t, s.Exists(ctx, "conformance/foo.txt"))
+	buf, err = s.Get(ctx, "conformance/copy.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "foo2", string(buf))
+
+	// move
+	require.NoError(t, s.Move(ctx, "conformance/copy.txt", "moved.txt", true))
+	assert.False(t, s.Exists(ctx, "conformance/copy.txt"))
+	buf, err = s.Get(ctx, "moved.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "foo2", string(buf))
+
+	require.NoError(t, s.Delete(ctx, "moved.txt"))
+	assert.False(t, s.Exists(ctx, "moved.txt"))
+
+	require.NoError(t, s.Prune(ctx, "conformance"))
+	assert.False(t, s.Exists(ctx, "conformance/foo.txt"))
+	assert.False(t, s.Exists(ctx, "conformance/sub/bar.txt"))
+	assert.False(t, s.IsDir(ctx, "conformance"))
+	assert.True(t, s.Exists(ctx, "other.txt"))
+
+	require.NoError(t, s.Fsck(ctx))
+}
+
+// isSyncSkip returns true if the error is one that gopass sync reports as a
+// skipped store instead of a failure.
+func isSyncSkip(err error) bool {
+	return err == nil ||
+		errors.Is(err, store.ErrGitNoRemote) ||
+		errors.Is(err, store.ErrGitNotInit) ||
+		errors.Is(err, backend.ErrNotSupported)
+}
+
+func testRCS(ctx context.Context, t *testing.T, s backend.Storage) {
+	t.Helper()
+
+	// the leaf store calls these after every write and relies on them to
+	// ignore backends (or stores) without version control.
+	require.NoError(t, s.Set(ctx, "rcs.txt", []byte("rcs")))
+	require.NoError(t, s.TryAdd(ctx, "rcs.txt"))
+	require.NoError(t, s.TryCommit(ctx, "Add rcs.txt"))
+	require.NoError(t, s.TryCommit(ctx, "Nothing changed"))
+	require.NoError(t, s.TryPush(ctx, "", ""))
+
+	// without a remote sync must be able to skip the store.
+	err := s.Push(ctx, "", "")
+	assert.True(t, isSyncSkip(err), "unexpected push error: %s", err)
+	err = s.Pull(ctx, "", "")
+	assert.True(t, isSyncSkip(err), "unexpected pull error: %s", err)
+}
+
+func testHistory(ctx context.Context, t *testing.T, s backend.Storage) {
+	t.Helper()
+
+	name := "history/entry.txt"
+	require.NoError(t, s.Set(ctx, name, []byte("first")))
+	require.NoError(t, s.Add(ctx, name))
+	require.NoError(t, s.Commit(ctx, "First revision"))
+
+	// nothing changed
+	require.ErrorIs(t, s.Commit(ctx, "Empty"), store.ErrGitNothingToCommit)
+
+	require.NoError(t, s.Set(ctx, "history/other.txt", []byte("other")))
+	require.NoError(t, s.Add(ctx, "history/other.txt"))
+	require.NoError(t, s.Commit(ctx, "Other entry"))
+
+	require.NoError(t, s.Set(ctx, name, []byte("second")))
+	require.NoError(t, s.Add(ctx, name))
+	require.NoError(t, s.Commit(ctx, "Second revision"))
+
+	revs, err := s.Revisions(ctx, name)
+	require.NoError(t, err)
+	require.Len(t, revs, 2, "revisions: %+v", revs)
+
+	// newest first
+	assert.Equal(t, "Second revision", revs[0].Subject)
+	assert.Equal(t, "First revision", revs[1].Subject)
+	for _, rev := range revs {
+		assert.NotEmpty(t, rev.Hash)
+		assert.False(t, rev.Date.IsZero())
+	}
+
+	buf, err := s.GetRevision(ctx, name, revs[1].Hash)
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(buf))
+
+	buf, err = s.GetRevision(ctx, name, revs[0].Hash)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(buf))
+}
+
+func testRemotes(ctx context.Context, t *testing.T, s backend.Storage, url string) {
+	t.Helper()
+
+	require.NoError(t, s.AddRemote(ctx, "conformance", url))
+	require.NoError(t, s.RemoveRemote(ctx, "conformance"))
+	require.Error(t, s.RemoveRemote(ctx, "conformance"))
+
+	// no remote named origin
+	require.ErrorIs(t, s.Push(ctx, "", ""), store.ErrGitNoRemote)
+}
+
+func commit(ctx context.Context, t *testing.T, s backend.Storage, name, content string) {
+	t.Helper()
+
+	require.NoError(t, s.Set(ctx, name, []byte(content)))
+	require.NoError(t, s.Add(ctx, name))
+	require.NoError(t, s.Commit(ctx, "Update "+name))
+}
+
+func testSync(ctx context.Context, t *testing.T, opts Options) {
+	t.Helper()
+
+	url := opts.Remote(t)
+
+	var a backend.Storage
+	if opts.CloneOrigin {
+		a = opts.Clone(t, url)
+	} else {
+		a = opts.New(t)
+		require.NoError(t, a.AddRemote(ctx, "origin", url))
+	}
+	commit(ctx, t, a, "shared.txt", "a1")
+	require.NoError(t, a.Push(ctx, "", ""))
+
+	b := opts.Clone(t, url)
+	buf, err := b.Get(ctx, "shared.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "a1", string(buf))
+
+	// non-conflicting changes are merged
+	commit(ctx, t, b, "b.txt", "b1")
+	require.NoError(t, b.Push(ctx, "", ""))
+	require.NoError(t, a.Pull(ctx, "", ""))
+	buf, err = a.Get(ctx, "b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "b1", string(buf))
+
+	// concurrent changes to the same entry are reported as a conflict
+	commit(ctx, t, a, "shared.txt", "a2")
+	require.NoError(t, a.Push(ctx, "", ""))
+	commit(ctx, t, b, "shared.txt", "b2")
+	require.ErrorIs(t, b.Push(ctx, "", ""), backend.ErrConflict)
+}
@@ -0,0 +1,268 @@
+//go:build !windows
+
+package wayland
+
+import (
+	"net"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCompositor implements the parts of a Wayland compositor needed for
+// the data control protocol. It supports one seat.
+type fakeCompositor struct {
+	ln      *net.UnixListener
+	globals []global
+
+	mu      sync.Mutex
+	clients []*fakeClient
+	owners  map[Selection]*fakeSource
+	offers  map[*fakeClient]map[uint32]*fakeSource
+}
+
+type fakeClient struct {
+	w       *wire
+	sendMu  sync.Mutex
+	objects map[uint32]kind
+	device  uint32
+	nextID  uint32
+}
+
+type fakeSource struct {
+	client *fakeClient
+	id     uint32
+	mimes  []string
+}
+
+func newFakeCompositor(t *testing.T, globals ...global) *fakeCompositor {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "wayland-0")
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	require.NoError(t, err)
+	t.Setenv("WAYLAND_DISPLAY", path)
+
+	if len(globals) < 1 {
+		globals = []global{
+			{name: 1, iface: "wl_seat", version: 7},
+			{name: 2, iface: "zwlr_data_control_manager_v1", version: 2},
+		}
+	}
+
+	fc := &fakeCompositor{
+		ln:      ln,
+		globals: globals,
+		owners:  make(map[Selection]*fakeSource, 2),
+		offers:  make(map[*fakeClient]map[uint32]*fakeSource),
+	}
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+
+	go fc.accept()
+
+	return fc
+}
+
+func (fc *fakeCompositor) accept() {
+	for {
+		conn, err := fc.ln.AcceptUnix()
+		if err != nil {
+			return
+		}
+
+		cl := &fakeClient{
+			w:       &wire{conn: conn},
+			objects: make(map[uint32]kind),
+			nextID:  0xff000000,
+		}
+		fc.mu.Lock()
+		fc.clients = append(fc.clients, cl)
+		fc.offers[cl] = make(map[uint32]*fakeSource)
+		fc.mu.Unlock()
+
+		go fc.serve(cl)
+	}
+}
+
+func (cl *fakeClient) send(m *message) {
+	cl.sendMu.Lock()
+	defer cl.sendMu.Unlock()
+
+	_ = cl.w.send(m)
+}
+
+func (fc *fakeCompositor) serve(cl *fakeClient) {
+	defer func() {
+		fc.disconnect(cl)
+		_ = cl.w.close()
+	}()
+
+	nfds := func(object uint32, opcode uint16) int {
+		fc.mu.Lock()
+		defer fc.mu.Unlock()
+
+		if cl.objects[object] == kindOffer && opcode == offerReceive {
+			return 1
+		}
+
+		return 0
+	}
+
+	for {
+		m, err := cl.w.receive(nfds)
+		if err != nil {
+			return
+		}
+
+		fc.mu.Lock()
+		fc.handle(cl, m)
+		fc.mu.Unlock()
+	}
+}
+
+func (fc *fakeCompositor) handle(cl *fakeClient, m *message) {
+	d := &decoder{m: m}
+
+	if m.object == 1 {
+		id := d.uint()
+		switch m.opcode {
+		case displaySync:
+			cl.send(newMessage(id, 0).putUint(0))
+			cl.send(newMessage(1, displayDeleteID).putUint(id))
+		case displayGetRegistry:
+			cl.objects[id] = kindRegistry
+			for _, g := range fc.globals {
+				cl.send(newMessage(id, registryGlobal).putUint(g.name).putString(g.iface).putUint(g.version))
+			}
+		}
+
+		return
+	}
+
+	switch cl.objects[m.object] {
+	case kindRegistry:
+		_, iface, _, id := d.uint(), d.string(), d.uint(), d.uint()
+		if iface == "wl_seat" {
+			cl.objects[id] = kindSeat
+		} else {
+			cl.objects[id] = kindManager
+		}
+	case kindManager:
+		id := d.uint()
+		switch m.opcode {
+		case managerCreateSource:
+			cl.objects[id] = kindSource
+			fc.offers[cl][id] = &fakeSource{client: cl, id: id}
+		case managerGetDevice:
+			cl.objects[id] = kindDevice
+			cl.device = id
+			fc.announce(cl, Clipboard)
+			fc.announce(cl, Primary)
+		}
+	case kindSource:
+		src := fc.offers[cl][m.object]
+		switch m.opcode {
+		case sourceOffer:
+			src.mimes = append(src.mimes, d.string())
+		case sourceDestroy:
+			for sel, owner := range fc.owners {
+				if owner == src {
+					delete(fc.owners, sel)
+					fc.broadcast(sel)
+				}
+			}
+			delete(cl.objects, m.object)
+			cl.send(newMessage(1, displayDeleteID).putUint(m.object))
+		}
+	case kindDevice:
+		sel := Clipboard
+		if m.opcode == deviceSetPrimarySelection {
+			sel = Primary
+		}
+
+		var src *fakeSource
+		if id := d.uint(); id != 0 {
+			src = fc.offers[cl][id]
+		}
+		if old := fc.owners[sel]; old != nil && old != src {
+			old.client.send(newMessage(old.id, sourceCancelled))
+		}
+		if src == nil {
+			delete(fc.owners, sel)
+		} else {
+			fc.owners[sel] = src
+		}
+		fc.broadcast(sel)
+	case kindOffer:
+		src := fc.offers[cl][m.object]
+		if m.opcode != offerReceive {
+			delete(fc.offers[cl], m.object)
+
+			return
+		}
+
+		mime, fd := d.string(), d.fd()
+		src.client.send(newMessage(src.id, sourceSend).putString(mime).putFd(fd))
+		_ = syscall.Close(fd)
+	}
+}
+
+// broadcast announces the selection to all clients.
+func (fc *fakeCompositor) broadcast(sel Selection) {
+	for _, cl := range fc.clients {
+		fc.announce(cl, sel)
+	}
+}
+
+func (fc *fakeCompositor) announce(cl *fakeClient, sel Selection) {
+	if cl.device == 0 {
+		return
+	}
+
+	op := uint16(deviceSelection)
+	if sel == Primary {
+		op = devicePrimarySelection
+	}
+
+	src := fc.owners[sel]
+	if src == nil {
+		cl.send(newMessage(cl.device, op).putUint(0))
+
+		return
+	}
+
+	id := cl.nextID
+	cl.nextID++
+	cl.objects[id] = kindOffer
+	fc.offers[cl][id] = src
+
+	cl.send(newMessage(cl.device, deviceDataOffer).putUint(id))
+	for _, mime := range src.mimes {
+		cl.send(newMessage(id, offerOffer).putString(mime))
+	}
+	cl.send(newMessage(cl.device, op).putUint(id))
+}
+
+func (fc *fakeCompositor) disconnect(cl *fakeClient) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	for sel, owner := range fc.owners {
+		if owner.client == cl {
+			delete(fc.owners, sel)
+			fc.broadcast(sel)
+		}
+	}
+	for i, c := range fc.clients {
+		if c == cl {
+			fc.clients = append(fc.clients[:i], fc.clients[i+1:]...)
+
+			break
+		}
+	}
+}
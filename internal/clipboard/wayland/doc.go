@@ -0,0 +1,5 @@
+// Package wayland implements a minimal Wayland client for the data control
+// protocols (ext-data-control-v1 and wlr-data-control-unstable-v1). They
+// allow a client without a surface to own and read the clipboard and the
+// primary selection.
+package wayland
@@ -0,0 +1,509 @@
+//go:build !windows
+
+package wayland
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// Selection is the clipboard or the primary selection.
+type Selection int
+
+const (
+	// Clipboard is the regular clipboard (Ctrl+C / Ctrl+V).
+	Clipboard Selection = iota
+	// Primary is the primary selection (select / middle click).
+	Primary
+)
+
+// HintMIME is the MIME type offered to tell clipboard history managers to
+// skip the content.
+const HintMIME = "x-kde-passwordManagerHint"
+
+var (
+	// ErrNoDisplay is returned if WAYLAND_DISPLAY is not set.
+	ErrNoDisplay = errors.New("WAYLAND_DISPLAY is not set")
+	// ErrNotSupported is returned if the compositor does not support the data
+	// control protocol or the primary selection.
+	ErrNotSupported = errors.New("data control protocol not supported by the compositor")
+
+	textMIMEs = []string{"text/plain;charset=utf-8", "text/plain", "UTF8_STRING", "STRING", "TEXT"}
+
+	readTimeout = 5 * time.Second
+)
+
+// object kinds, the interface of an object id.
+type kind int
+
+const (
+	kindCallback kind = iota + 1
+	kindRegistry
+	kindSeat
+	kindManager
+	kindDevice
+	kindSource
+	kindOffer
+)
+
+// opcodes of requests and events.
+const (
+	displaySync        = 0
+	displayGetRegistry = 1
+	displayError       = 0
+	displayDeleteID    = 1
+
+	registryBind   = 0
+	registryGlobal = 0
+
+	managerCreateSource = 0
+	managerGetDevice    = 1
+
+	deviceSetSelection        = 0
+	deviceSetPrimarySelection = 2
+	deviceDataOffer           = 0
+	deviceSelection           = 1
+	deviceFinished            = 2
+	devicePrimarySelection    = 3
+
+	sourceOffer     = 0
+	sourceDestroy   = 1
+	sourceSend      = 0
+	sourceCancelled = 1
+
+	offerReceive = 0
+	offerDestroy = 1
+	offerOffer   = 0
+)
+
+// managers lists the supported data control managers, preferred first, with
+// the version that supports the primary selection.
+var managers = []struct {
+	name    string
+	primary uint32
+}{
+	{"ext_data_control_manager_v1", 1},
+	{"zwlr_data_control_manager_v1", 2},
+}
+
+type global struct {
+	name    uint32
+	iface   string
+	version uint32
+}
+
+// Client is a connection to a Wayland compositor that supports the data
+// control protocol.
+type Client struct {
+	w       *wire
+	nextID  uint32
+	objects map[uint32]kind
+	globals []global
+	done    map[uint32]bool
+
+	device  uint32
+	primary bool
+
+	offers    map[uint32][]string
+	selection map[Selection]uint32
+
+	sources map[uint32]Selection
+	content []byte
+}
+
+// Dial connects to the compositor given by WAYLAND_DISPLAY.
+func Dial() (*Client, error) {
+	name := os.Getenv("WAYLAND_DISPLAY")
+	if name == "" {
+		return nil, ErrNoDisplay
+	}
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(os.Getenv("XDG_RUNTIME_DIR"), name)
+	}
+
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: name, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", name, err)
+	}
+
+	c := &Client{
+		w:         &wire{conn: conn},
+		nextID:    2, // 1 is the display
+		objects:   make(map[uint32]kind, 8),
+		done:      make(map[uint32]bool, 1),
+		offers:    make(map[uint32][]string, 2),
+		selection: make(map[Selection]uint32, 2),
+		sources:   make(map[uint32]Selection, 2),
+	}
+
+	if err := c.init(); err != nil {
+		_ = c.Close()
+
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Client) init() error {
+	registry := c.newID(kindRegistry)
+	if err := c.w.send(newMessage(1, displayGetRegistry).putUint(registry)); err != nil {
+		return err
+	}
+	if err := c.roundtrip(); err != nil {
+		return err
+	}
+
+	var seat, manager *global
+	var primary uint32
+	for i, g := range c.globals {
+		if g.iface == "wl_seat" && seat == nil {
+			seat = &c.globals[i]
+		}
+	}
+	for _, m := range managers {
+		idx := slices.IndexFunc(c.globals, func(g global) bool { return g.iface == m.name })
+		if idx >= 0 {
+			manager = &c.globals[idx]
+			primary = m.primary
+
+			break
+		}
+	}
+	if seat == nil || manager == nil {
+		return ErrNotSupported
+	}
+
+	seatID := c.newID(kindSeat)
+	if err := c.bind(registry, *seat, 1, seatID); err != nil {
+		return err
+	}
+
+	managerID := c.newID(kindManager)
+	version := min(manager.version, primary)
+	if err := c.bind(registry, *manager, version, managerID); err != nil {
+		return err
+	}
+	c.primary = version >= primary
+
+	c.device = c.newID(kindDevice)
+	if err := c.w.send(newMessage(managerID, managerGetDevice).putUint(c.device).putUint(seatID)); err != nil {
+		return err
+	}
+
+	// receive the current selections
+	return c.roundtrip()
+}
+
+func (c *Client) bind(registry uint32, g global, version, id uint32) error {
+	return c.w.send(newMessage(registry, registryBind).putUint(g.name).putString(g.iface).putUint(version).putUint(id))
+}
+
+// Close closes the connection. Selections owned by the client are dropped
+// by the compositor.
+func (c *Client) Close() error {
+	return c.w.close()
+}
+
+// Read returns the current content of the selection.
+func (c *Client) Read(sel Selection) ([]byte, error) {
+	if sel == Primary && !c.primary {
+		return nil, ErrNotSupported
+	}
+
+	if err := c.roundtrip(); err != nil {
+		return nil, err
+	}
+
+	offer := c.selection[sel]
+	if offer == 0 {
+		return nil, nil
+	}
+
+	idx := slices.IndexFunc(textMIMEs, func(m string) bool { return slices.Contains(c.offers[offer], m) })
+	if idx < 0 {
+		return nil, fmt.Errorf("selection has no text content")
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe: %w", err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	err = c.w.send(newMessage(offer, offerReceive).putString(textMIMEs[idx]).putFd(int(w.Fd())))
+	_ = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	// make sure the compositor got the request before waiting for the data.
+	if err := c.roundtrip(); err != nil {
+		return nil, err
+	}
+
+	if err := r.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+		debug.Log("failed to set read deadline: %s", err)
+	}
+
+	return io.ReadAll(r)
+}
+
+// Supports reports whether the compositor supports the selection.
+func (c *Client) Supports(sel Selection) bool {
+	return sel == Clipboard || c.primary
+}
+
+// Serve takes over the selections with content and answers paste requests
+// until the context is done or other clients took over all selections. The
+// content is offered as text and with the password manager hint.
+//
+// When the context is done, the selections that are still owned are
+// cleared. Serve reports whether that happened. ready is called once the
+// selections are owned.
+func (c *Client) Serve(ctx context.Context, content []byte, sels []Selection, ready func()) (bool, error) {
+	c.content = content
+
+	for _, sel := range sels {
+		if !c.Supports(sel) {
+			return false, ErrNotSupported
+		}
+
+		if err := c.own(sel); err != nil {
+			return false, err
+		}
+	}
+	if err := c.roundtrip(); err != nil {
+		return false, err
+	}
+	if ready != nil {
+		ready()
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// unblock the pending read
+			_ = c.w.conn.SetReadDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
+	for len(c.sources) > 0 {
+		if err := c.dispatch(); err != nil {
+			if ctx.Err() != nil && errors.Is(err, os.ErrDeadlineExceeded) {
+				break
+			}
+
+			return false, err
+		}
+	}
+
+	if len(c.sources) < 1 {
+		debug.Log("all selections were taken over by other clients")
+
+		return false, nil
+	}
+
+	if err := c.w.conn.SetReadDeadline(time.Time{}); err != nil {
+		return false, fmt.Errorf("failed to reset deadline: %w", err)
+	}
+
+	for src, sel := range c.sources {
+		if err := c.setSelection(sel, 0); err != nil {
+			return false, err
+		}
+		if err := c.w.send(newMessage(src, sourceDestroy)); err != nil {
+			return false, err
+		}
+		delete(c.sources, src)
+	}
+
+	return true, c.roundtrip()
+}
+
+func (c *Client) own(sel Selection) error {
+	src := c.newID(kindSource)
+	if err := c.w.send(newMessage(c.objectID(kindManager), managerCreateSource).putUint(src)); err != nil {
+		return err
+	}
+
+	for _, mime := range append(slices.Clone(textMIMEs), HintMIME) {
+		if err := c.w.send(newMessage(src, sourceOffer).putString(mime)); err != nil {
+			return err
+		}
+	}
+	c.sources[src] = sel
+
+	return c.setSelection(sel, src)
+}
+
+func (c *Client) setSelection(sel Selection, src uint32) error {
+	op := uint16(deviceSetSelection)
+	if sel == Primary {
+		op = deviceSetPrimarySelection
+	}
+
+	return c.w.send(newMessage(c.device, op).putUint(src))
+}
+
+func (c *Client) objectID(k kind) uint32 {
+	for id, ok := range c.objects {
+		if ok == k {
+			return id
+		}
+	}
+
+	return 0
+}
+
+func (c *Client) newID(k kind) uint32 {
+	id := c.nextID
+	c.nextID++
+	c.objects[id] = k
+
+	return id
+}
+
+// roundtrip waits until the compositor processed all requests sent so far.
+func (c *Client) roundtrip() error {
+	cb := c.newID(kindCallback)
+	if err := c.w.send(newMessage(1, displaySync).putUint(cb)); err != nil {
+		return err
+	}
+
+	for !c.done[cb] {
+		if err := c.dispatch(); err != nil {
+			return err
+		}
+	}
+	delete(c.done, cb)
+
+	return nil
+}
+
+func (c *Client) nfds(object uint32, opcode uint16) int {
+	if c.objects[object] == kindSource && opcode == sourceSend {
+		return 1
+	}
+
+	return 0
+}
+
+// dispatch receives and handles one event.
+func (c *Client) dispatch() error {
+	m, err := c.w.receive(c.nfds)
+	if err != nil {
+		return err
+	}
+	d := &decoder{m: m}
+
+	if m.object == 1 {
+		switch m.opcode {
+		case displayError:
+			obj, code, msg := d.uint(), d.uint(), d.string()
+
+			return fmt.Errorf("wayland error on object %d (code %d): %s", obj, code, msg)
+		case displayDeleteID:
+			delete(c.objects, d.uint())
+		}
+
+		return d.err
+	}
+
+	switch c.objects[m.object] {
+	case kindCallback:
+		c.done[m.object] = true
+	case kindRegistry:
+		if m.opcode == registryGlobal {
+			c.globals = append(c.globals, global{name: d.uint(), iface: d.string(), version: d.uint()})
+		}
+	case kindDevice:
+		return c.handleDevice(m.opcode, d)
+	case kindOffer:
+		if m.opcode == offerOffer {
+			c.offers[m.object] = append(c.offers[m.object], d.string())
+		}
+	case kindSource:
+		return c.handleSource(m.object, m.opcode, d)
+	default:
+	}
+
+	return d.err
+}
+
+func (c *Client) handleDevice(opcode uint16, d *decoder) error {
+	switch opcode {
+	case deviceDataOffer:
+		id := d.uint()
+		c.objects[id] = kindOffer
+		c.offers[id] = nil
+	case deviceSelection:
+		return c.setOffer(Clipboard, d.uint())
+	case devicePrimarySelection:
+		return c.setOffer(Primary, d.uint())
+	case deviceFinished:
+		return fmt.Errorf("data device is no longer valid")
+	}
+
+	return d.err
+}
+
+// setOffer records the offer of a selection and destroys the previous one.
+func (c *Client) setOffer(sel Selection, offer uint32) error {
+	old := c.selection[sel]
+	c.selection[sel] = offer
+	if old == 0 || old == offer || old == c.selection[Clipboard] || old == c.selection[Primary] {
+		return nil
+	}
+
+	delete(c.offers, old)
+	delete(c.objects, old)
+
+	return c.w.send(newMessage(old, offerDestroy))
+}
+
+func (c *Client) handleSource(src uint32, opcode uint16, d *decoder) error {
+	switch opcode {
+	case sourceSend:
+		mime, fd := d.string(), d.fd()
+		if d.err != nil {
+			return d.err
+		}
+		f := os.NewFile(uintptr(fd), "wayland-send")
+		defer func() {
+			_ = f.Close()
+		}()
+
+		data := c.content
+		if mime == HintMIME {
+			data = []byte("secret")
+		}
+		if _, err := f.Write(data); err != nil && !errors.Is(err, syscall.EPIPE) {
+			debug.Log("failed to send selection: %s", err)
+		}
+	case sourceCancelled:
+		if _, found := c.sources[src]; !found {
+			return nil
+		}
+		debug.Log("selection %d was taken over", c.sources[src])
+		delete(c.sources, src)
+
+		return c.w.send(newMessage(src, sourceDestroy))
+	}
+
+	return d.err
+}
@@ -0,0 +1,166 @@
+//go:build !windows
+
+package wayland
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage(t *testing.T) {
+	t.Parallel()
+
+	m := newMessage(3, 2).putUint(42).putString("text/plain").putString("abc")
+	b := m.bytes()
+	assert.Len(t, b, 8+4+4+12+4+4)
+
+	d := &decoder{m: &message{args: m.args}}
+	assert.Equal(t, uint32(42), d.uint())
+	assert.Equal(t, "text/plain", d.string())
+	assert.Equal(t, "abc", d.string())
+	require.NoError(t, d.err)
+
+	d.uint()
+	require.ErrorIs(t, d.err, errShortMessage)
+}
+
+// serve starts serving content and waits until the selections are owned.
+func serve(t *testing.T, ctx context.Context, content string, sels ...Selection) <-chan bool {
+	t.Helper()
+
+	c, err := Dial()
+	require.NoError(t, err)
+
+	ready := make(chan struct{})
+	cleared := make(chan bool, 1)
+	go func() {
+		defer func() {
+			_ = c.Close()
+		}()
+
+		ok, err := c.Serve(ctx, []byte(content), sels, func() { close(ready) })
+		assert.NoError(t, err)
+		cleared <- ok
+	}()
+
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for selection")
+	}
+
+	return cleared
+}
+
+func TestServe(t *testing.T) {
+	newFakeCompositor(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cleared := serve(t, ctx, "s3cret", Clipboard)
+
+	c, err := Dial()
+	require.NoError(t, err)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	buf, err := c.Read(Clipboard)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", string(buf))
+	assert.Contains(t, c.offers[c.selection[Clipboard]], HintMIME)
+
+	buf, err = c.Read(Primary)
+	require.NoError(t, err)
+	assert.Empty(t, buf)
+
+	cancel()
+	assert.True(t, <-cleared)
+
+	buf, err = c.Read(Clipboard)
+	require.NoError(t, err)
+	assert.Empty(t, buf)
+}
+
+func TestServePrimary(t *testing.T) {
+	newFakeCompositor(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	serve(t, ctx, "s3cret", Clipboard, Primary)
+
+	c, err := Dial()
+	require.NoError(t, err)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	for _, sel := range []Selection{Clipboard, Primary} {
+		buf, err := c.Read(sel)
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", string(buf))
+	}
+}
+
+func TestServeReplaced(t *testing.T) {
+	newFakeCompositor(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := serve(t, ctx, "first", Clipboard)
+	serve(t, ctx, "second", Clipboard)
+
+	// the first client lost the selection and must not clear it.
+	select {
+	case cleared := <-first:
+		assert.False(t, cleared)
+	case <-time.After(5 * time.Second):
+		t.Fatal("first client still serving")
+	}
+
+	c, err := Dial()
+	require.NoError(t, err)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	buf, err := c.Read(Clipboard)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(buf))
+}
+
+func TestDial(t *testing.T) {
+	t.Run("no display", func(t *testing.T) {
+		t.Setenv("WAYLAND_DISPLAY", "")
+
+		_, err := Dial()
+		require.ErrorIs(t, err, ErrNoDisplay)
+	})
+
+	t.Run("no data control", func(t *testing.T) {
+		newFakeCompositor(t, global{name: 1, iface: "wl_seat", version: 7})
+
+		_, err := Dial()
+		require.ErrorIs(t, err, ErrNotSupported)
+	})
+
+	t.Run("no primary selection", func(t *testing.T) {
+		newFakeCompositor(t,
+			global{name: 1, iface: "wl_seat", version: 7},
+			global{name: 2, iface: "zwlr_data_control_manager_v1", version: 1},
+		)
+
+		c, err := Dial()
+		require.NoError(t, err)
+		defer func() {
+			_ = c.Close()
+		}()
+
+		assert.True(t, c.Supports(Clipboard))
+		assert.False(t, c.Supports(Primary))
+	})
+}
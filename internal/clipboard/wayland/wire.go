@@ -0,0 +1,235 @@
+//go:build !windows
+
+package wayland
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// headerSize is the size of a message header: the object id, the opcode
+// and the message size.
+const headerSize = 8
+
+var errShortMessage = errors.New("short message")
+
+// message is a request or an event of the Wayland wire protocol.
+type message struct {
+	object uint32
+	opcode uint16
+	args   []byte
+	fds    []int
+}
+
+// newMessage starts a message for the given object and opcode. Arguments
+// are appended with the put methods.
+func newMessage(object uint32, opcode uint16) *message {
+	return &message{
+		object: object,
+		opcode: opcode,
+	}
+}
+
+func (m *message) putUint(v uint32) *message {
+	m.args = binary.NativeEndian.AppendUint32(m.args, v)
+
+	return m
+}
+
+// putString appends a NUL terminated string padded to 32 bits.
+func (m *message) putString(s string) *message {
+	m.putUint(uint32(len(s) + 1))
+	m.args = append(m.args, s...)
+	m.args = append(m.args, make([]byte, pad(len(s)+1)-len(s))...)
+
+	return m
+}
+
+// putFd attaches a file descriptor. It is sent as ancillary data and
+// takes no space in the message body.
+func (m *message) putFd(fd int) *message {
+	m.fds = append(m.fds, fd)
+
+	return m
+}
+
+// bytes encodes the message without the file descriptors.
+func (m *message) bytes() []byte {
+	b := make([]byte, 0, headerSize+len(m.args))
+	b = binary.NativeEndian.AppendUint32(b, m.object)
+	b = binary.NativeEndian.AppendUint32(b, uint32(headerSize+len(m.args))<<16|uint32(m.opcode))
+
+	return append(b, m.args...)
+}
+
+// decoder reads the arguments of a received message.
+type decoder struct {
+	m   *message
+	err error
+}
+
+func (d *decoder) uint() uint32 {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.m.args) < 4 {
+		d.err = errShortMessage
+
+		return 0
+	}
+
+	v := binary.NativeEndian.Uint32(d.m.args)
+	d.m.args = d.m.args[4:]
+
+	return v
+}
+
+func (d *decoder) string() string {
+	n := int(d.uint())
+	if d.err != nil || n == 0 {
+		return ""
+	}
+	if len(d.m.args) < pad(n) {
+		d.err = errShortMessage
+
+		return ""
+	}
+
+	s := string(d.m.args[:n-1])
+	d.m.args = d.m.args[pad(n):]
+
+	return s
+}
+
+func (d *decoder) fd() int {
+	if d.err != nil {
+		return -1
+	}
+	if len(d.m.fds) < 1 {
+		d.err = fmt.Errorf("missing file descriptor")
+
+		return -1
+	}
+
+	fd := d.m.fds[0]
+	d.m.fds = d.m.fds[1:]
+
+	return fd
+}
+
+// wire sends and receives messages on a Wayland socket. File descriptors
+// are passed as SCM_RIGHTS ancillary data.
+type wire struct {
+	conn *net.UnixConn
+	buf  []byte
+	fds  []int
+}
+
+func (w *wire) send(m *message) error {
+	var oob []byte
+	if len(m.fds) > 0 {
+		oob = syscall.UnixRights(m.fds...)
+	}
+
+	if _, _, err := w.conn.WriteMsgUnix(m.bytes(), oob, nil); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return nil
+}
+
+// receive returns the next message. Fds of messages that take
+// fewer fds than received are handed to the following messages, so
+// nfds tells how many fds the message with the given object and opcode
+// carries.
+func (w *wire) receive(nfds func(object uint32, opcode uint16) int) (*message, error) {
+	for {
+		if m, ok := w.decode(nfds); ok {
+			return m, nil
+		}
+
+		buf := make([]byte, 4096)
+		oob := make([]byte, syscall.CmsgSpace(28*4))
+		n, oobn, _, _, err := w.conn.ReadMsgUnix(buf, oob)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("connection closed")
+		}
+
+		w.buf = append(w.buf, buf[:n]...)
+		if oobn > 0 {
+			fds, err := parseRights(oob[:oobn])
+			if err != nil {
+				return nil, err
+			}
+			w.fds = append(w.fds, fds...)
+		}
+	}
+}
+
+func (w *wire) decode(nfds func(uint32, uint16) int) (*message, bool) {
+	if len(w.buf) < headerSize {
+		return nil, false
+	}
+
+	hdr := binary.NativeEndian.Uint32(w.buf[4:])
+	size := int(hdr >> 16)
+	if size < headerSize || len(w.buf) < size {
+		return nil, false
+	}
+
+	m := &message{
+		object: binary.NativeEndian.Uint32(w.buf),
+		opcode: uint16(hdr & 0xffff),
+		args:   append([]byte(nil), w.buf[headerSize:size]...),
+	}
+
+	if n := nfds(m.object, m.opcode); n > 0 {
+		if len(w.fds) < n {
+			return nil, false
+		}
+		m.fds = w.fds[:n:n]
+		w.fds = w.fds[n:]
+	}
+	w.buf = w.buf[size:]
+
+	return m, true
+}
+
+// close closes the connection and all received but unused fds.
+func (w *wire) close() error {
+	for _, fd := range w.fds {
+		_ = syscall.Close(fd)
+	}
+	w.fds = nil
+
+	return w.conn.Close()
+}
+
+func parseRights(oob []byte) ([]int, error) {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse control message: %w", err)
+	}
+
+	var fds []int
+	for _, msg := range msgs {
+		rights, err := syscall.ParseUnixRights(&msg)
+		if err != nil {
+			continue
+		}
+		fds = append(fds, rights...)
+	}
+
+	return fds, nil
+}
+
+// pad rounds n up to a multiple of 32 bits.
+func pad(n int) int {
+	return (n + 3) &^ 3
+}
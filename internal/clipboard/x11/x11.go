@@ -0,0 +1,301 @@
+// Package x11 implements an X11 client that owns and reads the clipboard and
+// the primary selection.
+package x11
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// Selection is the clipboard or the primary selection.
+type Selection int
+
+const (
+	// Clipboard is the CLIPBOARD selection (Ctrl+C / Ctrl+V).
+	Clipboard Selection = iota
+	// Primary is the PRIMARY selection (select / middle click).
+	Primary
+)
+
+// HintTarget is the target offered to tell clipboard history managers to
+// skip the content.
+const HintTarget = "x-kde-passwordManagerHint"
+
+// maxContent is the largest content that fits into a single property
+// change without the INCR protocol.
+const maxContent = 1 << 17
+
+var (
+	// ErrNoDisplay is returned if DISPLAY is not set.
+	ErrNoDisplay = errors.New("DISPLAY is not set")
+
+	textTargets = []string{"UTF8_STRING", "text/plain;charset=utf-8", "text/plain", "STRING", "TEXT"}
+	atomNames   = append([]string{"CLIPBOARD", "TARGETS", "INCR", "GOPASS_SELECTION", HintTarget}, textTargets...)
+
+	readTimeout = 5 * time.Second
+)
+
+// Client is a connection to an X server with an invisible window that is
+// used to own and convert selections.
+type Client struct {
+	conn   *xgb.Conn
+	win    xproto.Window
+	atoms  map[string]xproto.Atom
+	events chan xgb.Event
+	closed chan struct{}
+}
+
+// Dial connects to the X server given by DISPLAY.
+func Dial() (*Client, error) {
+	if os.Getenv("DISPLAY") == "" {
+		return nil, ErrNoDisplay
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to X server: %w", err)
+	}
+
+	c := &Client{
+		conn:   conn,
+		atoms:  make(map[string]xproto.Atom, len(atomNames)),
+		events: make(chan xgb.Event),
+		closed: make(chan struct{}),
+	}
+
+	if err := c.init(); err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	go c.pump()
+
+	return c, nil
+}
+
+func (c *Client) init() error {
+	win, err := xproto.NewWindowId(c.conn)
+	if err != nil {
+		return fmt.Errorf("failed to allocate window: %w", err)
+	}
+
+	screen := xproto.Setup(c.conn).DefaultScreen(c.conn)
+	if err := xproto.CreateWindowChecked(c.conn, 0, win, screen.Root, 0, 0, 1, 1, 0,
+		xproto.WindowClassInputOnly, 0, xproto.CwEventMask, []uint32{xproto.EventMaskPropertyChange}).Check(); err != nil {
+		return fmt.Errorf("failed to create window: %w", err)
+	}
+	c.win = win
+
+	for _, name := range atomNames {
+		r, err := xproto.InternAtom(c.conn, false, uint16(len(name)), name).Reply()
+		if err != nil {
+			return fmt.Errorf("failed to intern atom %s: %w", name, err)
+		}
+		c.atoms[name] = r.Atom
+	}
+	c.atoms["STRING"] = xproto.AtomString
+
+	return nil
+}
+
+// pump forwards events until the connection is closed.
+func (c *Client) pump() {
+	defer close(c.events)
+
+	for {
+		ev, xerr := c.conn.WaitForEvent()
+		if ev == nil && xerr == nil {
+			return
+		}
+		if xerr != nil {
+			debug.Log("X error: %s", xerr)
+
+			continue
+		}
+
+		select {
+		case c.events <- ev:
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+// Close closes the connection. Selections owned by the client are dropped
+// by the X server.
+func (c *Client) Close() error {
+	close(c.closed)
+	c.conn.Close()
+
+	return nil
+}
+
+func (c *Client) selection(sel Selection) xproto.Atom {
+	if sel == Primary {
+		return xproto.AtomPrimary
+	}
+
+	return c.atoms["CLIPBOARD"]
+}
+
+// Read returns the current content of the selection as text.
+func (c *Client) Read(sel Selection) ([]byte, error) {
+	return c.read(sel, "UTF8_STRING")
+}
+
+func (c *Client) read(sel Selection, target string) ([]byte, error) {
+	prop := c.atoms["GOPASS_SELECTION"]
+	xproto.ConvertSelection(c.conn, c.win, c.selection(sel), c.atoms[target], prop, xproto.TimeCurrentTime)
+
+	timeout := time.After(readTimeout)
+	for {
+		select {
+		case ev, ok := <-c.events:
+			if !ok {
+				return nil, fmt.Errorf("connection closed")
+			}
+			n, ok := ev.(xproto.SelectionNotifyEvent)
+			if !ok || n.Requestor != c.win {
+				continue
+			}
+			if n.Property == xproto.AtomNone {
+				// no owner or the owner does not support the target
+				return nil, nil
+			}
+
+			r, err := xproto.GetProperty(c.conn, true, c.win, prop, xproto.GetPropertyTypeAny, 0, maxContent/4).Reply()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get property: %w", err)
+			}
+			if r.Type == c.atoms["INCR"] {
+				return nil, fmt.Errorf("selection too large")
+			}
+
+			return r.Value, nil
+		case <-timeout:
+			return nil, fmt.Errorf("timeout waiting for selection owner")
+		}
+	}
+}
+
+// Serve takes over the selections with content and answers paste requests
+// until the context is done or other clients took over all selections. The
+// content is offered as text and with the password manager hint.
+//
+// When the context is done, the selections that are still owned are
+// cleared. Serve reports whether that happened. ready is called once the
+// selections are owned.
+func (c *Client) Serve(ctx context.Context, content []byte, sels []Selection, ready func()) (bool, error) {
+	if len(content) > maxContent {
+		return false, fmt.Errorf("content too large")
+	}
+
+	owned := make(map[xproto.Atom]bool, len(sels))
+	for _, sel := range sels {
+		atom := c.selection(sel)
+		xproto.SetSelectionOwner(c.conn, c.win, atom, xproto.TimeCurrentTime)
+
+		r, err := xproto.GetSelectionOwner(c.conn, atom).Reply()
+		if err != nil {
+			return false, fmt.Errorf("failed to get selection owner: %w", err)
+		}
+		if r.Owner != c.win {
+			return false, fmt.Errorf("failed to own selection")
+		}
+		owned[atom] = true
+	}
+	if ready != nil {
+		ready()
+	}
+
+	for len(owned) > 0 {
+		select {
+		case <-ctx.Done():
+			for atom := range owned {
+				xproto.SetSelectionOwner(c.conn, xproto.WindowNone, atom, xproto.TimeCurrentTime)
+			}
+			// wait until the X server processed the requests.
+			if _, err := xproto.GetInputFocus(c.conn).Reply(); err != nil {
+				return false, fmt.Errorf("failed to clear selection: %w", err)
+			}
+
+			return true, nil
+		case ev, ok := <-c.events:
+			if !ok {
+				return false, fmt.Errorf("connection closed")
+			}
+
+			switch e := ev.(type) {
+			case xproto.SelectionRequestEvent:
+				c.answer(e, content)
+			case xproto.SelectionClearEvent:
+				debug.Log("selection %d was taken over", e.Selection)
+				delete(owned, e.Selection)
+			}
+		}
+	}
+
+	debug.Log("all selections were taken over by other clients")
+
+	return false, nil
+}
+
+// answer converts the selection to the requested target.
+func (c *Client) answer(e xproto.SelectionRequestEvent, content []byte) {
+	prop := e.Property
+	if prop == xproto.AtomNone {
+		// obsolete clients
+		prop = e.Target
+	}
+
+	switch e.Target {
+	case c.atoms["TARGETS"]:
+		targets := []xproto.Atom{c.atoms["TARGETS"], c.atoms[HintTarget]}
+		for _, t := range textTargets {
+			targets = append(targets, c.atoms[t])
+		}
+
+		data := make([]byte, 0, 4*len(targets))
+		for _, t := range targets {
+			data = binary.NativeEndian.AppendUint32(data, uint32(t))
+		}
+		xproto.ChangeProperty(c.conn, xproto.PropModeReplace, e.Requestor, prop, xproto.AtomAtom, 32, uint32(len(targets)), data)
+	case c.atoms[HintTarget]:
+		xproto.ChangeProperty(c.conn, xproto.PropModeReplace, e.Requestor, prop, e.Target, 8, 6, []byte("secret"))
+	default:
+		if !c.isText(e.Target) {
+			prop = xproto.AtomNone
+
+			break
+		}
+		xproto.ChangeProperty(c.conn, xproto.PropModeReplace, e.Requestor, prop, e.Target, 8, uint32(len(content)), content)
+	}
+
+	n := xproto.SelectionNotifyEvent{
+		Time:      e.Time,
+		Requestor: e.Requestor,
+		Selection: e.Selection,
+		Target:    e.Target,
+		Property:  prop,
+	}
+	xproto.SendEvent(c.conn, false, e.Requestor, xproto.EventMaskNoEvent, string(n.Bytes()))
+}
+
+func (c *Client) isText(target xproto.Atom) bool {
+	for _, t := range textTargets {
+		if c.atoms[t] == target {
+			return true
+		}
+	}
+
+	return false
+}
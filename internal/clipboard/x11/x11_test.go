@@ -0,0 +1,113 @@
+package x11
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jezek/xgb/xproto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests need an X server, e.g. xvfb-run go test ./internal/clipboard/x11/.
+func dial(t *testing.T) *Client {
+	t.Helper()
+
+	if os.Getenv("DISPLAY") == "" {
+		t.Skip("no X server, set DISPLAY or use xvfb-run")
+	}
+
+	c, err := Dial()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+
+	return c
+}
+
+// serve starts serving content and waits until the selections are owned.
+func serve(t *testing.T, ctx context.Context, content string, sels ...Selection) <-chan bool {
+	t.Helper()
+
+	c := dial(t)
+
+	ready := make(chan struct{})
+	cleared := make(chan bool, 1)
+	go func() {
+		ok, err := c.Serve(ctx, []byte(content), sels, func() { close(ready) })
+		assert.NoError(t, err)
+		cleared <- ok
+	}()
+
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for selection")
+	}
+
+	return cleared
+}
+
+func TestServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cleared := serve(t, ctx, "s3cret", Clipboard, Primary)
+	c := dial(t)
+
+	for _, sel := range []Selection{Clipboard, Primary} {
+		buf, err := c.Read(sel)
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", string(buf))
+	}
+
+	buf, err := c.read(Clipboard, HintTarget)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(buf))
+
+	buf, err = c.read(Clipboard, "TARGETS")
+	require.NoError(t, err)
+	var targets []xproto.Atom
+	for i := 0; i+4 <= len(buf); i += 4 {
+		targets = append(targets, xproto.Atom(binary.NativeEndian.Uint32(buf[i:])))
+	}
+	assert.Contains(t, targets, c.atoms[HintTarget])
+	assert.Contains(t, targets, c.atoms["UTF8_STRING"])
+
+	cancel()
+	assert.True(t, <-cleared)
+
+	buf, err = c.Read(Clipboard)
+	require.NoError(t, err)
+	assert.Empty(t, buf)
+}
+
+func TestServeReplaced(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := serve(t, ctx, "first", Clipboard)
+	serve(t, ctx, "second", Clipboard)
+
+	select {
+	case cleared := <-first:
+		assert.False(t, cleared)
+	case <-time.After(5 * time.Second):
+		t.Fatal("first client still serving")
+	}
+
+	buf, err := dial(t).Read(Clipboard)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(buf))
+}
+
+func TestDialNoDisplay(t *testing.T) {
+	t.Setenv("DISPLAY", "")
+
+	_, err := Dial()
+	require.ErrorIs(t, err, ErrNoDisplay)
+}
@@ -59,6 +59,7 @@ var defaults = map[string]string{
 	"core.follow-references": "false",
 	"pwgen.xkcd-lang":        "en",
 	"show.fuzzysearch":       "true",
+	"tui.idle-timeout":       "300",
 }
 
 // Config is a gopass config handler.
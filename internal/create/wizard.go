@@ -16,6 +16,7 @@ import (
 	"github.com/gopasspw/gopass/internal/editor"
 	"github.com/gopasspw/gopass/internal/hook"
 	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/internal/policy"
 	"github.com/gopasspw/gopass/internal/store/root"
 	"github.com/gopasspw/gopass/internal/tpl"
 	"github.com/gopasspw/gopass/pkg/ctxutil"
@@ -24,9 +25,9 @@ import (
 	"github.com/gopasspw/gopass/pkg/gopass/secrets"
 	"github.com/gopasspw/gopass/pkg/pwgen"
 	"github.com/gopasspw/gopass/pkg/pwgen/pwrules"
+	"github.com/gopasspw/gopass/pkg/pwgen/xkcdgen"
 	"github.com/gopasspw/gopass/pkg/set"
 	"github.com/gopasspw/gopass/pkg/termio"
-	"github.com/martinhoefling/goxkcdpwgen/xkcdpwgen"
 	"github.com/urfave/cli/v3"
 	"go.yaml.in/yaml/v3"
 )
@@ -46,6 +47,8 @@ type Attribute struct {
 	Max          int    `yaml:"max"`
 	AlwaysPrompt bool   `yaml:"always_prompt"` // always prompt for the crendentials
 	Strict       bool   `yaml:"strict"`        // enforce character class rules (all detected classes must be present)
+	Wordlist     string `yaml:"wordlist"`      // generate a passphrase from this wordlist
+	Entropy      int    `yaml:"entropy"`       // minimum entropy in bits of a generated passphrase
 }
 
 // Template is an action template for the create wizard.
@@ -279,7 +282,7 @@ func mkActFunc(tpl Template, s *root.Store, cb ActionCallback) func(context.Cont
 				}
 
 				if genPw { //nolint:nestif
-					password, err = generatePassword(ctx, hostname, v.Charset, v.Strict)
+					password, err = generatePassword(ctx, hostname, v)
 					if err != nil {
 						return err
 					}
@@ -331,7 +334,18 @@ func mkActFunc(tpl Template, s *root.Store, cb ActionCallback) func(context.Cont
 			}
 		}
 
-		if err := s.Set(ctxutil.WithCommitMessage(ctx, "Create new entry"), name, sec); err != nil {
+		ctx = ctxutil.WithCommitMessage(ctx, "Create new entry")
+
+		// force also overrides the password policy of the store.
+		if password != "" {
+			var err error
+			ctx, err = policy.Enforce(ctx, s, name, password, force)
+			if err != nil {
+				return exit.Error(exit.Policy, err, "%s. Use --force to override it", err)
+			}
+		}
+
+		if err := s.Set(ctx, name, sec); err != nil {
 			return fmt.Errorf("failed to set %q: %w", name, err)
 		}
 		out.OKf(ctx, "Credentials saved to %q", name)
@@ -364,10 +378,14 @@ func renderTemplate(ctx context.Context, name string, s *root.Store) ([]byte, er
 }
 
 // generatePassword will walk through the password generation steps.
-func generatePassword(ctx context.Context, hostname, charset string, strict bool) (string, error) {
+func generatePassword(ctx context.Context, hostname string, attr Attribute) (string, error) {
 	defaultLength, _ := config.DefaultPasswordLengthFromEnv(ctx)
 
-	if charset != "" {
+	if attr.Wordlist != "" || attr.Entropy > 0 {
+		return generatePasswordXKCD(ctx, attr.Wordlist, attr.Entropy)
+	}
+
+	if charset, strict := attr.Charset, attr.Strict; charset != "" {
 		length, err := termio.AskForInt(ctx, fmtfn(4, "a", "How long?"), 4)
 		if err != nil {
 			return "", err
@@ -395,7 +413,7 @@ func generatePassword(ctx context.Context, hostname, charset string, strict bool
 		return "", err
 	}
 	if xkcd {
-		return generatePasswordXKCD(ctx)
+		return generatePasswordXKCD(ctx, "", 0)
 	}
 
 	length, err := termio.AskForInt(ctx, fmtfn(4, "b", "How long?"), defaultLength)
@@ -419,30 +437,44 @@ func generatePassword(ctx context.Context, hostname, charset string, strict bool
 	return pwgen.GeneratePassword(length, symbols), nil
 }
 
-func generatePasswordXKCD(ctx context.Context) (string, error) {
-	length, err := termio.AskForInt(ctx, fmtfn(4, "b", "How many words?"), config.Int(ctx, "pwgen.xkcd-len"))
-	if err != nil {
-		return "", err
+func generatePasswordXKCD(ctx context.Context, wordlist string, minBits int) (string, error) {
+	if wordlist == "" {
+		wordlist = config.String(ctx, "pwgen.xkcd-wordlist")
 	}
-	if length < 1 {
-		length = config.DefaultXKCDLength
+	if wordlist == "" {
+		wordlist = config.String(ctx, "pwgen.xkcd-lang")
+	}
+	if minBits < 1 {
+		minBits = config.Int(ctx, "pwgen.xkcd-min-entropy")
 	}
 
-	g := xkcdpwgen.NewGenerator()
-	g.SetNumWords(length)
-
+	d, err := xkcdgen.NewDiceware(wordlist)
+	if err != nil {
+		return "", err
+	}
 	if sv := config.String(ctx, "pwgen.xkcd-sep"); sv != "" {
-		g.SetDelimiter(sv)
+		d.Delimiter = sv
 	}
+	d.Capitalize = config.Bool(ctx, "pwgen.xkcd-capitalize")
+	d.Numbers = config.Bool(ctx, "pwgen.xkcd-numbers")
 
-	g.SetCapitalize(config.Bool(ctx, "pwgen.xkcd-capitalize"))
-	g.SetRandomNumbers(config.Bool(ctx, "pwgen.xkcd-numbers"))
-
-	if sv := config.String(ctx, "pwgen.xkcd-lang"); sv != "" {
-		if err := g.UseLangWordlist(sv); err != nil {
-			return "", fmt.Errorf("failed to use wordlist for lang %s: %w", sv, err)
+	// a minimum entropy replaces the question for the number of words.
+	length := d.WordsFor(float64(minBits))
+	if minBits < 1 {
+		length, err = termio.AskForInt(ctx, fmtfn(4, "b", "How many words?"), config.Int(ctx, "pwgen.xkcd-len"))
+		if err != nil {
+			return "", err
 		}
+		if length < 1 {
+			length = config.DefaultXKCDLength
+		}
+	}
+
+	password, err := d.Generate(length)
+	if err != nil {
+		return "", err
 	}
+	out.Noticef(ctx, "Passphrase entropy: %.1f bits (%d words from a list of %d)", d.Entropy(length), length, d.Size())
 
-	return g.GeneratePasswordString(), nil
+	return password, nil
 }
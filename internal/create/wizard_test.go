@@ -2,6 +2,7 @@ package create
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/gopasspw/gopass/internal/config"
@@ -91,3 +92,21 @@ attributes:
 	assert.Equal(t, "password", w.Templates[0].Attributes[2].Type, "wrong type")
 	assert.True(t, w.Templates[0].Attributes[2].AlwaysPrompt, "wrong always_prompt")
 }
+
+func TestGeneratePasswordDiceware(t *testing.T) {
+	t.Parallel()
+
+	ctx := config.NewContextInMemory()
+
+	// 7 words of the EFF short wordlist provide 72.4 bits of entropy
+	pw, err := generatePassword(ctx, "", Attribute{Wordlist: "eff-short", Entropy: 70})
+	require.NoError(t, err)
+	assert.Len(t, strings.Fields(pw), 7, pw)
+
+	pw, err = generatePassword(ctx, "", Attribute{Entropy: 70})
+	require.NoError(t, err)
+	assert.Len(t, strings.Fields(pw), 6, pw)
+
+	_, err = generatePassword(ctx, "", Attribute{Wordlist: "does-not-exist.txt"})
+	require.Error(t, err)
+}
@@ -0,0 +1,237 @@
+package nativehost
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/gopasspw/gopass/pkg/appdir"
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// Name is the name of the native messaging host expected by gopassbridge.
+const Name = "com.justwatch.gopass"
+
+const (
+	// firefoxExtension is the ID of gopassbridge for Firefox.
+	firefoxExtension = "{eec37db0-22ad-4bf1-9068-5ae08df8c7e9}"
+	// chromeOrigin is the origin of gopassbridge for Chrome and derived
+	// browsers.
+	chromeOrigin = "chrome-extension://kkhfnlkhiapbiehimabddjbimfaijdhk/"
+)
+
+// Browser is a browser the manifest can be installed for.
+type Browser struct {
+	Name    string
+	firefox bool
+	// dirs are the manifest directories relative to the home directory
+	// by GOOS.
+	dirs map[string]string
+}
+
+// Browsers are the supported browsers.
+var Browsers = []Browser{
+	{
+		Name:    "firefox",
+		firefox: true,
+		dirs: map[string]string{
+			"linux":  ".mozilla/native-messaging-hosts",
+			"darwin": "Library/Application Support/Mozilla/NativeMessagingHosts",
+		},
+	},
+	{
+		Name:    "librewolf",
+		firefox: true,
+		dirs: map[string]string{
+			"linux":  ".librewolf/native-messaging-hosts",
+			"darwin": "Library/Application Support/LibreWolf/NativeMessagingHosts",
+		},
+	},
+	{
+		Name: "chrome",
+		dirs: map[string]string{
+			"linux":  ".config/google-chrome/NativeMessagingHosts",
+			"darwin": "Library/Application Support/Google/Chrome/NativeMessagingHosts",
+		},
+	},
+	{
+		Name: "chromium",
+		dirs: map[string]string{
+			"linux":  ".config/chromium/NativeMessagingHosts",
+			"darwin": "Library/Application Support/Chromium/NativeMessagingHosts",
+		},
+	},
+	{
+		Name: "brave",
+		dirs: map[string]string{
+			"linux":  ".config/BraveSoftware/Brave-Browser/NativeMessagingHosts",
+			"darwin": "Library/Application Support/BraveSoftware/Brave-Browser/NativeMessagingHosts",
+		},
+	},
+	{
+		Name: "vivaldi",
+		dirs: map[string]string{
+			"linux":  ".config/vivaldi/NativeMessagingHosts",
+			"darwin": "Library/Application Support/Vivaldi/NativeMessagingHosts",
+		},
+	},
+	{
+		Name: "edge",
+		dirs: map[string]string{
+			"linux":  ".config/microsoft-edge/NativeMessagingHosts",
+			"darwin": "Library/Application Support/Microsoft Edge/NativeMessagingHosts",
+		},
+	},
+}
+
+// BrowserNames returns the names of all supported browsers.
+func BrowserNames() []string {
+	names := make([]string, 0, len(Browsers))
+	for _, b := range Browsers {
+		names = append(names, b.Name)
+	}
+
+	return names
+}
+
+// LookupBrowser returns the browser with the given name.
+func LookupBrowser(name string) (Browser, bool) {
+	idx := slices.IndexFunc(Browsers, func(b Browser) bool { return b.Name == name })
+	if idx < 0 {
+		return Browser{}, false
+	}
+
+	return Browsers[idx], true
+}
+
+// ManifestPath returns the path of the manifest in the home directory.
+// Windows has no well-known location, the manifest is registered in the
+// registry instead.
+func (b Browser) ManifestPath() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(appdir.UserConfig(), "native-host", b.Name, Name+".json")
+	}
+
+	dir, found := b.dirs[runtime.GOOS]
+	if !found {
+		// other unix systems use the Linux locations.
+		dir = b.dirs["linux"]
+	}
+
+	return filepath.Join(appdir.UserHome(), filepath.FromSlash(dir), Name+".json")
+}
+
+// Installed returns true if the browser has a profile in the home
+// directory, i.e. the parent of the manifest directory exists.
+func (b Browser) Installed() bool {
+	_, err := os.Stat(filepath.Dir(filepath.Dir(b.ManifestPath())))
+
+	return err == nil
+}
+
+// RegistryKey returns the registry key to register the manifest on
+// Windows.
+func (b Browser) RegistryKey() string {
+	switch {
+	case b.firefox:
+		return `HKCU\Software\Mozilla\NativeMessagingHosts\` + Name
+	case b.Name == "edge":
+		return `HKCU\Software\Microsoft\Edge\NativeMessagingHosts\` + Name
+	default:
+		return `HKCU\Software\Google\Chrome\NativeMessagingHosts\` + Name
+	}
+}
+
+type manifest struct {
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	Path              string   `json:"path"`
+	Type              string   `json:"type"`
+	AllowedExtensions []string `json:"allowed_extensions,omitempty"`
+	AllowedOrigins    []string `json:"allowed_origins,omitempty"`
+}
+
+// Manifest returns the manifest that starts the host with the wrapper.
+func (b Browser) Manifest(wrapper string) ([]byte, error) {
+	m := manifest{
+		Name:        Name,
+		Description: "Gopass wrapper to search and return passwords",
+		Path:        wrapper,
+		Type:        "stdio",
+	}
+	if b.firefox {
+		m.AllowedExtensions = []string{firefoxExtension}
+	} else {
+		m.AllowedOrigins = []string{chromeOrigin}
+	}
+
+	buf, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	return append(buf, '\n'), nil
+}
+
+// Install writes the manifest of the browser that starts the host with the
+// wrapper and returns its path.
+func (b Browser) Install(wrapper string) (string, error) {
+	p := b.ManifestPath()
+	buf, err := b.Manifest(wrapper)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create manifest dir: %w", err)
+	}
+	if err := os.WriteFile(p, buf, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write manifest: %w", err)
+	}
+	debug.Log("installed manifest for %s at %s", b.Name, p)
+
+	return p, nil
+}
+
+// WrapperPath returns the path of the script that starts the host. Browsers
+// can not pass arguments to a native messaging host, so the manifest points
+// to this script.
+func WrapperPath() string {
+	name := "native-host.sh"
+	if runtime.GOOS == "windows" {
+		name = "native-host.bat"
+	}
+
+	return filepath.Join(appdir.UserConfig(), name)
+}
+
+// Wrapper returns the script that starts gopass native-host with the given
+// binary. Browsers start the host with a minimal environment, so common
+// install locations are added to the PATH for gpg and git.
+func Wrapper(binary string) []byte {
+	if runtime.GOOS == "windows" {
+		return []byte("@echo off\r\n\"" + binary + "\" native-host %*\r\n")
+	}
+
+	return []byte(`#!/bin/sh
+export PATH="$PATH:/usr/local/bin:/opt/homebrew/bin"
+exec '` + strings.ReplaceAll(binary, `'`, `'\''`) + `' native-host "$@"
+`)
+}
+
+// InstallWrapper writes the wrapper script for the binary.
+func InstallWrapper(binary string) (string, error) {
+	p := WrapperPath()
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create config dir: %w", err)
+	}
+	if err := os.WriteFile(p, Wrapper(binary), 0o755); err != nil {
+		return "", fmt.Errorf("failed to write wrapper: %w", err)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,75 @@
+package nativehost
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest(t *testing.T) {
+	t.Parallel()
+
+	ff, found := LookupBrowser("firefox")
+	require.True(t, found)
+
+	buf, err := ff.Manifest("/home/alice/.config/gopass/native-host.sh")
+	require.NoError(t, err)
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(buf, &m))
+	assert.Equal(t, map[string]any{
+		"name":               Name,
+		"description":        "Gopass wrapper to search and return passwords",
+		"path":               "/home/alice/.config/gopass/native-host.sh",
+		"type":               "stdio",
+		"allowed_extensions": []any{firefoxExtension},
+	}, m)
+
+	chrome, found := LookupBrowser("chrome")
+	require.True(t, found)
+
+	buf, err = chrome.Manifest("/usr/local/bin/wrapper")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(buf, &m))
+	assert.Equal(t, []any{chromeOrigin}, m["allowed_origins"])
+
+	_, found = LookupBrowser("netscape")
+	assert.False(t, found)
+}
+
+func TestInstall(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("test uses the Linux manifest locations")
+	}
+
+	home := t.TempDir()
+	t.Setenv("GOPASS_HOMEDIR", home)
+
+	chromium, found := LookupBrowser("chromium")
+	require.True(t, found)
+	assert.False(t, chromium.Installed())
+
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".config", "chromium"), 0o700))
+	assert.True(t, chromium.Installed())
+
+	wrapper, err := InstallWrapper("/usr/bin/gopass")
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\nexport PATH=\"$PATH:/usr/local/bin:/opt/homebrew/bin\"\nexec '/usr/bin/gopass' native-host \"$@\"\n", string(Wrapper("/usr/bin/gopass")))
+
+	fi, err := os.Stat(wrapper)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), fi.Mode().Perm())
+
+	p, err := chromium.Install(wrapper)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".config", "chromium", "NativeMessagingHosts", Name+".json"), p)
+
+	buf, err := os.ReadFile(p)
+	require.NoError(t, err)
+	assert.Contains(t, string(buf), wrapper)
+}
@@ -0,0 +1,366 @@
+// Package nativehost implements a native messaging host for browser
+// extensions like gopassbridge. The browser starts the host and exchanges
+// length-prefixed JSON messages with it over stdin and stdout.
+//
+// The protocol is compatible with gopass-jsonapi. Every request has a type
+// and every response is either the requested value or an object with an
+// error message.
+package nativehost
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/gopasspw/gopass/internal/username"
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/gopasspw/gopass/pkg/pwgen/pwrules"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Backend provides the store operations of the native messaging host.
+type Backend interface {
+	// List returns the names of all secrets.
+	List(ctx context.Context) ([]string, error)
+	// Get returns a secret.
+	Get(ctx context.Context, name string) (gopass.Secret, error)
+	// Exists returns true if the secret exists.
+	Exists(ctx context.Context, name string) bool
+	// Create stores a new secret. It fails if the password violates the
+	// password policy of the secret.
+	Create(ctx context.Context, name string, sec gopass.Secret) error
+	// Generate returns a new password for the secret. It uses the password
+	// rules of the domain in the name, if any.
+	Generate(ctx context.Context, name string, length int, symbols bool) (string, error)
+	// OTP returns the current one-time password of the secret.
+	OTP(ctx context.Context, name string) (string, error)
+}
+
+// Host answers requests from a browser extension.
+type Host struct {
+	b       Backend
+	version semver.Version
+}
+
+// New returns a new native messaging host.
+func New(b Backend, version semver.Version) *Host {
+	return &Host{
+		b:       b,
+		version: version,
+	}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type loginResponse struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type otpResponse struct {
+	Token string `json:"token"`
+}
+
+type versionResponse struct {
+	Version string `json:"version"`
+	Major   uint64 `json:"major"`
+	Minor   uint64 `json:"minor"`
+	Patch   uint64 `json:"patch"`
+}
+
+type queryRequest struct {
+	Query string `json:"query"`
+}
+
+type queryHostRequest struct {
+	Host string `json:"host"`
+}
+
+type entryRequest struct {
+	Entry string `json:"entry"`
+}
+
+type createRequest struct {
+	Name     string `json:"entry_name"`
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Length   int    `json:"length"`
+	Generate bool   `json:"generate"`
+	Symbols  bool   `json:"use_symbols"`
+}
+
+// Serve answers the requests read from r on w until r is closed.
+func (h *Host) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	for {
+		req, err := readMessage(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = writeMessage(w, h.handle(ctx, req))
+		if errors.Is(err, ErrTooLarge) {
+			err = writeMessage(w, errorResponse{Error: err.Error()})
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (h *Host) handle(ctx context.Context, req []byte) any {
+	var msg struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(req, &msg); err != nil {
+		return errorResponse{Error: fmt.Sprintf("invalid message: %s", err)}
+	}
+	debug.Log("received %s request", msg.Type)
+
+	var resp any
+	var err error
+
+	switch msg.Type {
+	case "query":
+		resp, err = h.query(ctx, req)
+	case "queryHost":
+		resp, err = h.queryHost(ctx, req)
+	case "getLogin":
+		resp, err = h.getLogin(ctx, req)
+	case "getData":
+		resp, err = h.getData(ctx, req)
+	case "getOTP":
+		resp, err = h.getOTP(ctx, req)
+	case "create":
+		resp, err = h.create(ctx, req)
+	case "getVersion":
+		resp = versionResponse{
+			Version: h.version.String(),
+			Major:   h.version.Major,
+			Minor:   h.version.Minor,
+			Patch:   h.version.Patch,
+		}
+	default:
+		err = fmt.Errorf("unknown message type %q", msg.Type)
+	}
+
+	if err != nil {
+		debug.Log("%s request failed: %s", msg.Type, err)
+
+		return errorResponse{Error: err.Error()}
+	}
+
+	return resp
+}
+
+func decode(req []byte, v any) error {
+	if err := json.Unmarshal(req, v); err != nil {
+		return fmt.Errorf("invalid message: %w", err)
+	}
+
+	return nil
+}
+
+// query returns all secrets that contain every word of the query.
+func (h *Host) query(ctx context.Context, req []byte) ([]string, error) {
+	var m queryRequest
+	if err := decode(req, &m); err != nil {
+		return nil, err
+	}
+
+	words := strings.Fields(strings.ToLower(m.Query))
+	if len(words) < 1 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	list, err := h.b.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []string{}
+	for _, name := range list {
+		lower := strings.ToLower(name)
+		if !slices.ContainsFunc(words, func(w string) bool { return !strings.Contains(lower, w) }) {
+			matches = append(matches, name)
+		}
+	}
+
+	return matches, nil
+}
+
+// queryHost returns the secrets for a host name. A secret matches if one of
+// the elements of its name is the host or one of its aliases. If there is no
+// match the leading labels are removed one by one down to the registered
+// domain, i.e. secrets for example.com match login.example.com.
+func (h *Host) queryHost(ctx context.Context, req []byte) ([]string, error) {
+	var m queryHostRequest
+	if err := decode(req, &m); err != nil {
+		return nil, err
+	}
+
+	host := normalizeHost(m.Host)
+	if host == "" {
+		return nil, fmt.Errorf("empty host")
+	}
+
+	list, err := h.b.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if matches := matchHost(ctx, list, host); len(matches) > 0 {
+			return matches, nil
+		}
+
+		domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+		if err != nil || domain == host {
+			break
+		}
+		_, host, _ = strings.Cut(host, ".")
+	}
+
+	return []string{}, nil
+}
+
+// normalizeHost accepts a host name with an optional port or an URL.
+func normalizeHost(host string) string {
+	host = strings.TrimSpace(host)
+	if u, err := url.Parse(host); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+func matchHost(ctx context.Context, list []string, host string) []string {
+	hosts := append([]string{host}, pwrules.LookupAliases(ctx, host)...)
+
+	matches := []string{}
+	for _, name := range list {
+		elems := strings.Split(strings.ToLower(name), "/")
+		if slices.ContainsFunc(elems, func(e string) bool { return slices.Contains(hosts, e) }) {
+			matches = append(matches, name)
+		}
+	}
+
+	return matches
+}
+
+func (h *Host) getLogin(ctx context.Context, req []byte) (*loginResponse, error) {
+	var m entryRequest
+	if err := decode(req, &m); err != nil {
+		return nil, err
+	}
+
+	sec, err := h.b.Get(ctx, m.Entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &loginResponse{
+		Username: username.Get(m.Entry, sec),
+		Password: sec.Password(),
+	}, nil
+}
+
+// getData returns all keys of the secret except the password.
+func (h *Host) getData(ctx context.Context, req []byte) (map[string]string, error) {
+	var m entryRequest
+	if err := decode(req, &m); err != nil {
+		return nil, err
+	}
+
+	sec, err := h.b.Get(ctx, m.Entry)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]string, len(sec.Keys()))
+	for _, k := range sec.Keys() {
+		if k == "password" {
+			continue
+		}
+		if v, found := sec.Values(k); found {
+			data[k] = strings.Join(v, "\n")
+		}
+	}
+
+	return data, nil
+}
+
+func (h *Host) getOTP(ctx context.Context, req []byte) (*otpResponse, error) {
+	var m entryRequest
+	if err := decode(req, &m); err != nil {
+		return nil, err
+	}
+
+	token, err := h.b.OTP(ctx, m.Entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &otpResponse{Token: token}, nil
+}
+
+// create stores a new login. The password is either provided by the
+// extension or generated.
+func (h *Host) create(ctx context.Context, req []byte) (*loginResponse, error) {
+	var m createRequest
+	if err := decode(req, &m); err != nil {
+		return nil, err
+	}
+
+	if m.Name == "" {
+		return nil, fmt.Errorf("no entry name given")
+	}
+	if h.b.Exists(ctx, m.Name) {
+		return nil, fmt.Errorf("secret %s already exists", m.Name)
+	}
+
+	pw := m.Password
+	if m.Generate {
+		var err error
+		pw, err = h.b.Generate(ctx, m.Name, m.Length, m.Symbols)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if pw == "" {
+		return nil, fmt.Errorf("no password given")
+	}
+
+	sec := secrets.NewAKV()
+	sec.SetPassword(pw)
+	if m.Login != "" {
+		if err := sec.Set(username.Keys[0], m.Login); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := h.b.Create(ctx, m.Name, sec); err != nil {
+		return nil, err
+	}
+
+	return &loginResponse{
+		Username: m.Login,
+		Password: pw,
+	}, nil
+}
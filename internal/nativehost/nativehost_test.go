@@ -0,0 +1,267 @@
+package nativehost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackend struct {
+	secrets map[string]string
+}
+
+func (f *fakeBackend) List(context.Context) ([]string, error) {
+	names := make([]string, 0, len(f.secrets))
+	for name := range f.secrets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names, nil
+}
+
+func (f *fakeBackend) Get(_ context.Context, name string) (gopass.Secret, error) {
+	content, found := f.secrets[name]
+	if !found {
+		return nil, fmt.Errorf("secret %s not found", name)
+	}
+
+	return secrets.ParseAKV([]byte(content)), nil
+}
+
+func (f *fakeBackend) Exists(_ context.Context, name string) bool {
+	_, found := f.secrets[name]
+
+	return found
+}
+
+func (f *fakeBackend) Create(_ context.Context, name string, sec gopass.Secret) error {
+	if sec.Password() == "weak" {
+		return fmt.Errorf("password violates the policy")
+	}
+	f.secrets[name] = string(sec.Bytes())
+
+	return nil
+}
+
+func (f *fakeBackend) Generate(_ context.Context, name string, length int, symbols bool) (string, error) {
+	return fmt.Sprintf("generated-%d-%t", length, symbols), nil
+}
+
+func (f *fakeBackend) OTP(_ context.Context, name string) (string, error) {
+	if name != "websites/github.com/alice" {
+		return "", fmt.Errorf("no OTP entry found")
+	}
+
+	return "123456", nil
+}
+
+// client talks to a host over pipes like a browser does.
+type client struct {
+	t *testing.T
+	w io.Writer
+	r io.Reader
+}
+
+func newClient(t *testing.T, b Backend) *client {
+	t.Helper()
+
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- New(b, semver.MustParse("1.16.0")).Serve(config.NewContextInMemory(), reqR, respW)
+		_ = respW.Close()
+	}()
+	t.Cleanup(func() {
+		_ = reqW.Close()
+		assert.NoError(t, <-done)
+	})
+
+	return &client{t: t, w: reqW, r: respR}
+}
+
+func (c *client) call(req map[string]any, resp any) {
+	c.t.Helper()
+
+	require.NoError(c.t, writeMessage(c.w, req))
+	buf, err := readMessage(c.r)
+	require.NoError(c.t, err)
+	require.NoError(c.t, json.Unmarshal(buf, resp), string(buf))
+}
+
+func testBackend() *fakeBackend {
+	return &fakeBackend{secrets: map[string]string{
+		"websites/github.com/alice":      "s3cret\nlogin: alice@example.com\n",
+		"websites/github.com/bob":        "hunter2\n",
+		"websites/login.example.com/eve": "eve-pw\nusername: eve\n",
+		"websites/envato.com/carol":      "carol-pw\n",
+		"misc/github-token":              "token\n",
+	}}
+}
+
+func TestQueryHost(t *testing.T) {
+	t.Parallel()
+
+	c := newClient(t, testBackend())
+
+	for _, tc := range []struct {
+		host string
+		want []string
+	}{
+		{host: "github.com", want: []string{"websites/github.com/alice", "websites/github.com/bob"}},
+		{host: "gist.github.com", want: []string{"websites/github.com/alice", "websites/github.com/bob"}},
+		{host: "GitHub.com:443", want: []string{"websites/github.com/alice", "websites/github.com/bob"}},
+		{host: "https://login.example.com/signin", want: []string{"websites/login.example.com/eve"}},
+		// aliases
+		{host: "audiojungle.net", want: []string{"websites/envato.com/carol"}},
+		// no match for the public suffix only
+		{host: "www.example.com", want: []string{}},
+		{host: "example.org", want: []string{}},
+	} {
+		var resp []string
+		c.call(map[string]any{"type": "queryHost", "host": tc.host}, &resp)
+		assert.Equal(t, tc.want, resp, tc.host)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+
+	c := newClient(t, testBackend())
+
+	var resp []string
+	c.call(map[string]any{"type": "query", "query": "GitHub"}, &resp)
+	assert.Equal(t, []string{"misc/github-token", "websites/github.com/alice", "websites/github.com/bob"}, resp)
+
+	c.call(map[string]any{"type": "query", "query": "github ali"}, &resp)
+	assert.Equal(t, []string{"websites/github.com/alice"}, resp)
+
+	var errResp errorResponse
+	c.call(map[string]any{"type": "query", "query": " "}, &errResp)
+	assert.Equal(t, "empty query", errResp.Error)
+}
+
+func TestGetLogin(t *testing.T) {
+	t.Parallel()
+
+	c := newClient(t, testBackend())
+
+	var resp loginResponse
+	c.call(map[string]any{"type": "getLogin", "entry": "websites/github.com/alice"}, &resp)
+	assert.Equal(t, loginResponse{Username: "alice@example.com", Password: "s3cret"}, resp)
+
+	// the username defaults to the last element of the name.
+	c.call(map[string]any{"type": "getLogin", "entry": "websites/github.com/bob"}, &resp)
+	assert.Equal(t, loginResponse{Username: "bob", Password: "hunter2"}, resp)
+
+	var errResp errorResponse
+	c.call(map[string]any{"type": "getLogin", "entry": "foo"}, &errResp)
+	assert.Equal(t, "secret foo not found", errResp.Error)
+}
+
+func TestGetData(t *testing.T) {
+	t.Parallel()
+
+	c := newClient(t, testBackend())
+
+	var resp map[string]string
+	c.call(map[string]any{"type": "getData", "entry": "websites/login.example.com/eve"}, &resp)
+	assert.Equal(t, map[string]string{"username": "eve"}, resp)
+}
+
+func TestGetOTP(t *testing.T) {
+	t.Parallel()
+
+	c := newClient(t, testBackend())
+
+	var resp otpResponse
+	c.call(map[string]any{"type": "getOTP", "entry": "websites/github.com/alice"}, &resp)
+	assert.Equal(t, "123456", resp.Token)
+
+	var errResp errorResponse
+	c.call(map[string]any{"type": "getOTP", "entry": "websites/github.com/bob"}, &errResp)
+	assert.Equal(t, "no OTP entry found", errResp.Error)
+}
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+
+	b := testBackend()
+	c := newClient(t, b)
+
+	var resp loginResponse
+	c.call(map[string]any{
+		"type":       "create",
+		"entry_name": "websites/gitlab.com/alice",
+		"login":      "alice",
+		"password":   "foobar",
+	}, &resp)
+	assert.Equal(t, loginResponse{Username: "alice", Password: "foobar"}, resp)
+	assert.Equal(t, "foobar\nusername: alice\n", b.secrets["websites/gitlab.com/alice"])
+
+	c.call(map[string]any{
+		"type":        "create",
+		"entry_name":  "websites/gitlab.com/bob",
+		"login":       "bob",
+		"generate":    true,
+		"length":      24,
+		"use_symbols": true,
+	}, &resp)
+	assert.Equal(t, loginResponse{Username: "bob", Password: "generated-24-true"}, resp)
+
+	for _, tc := range []struct {
+		req  map[string]any
+		want string
+	}{
+		{req: map[string]any{"password": "foo"}, want: "no entry name given"},
+		{req: map[string]any{"entry_name": "websites/github.com/bob", "password": "foo"}, want: "secret websites/github.com/bob already exists"},
+		{req: map[string]any{"entry_name": "new"}, want: "no password given"},
+		{req: map[string]any{"entry_name": "new", "password": "weak"}, want: "password violates the policy"},
+	} {
+		tc.req["type"] = "create"
+
+		var errResp errorResponse
+		c.call(tc.req, &errResp)
+		assert.Equal(t, tc.want, errResp.Error)
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	t.Parallel()
+
+	c := newClient(t, testBackend())
+
+	var resp versionResponse
+	c.call(map[string]any{"type": "getVersion"}, &resp)
+	assert.Equal(t, versionResponse{Version: "1.16.0", Major: 1, Minor: 16}, resp)
+}
+
+func TestInvalidRequests(t *testing.T) {
+	t.Parallel()
+
+	c := newClient(t, testBackend())
+
+	var errResp errorResponse
+	c.call(map[string]any{"type": "foo"}, &errResp)
+	assert.Equal(t, `unknown message type "foo"`, errResp.Error)
+
+	c.call(map[string]any{"type": "getLogin", "entry": 42}, &errResp)
+	assert.Contains(t, errResp.Error, "invalid message")
+
+	// the host keeps serving after errors.
+	var resp versionResponse
+	c.call(map[string]any{"type": "getVersion"}, &resp)
+	assert.Equal(t, "1.16.0", resp.Version)
+}
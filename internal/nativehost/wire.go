@@ -0,0 +1,66 @@
+package nativehost
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The browser prefixes each message with its length as a 32-bit unsigned
+// integer in native byte order.
+const (
+	// maxRequest limits the messages we accept. Browsers allow up to 4 GiB
+	// but none of our requests come close.
+	maxRequest = 1 << 20
+	// maxResponse is the largest message browsers accept from a native
+	// messaging host.
+	maxResponse = 1 << 20
+)
+
+// ErrTooLarge is returned for messages that exceed the size limits.
+var ErrTooLarge = errors.New("message too large")
+
+// readMessage reads a single message. It returns io.EOF if the browser
+// closed the connection between messages.
+func readMessage(r io.Reader) ([]byte, error) {
+	var size uint32
+	if err := binary.Read(r, binary.NativeEndian, &size); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+
+		return nil, fmt.Errorf("failed to read message length: %w", err)
+	}
+
+	if size > maxRequest {
+		return nil, fmt.Errorf("%w: %d bytes", ErrTooLarge, size)
+	}
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+
+	return buf, nil
+}
+
+// writeMessage encodes v as JSON and writes it as a single message.
+func writeMessage(w io.Writer, v any) error {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	if len(buf) > maxResponse {
+		return fmt.Errorf("%w: %d bytes", ErrTooLarge, len(buf))
+	}
+
+	msg := binary.NativeEndian.AppendUint32(make([]byte, 0, 4+len(buf)), uint32(len(buf)))
+	if _, err := w.Write(append(msg, buf...)); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	return nil
+}
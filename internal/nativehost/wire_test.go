@@ -0,0 +1,48 @@
+package nativehost
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWire(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, writeMessage(buf, map[string]string{"type": "getVersion"}))
+	assert.Equal(t, uint32(21), binary.NativeEndian.Uint32(buf.Bytes()))
+
+	msg, err := readMessage(buf)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"getVersion"}`, string(msg))
+
+	_, err = readMessage(buf)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestWireErrors(t *testing.T) {
+	t.Parallel()
+
+	// truncated length
+	_, err := readMessage(bytes.NewReader([]byte{1, 0}))
+	require.Error(t, err)
+	require.NotErrorIs(t, err, io.EOF)
+
+	// truncated message
+	msg := binary.NativeEndian.AppendUint32(nil, 10)
+	_, err = readMessage(bytes.NewReader(append(msg, '{')))
+	require.Error(t, err)
+
+	// too large
+	msg = binary.NativeEndian.AppendUint32(nil, maxRequest+1)
+	_, err = readMessage(bytes.NewReader(msg))
+	require.ErrorIs(t, err, ErrTooLarge)
+
+	require.ErrorIs(t, writeMessage(io.Discard, strings.Repeat("a", maxResponse)), ErrTooLarge)
+}
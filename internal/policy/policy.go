@@ -0,0 +1,155 @@
+// Package policy implements password policies. A policy is stored in a
+// .gopass-policy.yml file in a folder of the password store and applies to
+// all secrets below that folder, so it is enforced for everyone using the
+// store.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/pwgen"
+	"go.yaml.in/yaml/v3"
+)
+
+// ErrViolation is returned if a password violates a policy.
+var ErrViolation = errors.New("password policy violated")
+
+// classes are the character classes a policy can require.
+var classes = map[string]func(rune) bool{
+	"upper":  unicode.IsUpper,
+	"lower":  unicode.IsLower,
+	"digit":  unicode.IsDigit,
+	"symbol": func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) || r == ' ' },
+}
+
+// Policy is a password policy.
+type Policy struct {
+	// MinLength is the minimum number of characters.
+	MinLength int `yaml:"min_length"`
+	// Require lists the required character classes: upper, lower, digit and
+	// symbol.
+	Require []string `yaml:"require"`
+	// Banned lists regular expressions passwords must not match.
+	Banned []string `yaml:"banned"`
+	// MinScore is the minimum strength score from 0 to 4, see pwgen.Score.
+	MinScore int `yaml:"min_score"`
+
+	banned []*regexp.Regexp
+}
+
+// Parse parses and validates a policy.
+func Parse(buf []byte) (*Policy, error) {
+	p := &Policy{}
+	if err := yaml.Unmarshal(buf, p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	if p.MinLength < 0 {
+		return nil, fmt.Errorf("invalid min_length %d", p.MinLength)
+	}
+	if p.MinScore < 0 || p.MinScore > pwgen.MaxScore {
+		return nil, fmt.Errorf("invalid min_score %d, must be between 0 and %d", p.MinScore, pwgen.MaxScore)
+	}
+	for _, c := range p.Require {
+		if _, found := classes[c]; !found {
+			return nil, fmt.Errorf("invalid character class %q, must be one of upper, lower, digit or symbol", c)
+		}
+	}
+	for _, b := range p.Banned {
+		re, err := regexp.Compile(b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid banned pattern %q: %w", b, err)
+		}
+		p.banned = append(p.banned, re)
+	}
+
+	return p, nil
+}
+
+// Check returns the rules of the policy the password violates.
+func (p *Policy) Check(pw string) []string {
+	var violations []string
+
+	if n := utf8.RuneCountInString(pw); n < p.MinLength {
+		violations = append(violations, fmt.Sprintf("shorter than %d characters", p.MinLength))
+	}
+
+	for _, c := range p.Require {
+		if strings.IndexFunc(pw, classes[c]) < 0 {
+			violations = append(violations, fmt.Sprintf("no %s character", c))
+		}
+	}
+
+	for i, re := range p.banned {
+		if re.MatchString(pw) {
+			violations = append(violations, fmt.Sprintf("matches banned pattern %q", p.Banned[i]))
+		}
+	}
+
+	if p.MinScore > 0 {
+		if score := pwgen.Score(pw); score < p.MinScore {
+			violations = append(violations, fmt.Sprintf("strength score %d is below %d", score, p.MinScore))
+		}
+	}
+
+	return violations
+}
+
+// Lookuper finds the policy file that applies to a secret.
+type Lookuper interface {
+	LookupPolicy(ctx context.Context, name string) (string, []byte, bool)
+}
+
+// Lookup returns the policy that applies to the given secret and the path of
+// its file. It returns nil if there is no policy.
+func Lookup(ctx context.Context, s Lookuper, name string) (*Policy, string, error) {
+	file, buf, found := s.LookupPolicy(ctx, name)
+	if !found {
+		return nil, "", nil
+	}
+
+	p, err := Parse(buf)
+	if err != nil {
+		return nil, file, fmt.Errorf("invalid password policy %s: %w", file, err)
+	}
+	debug.Log("using password policy %s for %s", file, name)
+
+	return p, file, nil
+}
+
+// Enforce checks the password of the given secret against the policy that
+// applies to it. If the password violates the policy an error wrapping
+// ErrViolation is returned, unless force is set. Then the override is
+// recorded in the commit message of the returned context instead.
+func Enforce(ctx context.Context, s Lookuper, name, pw string, force bool) (context.Context, error) {
+	p, file, err := Lookup(ctx, s, name)
+	if err != nil || p == nil {
+		return ctx, err
+	}
+
+	return Apply(ctx, p, file, pw, force)
+}
+
+// Apply checks the password against the given policy, see Enforce.
+func Apply(ctx context.Context, p *Policy, file, pw string, force bool) (context.Context, error) {
+	violations := p.Check(pw)
+	if len(violations) < 1 {
+		return ctx, nil
+	}
+
+	if !force {
+		return ctx, fmt.Errorf("%w: password %s (see %s)", ErrViolation, strings.Join(violations, ", "), file)
+	}
+
+	debug.Log("overriding password policy %s: %v", file, violations)
+
+	return ctxutil.AddToCommitMessageBody(ctx, fmt.Sprintf("Password policy %s overridden with --force: %s", file, strings.Join(violations, ", "))), nil
+}
@@ -0,0 +1,100 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLookuper map[string][]byte
+
+func (f fakeLookuper) LookupPolicy(_ context.Context, name string) (string, []byte, bool) {
+	buf, found := f[name]
+
+	return "team/.gopass-policy.yml", buf, found
+}
+
+const testPolicy = `---
+min_length: 12
+require:
+  - upper
+  - digit
+banned:
+  - "(?i)passw(o|0)rd"
+  - "^[0-9]+$"
+min_score: 3
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	p, err := Parse([]byte(testPolicy))
+	require.NoError(t, err)
+	assert.Equal(t, 12, p.MinLength)
+	assert.Equal(t, []string{"upper", "digit"}, p.Require)
+	assert.Equal(t, 3, p.MinScore)
+
+	for _, in := range []string{
+		"min_length: -1",
+		"require: [emoji]",
+		"banned: ['(']",
+		"min_score: 5",
+		"min_length: [",
+	} {
+		_, err := Parse([]byte(in))
+		require.Error(t, err, in)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	p, err := Parse([]byte(testPolicy))
+	require.NoError(t, err)
+
+	assert.Empty(t, p.Check("kxq7zvMw!p2Lq"))
+	assert.Equal(t, []string{
+		"shorter than 12 characters",
+		"no upper character",
+		"no digit character",
+		"strength score 1 is below 3",
+	}, p.Check("short"))
+	assert.Equal(t, []string{
+		`matches banned pattern "(?i)passw(o|0)rd"`,
+	}, p.Check("MyPassw0rd-kxq7zvMw"))
+
+	// an empty policy accepts everything
+	p, err = Parse(nil)
+	require.NoError(t, err)
+	assert.Empty(t, p.Check(""))
+}
+
+func TestEnforce(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	s := fakeLookuper{"team/db": []byte(testPolicy), "broken": []byte("min_score: 9")}
+
+	// no policy
+	_, err := Enforce(ctx, s, "other", "short", false)
+	require.NoError(t, err)
+
+	_, err = Enforce(ctx, s, "team/db", "kxq7zvMw!p2Lq", false)
+	require.NoError(t, err)
+
+	_, err = Enforce(ctx, s, "team/db", "short", false)
+	require.ErrorIs(t, err, ErrViolation)
+	assert.Contains(t, err.Error(), "team/.gopass-policy.yml")
+
+	ctx = ctxutil.WithCommitMessage(ctx, "Insert")
+	fctx, err := Enforce(ctx, s, "team/db", "short", true)
+	require.NoError(t, err)
+	assert.Equal(t, "Insert", ctxutil.GetCommitMessage(fctx))
+	assert.Contains(t, ctxutil.GetCommitMessageBody(fctx), "Password policy team/.gopass-policy.yml overridden with --force: shorter than 12 characters")
+
+	_, err = Enforce(ctx, s, "broken", "short", true)
+	require.Error(t, err)
+}
@@ -0,0 +1,104 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffCells limits the size of the table used to diff two files. Larger
+// files are shown as completely replaced.
+const maxDiffCells = 4 << 20
+
+// Diff returns a line diff from the existing content to the rendered
+// content. Lines are only shown by their line number, never by their
+// content, since any line may contain a secret or a value derived from one,
+// e.g. its base64 encoding or a hash.
+func Diff(old, rendered []byte) []string {
+	a := splitLines(string(old))
+	b := splitLines(string(rendered))
+
+	var lines []string
+
+	ai, bi := 0, 0
+	for _, op := range editScript(a, b) {
+		switch op {
+		case ' ':
+			ai++
+			bi++
+		case '-':
+			ai++
+			lines = append(lines, fmt.Sprintf("- line %d", ai))
+		case '+':
+			bi++
+			lines = append(lines, fmt.Sprintf("+ line %d", bi))
+		}
+	}
+
+	return lines
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// editScript returns the operations (' ' keep, '-' remove, '+' add) of a
+// shortest edit from a to b.
+func editScript(a, b []string) []byte {
+	n, m := len(a), len(b)
+	if n*m > maxDiffCells {
+		ops := make([]byte, 0, n+m)
+		for range a {
+			ops = append(ops, '-')
+		}
+		for range b {
+			ops = append(ops, '+')
+		}
+
+		return ops
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]byte, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, ' ')
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, '-')
+			i++
+		default:
+			ops = append(ops, '+')
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, '-')
+	}
+	for ; j < m; j++ {
+		ops = append(ops, '+')
+	}
+
+	return ops
+}
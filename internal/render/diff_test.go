@@ -0,0 +1,24 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	old := []byte("user=app\npassword=old\nport=5432\n")
+	rendered := []byte("user=app\npassword=new\nport=5432\ntoken=YWJj\n")
+
+	assert.Equal(t, []string{
+		"- line 2",
+		"+ line 2",
+		"+ line 4",
+	}, Diff(old, rendered))
+
+	assert.Empty(t, Diff(rendered, rendered))
+	assert.Equal(t, []string{"+ line 1"}, Diff(nil, []byte("s3cr3t\n")))
+	assert.Equal(t, []string{"- line 1", "- line 2"}, Diff([]byte("a\nb"), nil))
+}
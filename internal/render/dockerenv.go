@@ -0,0 +1,60 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// DockerEnv returns the values as env file for docker run --env-file and
+// docker compose. The format has no quoting, so values can not span multiple
+// lines.
+func DockerEnv(values map[string]string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for _, k := range slices.Sorted(maps.Keys(values)) {
+		if k == "" || strings.ContainsAny(k, "= \t\r\n") {
+			return nil, fmt.Errorf("invalid variable name %q", k)
+		}
+
+		v := values[k]
+		if strings.ContainsAny(v, "\r\n") {
+			// never include the value, it is most likely a secret.
+			return nil, fmt.Errorf("the value of %s spans multiple lines, env files only support single line values", k)
+		}
+
+		fmt.Fprintf(buf, "%s=%s\n", k, v)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ParseDockerEnv parses an env file. Empty lines and comments are skipped,
+// variables without a value are taken from the environment by Docker and
+// are ignored.
+func ParseDockerEnv(buf []byte) (map[string]string, error) {
+	values := map[string]string{}
+
+	sc := bufio.NewScanner(bytes.NewReader(buf))
+	for sc.Scan() {
+		line := strings.TrimSuffix(sc.Text(), "\r")
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		k, v, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+
+		values[strings.TrimLeft(k, " \t")] = v
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse env file: %w", err)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,103 @@
+package render
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes buf atomically to filename with permissions 0600. The
+// content is written to a temporary file in the same directory that is
+// renamed to filename, so readers never see a partially written file.
+func WriteFile(filename string, buf []byte) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	// os.CreateTemp creates the file with mode 0600.
+	fh, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmp := fh.Name()
+
+	if _, err := fh.Write(buf); err != nil {
+		_ = fh.Close()
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+
+	if err := fh.Sync(); err != nil {
+		_ = fh.Close()
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to sync %s: %w", tmp, err)
+	}
+
+	if err := fh.Close(); err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to close %s: %w", tmp, err)
+	}
+
+	// the permissions of an existing file are replaced as well.
+	if err := os.Chmod(tmp, 0o600); err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to chmod %s: %w", tmp, err)
+	}
+
+	if err := os.Rename(tmp, filename); err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to replace %s: %w", filename, err)
+	}
+
+	return nil
+}
+
+// Fingerprint returns a fingerprint of the names and of the names, sizes
+// and modification times of all files below the paths. It changes whenever
+// a file is added, removed or written, e.g. when a store is synced, without
+// reading any content. Git metadata is skipped.
+func Fingerprint(names []string, paths ...string) (uint64, error) {
+	h := fnv.New64a()
+
+	for _, n := range names {
+		_, _ = fmt.Fprintf(h, "%s\x00", n)
+	}
+
+	for _, p := range paths {
+		err := filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() {
+				if d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(h, "%s\x00%d\x00%d\x00", path, fi.Size(), fi.ModTime().UnixNano())
+
+			return nil
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to walk %s: %w", p, err)
+		}
+	}
+
+	return h.Sum64(), nil
+}
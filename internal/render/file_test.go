@@ -0,0 +1,75 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "sub", "app.env")
+
+	require.NoError(t, WriteFile(fn, []byte("A=1\n")))
+	buf, err := os.ReadFile(fn)
+	require.NoError(t, err)
+	assert.Equal(t, "A=1\n", string(buf))
+
+	// existing files are replaced with restricted permissions.
+	require.NoError(t, os.Chmod(fn, 0o644))
+	require.NoError(t, WriteFile(fn, []byte("A=2\n")))
+	buf, err = os.ReadFile(fn)
+	require.NoError(t, err)
+	assert.Equal(t, "A=2\n", string(buf))
+
+	fi, err := os.Stat(fn)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+	// no temporary files are left behind.
+	entries, err := os.ReadDir(filepath.Dir(fn))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo.gpg"), []byte("foo"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0o700))
+
+	fp, err := Fingerprint(nil, dir)
+	require.NoError(t, err)
+
+	// git metadata is ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "index"), []byte("x"), 0o600))
+	again, err := Fingerprint(nil, dir)
+	require.NoError(t, err)
+	assert.Equal(t, fp, again)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bar.gpg"), []byte("bar"), 0o600))
+	added, err := Fingerprint(nil, dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, fp, added)
+
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "foo.gpg"), future, future))
+	touched, err := Fingerprint(nil, dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, added, touched)
+
+	// names are part of the fingerprint, e.g. for remote stores.
+	named, err := Fingerprint([]string{"foo"}, dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, touched, named)
+
+	_, err = Fingerprint(nil, filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
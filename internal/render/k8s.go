@@ -0,0 +1,111 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+var (
+	// reK8sKey matches valid keys of a Kubernetes Secret.
+	reK8sKey = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+	// reK8sInvalid matches the characters that are not allowed in names of
+	// Kubernetes objects.
+	reK8sInvalid = regexp.MustCompile(`[^a-z0-9.-]+`)
+)
+
+// K8sSecret is a Kubernetes Secret manifest.
+type K8sSecret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   K8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Data       map[string]string `yaml:"data,omitempty"`
+	StringData map[string]string `yaml:"stringData,omitempty"`
+}
+
+// K8sMetadata is the metadata of a Kubernetes object.
+type K8sMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// NewK8sSecret returns an Opaque Secret with the base64 encoded values.
+func NewK8sSecret(name, namespace string, values map[string]string) (*K8sSecret, error) {
+	data := make(map[string]string, len(values))
+	for k, v := range values {
+		if !reK8sKey.MatchString(k) {
+			return nil, fmt.Errorf("invalid key %q, keys may only contain letters, digits, '-', '_' and '.'", k)
+		}
+		data[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+
+	return &K8sSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: K8sMetadata{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Type: "Opaque",
+		Data: data,
+	}, nil
+}
+
+// ParseK8sSecret parses a Secret manifest.
+func ParseK8sSecret(buf []byte) (*K8sSecret, error) {
+	s := &K8sSecret{}
+	if err := yaml.Unmarshal(buf, s); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	if s.Kind != "Secret" {
+		return nil, fmt.Errorf("manifest is a %q, not a Secret", s.Kind)
+	}
+
+	return s, nil
+}
+
+// Bytes returns the manifest as YAML.
+func (s *K8sSecret) Bytes() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(s); err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Values returns the decoded values of the Secret. Values in stringData take
+// precedence like they do in Kubernetes.
+func (s *K8sSecret) Values() (map[string]string, error) {
+	values := make(map[string]string, len(s.Data)+len(s.StringData))
+	for k, v := range s.Data {
+		buf, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			// never include the value, it is most likely a secret.
+			return nil, fmt.Errorf("invalid base64 value of key %q", k)
+		}
+		values[k] = string(buf)
+	}
+
+	maps.Copy(values, s.StringData)
+
+	return values, nil
+}
+
+// K8sName turns the name of a folder into a valid name of a Kubernetes
+// object.
+func K8sName(name string) string {
+	name = reK8sInvalid.ReplaceAllString(strings.ToLower(name), "-")
+
+	return strings.Trim(name, "-.")
+}
@@ -0,0 +1,112 @@
+// Package render turns secrets into files for deployments, e.g. Kubernetes
+// Secret manifests and Docker env files, and compares them with existing
+// files without revealing any values.
+package render
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gopasspw/gopass/internal/diff"
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+// Data returns the values of the secrets by key. The password of every secret
+// is stored under the name of the secret without its folder, every key of the
+// secret is stored under its own name. Multiple values of a key are joined by
+// newlines. If key is not nil it is applied to every key, e.g. to upper case
+// them. Two values with the same key are an error.
+func Data(secs map[string]gopass.Secret, key func(string) string) (map[string]string, error) {
+	if key == nil {
+		key = func(s string) string { return s }
+	}
+
+	data := make(map[string]string, len(secs))
+	origin := make(map[string]string, len(secs))
+
+	add := func(name, k, v string) error {
+		if other, found := origin[k]; found {
+			return fmt.Errorf("key %q of %s conflicts with %s", k, name, other)
+		}
+		data[k] = v
+		origin[k] = name
+
+		return nil
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(secs)) {
+		sec := secs[name]
+
+		if pw := sec.Password(); pw != "" {
+			if err := add(name, key(path.Base(name)), pw); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, k := range sec.Keys() {
+			values, _ := sec.Values(k)
+			if err := add(name, key(k), strings.Join(values, "\n")); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return data, nil
+}
+
+// Kind is the kind of a change between two sets of values.
+type Kind int
+
+const (
+	// Added is a key that is missing in the existing file.
+	Added Kind = iota
+	// Removed is a key that only exists in the existing file.
+	Removed
+	// Changed is a key with a different value.
+	Changed
+)
+
+// Change is a difference of one key. It never contains the values.
+type Change struct {
+	Key  string
+	Kind Kind
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return "+ " + c.Key
+	case Removed:
+		return "- " + c.Key
+	default:
+		return "~ " + c.Key
+	}
+}
+
+// Compare returns the changes from the existing values to the wanted values,
+// sorted by key.
+func Compare(want, have map[string]string) []Change {
+	added, removed := diff.List(slices.Collect(maps.Keys(have)), slices.Collect(maps.Keys(want)))
+
+	changes := make([]Change, 0, len(added)+len(removed))
+	for _, k := range added {
+		changes = append(changes, Change{Key: k, Kind: Added})
+	}
+	for _, k := range removed {
+		changes = append(changes, Change{Key: k, Kind: Removed})
+	}
+	for k, v := range want {
+		if hv, found := have[k]; found && hv != v {
+			changes = append(changes, Change{Key: k, Kind: Changed})
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b Change) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return changes
+}
@@ -0,0 +1,167 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSecrets(t *testing.T) map[string]gopass.Secret {
+	t.Helper()
+
+	db := secrets.NewAKV()
+	db.SetPassword("s3cr3t")
+	require.NoError(t, db.Set("user", "app"))
+	require.NoError(t, db.Set("host", "db1"))
+	require.NoError(t, db.Add("host", "db2"))
+
+	api := secrets.NewAKV()
+	require.NoError(t, api.Set("token", "abc"))
+
+	return map[string]gopass.Secret{
+		"prod/app/db_password": db,
+		"prod/app/api":         api,
+	}
+}
+
+func TestData(t *testing.T) {
+	t.Parallel()
+
+	secs := testSecrets(t)
+
+	data, err := Data(secs, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"db_password": "s3cr3t",
+		"user":        "app",
+		"host":        "db1\ndb2",
+		"token":       "abc",
+	}, data)
+
+	data, err = Data(secs, strings.ToUpper)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", data["DB_PASSWORD"])
+	assert.Equal(t, "abc", data["TOKEN"])
+
+	other := secrets.NewAKV()
+	require.NoError(t, other.Set("user", "admin"))
+	secs["prod/app/other"] = other
+
+	_, err = Data(secs, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `key "user" of prod/app/other conflicts with prod/app/db_password`)
+	assert.NotContains(t, err.Error(), "admin")
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	changes := Compare(
+		map[string]string{"a": "1", "b": "2", "c": "3"},
+		map[string]string{"b": "2", "c": "4", "d": "5"},
+	)
+
+	assert.Equal(t, []Change{
+		{Key: "a", Kind: Added},
+		{Key: "c", Kind: Changed},
+		{Key: "d", Kind: Removed},
+	}, changes)
+	assert.Equal(t, "+ a", changes[0].String())
+	assert.Equal(t, "~ c", changes[1].String())
+	assert.Equal(t, "- d", changes[2].String())
+
+	assert.Empty(t, Compare(map[string]string{"a": "1"}, map[string]string{"a": "1"}))
+}
+
+func TestK8sSecret(t *testing.T) {
+	t.Parallel()
+
+	sec, err := NewK8sSecret("app", "prod", map[string]string{"user": "app", "db_password": "s3cr3t"})
+	require.NoError(t, err)
+
+	buf, err := sec.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  namespace: prod
+type: Opaque
+data:
+  db_password: czNjcjN0
+  user: YXBw
+`, string(buf))
+
+	parsed, err := ParseK8sSecret(buf)
+	require.NoError(t, err)
+	assert.Equal(t, sec, parsed)
+
+	values, err := parsed.Values()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"user": "app", "db_password": "s3cr3t"}, values)
+
+	_, err = NewK8sSecret("app", "", map[string]string{"a/b": "x"})
+	require.Error(t, err)
+
+	_, err = ParseK8sSecret([]byte("kind: ConfigMap\n"))
+	require.Error(t, err)
+}
+
+func TestK8sSecretValues(t *testing.T) {
+	t.Parallel()
+
+	sec, err := ParseK8sSecret([]byte(`kind: Secret
+data:
+  a: MQ==
+  b: Mg==
+stringData:
+  b: two
+`))
+	require.NoError(t, err)
+
+	values, err := sec.Values()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "two"}, values)
+
+	sec.Data["c"] = "not base64 s3cr3t"
+	_, err = sec.Values()
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cr3t")
+}
+
+func TestK8sName(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]string{
+		"app":        "app",
+		"My_App":     "my-app",
+		"api.v2":     "api.v2",
+		"__secret__": "secret",
+		"___":        "",
+	} {
+		assert.Equal(t, want, K8sName(in), in)
+	}
+}
+
+func TestDockerEnv(t *testing.T) {
+	t.Parallel()
+
+	buf, err := DockerEnv(map[string]string{"USER": "app", "PASSWORD": "s3 cr=t"})
+	require.NoError(t, err)
+	assert.Equal(t, "PASSWORD=s3 cr=t\nUSER=app\n", string(buf))
+
+	values, err := ParseDockerEnv(append([]byte("# comment\n\nFROM_ENV\n"), buf...))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"USER": "app", "PASSWORD": "s3 cr=t"}, values)
+
+	_, err = DockerEnv(map[string]string{"CERT": "line1\ns3cr3t"})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cr3t")
+
+	_, err = DockerEnv(map[string]string{"A B": "x"})
+	require.Error(t, err)
+}
@@ -0,0 +1,451 @@
+//go:build linux || darwin || freebsd
+
+package secretfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+)
+
+// fileSystem holds the state shared by all nodes.
+type fileSystem struct {
+	// ctx is used for the backend, the contexts of FUSE requests don't
+	// carry the gopass configuration.
+	ctx  context.Context //nolint:containedctx
+	b    Backend
+	opts Options
+
+	mu       sync.Mutex
+	names    []string
+	listedAt time.Time
+	cache    map[string]*cached
+	loading  map[string]*loading
+}
+
+// loading is a secret that is being decrypted.
+type loading struct {
+	done chan struct{}
+	sec  gopass.Secret
+	err  error
+}
+
+// cached is a decrypted secret and the nodes that exposed its content to
+// the page cache.
+type cached struct {
+	sec   gopass.Secret
+	nodes []*fileNode
+}
+
+// Mount mounts the secrets read-only on dir. It returns once the file system
+// is ready.
+func Mount(ctx context.Context, dir string, b Backend, opts Options) (Server, error) {
+	opts.Prefix = strings.Trim(opts.Prefix, "/")
+	f := &fileSystem{
+		ctx:     ctx,
+		b:       b,
+		opts:    opts,
+		cache:   map[string]*cached{},
+		loading: map[string]*loading{},
+	}
+
+	// the kernel must ask again for every lookup and attribute since
+	// secrets can change and their size is only known once decrypted.
+	var zero time.Duration
+	srv, err := fs.Mount(dir, &dirNode{f: f, name: opts.Prefix}, &fs.Options{
+		MountOptions: fuse.MountOptions{
+			FsName:      "gopass",
+			Name:        "gopass",
+			Options:     []string{"ro"},
+			DirectMount: true,
+		},
+		EntryTimeout: &zero,
+		AttrTimeout:  &zero,
+		UID:          uint32(os.Getuid()), //nolint:gosec
+		GID:          uint32(os.Getgid()), //nolint:gosec
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to mount %s: %w", dir, err)
+	}
+
+	return srv, nil
+}
+
+// list returns the names of all secrets below the prefix.
+func (f *fileSystem) list() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.names != nil && time.Since(f.listedAt) < listTTL {
+		return f.names, nil
+	}
+
+	names, err := f.b.List(f.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.opts.Prefix != "" {
+		names = slices.DeleteFunc(names, func(name string) bool {
+			return !strings.HasPrefix(name, f.opts.Prefix+"/")
+		})
+	}
+
+	f.names = names
+	f.listedAt = time.Now()
+
+	return names, nil
+}
+
+// secret decrypts a secret. With a cache timeout the secret is kept until
+// the timeout expires, then it is dropped from memory and from the page
+// cache of all files that showed it.
+func (f *fileSystem) secret(name string, node *fileNode) (gopass.Secret, error) {
+	if f.opts.CacheTimeout <= 0 {
+		return f.b.Get(f.ctx, name)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, found := f.cache[name]
+	if !found {
+		// decrypting can take a while, e.g. to ask for the passphrase, so
+		// it must not block the other files. Concurrent reads of the same
+		// secret wait for the first one to decrypt it.
+		l, found := f.loading[name]
+		if !found {
+			l = &loading{done: make(chan struct{})}
+			f.loading[name] = l
+
+			f.mu.Unlock()
+			l.sec, l.err = f.b.Get(f.ctx, name)
+			f.mu.Lock()
+
+			delete(f.loading, name)
+			close(l.done)
+		} else {
+			f.mu.Unlock()
+			<-l.done
+			f.mu.Lock()
+		}
+
+		if l.err != nil {
+			return nil, l.err
+		}
+
+		// the secret is cached by the first reader, unless the cache
+		// timeout was shorter than the wait.
+		c, found = f.cache[name]
+		if !found {
+			c = &cached{sec: l.sec}
+			f.cache[name] = c
+			time.AfterFunc(f.opts.CacheTimeout, func() { f.drop(name) })
+		}
+	}
+
+	if node != nil && !slices.Contains(c.nodes, node) {
+		c.nodes = append(c.nodes, node)
+	}
+
+	return c.sec, nil
+}
+
+// cachedSize returns the size of a cached file.
+func (f *fileSystem) cachedSize(name, key string) uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, found := f.cache[name]
+	if !found {
+		return 0
+	}
+
+	buf, _ := content(c.sec, key)
+
+	return uint64(len(buf))
+}
+
+func (f *fileSystem) drop(name string) {
+	f.mu.Lock()
+	c := f.cache[name]
+	delete(f.cache, name)
+	f.mu.Unlock()
+
+	if c == nil {
+		return
+	}
+
+	debug.Log("dropping cached content of %s", name)
+	for _, n := range c.nodes {
+		// invalidate the whole page cache of the file.
+		if errno := n.NotifyContent(0, 0); errno != 0 && !errors.Is(errno, syscall.ENOENT) {
+			debug.Log("failed to invalidate %s: %s", name, errno)
+		}
+	}
+}
+
+// allowed checks the executable of the process of a request against the
+// allowlist.
+func (f *fileSystem) allowed(ctx context.Context) bool {
+	if len(f.opts.Allow) < 1 {
+		return true
+	}
+
+	caller, found := fuse.FromContext(ctx)
+	if !found {
+		return false
+	}
+
+	exe, err := executable(caller.Pid)
+	if err != nil {
+		debug.Log("denying access to pid %d: %s", caller.Pid, err)
+
+		return false
+	}
+
+	if !isAllowed(exe, f.opts.Allow) {
+		debug.Log("denying access to %s (pid %d)", exe, caller.Pid)
+
+		return false
+	}
+
+	return true
+}
+
+// stable returns stable inode numbers so the kernel caches can be
+// invalidated for a secret.
+func stable(mode uint32, name string) fs.StableAttr {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%o:%s", mode, name)
+
+	return fs.StableAttr{Mode: mode, Ino: h.Sum64()}
+}
+
+// dirNode is a folder of the store.
+type dirNode struct {
+	fs.Inode
+
+	f    *fileSystem
+	name string
+}
+
+var (
+	_ fs.NodeGetattrer = (*dirNode)(nil)
+	_ fs.NodeLookuper  = (*dirNode)(nil)
+	_ fs.NodeReaddirer = (*dirNode)(nil)
+)
+
+func (n *dirNode) Getattr(ctx context.Context, _ fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	out.Mode = fuse.S_IFDIR | 0o500
+
+	return 0
+}
+
+func (n *dirNode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
+	names, err := n.f.list()
+	if err != nil {
+		debug.Log("failed to list secrets: %s", err)
+
+		return nil, syscall.EIO
+	}
+
+	secretMode := uint32(fuse.S_IFREG)
+	if n.f.opts.Keys {
+		secretMode = fuse.S_IFDIR
+	}
+
+	folders, secrets := entries(names, n.name)
+	list := make([]fuse.DirEntry, 0, len(folders)+len(secrets))
+	for _, d := range folders {
+		list = append(list, fuse.DirEntry{Name: d, Mode: fuse.S_IFDIR, Ino: stable(fuse.S_IFDIR, join(n.name, d)).Ino})
+	}
+	for _, s := range secrets {
+		list = append(list, fuse.DirEntry{Name: s, Mode: secretMode, Ino: stable(secretMode, join(n.name, s)).Ino})
+	}
+
+	return fs.NewListDirStream(list), 0
+}
+
+func (n *dirNode) Lookup(ctx context.Context, name string, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	names, err := n.f.list()
+	if err != nil {
+		debug.Log("failed to list secrets: %s", err)
+
+		return nil, syscall.EIO
+	}
+
+	full := join(n.name, name)
+	folders, secrets := entries(names, n.name)
+
+	switch {
+	case slices.Contains(folders, name):
+		out.Mode = fuse.S_IFDIR | 0o500
+
+		return n.NewInode(ctx, &dirNode{f: n.f, name: full}, stable(fuse.S_IFDIR, full)), 0
+	case slices.Contains(secrets, name) && n.f.opts.Keys:
+		out.Mode = fuse.S_IFDIR | 0o500
+
+		return n.NewInode(ctx, &secretDirNode{f: n.f, name: full}, stable(fuse.S_IFDIR, full)), 0
+	case slices.Contains(secrets, name):
+		child := &fileNode{f: n.f, name: full}
+		child.attr(&out.Attr)
+
+		return n.NewInode(ctx, child, stable(fuse.S_IFREG, full)), 0
+	default:
+		return nil, syscall.ENOENT
+	}
+}
+
+// secretDirNode is a secret with one file per key.
+type secretDirNode struct {
+	fs.Inode
+
+	f    *fileSystem
+	name string
+}
+
+var (
+	_ fs.NodeGetattrer = (*secretDirNode)(nil)
+	_ fs.NodeLookuper  = (*secretDirNode)(nil)
+	_ fs.NodeReaddirer = (*secretDirNode)(nil)
+)
+
+func (n *secretDirNode) Getattr(ctx context.Context, _ fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	out.Mode = fuse.S_IFDIR | 0o500
+
+	return 0
+}
+
+// files decrypts the secret to find its keys.
+func (n *secretDirNode) files(ctx context.Context) ([]string, syscall.Errno) {
+	if !n.f.allowed(ctx) {
+		return nil, syscall.EACCES
+	}
+
+	sec, err := n.f.secret(n.name, nil)
+	if err != nil {
+		debug.Log("failed to decrypt %s: %s", n.name, err)
+
+		return nil, syscall.EIO
+	}
+
+	return keyFiles(sec), 0
+}
+
+func (n *secretDirNode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
+	files, errno := n.files(ctx)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	list := make([]fuse.DirEntry, 0, len(files))
+	for _, k := range files {
+		list = append(list, fuse.DirEntry{Name: k, Mode: fuse.S_IFREG, Ino: stable(fuse.S_IFREG, n.name+"\x00"+k).Ino})
+	}
+
+	return fs.NewListDirStream(list), 0
+}
+
+func (n *secretDirNode) Lookup(ctx context.Context, name string, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	files, errno := n.files(ctx)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	if !slices.Contains(files, name) {
+		return nil, syscall.ENOENT
+	}
+
+	child := &fileNode{f: n.f, name: n.name, key: name}
+	child.attr(&out.Attr)
+
+	return n.NewInode(ctx, child, stable(fuse.S_IFREG, n.name+"\x00"+name)), 0
+}
+
+// fileNode is a secret or a key of a secret.
+type fileNode struct {
+	fs.Inode
+
+	f    *fileSystem
+	name string
+	// key is empty for the whole secret.
+	key string
+}
+
+var (
+	_ fs.NodeGetattrer = (*fileNode)(nil)
+	_ fs.NodeOpener    = (*fileNode)(nil)
+)
+
+// attr sets the attributes of the file. The size is only known while the
+// secret is cached.
+func (n *fileNode) attr(out *fuse.Attr) {
+	out.Mode = fuse.S_IFREG | 0o400
+	out.Size = n.f.cachedSize(n.name, n.key)
+}
+
+func (n *fileNode) Getattr(ctx context.Context, _ fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	n.attr(&out.Attr)
+
+	return 0
+}
+
+func (n *fileNode) Open(ctx context.Context, flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
+	if flags&syscall.O_ACCMODE != syscall.O_RDONLY {
+		return nil, 0, syscall.EROFS
+	}
+
+	if !n.f.allowed(ctx) {
+		return nil, 0, syscall.EACCES
+	}
+
+	sec, err := n.f.secret(n.name, n)
+	if err != nil {
+		debug.Log("failed to decrypt %s: %s", n.name, err)
+
+		return nil, 0, syscall.EIO
+	}
+
+	buf, found := content(sec, n.key)
+	if !found {
+		return nil, 0, syscall.ENOENT
+	}
+
+	// without caching the content must never reach the page cache.
+	fuseFlags := uint32(fuse.FOPEN_DIRECT_IO)
+	if n.f.opts.CacheTimeout > 0 {
+		fuseFlags = fuse.FOPEN_KEEP_CACHE
+	}
+
+	return &fileHandle{content: buf}, fuseFlags, 0
+}
+
+// fileHandle holds the decrypted content of an open file.
+type fileHandle struct {
+	content []byte
+}
+
+var _ fs.FileReader = (*fileHandle)(nil)
+
+func (h *fileHandle) Read(ctx context.Context, dest []byte, off int64) (fuse.ReadResult, syscall.Errno) {
+	if off >= int64(len(h.content)) {
+		return fuse.ReadResultData(nil), 0
+	}
+
+	end := min(off+int64(len(dest)), int64(len(h.content)))
+
+	return fuse.ReadResultData(h.content[off:end]), 0
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+
+package secretfs
+
+import "context"
+
+// Mount is not supported on this platform.
+func Mount(ctx context.Context, dir string, b Backend, opts Options) (Server, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,235 @@
+//go:build linux
+
+package secretfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackend struct {
+	secrets map[string]gopass.Secret
+
+	mu   sync.Mutex
+	gets map[string]int
+}
+
+func (b *fakeBackend) List(ctx context.Context) ([]string, error) {
+	return slices.Sorted(maps.Keys(b.secrets)), nil
+}
+
+func (b *fakeBackend) Get(ctx context.Context, name string) (gopass.Secret, error) {
+	sec, found := b.secrets[name]
+	if !found {
+		return nil, fmt.Errorf("not found")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gets[name]++
+
+	return sec, nil
+}
+
+// decrypted returns how often the secret was decrypted.
+func (b *fakeBackend) decrypted(name string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.gets[name]
+}
+
+func newFakeBackend(t *testing.T) *fakeBackend {
+	t.Helper()
+
+	sec := secrets.NewAKV()
+	sec.SetPassword("s3cr3t")
+	require.NoError(t, sec.Set("username", "alice"))
+
+	return &fakeBackend{
+		secrets: map[string]gopass.Secret{
+			"websites/example.com/alice": sec,
+			"wifi":                       secrets.NewAKVWithData("wifi-pw", nil, "", false),
+		},
+		gets: map[string]int{},
+	}
+}
+
+// mount mounts the file system or skips the test if FUSE is not available.
+func mount(t *testing.T, b Backend, opts Options) string {
+	t.Helper()
+
+	if _, err := os.Stat("/dev/fuse"); err != nil {
+		t.Skip("FUSE is not available")
+	}
+
+	dir := t.TempDir()
+	srv, err := Mount(t.Context(), dir, b, opts)
+	if err != nil {
+		t.Skipf("failed to mount: %s", err)
+	}
+	t.Cleanup(func() {
+		_ = srv.Unmount()
+	})
+
+	return dir
+}
+
+func TestMount(t *testing.T) {
+	b := newFakeBackend(t)
+	dir := mount(t, b, Options{})
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "websites", entries[0].Name())
+	assert.True(t, entries[0].IsDir())
+	assert.Equal(t, "wifi", entries[1].Name())
+
+	// listing does not decrypt.
+	assert.Equal(t, 0, b.decrypted("websites/example.com/alice"))
+
+	buf, err := os.ReadFile(filepath.Join(dir, "websites", "example.com", "alice"))
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t\nusername: alice\n", string(buf))
+
+	// every open decrypts again without caching.
+	_, err = os.ReadFile(filepath.Join(dir, "websites", "example.com", "alice"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, b.decrypted("websites/example.com/alice"))
+
+	err = os.WriteFile(filepath.Join(dir, "wifi"), []byte("new"), 0o600)
+	require.Error(t, err)
+	_, err = os.Stat(filepath.Join(dir, "missing"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestMountKeys(t *testing.T) {
+	b := newFakeBackend(t)
+	dir := mount(t, b, Options{Prefix: "websites", Keys: true, CacheTimeout: time.Minute})
+
+	entries, err := os.ReadDir(filepath.Join(dir, "example.com", "alice"))
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{"password", "username"}, names)
+
+	buf, err := os.ReadFile(filepath.Join(dir, "example.com", "alice", "username"))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", string(buf))
+
+	buf, err = os.ReadFile(filepath.Join(dir, "example.com", "alice", "password"))
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", string(buf))
+
+	// the decrypted secret is cached.
+	assert.Equal(t, 1, b.decrypted("websites/example.com/alice"))
+
+	// the prefix hides other secrets.
+	_, err = os.Stat(filepath.Join(dir, "wifi"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestMountCacheTimeout(t *testing.T) {
+	b := newFakeBackend(t)
+	dir := mount(t, b, Options{CacheTimeout: 100 * time.Millisecond})
+
+	_, err := os.ReadFile(filepath.Join(dir, "wifi"))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		buf, err := os.ReadFile(filepath.Join(dir, "wifi"))
+
+		return err == nil && string(buf) == "wifi-pw\n" && b.decrypted("wifi") > 1
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+// slowBackend blocks decrypting one secret until release is closed.
+type slowBackend struct {
+	*fakeBackend
+
+	slow    string
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *slowBackend) Get(ctx context.Context, name string) (gopass.Secret, error) {
+	if name == b.slow {
+		b.started <- struct{}{}
+		<-b.release
+	}
+
+	return b.fakeBackend.Get(ctx, name)
+}
+
+func TestSecretConcurrent(t *testing.T) {
+	b := &slowBackend{
+		fakeBackend: newFakeBackend(t),
+		slow:        "websites/example.com/alice",
+		started:     make(chan struct{}, 2),
+		release:     make(chan struct{}),
+	}
+	f := &fileSystem{
+		ctx:     t.Context(),
+		b:       b,
+		opts:    Options{CacheTimeout: time.Minute},
+		cache:   map[string]*cached{},
+		loading: map[string]*loading{},
+	}
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Go(func() {
+			sec, err := f.secret(b.slow, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, "s3cr3t", sec.Password())
+		})
+	}
+	<-b.started
+
+	// other secrets can be read while one is decrypted
+	sec, err := f.secret("wifi", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "wifi-pw", sec.Password())
+
+	close(b.release)
+	wg.Wait()
+	assert.Equal(t, 1, b.decrypted(b.slow))
+}
+
+func TestMountAllowlist(t *testing.T) {
+	exe, err := os.Executable()
+	require.NoError(t, err)
+
+	t.Run("denied", func(t *testing.T) {
+		dir := mount(t, newFakeBackend(t), Options{Allow: []string{"ssh"}})
+
+		_, err := os.ReadFile(filepath.Join(dir, "wifi"))
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, syscall.EACCES), err)
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		dir := mount(t, newFakeBackend(t), Options{Allow: []string{filepath.Base(exe)}})
+
+		buf, err := os.ReadFile(filepath.Join(dir, "wifi"))
+		require.NoError(t, err)
+		assert.Equal(t, "wifi-pw\n", string(buf))
+	})
+}
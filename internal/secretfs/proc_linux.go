@@ -0,0 +1,11 @@
+package secretfs
+
+import (
+	"os"
+	"strconv"
+)
+
+// executable returns the path of the executable of a process.
+func executable(pid uint32) (string, error) {
+	return os.Readlink("/proc/" + strconv.FormatUint(uint64(pid), 10) + "/exe")
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package secretfs
+
+import "fmt"
+
+// executable returns the path of the executable of a process. It is only
+// supported on Linux, other platforms deny access if an allowlist is set.
+func executable(pid uint32) (string, error) {
+	return "", fmt.Errorf("can not determine the executable of pid %d on this platform", pid)
+}
@@ -0,0 +1,158 @@
+// Package secretfs presents the secrets of a store as a read-only FUSE file
+// system for tools that only read credentials from files.
+//
+// Folders are directories and secrets are files that are decrypted when they
+// are opened. Optionally every secret is a directory with one file per key
+// instead, e.g. websites/example.com/password and
+// websites/example.com/username.
+package secretfs
+
+import (
+	"context"
+	"errors"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+// ErrUnsupported is returned by Mount on platforms without FUSE support.
+var ErrUnsupported = errors.New("FUSE is not supported on this platform")
+
+// listTTL is how long the list of secrets is cached.
+const listTTL = 5 * time.Second
+
+// passwordFile is the name of the file holding the password if every secret
+// is a directory.
+const passwordFile = "password"
+
+// Backend provides the secrets.
+type Backend interface {
+	// List returns the names of all secrets.
+	List(ctx context.Context) ([]string, error)
+	// Get decrypts a secret.
+	Get(ctx context.Context, name string) (gopass.Secret, error)
+}
+
+// Options configure the file system.
+type Options struct {
+	// Prefix limits the file system to the secrets in this folder.
+	Prefix string
+	// Keys presents every secret as a directory with one file per key.
+	Keys bool
+	// CacheTimeout is how long decrypted content is kept in memory and in
+	// the page cache of the kernel. Zero disables caching, every open
+	// decrypts the secret again.
+	CacheTimeout time.Duration
+	// Allow lists the executables that may read secrets. Entries are either
+	// absolute paths or names of executables. If empty every process of the
+	// current user may read secrets.
+	Allow []string
+}
+
+// Server is a mounted file system.
+type Server interface {
+	// Unmount unmounts the file system.
+	Unmount() error
+	// Wait returns once the file system was unmounted.
+	Wait()
+}
+
+// entries returns the folders and secrets directly inside dir. A name that
+// is both a secret and a folder is only returned as folder.
+func entries(names []string, dir string) ([]string, []string) {
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+
+	folders := map[string]struct{}{}
+	secrets := map[string]struct{}{}
+
+	for _, name := range names {
+		rest, found := strings.CutPrefix(name, prefix)
+		if !found || rest == "" {
+			continue
+		}
+
+		if folder, _, isFolder := strings.Cut(rest, "/"); isFolder {
+			folders[folder] = struct{}{}
+
+			continue
+		}
+
+		secrets[rest] = struct{}{}
+	}
+
+	for f := range folders {
+		delete(secrets, f)
+	}
+
+	return sortedKeys(folders), sortedKeys(secrets)
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// keyFiles returns the files of a secret if every secret is a directory.
+// Keys that are not valid file names are skipped.
+func keyFiles(sec gopass.Secret) []string {
+	files := []string{passwordFile}
+	for _, k := range sec.Keys() {
+		if k == "" || k == "." || k == ".." || strings.Contains(k, "/") || slices.Contains(files, k) {
+			continue
+		}
+		files = append(files, k)
+	}
+
+	return files
+}
+
+// content returns the content of the file for the key of the secret. An
+// empty key returns the whole secret.
+func content(sec gopass.Secret, key string) ([]byte, bool) {
+	switch key {
+	case "":
+		return sec.Bytes(), true
+	case passwordFile:
+		return []byte(sec.Password()), true
+	}
+
+	values, found := sec.Values(key)
+	if !found {
+		return nil, false
+	}
+
+	return []byte(strings.Join(values, "\n")), true
+}
+
+// isAllowed returns true if the executable matches one of the entries of
+// the allowlist.
+func isAllowed(exe string, allow []string) bool {
+	return slices.ContainsFunc(allow, func(a string) bool {
+		if filepath.IsAbs(a) {
+			return filepath.Clean(a) == exe
+		}
+
+		return filepath.Base(exe) == a
+	})
+}
+
+func join(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+
+	return path.Join(dir, name)
+}
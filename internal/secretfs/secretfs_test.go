@@ -0,0 +1,81 @@
+package secretfs
+
+import (
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntries(t *testing.T) {
+	t.Parallel()
+
+	names := []string{
+		"email/alice",
+		"email/bob",
+		"websites/example.com",
+		"websites/example.com/admin",
+		"websites/example.org/alice",
+		"wifi",
+	}
+
+	folders, secrets := entries(names, "")
+	assert.Equal(t, []string{"email", "websites"}, folders)
+	assert.Equal(t, []string{"wifi"}, secrets)
+
+	// a name that is a secret and a folder is only shown as folder.
+	folders, secrets = entries(names, "websites")
+	assert.Equal(t, []string{"example.com", "example.org"}, folders)
+	assert.Empty(t, secrets)
+
+	folders, secrets = entries(names, "email")
+	assert.Empty(t, folders)
+	assert.Equal(t, []string{"alice", "bob"}, secrets)
+
+	folders, secrets = entries(names, "missing")
+	assert.Empty(t, folders)
+	assert.Empty(t, secrets)
+}
+
+func TestKeyFiles(t *testing.T) {
+	t.Parallel()
+
+	sec := secrets.NewAKV()
+	sec.SetPassword("s3cr3t")
+	require.NoError(t, sec.Set("username", "alice"))
+	require.NoError(t, sec.Set("url", "https://example.com"))
+	require.NoError(t, sec.Add("url", "https://example.org"))
+	require.NoError(t, sec.Set("a/b", "invalid file name"))
+	require.NoError(t, sec.Set("password", "duplicate"))
+
+	assert.Equal(t, []string{"password", "url", "username"}, keyFiles(sec))
+
+	for key, want := range map[string]string{
+		"password": "s3cr3t",
+		"username": "alice",
+		"url":      "https://example.com\nhttps://example.org",
+	} {
+		buf, found := content(sec, key)
+		assert.True(t, found, key)
+		assert.Equal(t, want, string(buf), key)
+	}
+
+	buf, found := content(sec, "")
+	assert.True(t, found)
+	assert.Equal(t, sec.Bytes(), buf)
+
+	_, found = content(sec, "missing")
+	assert.False(t, found)
+}
+
+func TestIsAllowed(t *testing.T) {
+	t.Parallel()
+
+	allow := []string{"ssh", "/usr/local/bin/deploy"}
+
+	assert.True(t, isAllowed("/usr/bin/ssh", allow))
+	assert.True(t, isAllowed("/usr/local/bin/deploy", allow))
+	assert.False(t, isAllowed("/usr/bin/deploy", allow))
+	assert.False(t, isAllowed("/usr/bin/cat", allow))
+}
@@ -0,0 +1,107 @@
+package leaf
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+type conflictResolver interface {
+	Conflicts(context.Context) ([]string, error)
+	ConflictVersions(context.Context, string) ([]byte, []byte, error)
+	ResolveConflict(context.Context, string, []byte) error
+}
+
+// ConflictFunc decides which version of a secret with conflicting changes
+// is kept. It returns false for ok if it can not decide.
+type ConflictFunc func(ours, theirs gopass.Secret) (useTheirs, ok bool)
+
+// ResolveConflicts resolves the conflicting changes of secrets left by a
+// failed sync using the given function. It returns the names of the
+// resolved secrets. If any conflicts are left an error wrapping
+// backend.ErrConflict is returned.
+func (s *Store) ResolveConflicts(ctx context.Context, fn ConflictFunc) ([]string, error) {
+	cr, ok := s.storage.(conflictResolver)
+	if !ok {
+		return nil, backend.ErrNotSupported
+	}
+
+	files, err := cr.Conflicts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]string, 0, len(files))
+	left := make([]string, 0, len(files))
+	for _, file := range files {
+		name, ok := s.resolveConflict(ctx, cr, file, fn)
+		if !ok {
+			left = append(left, file)
+
+			continue
+		}
+		resolved = append(resolved, name)
+	}
+
+	if len(left) > 0 {
+		return resolved, fmt.Errorf("%w: unresolved conflicts in %s", backend.ErrConflict, strings.Join(left, ", "))
+	}
+
+	return resolved, nil
+}
+
+// resolveConflict resolves a single conflicting file. It returns the name
+// of the secret and true if it was resolved.
+func (s *Store) resolveConflict(ctx context.Context, cr conflictResolver, file string, fn ConflictFunc) (string, bool) {
+	ext := "." + s.crypto.Ext()
+	if !strings.HasSuffix(file, ext) {
+		return "", false
+	}
+	name := strings.TrimSuffix(file, ext)
+
+	ours, theirs, err := cr.ConflictVersions(ctx, file)
+	if err != nil {
+		debug.Log("failed to get conflicting versions of %s: %s", name, err)
+
+		return "", false
+	}
+
+	ourSec, err := s.crypto.Decrypt(ctx, ours)
+	if err != nil {
+		debug.Log("failed to decrypt our version of %s: %s", name, err)
+
+		return "", false
+	}
+	theirSec, err := s.crypto.Decrypt(ctx, theirs)
+	if err != nil {
+		debug.Log("failed to decrypt their version of %s: %s", name, err)
+
+		return "", false
+	}
+
+	useTheirs, ok := fn(secrets.ParseAKV(ourSec), secrets.ParseAKV(theirSec))
+	if !ok {
+		debug.Log("can not resolve conflict of %s", name)
+
+		return "", false
+	}
+
+	// keep the ciphertext as is, so the recipients do not change.
+	content := ours
+	if useTheirs {
+		content = theirs
+	}
+	if err := cr.ResolveConflict(ctx, file, content); err != nil {
+		debug.Log("failed to resolve conflict of %s: %s", name, err)
+
+		return "", false
+	}
+	debug.Log("resolved conflict of %s (theirs: %t)", name, useTheirs)
+
+	return name, true
+}
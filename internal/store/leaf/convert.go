@@ -2,6 +2,7 @@ package leaf
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"os"
@@ -20,25 +21,129 @@ import (
 	"github.com/gopasspw/gopass/pkg/termio"
 )
 
+// convertSampleSize is the number of entries used to estimate the duration
+// of a conversion.
+const convertSampleSize = 25
+
+// ConvertEstimate is a rough estimate of the cost of a conversion.
+type ConvertEstimate struct {
+	// Entries is the number of entries in the store.
+	Entries int
+	// Done is the number of entries already converted by a previous,
+	// interrupted attempt. These will be skipped.
+	Done int
+	// Revisions is the (extrapolated) number of revisions to convert.
+	Revisions int
+	// Size is the approximate size of the converted store in bytes.
+	Size int64
+	// Duration is the approximate time the conversion will take.
+	Duration time.Duration
+}
+
 // Convert will convert an existing store to a new store with possibly
 // different set of crypto and storage backends. Please note that it
 // will happily convert to the same set of backends if requested.
-func (s *Store) Convert(ctx context.Context, cryptoBe backend.CryptoBackend, storageBe backend.StorageBackend, move bool) error { //nolint:cyclop
-	// use a temp queue so we can flush it before removing the old store
-	q := queue.New(ctx)
-	ctx = queue.WithQueue(ctx, q)
+//
+// The progress is recorded next to the new store so an interrupted
+// conversion can be resumed by running it again with the same backends.
+// Once all entries are copied they are decrypted again and compared with
+// the source. The old store is only replaced if that verification succeeds.
+func (s *Store) Convert(ctx context.Context, cryptoBe backend.CryptoBackend, storageBe backend.StorageBackend, move bool) error {
+	// commit every revision right away. Queued commits would race with
+	// adding the next revision and a revision must only be recorded as
+	// converted once it's committed.
+	ctx = queue.WithQueue(ctx, nil)
+
+	tmpPath := s.path + "-autoconvert"
+	prog, err := openConvertProgress(tmpPath, cryptoBe, storageBe)
+	if err != nil {
+		return err
+	}
+	defer prog.Close() //nolint:errcheck
+
+	tmpStore, err := s.convertTarget(ctx, tmpPath, prog, cryptoBe, storageBe)
+	if err != nil {
+		return err
+	}
+
+	entries, err := s.List(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to list entries of the old store: %w", err)
+	}
+	for i, e := range entries {
+		entries[i] = strings.TrimPrefix(e, s.alias+Sep)
+	}
+
+	// Avoid network operations slowing down the bulk conversion.
+	// We will sync with the remote later.
+	ctx = ctxutil.WithNoNetwork(ctx, true)
+
+	if err := s.convertEntries(ctx, tmpStore, entries, prog); err != nil {
+		return fmt.Errorf("%w. Run the same command again to resume", err)
+	}
 
-	// remove any previous attempts
-	if pDir := filepath.Join(filepath.Dir(s.path), filepath.Base(s.path)+"-autoconvert"); fsutil.IsDir(pDir) {
-		if err := os.RemoveAll(pDir); err != nil {
-			return fmt.Errorf("failed to remove previous attempt %q: %w", pDir, err)
+	if err := s.verifyConvert(ctx, tmpStore, entries); err != nil {
+		return fmt.Errorf("verification of the converted store at %s failed, the old store was not modified: %w", tmpPath, err)
+	}
+
+	if err := prog.Remove(); err != nil {
+		debug.Log("failed to remove conversion progress: %s", err)
+	}
+
+	if !move {
+		debug.Log("conversion done. no move requested. keeping both.")
+
+		return nil
+	}
+
+	// remove any previous backups
+	bDir := filepath.Join(filepath.Dir(s.path), filepath.Base(s.path)+"-backup")
+	if fsutil.IsDir(bDir) {
+		if err := os.RemoveAll(bDir); err != nil {
+			debug.Log("failed to remove previous backup %q: %s", bDir, err)
+		}
+	}
+
+	// rename old to backup
+	if err := os.Rename(s.path, bDir); err != nil {
+		return fmt.Errorf("failed to rename old store from %s to backup at %s: %w", s.path, bDir, err)
+	}
+
+	// rename temp to old
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to rename temp store %s to old %s: %w", tmpPath, s.path, err)
+	}
+
+	return nil
+}
+
+// convertTarget initializes the new store at tmpPath or, when resuming,
+// opens the one created by the previous attempt.
+func (s *Store) convertTarget(ctx context.Context, tmpPath string, prog *convertProgress, cryptoBe backend.CryptoBackend, storageBe backend.StorageBackend) (*Store, error) {
+	crypto, err := backend.NewCrypto(ctx, cryptoBe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize new crypto backend %s: %w", cryptoBe.String(), err)
+	}
+
+	debug.Log("initialized Crypto %s", crypto)
+
+	if prog.Resumed() {
+		tmpStore, err := s.openConvertTarget(ctx, tmpPath, crypto, storageBe)
+		if err == nil {
+			out.Noticef(ctx, "Resuming previous conversion. %d entries already converted.", prog.Len())
+
+			return tmpStore, nil
+		}
+
+		// the previous attempt stopped before the new store was initialized.
+		debug.Log("not resuming previous conversion: %s", err)
+		if err := prog.Restart(); err != nil {
+			return nil, err
 		}
 	}
 
-	// create temp path
-	tmpPath := s.path + "-autoconvert"
 	if err := os.MkdirAll(tmpPath, 0o700); err != nil {
-		return fmt.Errorf("failed to create temporary conversion directory %s: %w", tmpPath, err)
+		return nil, fmt.Errorf("failed to create temporary conversion directory %s: %w", tmpPath, err)
 	}
 
 	debug.Log("create temporary store path for conversion: %s", tmpPath)
@@ -46,18 +151,11 @@ func (s *Store) Convert(ctx context.Context, cryptoBe backend.CryptoBackend, sto
 	// init new store at temp path
 	st, err := backend.InitStorage(ctx, storageBe, tmpPath)
 	if err != nil {
-		return fmt.Errorf("failed to initialize new storage backend %s: %w", storageBe.String(), err)
+		return nil, fmt.Errorf("failed to initialize new storage backend %s: %w", storageBe.String(), err)
 	}
 
 	debug.Log("initialized storage %s at %s", st, tmpPath)
 
-	crypto, err := backend.NewCrypto(ctx, cryptoBe)
-	if err != nil {
-		return fmt.Errorf("failed to initialize new crypto backend %s: %w", cryptoBe.String(), err)
-	}
-
-	debug.Log("initialized Crypto %s", crypto)
-
 	tmpStore := &Store{
 		alias:   s.alias,
 		path:    tmpPath,
@@ -68,105 +166,247 @@ func (s *Store) Convert(ctx context.Context, cryptoBe backend.CryptoBackend, sto
 	// init new store
 	key, err := cui.AskForPrivateKey(ctx, crypto, "Please select a private key")
 	if err != nil {
-		return fmt.Errorf("failed to ask for the private key for %v: %w", crypto, err)
+		return nil, fmt.Errorf("failed to ask for the private key for %v: %w", crypto, err)
 	}
 
 	if err := tmpStore.Init(ctx, tmpPath, key); err != nil {
-		return fmt.Errorf("failed to init new store at %s: %w", tmpPath, err)
+		return nil, fmt.Errorf("failed to init new store at %s: %w", tmpPath, err)
 	}
 
-	// copy everything from old to temp, including all revisions
-	entries, err := s.List(ctx, "")
+	return tmpStore, nil
+}
+
+// openConvertTarget opens the new store of a previous attempt. It fails if
+// that store was not initialized completely.
+func (s *Store) openConvertTarget(ctx context.Context, tmpPath string, crypto backend.Crypto, storageBe backend.StorageBackend) (*Store, error) {
+	st, err := backend.NewStorage(ctx, storageBe, tmpPath)
 	if err != nil {
-		return fmt.Errorf("failed to list entries of the old store: %w", err)
+		return nil, fmt.Errorf("failed to open storage backend %s at %s: %w", storageBe.String(), tmpPath, err)
 	}
 
+	tmpStore := &Store{
+		alias:   s.alias,
+		path:    tmpPath,
+		crypto:  crypto,
+		storage: st,
+	}
+	if !tmpStore.IsInitialized(ctx) {
+		return nil, fmt.Errorf("store at %s is not initialized", tmpPath)
+	}
+
+	return tmpStore, nil
+}
+
+// convertEntries copies everything from old to temp, including all revisions.
+// Entries completed by a previous attempt are skipped.
+func (s *Store) convertEntries(ctx context.Context, tmpStore *Store, entries []string, prog *convertProgress) error {
 	out.Printf(ctx, "Converting store ...")
 	bar := termio.NewProgressBar(int64(len(entries)))
 	bar.Hidden = ctxutil.IsHidden(ctx)
 	if !ctxutil.IsTerminal(ctx) || ctxutil.IsHidden(ctx) {
 		bar = nil
 	}
+	defer bar.Done()
 
-	// Avoid network operations slowing down the bulk conversion.
-	// We will sync with the remote later.
-	ctx = ctxutil.WithNoNetwork(ctx, true)
 	for _, e := range entries {
-		e = strings.TrimPrefix(e, s.alias+Sep)
-		debug.Log("converting %s", e)
-		revs, err := s.ListRevisions(ctx, e)
+		if prog.IsDone(e) {
+			debug.Log("skipping %s, already converted", e)
+			bar.Inc()
+
+			continue
+		}
+
+		// an entry that was interrupted halfway continues after the last
+		// revision that was written to the new store.
+		if err := s.convertEntry(ctx, tmpStore, e, prog); err != nil {
+			return err
+		}
+
+		if err := prog.MarkDone(e); err != nil {
+			return fmt.Errorf("failed to record conversion progress: %w", err)
+		}
+		bar.Inc()
+	}
+
+	return nil
+}
+
+func (s *Store) convertEntry(ctx context.Context, tmpStore *Store, e string, prog *convertProgress) error {
+	debug.Log("converting %s", e)
+	revs, err := s.ListRevisions(ctx, e)
+	if err != nil {
+		// the fs backend does not support revisions. but we can still convert the "latest" (only) revision.
+		if !errors.Is(err, backend.ErrNotSupported) || len(revs) < 1 {
+			return fmt.Errorf("failed to list revision of %s: %w", e, err)
+		}
+	}
+	sort.Sort(sort.Reverse(backend.Revisions(revs)))
+
+	// fail if the first revision fails, but if others fail only warn
+	first := true
+	for _, r := range revs {
+		if prog.IsRevisionDone(e, r.Hash) {
+			debug.Log("skipping %s@%s, already converted", e, r.Hash)
+			first = false
+
+			continue
+		}
+
+		debug.Log("converting %s@%s", e, r.Hash)
+		sec, err := s.GetRevision(ctx, e, r.Hash)
 		if err != nil {
-			// the fs backend does not support revisions. but we can still convert the "latest" (only) revision.
-			if !errors.Is(err, backend.ErrNotSupported) || len(revs) < 1 {
-				return fmt.Errorf("failed to list revision of %s: %w", e, err)
+			if first {
+				return fmt.Errorf("failed to convert revision %s of %s: %w", r.Hash, e, err)
 			}
+			debug.Log("failed to convert revision %s of %s: %w", r.Hash, e, err)
+
+			continue
 		}
-		sort.Sort(sort.Reverse(backend.Revisions(revs)))
 
-		// fail if the first revision fails, but if others fail only warn
-		first := true
-		for _, r := range revs {
-			debug.Log("converting %s@%s", e, r.Hash)
-			sec, err := s.GetRevision(ctx, e, r.Hash)
-			if err != nil {
-				if first {
-					return fmt.Errorf("failed to convert revision %s of %s: %w", r.Hash, e, err)
-				}
-				debug.Log("failed to convert revision %s of %s: %w", r.Hash, e, err)
-
-				continue
+		msg := fmt.Sprintf(
+			"%s\n%s\nCommitted as: %s\nDate: %s\nAuthor: %s <%s>",
+			r.Subject,
+			r.Body,
+			r.Hash,
+			r.Date.Format(time.RFC3339),
+			r.AuthorName,
+			r.AuthorEmail,
+		)
+		ctx := ctxutil.WithCommitMessage(ctx, msg)
+		ctx = ctxutil.WithCommitTimestamp(ctx, r.Date)
+		if err := tmpStore.Set(ctx, e, sec); err != nil {
+			if first {
+				return fmt.Errorf("failed to write converted revision %s of %s to the new store: %w", r.Hash, e, err)
 			}
+			debug.Log("failed to write converted revision %s of %s to the new store: %w", r.Hash, e, err)
+		} else if err := prog.MarkRevisionDone(e, r.Hash); err != nil {
+			return fmt.Errorf("failed to record conversion progress: %w", err)
+		}
 
-			msg := fmt.Sprintf(
-				"%s\n%s\nCommitted as: %s\nDate: %s\nAuthor: %s <%s>",
-				r.Subject,
-				r.Body,
-				r.Hash,
-				r.Date.Format(time.RFC3339),
-				r.AuthorName,
-				r.AuthorEmail,
-			)
-			ctx := ctxutil.WithCommitMessage(ctx, msg)
-			ctx = ctxutil.WithCommitTimestamp(ctx, r.Date)
-			if err := tmpStore.Set(ctx, e, sec); err != nil {
-				if first {
-					return fmt.Errorf("failed to write converted revision %s of %s to the new store: %w", r.Hash, e, err)
-				}
-				debug.Log("failed to write converted revision %s of %s to the new store: %w", r.Hash, e, err)
-			}
+		first = false
+	}
 
-			first = false
+	return nil
+}
+
+// verifyConvert decrypts every entry of the new store and compares it with
+// the source. It also makes sure the new store contains no extra entries.
+func (s *Store) verifyConvert(ctx context.Context, tmpStore *Store, entries []string) error {
+	out.Printf(ctx, "Verifying converted store ...")
+	bar := termio.NewProgressBar(int64(len(entries)))
+	bar.Hidden = ctxutil.IsHidden(ctx)
+	if !ctxutil.IsTerminal(ctx) || ctxutil.IsHidden(ctx) {
+		bar = nil
+	}
+	defer bar.Done()
+
+	var mismatch []string
+	for _, e := range entries {
+		want, err := s.plaintextHash(ctx, e)
+		if err != nil {
+			return fmt.Errorf("failed to read %s from the old store: %w", e, err)
+		}
+
+		got, err := tmpStore.plaintextHash(ctx, e)
+		if err != nil {
+			debug.Log("failed to read %s from the new store: %s", e, err)
+			mismatch = append(mismatch, e)
+			bar.Inc()
+
+			continue
+		}
+
+		if got != want {
+			debug.Log("content of %s differs after conversion", e)
+			mismatch = append(mismatch, e)
 		}
 		bar.Inc()
 	}
-	bar.Done()
 
-	// flush queue
-	_ = q.Close(ctx)
+	converted, err := tmpStore.List(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to list entries of the new store: %w", err)
+	}
+	if len(converted) != len(entries) {
+		return fmt.Errorf("new store has %d entries, expected %d", len(converted), len(entries))
+	}
 
-	if !move {
-		debug.Log("conversion done. no move requested. keeping both.")
+	if len(mismatch) > 0 {
+		return fmt.Errorf("%d entries differ: %s", len(mismatch), strings.Join(mismatch, ", "))
+	}
 
-		return nil
+	return nil
+}
+
+// plaintextHash returns the SHA-256 hash of the decrypted content of the
+// named entry. The content is parsed the same way as during the conversion
+// so that a normalized representation is compared.
+func (s *Store) plaintextHash(ctx context.Context, name string) ([sha256.Size]byte, error) {
+	sec, err := s.Get(ctx, name)
+	if err != nil {
+		return [sha256.Size]byte{}, err
 	}
 
-	// remove any previous backups
-	bDir := filepath.Join(filepath.Dir(s.path), filepath.Base(s.path)+"-backup")
-	if fsutil.IsDir(bDir) {
-		if err := os.RemoveAll(bDir); err != nil {
-			debug.Log("failed to remove previous backup %q: %s", bDir, err)
-		}
+	return sha256.Sum256(sec.Bytes()), nil
+}
+
+// EstimateConvert estimates the size and duration of converting this store
+// to the given backends without modifying anything. The duration is
+// extrapolated from reading a sample of the entries.
+func (s *Store) EstimateConvert(ctx context.Context, cryptoBe backend.CryptoBackend, storageBe backend.StorageBackend) (ConvertEstimate, error) {
+	est := ConvertEstimate{}
+
+	entries, err := s.List(ctx, "")
+	if err != nil {
+		return est, fmt.Errorf("failed to list entries: %w", err)
+	}
+	est.Entries = len(entries)
+	if est.Entries < 1 {
+		return est, nil
 	}
 
-	// rename old to backup
-	if err := os.Rename(s.path, bDir); err != nil {
-		return fmt.Errorf("failed to rename old store from %s to backup at %s: %w", s.path, bDir, err)
+	est.Done = peekConvertProgress(s.path+"-autoconvert", cryptoBe, storageBe)
+
+	var size int64
+	for _, e := range entries {
+		buf, err := s.storage.Get(ctx, s.passfile(ctx, strings.TrimPrefix(e, s.alias+Sep)))
+		if err != nil {
+			return est, fmt.Errorf("failed to read %s: %w", e, err)
+		}
+		size += int64(len(buf))
 	}
 
-	// rename temp to old
-	if err := os.Rename(tmpPath, s.path); err != nil {
-		return fmt.Errorf("failed to rename temp store %s to old %s: %w", tmpPath, s.path, err)
+	// sample entries evenly spread over the store.
+	step := max(1, len(entries)/convertSampleSize)
+	var sampled, revisions int
+	start := time.Now()
+	for i := 0; i < len(entries); i += step {
+		e := strings.TrimPrefix(entries[i], s.alias+Sep)
+		revs, err := s.ListRevisions(ctx, e)
+		if err != nil && (!errors.Is(err, backend.ErrNotSupported) || len(revs) < 1) {
+			return est, fmt.Errorf("failed to list revisions of %s: %w", e, err)
+		}
+		for _, r := range revs {
+			if _, err := s.GetRevision(ctx, e, r.Hash); err != nil {
+				debug.Log("failed to read %s@%s: %s", e, r.Hash, err)
+			}
+		}
+		sampled++
+		revisions += len(revs)
 	}
+	elapsed := time.Since(start)
 
-	return nil
+	revsPerEntry := float64(revisions) / float64(sampled)
+	est.Revisions = int(revsPerEntry * float64(est.Entries))
+	est.Size = int64(float64(size) * revsPerEntry)
+
+	// reading the sample took one decryption per revision. Converting
+	// additionally encrypts every revision and the verification decrypts
+	// every entry twice.
+	perEntry := float64(elapsed) / float64(sampled)
+	perDecrypt := float64(elapsed) / float64(max(1, revisions))
+	remaining := float64(est.Entries - est.Done)
+	est.Duration = time.Duration(remaining*(2*perEntry) + float64(est.Entries)*2*perDecrypt)
+
+	return est, nil
 }
@@ -0,0 +1,248 @@
+package leaf
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/pkg/debug"
+	"github.com/gopasspw/gopass/pkg/fsutil"
+)
+
+// convertHeader identifies the target of a conversion. A previous attempt
+// is only resumed if it converted to the same backends.
+type convertHeader struct {
+	Crypto  string `json:"crypto"`
+	Storage string `json:"storage"`
+}
+
+// convertRevision records a single revision that was written to the new
+// store while its entry was still being converted.
+type convertRevision struct {
+	Name     string `json:"name"`
+	Revision string `json:"revision"`
+}
+
+// convertProgress records which entries and revisions have been converted.
+// It is stored next to the temporary store as a header line followed by one
+// JSON value per line, so recording progress is a single append. A line is
+// either the name of a fully converted entry or a convertRevision.
+type convertProgress struct {
+	path    string
+	tmpPath string
+	hdr     convertHeader
+	fh      *os.File
+	done    map[string]bool
+	revs    map[convertRevision]bool
+	resumed bool
+}
+
+func convertProgressPath(tmpPath string) string {
+	return tmpPath + ".progress"
+}
+
+// openConvertProgress loads the progress of a previous attempt to convert
+// to the same backends. Otherwise it removes any leftovers and starts over.
+func openConvertProgress(tmpPath string, cryptoBe backend.CryptoBackend, storageBe backend.StorageBackend) (*convertProgress, error) {
+	hdr := convertHeader{Crypto: cryptoBe.String(), Storage: storageBe.String()}
+	p := &convertProgress{
+		path:    convertProgressPath(tmpPath),
+		tmpPath: tmpPath,
+		hdr:     hdr,
+		done:    make(map[string]bool, 128),
+		revs:    make(map[convertRevision]bool, 128),
+	}
+
+	if done, revs, err := readConvertProgress(p.path, hdr); err == nil && fsutil.IsDir(tmpPath) {
+		p.done = done
+		p.revs = revs
+		p.resumed = true
+
+		fh, err := os.OpenFile(p.path, os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open conversion progress %s: %w", p.path, err)
+		}
+		p.fh = fh
+
+		return p, nil
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		debug.Log("not resuming previous conversion: %s", err)
+	}
+
+	if err := p.start(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// start removes any previous attempt and records a new one.
+func (p *convertProgress) start() error {
+	if fsutil.IsDir(p.tmpPath) {
+		if err := os.RemoveAll(p.tmpPath); err != nil {
+			return fmt.Errorf("failed to remove previous attempt %q: %w", p.tmpPath, err)
+		}
+	}
+
+	fh, err := os.OpenFile(p.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create conversion progress %s: %w", p.path, err)
+	}
+	p.fh = fh
+
+	if err := p.writeLine(p.hdr); err != nil {
+		_ = fh.Close()
+		p.fh = nil
+
+		return err
+	}
+
+	return nil
+}
+
+// Restart discards the previous attempt, e.g. if its new store was never
+// initialized completely, and starts over.
+func (p *convertProgress) Restart() error {
+	if err := p.Close(); err != nil {
+		debug.Log("failed to close %s: %s", p.path, err)
+	}
+
+	p.done = make(map[string]bool, 128)
+	p.revs = make(map[convertRevision]bool, 128)
+	p.resumed = false
+
+	return p.start()
+}
+
+// peekConvertProgress returns the number of entries already converted by a
+// previous attempt to convert to the same backends.
+func peekConvertProgress(tmpPath string, cryptoBe backend.CryptoBackend, storageBe backend.StorageBackend) int {
+	if !fsutil.IsDir(tmpPath) {
+		return 0
+	}
+
+	done, _, err := readConvertProgress(convertProgressPath(tmpPath), convertHeader{Crypto: cryptoBe.String(), Storage: storageBe.String()})
+	if err != nil {
+		return 0
+	}
+
+	return len(done)
+}
+
+func readConvertProgress(path string, want convertHeader) (map[string]bool, map[convertRevision]bool, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer fh.Close() //nolint:errcheck
+
+	sc := bufio.NewScanner(fh)
+	if !sc.Scan() {
+		return nil, nil, fmt.Errorf("empty progress file")
+	}
+
+	var hdr convertHeader
+	if err := json.Unmarshal(sc.Bytes(), &hdr); err != nil {
+		return nil, nil, fmt.Errorf("invalid progress header: %w", err)
+	}
+	if hdr != want {
+		return nil, nil, fmt.Errorf("previous attempt converted to %s/%s", hdr.Crypto, hdr.Storage)
+	}
+
+	done := make(map[string]bool, 128)
+	revs := make(map[convertRevision]bool, 128)
+	for sc.Scan() {
+		var name string
+		if err := json.Unmarshal(sc.Bytes(), &name); err == nil {
+			done[name] = true
+
+			continue
+		}
+
+		var rev convertRevision
+		if err := json.Unmarshal(sc.Bytes(), &rev); err != nil || rev.Name == "" {
+			// most likely a partial write when the conversion was
+			// interrupted. That revision will be converted again.
+			debug.Log("ignoring invalid progress line %q: %v", sc.Text(), err)
+
+			continue
+		}
+		revs[rev] = true
+	}
+
+	return done, revs, sc.Err()
+}
+
+func (p *convertProgress) writeLine(v any) error {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\n')
+
+	if _, err := p.fh.Write(buf); err != nil {
+		return fmt.Errorf("failed to write %s: %w", p.path, err)
+	}
+
+	return nil
+}
+
+// Resumed returns true if a previous attempt is being continued.
+func (p *convertProgress) Resumed() bool {
+	return p.resumed
+}
+
+// Len returns the number of entries already converted.
+func (p *convertProgress) Len() int {
+	return len(p.done)
+}
+
+// IsDone returns true if the entry has been converted already.
+func (p *convertProgress) IsDone(name string) bool {
+	return p.done[name]
+}
+
+// MarkDone records that the entry has been converted completely.
+func (p *convertProgress) MarkDone(name string) error {
+	p.done[name] = true
+
+	return p.writeLine(name)
+}
+
+// IsRevisionDone returns true if the revision of the entry has been written
+// to the new store already.
+func (p *convertProgress) IsRevisionDone(name, revision string) bool {
+	return p.revs[convertRevision{Name: name, Revision: revision}]
+}
+
+// MarkRevisionDone records that the revision of the entry has been written
+// to the new store.
+func (p *convertProgress) MarkRevisionDone(name, revision string) error {
+	rev := convertRevision{Name: name, Revision: revision}
+	p.revs[rev] = true
+
+	return p.writeLine(rev)
+}
+
+// Close closes the progress file.
+func (p *convertProgress) Close() error {
+	if p.fh == nil {
+		return nil
+	}
+	err := p.fh.Close()
+	p.fh = nil
+
+	return err
+}
+
+// Remove deletes the progress file once the conversion is complete.
+func (p *convertProgress) Remove() error {
+	if err := p.Close(); err != nil {
+		debug.Log("failed to close %s: %s", p.path, err)
+	}
+
+	return os.Remove(p.path)
+}
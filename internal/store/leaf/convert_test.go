@@ -0,0 +1,225 @@
+package leaf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/out"
+	"github.com/gopasspw/gopass/pkg/ctxutil"
+	"github.com/gopasspw/gopass/pkg/fsutil"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	ctx := config.NewContextInMemory()
+
+	obuf := &bytes.Buffer{}
+	out.Stdout = obuf
+	defer func() {
+		out.Stdout = os.Stdout
+	}()
+
+	s, err := createSubStore(t)
+	require.NoError(t, err)
+
+	sec := secrets.NewAKV()
+	sec.SetPassword("secret")
+	require.NoError(t, s.Set(ctx, "foo/secret", sec))
+
+	tmpPath := s.path + "-autoconvert"
+	require.NoError(t, s.Convert(ctx, backend.Plain, backend.FS, true))
+
+	assert.True(t, fsutil.IsDir(s.path+"-backup"))
+	assert.False(t, fsutil.IsDir(tmpPath))
+	assert.False(t, fsutil.IsFile(convertProgressPath(tmpPath)))
+
+	got, err := s.Get(ctx, "foo/secret")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", got.Password())
+}
+
+func TestConvertResume(t *testing.T) {
+	ctx := config.NewContextInMemory()
+
+	obuf := &bytes.Buffer{}
+	out.Stdout = obuf
+	defer func() {
+		out.Stdout = os.Stdout
+	}()
+
+	s, err := createSubStore(t)
+	require.NoError(t, err)
+
+	tmpPath := s.path + "-autoconvert"
+
+	// simulate an attempt that was interrupted after the first entry
+	prog, err := openConvertProgress(tmpPath, backend.Plain, backend.FS)
+	require.NoError(t, err)
+	assert.False(t, prog.Resumed())
+	tmpStore, err := s.convertTarget(ctx, tmpPath, prog, backend.Plain, backend.FS)
+	require.NoError(t, err)
+	require.NoError(t, s.convertEntry(ctx, tmpStore, "baz/ing/a", prog))
+	require.NoError(t, prog.MarkDone("baz/ing/a"))
+	require.NoError(t, prog.Close())
+
+	assert.Equal(t, 1, peekConvertProgress(tmpPath, backend.Plain, backend.FS))
+	// a different target does not resume
+	assert.Equal(t, 0, peekConvertProgress(tmpPath, backend.Plain, backend.GitFS))
+
+	est, err := s.EstimateConvert(ctx, backend.Plain, backend.FS)
+	require.NoError(t, err)
+	assert.Equal(t, 2, est.Entries)
+	assert.Equal(t, 1, est.Done)
+	assert.Equal(t, 2, est.Revisions)
+
+	prog, err = openConvertProgress(tmpPath, backend.Plain, backend.FS)
+	require.NoError(t, err)
+	assert.True(t, prog.Resumed())
+	assert.True(t, prog.IsDone("baz/ing/a"))
+	assert.False(t, prog.IsDone("foo/bar/baz"))
+	require.NoError(t, prog.Close())
+
+	require.NoError(t, s.Convert(ctx, backend.Plain, backend.FS, false))
+	assert.Contains(t, obuf.String(), "Resuming previous conversion. 1 entries already converted.")
+	assert.False(t, fsutil.IsFile(convertProgressPath(tmpPath)))
+	assert.True(t, fsutil.IsFile(filepath.Join(tmpPath, "foo", "bar", "baz.txt")))
+}
+
+func TestConvertResumeUninitialized(t *testing.T) {
+	ctx := config.NewContextInMemory()
+
+	obuf := &bytes.Buffer{}
+	out.Stdout = obuf
+	defer func() {
+		out.Stdout = os.Stdout
+	}()
+
+	s, err := createSubStore(t)
+	require.NoError(t, err)
+
+	tmpPath := s.path + "-autoconvert"
+
+	// simulate an attempt that was interrupted between initializing the
+	// storage and the store, e.g. while selecting the key.
+	prog, err := openConvertProgress(tmpPath, backend.Plain, backend.FS)
+	require.NoError(t, err)
+	_, err = backend.InitStorage(ctx, backend.FS, tmpPath)
+	require.NoError(t, err)
+	require.NoError(t, prog.Close())
+
+	prog, err = openConvertProgress(tmpPath, backend.Plain, backend.FS)
+	require.NoError(t, err)
+	assert.True(t, prog.Resumed())
+	require.NoError(t, prog.Close())
+
+	// the next attempt starts over instead of using the broken store.
+	require.NoError(t, s.Convert(ctx, backend.Plain, backend.FS, false))
+	assert.NotContains(t, obuf.String(), "Resuming previous conversion")
+	assert.False(t, fsutil.IsFile(convertProgressPath(tmpPath)))
+	assert.True(t, fsutil.IsFile(filepath.Join(tmpPath, "foo", "bar", "baz.txt")))
+	assert.True(t, fsutil.IsFile(filepath.Join(tmpPath, ".plain-id")))
+}
+
+func TestConvertResumeRevisions(t *testing.T) {
+	ctx := config.NewContextInMemory()
+	ctx = ctxutil.WithUsername(ctx, "foo")
+	ctx = ctxutil.WithEmail(ctx, "foo@baz.com")
+
+	obuf := &bytes.Buffer{}
+	out.Stdout = obuf
+	defer func() {
+		out.Stdout = os.Stdout
+	}()
+
+	s, err := createSubStore(t)
+	require.NoError(t, err)
+	require.NoError(t, s.GitInit(backend.WithStorageBackend(ctx, backend.GitFS)))
+
+	// distinct commit dates keep the order of the revisions stable
+	ts := time.Now().Add(-time.Hour)
+	for i, pw := range []string{"one", "two", "three"} {
+		sec := secrets.NewAKV()
+		sec.SetPassword(pw)
+		cctx := ctxutil.WithCommitTimestamp(ctx, ts.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, s.Set(ctxutil.WithCommitMessage(cctx, pw), "foo/hist", sec))
+	}
+	revs, err := s.ListRevisions(ctx, "foo/hist")
+	require.NoError(t, err)
+	require.Len(t, revs, 3)
+	sort.Sort(sort.Reverse(backend.Revisions(revs)))
+
+	tmpPath := s.path + "-autoconvert"
+
+	// simulate an attempt that was interrupted after the first revision
+	prog, err := openConvertProgress(tmpPath, backend.Plain, backend.GitFS)
+	require.NoError(t, err)
+	tmpStore, err := s.convertTarget(ctx, tmpPath, prog, backend.Plain, backend.GitFS)
+	require.NoError(t, err)
+	sec, err := s.GetRevision(ctx, "foo/hist", revs[0].Hash)
+	require.NoError(t, err)
+	require.NoError(t, tmpStore.Set(ctx, "foo/hist", sec))
+	require.NoError(t, prog.MarkRevisionDone("foo/hist", revs[0].Hash))
+	require.NoError(t, prog.Close())
+
+	prog, err = openConvertProgress(tmpPath, backend.Plain, backend.GitFS)
+	require.NoError(t, err)
+	assert.True(t, prog.Resumed())
+	assert.True(t, prog.IsRevisionDone("foo/hist", revs[0].Hash))
+	assert.False(t, prog.IsRevisionDone("foo/hist", revs[1].Hash))
+	assert.False(t, prog.IsDone("foo/hist"))
+	require.NoError(t, prog.Close())
+
+	require.NoError(t, s.Convert(ctx, backend.Plain, backend.GitFS, false))
+
+	// the interrupted entry continues with its second revision
+	got, err := s.ListRevisions(ctx, "foo/hist")
+	require.NoError(t, err)
+	assert.Len(t, got, 3)
+
+	latest, err := s.Get(ctx, "foo/hist")
+	require.NoError(t, err)
+	assert.Equal(t, "three", latest.Password())
+}
+
+func TestConvertVerifyFails(t *testing.T) {
+	ctx := config.NewContextInMemory()
+
+	obuf := &bytes.Buffer{}
+	out.Stdout = obuf
+	defer func() {
+		out.Stdout = os.Stdout
+	}()
+
+	s, err := createSubStore(t)
+	require.NoError(t, err)
+
+	tmpPath := s.path + "-autoconvert"
+
+	// a previous attempt left a corrupted entry behind
+	prog, err := openConvertProgress(tmpPath, backend.Plain, backend.FS)
+	require.NoError(t, err)
+	tmpStore, err := s.convertTarget(ctx, tmpPath, prog, backend.Plain, backend.FS)
+	require.NoError(t, err)
+	sec := secrets.NewAKV()
+	sec.SetPassword("tampered")
+	require.NoError(t, tmpStore.Set(ctx, "baz/ing/a", sec))
+	require.NoError(t, prog.MarkDone("baz/ing/a"))
+	require.NoError(t, prog.Close())
+
+	err = s.Convert(ctx, backend.Plain, backend.FS, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 entries differ: baz/ing/a")
+
+	// the old store is left untouched
+	assert.False(t, fsutil.IsDir(s.path+"-backup"))
+	assert.True(t, fsutil.IsFile(filepath.Join(s.path, "baz", "ing", "a.txt")))
+	assert.True(t, fsutil.IsFile(convertProgressPath(tmpPath)))
+}
@@ -0,0 +1,14 @@
+package leaf
+
+import (
+	"context"
+)
+
+// PolicyFile is the name of a password policy file.
+const PolicyFile = ".gopass-policy.yml"
+
+// LookupPolicy returns the path and content of the password policy that
+// applies to the given secret. The policy file closest to the secret wins.
+func (s *Store) LookupPolicy(ctx context.Context, name string) (string, []byte, bool) {
+	return s.lookupFile(ctx, name, PolicyFile)
+}
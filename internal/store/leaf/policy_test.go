@@ -0,0 +1,48 @@
+package leaf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupPolicy(t *testing.T) {
+	t.Parallel()
+
+	ctx := config.NewContextInMemory()
+
+	tempdir := t.TempDir()
+
+	_, _, err := createStore(tempdir, nil, nil)
+	require.NoError(t, err)
+
+	ctx, err = backend.WithCryptoBackendString(ctx, "plain")
+	require.NoError(t, err)
+	ctx, err = backend.WithStorageBackendString(ctx, "fs")
+	require.NoError(t, err)
+	s, err := New(ctx, "", tempdir)
+	require.NoError(t, err)
+
+	_, _, found := s.LookupPolicy(ctx, "foo/bar")
+	assert.False(t, found)
+
+	require.NoError(t, os.WriteFile(filepath.Join(tempdir, PolicyFile), []byte("min_length: 8"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempdir, "foo"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(tempdir, "foo", PolicyFile), []byte("min_length: 16"), 0o600))
+
+	// the closest policy wins
+	name, buf, found := s.LookupPolicy(ctx, "foo/bar/baz")
+	assert.True(t, found)
+	assert.Equal(t, "foo/"+PolicyFile, name)
+	assert.Equal(t, "min_length: 16", string(buf))
+
+	name, buf, found = s.LookupPolicy(ctx, "bar/baz")
+	assert.True(t, found)
+	assert.Equal(t, PolicyFile, name)
+	assert.Equal(t, "min_length: 8", string(buf))
+}
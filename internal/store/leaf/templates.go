@@ -22,8 +22,14 @@ const (
 
 // LookupTemplate will lookup and return a template.
 func (s *Store) LookupTemplate(ctx context.Context, name string) (string, []byte, bool) {
+	return s.lookupFile(ctx, name, TemplateFile)
+}
+
+// lookupFile goes upwards in the directory tree of name until it finds a
+// file with the given name and returns its path and content.
+func (s *Store) lookupFile(ctx context.Context, name, file string) (string, []byte, bool) {
 	oName := name
-	// go upwards in the directory tree until we find a template
+	// go upwards in the directory tree until we find the file
 	// by chopping off one path element by one.
 	for {
 		l1 := len(name)
@@ -33,11 +39,11 @@ func (s *Store) LookupTemplate(ctx context.Context, name string) (string, []byte
 			break
 		}
 
-		tpl := filepath.Join(name, TemplateFile)
+		tpl := filepath.Join(name, file)
 
 		if s.storage.Exists(ctx, tpl) {
 			if content, err := s.storage.Get(ctx, tpl); err == nil {
-				debug.Log("Found template %q for %q", tpl, oName)
+				debug.Log("Found %q for %q", tpl, oName)
 
 				return tpl, content, true
 			}
@@ -83,6 +83,9 @@ func (s *Store) gitCommitAndPush(ctx context.Context, name string) error {
 	if commitMessage == "" {
 		message = fmt.Sprintf("Save secret: %s", name)
 	}
+	if body := ctxutil.GetCommitMessageBody(ctx); body != "" {
+		message += "\n\n" + body
+	}
 	if err := s.storage.TryCommit(ctx, message); err != nil {
 		return fmt.Errorf("failed to commit changes to git: %w", err)
 	}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 
 	"github.com/gopasspw/gopass/internal/backend"
+	"github.com/gopasspw/gopass/internal/store/leaf"
 	"github.com/gopasspw/gopass/pkg/debug"
 )
 
 // Convert will try to convert a given mount to a different set of
-// backends.
+// backends. The mount is only updated after the converted store has been
+// verified.
 func (r *Store) Convert(ctx context.Context, name string, cryptoBe backend.CryptoBackend, storageBe backend.StorageBackend, move bool) error {
 	sub, err := r.GetSubStore(name)
 	if err != nil {
@@ -32,3 +34,14 @@ func (r *Store) Convert(ctx context.Context, name string, cryptoBe backend.Crypt
 
 	return r.cfg.Set("", "mounts."+name+".path", sub.Path())
 }
+
+// EstimateConvert estimates the cost of converting the given mount without
+// modifying it.
+func (r *Store) EstimateConvert(ctx context.Context, name string, cryptoBe backend.CryptoBackend, storageBe backend.StorageBackend) (leaf.ConvertEstimate, error) {
+	sub, err := r.GetSubStore(name)
+	if err != nil {
+		return leaf.ConvertEstimate{}, fmt.Errorf("mount %q not found: %w", name, err)
+	}
+
+	return sub.EstimateConvert(ctx, cryptoBe, storageBe)
+}
@@ -0,0 +1,17 @@
+package root
+
+import (
+	"context"
+	"path/filepath"
+)
+
+// LookupPolicy returns the path and content of the password policy that
+// applies to the given secret.
+func (r *Store) LookupPolicy(ctx context.Context, name string) (string, []byte, bool) {
+	oName := name
+	store, name := r.getStore(name)
+	pName, content, found := store.LookupPolicy(ctx, name)
+	pName = filepath.Join(r.MountPoint(oName), pName)
+
+	return pName, content, found
+}
@@ -2,6 +2,7 @@ package tpl
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -42,6 +43,7 @@ const (
 	FuncRoundDuration = "roundDuration"
 	FuncDate          = "date"
 	FuncTruncate      = "truncate"
+	FuncBase64        = "b64enc"
 )
 
 func md5sum() func(...string) (string, error) {
@@ -74,6 +76,12 @@ func blake3sum() func(...string) (string, error) {
 	}
 }
 
+func base64Func() func(...string) (string, error) {
+	return func(s ...string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(s[0])), nil
+	}
+}
+
 // saltLen tries to parse the given string into a numeric salt length.
 func saltLen(s []string) uint8 {
 	if len(s) < 2 {
@@ -181,7 +189,7 @@ func bcryptFunc() func(...string) (string, error) {
 func get(ctx context.Context, kv kvstore) func(...string) (string, error) {
 	return func(s ...string) (string, error) {
 		if len(s) < 1 {
-			return "", nil
+			return "", fmt.Errorf("usage: %s <secret>", FuncGet)
 		}
 
 		if kv == nil {
@@ -193,7 +201,7 @@ func get(ctx context.Context, kv kvstore) func(...string) (string, error) {
 			// Return a generic error instead of err.Error() to avoid leaking
 			// internal backend details (GPG errors, file paths, etc.) into
 			// template output.
-			return "", fmt.Errorf("failed to retrieve secret")
+			return "", fmt.Errorf("failed to retrieve secret %q", s[0])
 		}
 
 		return string(sec.Bytes()), nil
@@ -203,7 +211,7 @@ func get(ctx context.Context, kv kvstore) func(...string) (string, error) {
 func getPassword(ctx context.Context, kv kvstore) func(...string) (string, error) {
 	return func(s ...string) (string, error) {
 		if len(s) < 1 {
-			return "", nil
+			return "", fmt.Errorf("usage: %s <secret>", FuncGetPassword)
 		}
 
 		if kv == nil {
@@ -215,7 +223,7 @@ func getPassword(ctx context.Context, kv kvstore) func(...string) (string, error
 			// Return a generic error instead of err.Error() to avoid leaking
 			// internal backend details (GPG errors, file paths, etc.) into
 			// template output.
-			return "", fmt.Errorf("failed to retrieve secret")
+			return "", fmt.Errorf("failed to retrieve secret %q", s[0])
 		}
 
 		return sec.Password(), nil
@@ -225,7 +233,7 @@ func getPassword(ctx context.Context, kv kvstore) func(...string) (string, error
 func getValue(ctx context.Context, kv kvstore) func(...string) (string, error) {
 	return func(s ...string) (string, error) {
 		if len(s) < 2 {
-			return "", nil
+			return "", fmt.Errorf("usage: %s <secret> <key>", FuncGetValue)
 		}
 
 		if kv == nil {
@@ -237,7 +245,7 @@ func getValue(ctx context.Context, kv kvstore) func(...string) (string, error) {
 			// Return a generic error instead of err.Error() to avoid leaking
 			// internal backend details (GPG errors, file paths, etc.) into
 			// template output.
-			return "", fmt.Errorf("failed to retrieve secret")
+			return "", fmt.Errorf("failed to retrieve secret %q", s[0])
 		}
 
 		sv, found := sec.Get(s[1])
@@ -252,7 +260,7 @@ func getValue(ctx context.Context, kv kvstore) func(...string) (string, error) {
 func getValues(ctx context.Context, kv kvstore) func(...string) ([]string, error) {
 	return func(s ...string) ([]string, error) {
 		if len(s) < 2 {
-			return nil, nil
+			return nil, fmt.Errorf("usage: %s <secret> <key>", FuncGetValues)
 		}
 
 		if kv == nil {
@@ -408,6 +416,7 @@ func funcMap(ctx context.Context, kv kvstore) template.FuncMap {
 		FuncRoundDuration: roundDuration,
 		FuncDate:          date,
 		FuncTruncate:      truncate,
+		FuncBase64:        base64Func(),
 	}
 }
 
@@ -430,5 +439,6 @@ func PublicFuncMap() template.FuncMap {
 		FuncRoundDuration: roundDuration,
 		FuncDate:          date,
 		FuncTruncate:      truncate,
+		FuncBase64:        base64Func(),
 	}
 }
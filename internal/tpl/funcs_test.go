@@ -40,6 +40,12 @@ func TestBlake3sum(t *testing.T) {
 	assert.Equal(t, hashsum.Blake3Hex("test"), result)
 }
 
+func TestBase64Func(t *testing.T) {
+	result, err := base64Func()("test")
+	require.NoError(t, err)
+	assert.Equal(t, "dGVzdA==", result)
+}
+
 func TestMd5cryptFunc(t *testing.T) {
 	result, err := md5cryptFunc()("salt", "password")
 	require.NoError(t, err)
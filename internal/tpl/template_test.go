@@ -139,6 +139,20 @@ func TestVars(t *testing.T) {
 			Output:     "",
 			ShouldFail: true,
 		},
+		{
+			Template:   `{{getpw}}`,
+			Name:       "testdir",
+			Content:    []byte("foobar"),
+			Output:     "",
+			ShouldFail: true,
+		},
+		{
+			Template:   `{{getval "testdir"}}`,
+			Name:       "testdir",
+			Content:    []byte("foobar"),
+			Output:     "",
+			ShouldFail: true,
+		},
 		{
 			Template: `{{getvals "testdir" "barkey"}}`,
 			Name:     "testdir",
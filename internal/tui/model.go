@@ -0,0 +1,250 @@
+package tui
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gopasspw/gopass/internal/tree"
+)
+
+// item is a row of the folder tree pane.
+type item struct {
+	// name is the full name of the secret or folder, without a trailing slash.
+	name  string
+	label string
+	depth int
+	// folder is set for folders and mount points. A secret can have the same
+	// name as a folder, both get their own row.
+	folder bool
+	mount  bool
+	link   bool
+}
+
+// model is the state of the folder tree pane. It is independent from the
+// screen to keep it easy to test.
+type model struct {
+	root     *tree.Root
+	secrets  []string
+	expanded map[string]bool
+	filter   string
+	items    []item
+	cursor   int
+	// offset is the first item shown in the tree pane.
+	offset int
+}
+
+func newModel(root *tree.Root) *model {
+	m := &model{
+		expanded: make(map[string]bool),
+	}
+	m.setTree(root)
+
+	return m
+}
+
+// setTree replaces the tree, e.g. after a secret was moved or deleted, and
+// keeps the selection if possible.
+func (m *model) setTree(root *tree.Root) {
+	m.root = root
+	m.secrets = root.List(tree.INF)
+	m.rebuild()
+}
+
+// selected returns the selected item, if any.
+func (m *model) selected() (item, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.items) {
+		return item{}, false
+	}
+
+	return m.items[m.cursor], true
+}
+
+// hasSecret returns true if a secret with the given name exists.
+func (m *model) hasSecret(name string) bool {
+	return slices.Contains(m.secrets, name)
+}
+
+// setFilter updates the fuzzy filter and selects the best match.
+func (m *model) setFilter(filter string) {
+	m.filter = filter
+	m.rebuild()
+
+	if filter == "" {
+		return
+	}
+
+	best := -1
+	for i, it := range m.items {
+		if it.folder {
+			continue
+		}
+		if score, _ := fuzzyScore(filter, it.name); best < 0 || score > best {
+			best = score
+			m.cursor = i
+		}
+	}
+}
+
+// move moves the cursor by delta rows.
+func (m *model) move(delta int) {
+	m.cursor = max(0, min(m.cursor+delta, len(m.items)-1))
+}
+
+// expand opens the selected folder.
+func (m *model) expand() {
+	it, ok := m.selected()
+	if !ok || !it.folder || m.filter != "" {
+		return
+	}
+
+	m.expanded[it.name] = true
+	m.rebuild()
+}
+
+// collapse closes the selected folder or selects the parent folder of a
+// secret or a closed folder.
+func (m *model) collapse() {
+	it, ok := m.selected()
+	if !ok || m.filter != "" {
+		return
+	}
+
+	if it.folder && m.expanded[it.name] {
+		delete(m.expanded, it.name)
+		m.rebuild()
+
+		return
+	}
+
+	parent := path.Dir(it.name)
+	for i, o := range m.items {
+		if o.folder && o.name == parent {
+			m.cursor = i
+
+			return
+		}
+	}
+}
+
+// rebuild flattens the visible part of the tree into rows. Without a filter
+// only expanded folders are shown, with a filter all matching secrets and
+// their folders are shown.
+func (m *model) rebuild() {
+	var sel string
+	if it, ok := m.selected(); ok {
+		sel = it.name
+		if it.folder {
+			sel += "/"
+		}
+	}
+
+	var visible map[string]bool
+	if m.filter != "" {
+		visible = make(map[string]bool)
+		for _, name := range m.secrets {
+			if _, ok := fuzzyScore(m.filter, name); !ok {
+				continue
+			}
+			visible[name] = true
+			for d := path.Dir(name); d != "."; d = path.Dir(d) {
+				visible[d+"/"] = true
+			}
+		}
+	}
+
+	m.items = m.items[:0]
+	if m.root != nil && m.root.Subtree != nil {
+		m.flatten(m.root.Subtree, "", 0, visible)
+	}
+
+	m.cursor = 0
+	for i, it := range m.items {
+		name := it.name
+		if it.folder {
+			name += "/"
+		}
+		if name == sel {
+			m.cursor = i
+
+			break
+		}
+	}
+}
+
+func (m *model) flatten(t *tree.Tree, prefix string, depth int, visible map[string]bool) {
+	for _, n := range t.Nodes {
+		name := n.Name
+		if prefix != "" {
+			name = prefix + "/" + n.Name
+		}
+
+		if n.Leaf && !n.Mount && (visible == nil || visible[name]) {
+			m.items = append(m.items, item{name: name, label: n.Name, depth: depth, link: n.Link})
+		}
+
+		if n.Subtree == nil || len(n.Subtree.Nodes) == 0 {
+			continue
+		}
+		if visible != nil && !visible[name+"/"] {
+			continue
+		}
+
+		m.items = append(m.items, item{name: name, label: n.Name, depth: depth, folder: true, mount: n.Mount})
+		if visible != nil || m.expanded[name] {
+			m.flatten(n.Subtree, name, depth+1, visible)
+		}
+	}
+}
+
+// isExpanded returns true if the folder is shown with its content.
+func (m *model) isExpanded(it item) bool {
+	return it.folder && (m.filter != "" || m.expanded[it.name])
+}
+
+// scroll makes sure the cursor is visible in a pane with the given height.
+func (m *model) scroll(height int) {
+	if height < 1 {
+		return
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+	m.offset = max(0, min(m.offset, len(m.items)-height))
+}
+
+// fuzzyScore matches the characters of the needle in order against the
+// haystack, ignoring case. Consecutive characters and characters at the start
+// of a path component or word score higher.
+func fuzzyScore(needle, haystack string) (int, bool) {
+	n := []rune(strings.ToLower(needle))
+	h := []rune(strings.ToLower(haystack))
+
+	var score, ni int
+	prev := -2
+	for hi := 0; hi < len(h) && ni < len(n); hi++ {
+		if h[hi] != n[ni] {
+			continue
+		}
+
+		score++
+		if hi == prev+1 {
+			score += 5
+		}
+		if hi == 0 || strings.ContainsRune("/-_. @", h[hi-1]) {
+			score += 10
+		}
+		prev = hi
+		ni++
+	}
+
+	if ni < len(n) {
+		return 0, false
+	}
+
+	// prefer shorter names if the matches are equally good.
+	return score*100 - len(h), true
+}
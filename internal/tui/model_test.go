@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/tree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTree(t *testing.T) *tree.Root {
+	t.Helper()
+
+	root := tree.New("gopass")
+	for _, name := range []string{
+		"email/work",
+		"email/private",
+		"websites/example.com/alice",
+		"websites/github.com/bob",
+		"websites/github.com",
+		"wifi",
+	} {
+		require.NoError(t, root.AddFile(name, "text/plain"))
+	}
+
+	return root
+}
+
+func labels(m *model) []string {
+	out := make([]string, 0, len(m.items))
+	for _, it := range m.items {
+		name := it.name
+		if it.folder {
+			name += "/"
+		}
+		out = append(out, name)
+	}
+
+	return out
+}
+
+func TestModelTree(t *testing.T) {
+	t.Parallel()
+
+	m := newModel(testTree(t))
+	assert.Equal(t, []string{"email/", "websites/", "wifi"}, labels(m))
+
+	// open websites
+	m.move(1)
+	m.expand()
+	assert.Equal(t, []string{"email/", "websites/", "websites/example.com/", "websites/github.com", "websites/github.com/", "wifi"}, labels(m))
+	assert.Equal(t, 1, m.cursor)
+
+	// github.com is a secret and a folder
+	m.move(3)
+	m.expand()
+	assert.Equal(t, []string{"email/", "websites/", "websites/example.com/", "websites/github.com", "websites/github.com/", "websites/github.com/bob", "wifi"}, labels(m))
+
+	// close github.com, then go to the parent
+	m.collapse()
+	assert.Equal(t, "websites/github.com", m.items[m.cursor].name)
+	m.collapse()
+	assert.Equal(t, "websites", m.items[m.cursor].name)
+	m.collapse()
+	assert.Equal(t, []string{"email/", "websites/", "wifi"}, labels(m))
+
+	// the cursor stays in range
+	m.move(100)
+	assert.Equal(t, "wifi", m.items[m.cursor].name)
+	m.move(-100)
+	assert.Equal(t, "email", m.items[m.cursor].name)
+
+	assert.True(t, m.hasSecret("websites/github.com"))
+	assert.False(t, m.hasSecret("websites"))
+}
+
+func TestModelFilter(t *testing.T) {
+	t.Parallel()
+
+	m := newModel(testTree(t))
+
+	m.setFilter("gh")
+	assert.Equal(t, []string{"websites/", "websites/github.com", "websites/github.com/", "websites/github.com/bob"}, labels(m))
+	assert.Equal(t, "websites/github.com", m.items[m.cursor].name)
+
+	m.setFilter("ghbob")
+	assert.Equal(t, "websites/github.com/bob", m.items[m.cursor].name)
+
+	m.setFilter("wrk")
+	assert.Equal(t, []string{"email/", "email/work"}, labels(m))
+
+	m.setFilter("nothing")
+	assert.Empty(t, m.items)
+
+	m.setFilter("")
+	assert.Equal(t, []string{"email/", "websites/", "wifi"}, labels(m))
+}
+
+func TestFuzzyScore(t *testing.T) {
+	t.Parallel()
+
+	_, ok := fuzzyScore("gh", "websites/github.com")
+	assert.True(t, ok)
+	_, ok = fuzzyScore("hg", "websites/github.com")
+	assert.False(t, ok)
+
+	// matches at the start of a component and consecutive matches win.
+	a, _ := fuzzyScore("git", "websites/github.com")
+	b, _ := fuzzyScore("git", "websites/gxixt.com")
+	assert.Greater(t, a, b)
+
+	// shorter names win ties.
+	a, _ = fuzzyScore("work", "email/work")
+	b, _ = fuzzyScore("work", "email/work/old")
+	assert.Greater(t, a, b)
+
+	// case is ignored.
+	_, ok = fuzzyScore("GH", "websites/github.com")
+	assert.True(t, ok)
+}
@@ -0,0 +1,511 @@
+// Package tui implements a full-screen terminal user interface to browse
+// the password store. It shows a folder tree with incremental fuzzy
+// filtering next to a preview of the selected secret that never shows the
+// password, and it offers key bindings to copy, edit, move and delete
+// secrets.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gopasspw/gopass/internal/tree"
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// Field is a part of a secret that can be copied to the clipboard.
+type Field int
+
+const (
+	// Password is the first line of a secret.
+	Password Field = iota
+	// Username is the user name of a secret.
+	Username
+	// OTP is the current one-time password of a secret.
+	OTP
+)
+
+// String implements fmt.Stringer.
+func (f Field) String() string {
+	switch f {
+	case Password:
+		return "password"
+	case Username:
+		return "username"
+	case OTP:
+		return "OTP"
+	default:
+		return "unknown"
+	}
+}
+
+// Backend provides the store operations of the TUI. The implementations
+// take care of hooks, commit messages and clipboard timeouts.
+type Backend interface {
+	// Tree returns the tree of all secrets.
+	Tree(ctx context.Context) (*tree.Root, error)
+	// Preview returns the content of a secret with the password and other
+	// sensitive values masked. It may prompt for credentials.
+	Preview(ctx context.Context, name string) (string, error)
+	// Copy copies a field of the secret to the clipboard and returns a
+	// status message. It may prompt for credentials.
+	Copy(ctx context.Context, name string, field Field) (string, error)
+	// Edit opens the secret in an editor.
+	Edit(ctx context.Context, name string) error
+	// Move renames a secret.
+	Move(ctx context.Context, from, to string) error
+	// Delete removes a secret.
+	Delete(ctx context.Context, name string) error
+	// Lock drops all cached credentials.
+	Lock(ctx context.Context) error
+	// Unlock asks for the credentials dropped by Lock again.
+	Unlock(ctx context.Context) error
+}
+
+// Options configure the TUI.
+type Options struct {
+	// IdleTimeout locks the TUI after this time without key presses. Zero
+	// disables the lock.
+	IdleTimeout time.Duration
+}
+
+// prompt is a question shown in the status line. It reads a line of input.
+type prompt struct {
+	label  string
+	value  string
+	submit func(ctx context.Context, value string)
+}
+
+// UI is the terminal user interface.
+type UI struct {
+	backend Backend
+	screen  tcell.Screen
+	opts    Options
+
+	model     *model
+	filtering bool
+	prompt    *prompt
+	locked    bool
+	help      bool
+	status    string
+	isError   bool
+	quit      bool
+
+	// preview caches the preview of the selected secret.
+	previewName string
+	preview     string
+}
+
+// New creates a new TUI on the given screen. The caller initializes and
+// finalizes the screen.
+func New(backend Backend, screen tcell.Screen, opts Options) *UI {
+	return &UI{
+		backend: backend,
+		screen:  screen,
+		opts:    opts,
+	}
+}
+
+// Run shows the TUI until the user quits or the context is canceled.
+func (u *UI) Run(ctx context.Context) error {
+	root, err := u.backend.Tree(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list secrets: %w", err)
+	}
+	u.model = newModel(root)
+
+	events := make(chan tcell.Event, 16)
+	quit := make(chan struct{})
+	defer close(quit)
+	go u.screen.ChannelEvents(events, quit)
+
+	var idle *time.Timer
+	var idleC <-chan time.Time
+	if u.opts.IdleTimeout > 0 {
+		idle = time.NewTimer(u.opts.IdleTimeout)
+		defer idle.Stop()
+		idleC = idle.C
+	}
+
+	for !u.quit {
+		u.draw(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-idleC:
+			u.lock(ctx, "Locked after being idle.")
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			u.handle(ctx, ev)
+			if idle != nil {
+				idle.Reset(u.opts.IdleTimeout)
+			}
+		}
+	}
+
+	return nil
+}
+
+// lock hides all content and drops the cached credentials.
+func (u *UI) lock(ctx context.Context, msg string) {
+	if u.locked {
+		return
+	}
+
+	debug.Log("locking TUI: %s", msg)
+	u.locked = true
+	u.prompt = nil
+	u.filtering = false
+	u.help = false
+	u.previewName = ""
+	u.preview = ""
+	u.setStatus(msg, nil)
+
+	if err := u.backend.Lock(ctx); err != nil {
+		u.setStatus("", fmt.Errorf("failed to lock: %w", err))
+	}
+}
+
+// unlock shows the content again once the backend got the credentials.
+func (u *UI) unlock(ctx context.Context) {
+	if err := u.withTerminal(func() error { return u.backend.Unlock(ctx) }); err != nil {
+		u.setStatus("", fmt.Errorf("failed to unlock: %w", err))
+
+		return
+	}
+
+	u.locked = false
+	u.setStatus("", nil)
+}
+
+// withTerminal suspends the screen while fn runs, so editors and the
+// credential prompts of the crypto backends can use the terminal.
+func (u *UI) withTerminal(fn func() error) error {
+	if err := u.screen.Suspend(); err != nil {
+		return fmt.Errorf("failed to suspend terminal: %w", err)
+	}
+	err := fn()
+	if rerr := u.screen.Resume(); rerr != nil {
+		err = errors.Join(err, rerr)
+	}
+
+	return err
+}
+
+// setStatus shows a message or an error in the status line.
+func (u *UI) setStatus(msg string, err error) {
+	u.status = msg
+	u.isError = err != nil
+	if err != nil {
+		u.status = err.Error()
+	}
+}
+
+// handle processes a terminal event.
+func (u *UI) handle(ctx context.Context, ev tcell.Event) {
+	switch ev := ev.(type) {
+	case *tcell.EventResize:
+		u.screen.Sync()
+	case *tcell.EventKey:
+		if ev.Key() == tcell.KeyCtrlC {
+			u.quit = true
+
+			return
+		}
+
+		switch {
+		case u.locked:
+			u.handleLocked(ctx, ev)
+		case u.prompt != nil:
+			u.handlePrompt(ctx, ev)
+		case u.filtering:
+			u.handleFilter(ev)
+		default:
+			u.handleKey(ctx, ev)
+		}
+	}
+}
+
+func (u *UI) handleLocked(ctx context.Context, ev *tcell.EventKey) {
+	switch {
+	case ev.Key() == tcell.KeyEnter:
+		u.unlock(ctx)
+	case ev.Key() == tcell.KeyRune && ev.Rune() == 'q':
+		u.quit = true
+	}
+}
+
+func (u *UI) handlePrompt(ctx context.Context, ev *tcell.EventKey) {
+	p := u.prompt
+
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		u.prompt = nil
+		u.setStatus("Canceled.", nil)
+	case tcell.KeyEnter:
+		u.prompt = nil
+		p.submit(ctx, p.value)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if r := []rune(p.value); len(r) > 0 {
+			p.value = string(r[:len(r)-1])
+		}
+	case tcell.KeyRune:
+		p.value += string(ev.Rune())
+	}
+}
+
+func (u *UI) handleFilter(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		u.filtering = false
+		u.model.setFilter("")
+	case tcell.KeyEnter:
+		u.filtering = false
+	case tcell.KeyUp:
+		u.model.move(-1)
+	case tcell.KeyDown:
+		u.model.move(1)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if r := []rune(u.model.filter); len(r) > 0 {
+			u.model.setFilter(string(r[:len(r)-1]))
+		}
+	case tcell.KeyRune:
+		u.model.setFilter(u.model.filter + string(ev.Rune()))
+	}
+}
+
+func (u *UI) handleKey(ctx context.Context, ev *tcell.EventKey) {
+	u.help = false
+
+	switch ev.Key() {
+	case tcell.KeyUp:
+		u.model.move(-1)
+	case tcell.KeyDown:
+		u.model.move(1)
+	case tcell.KeyPgUp:
+		u.model.move(-u.treeHeight())
+	case tcell.KeyPgDn:
+		u.model.move(u.treeHeight())
+	case tcell.KeyHome:
+		u.model.move(-len(u.model.items))
+	case tcell.KeyEnd:
+		u.model.move(len(u.model.items))
+	case tcell.KeyRight, tcell.KeyEnter:
+		u.model.expand()
+	case tcell.KeyLeft:
+		u.model.collapse()
+	case tcell.KeyEscape:
+		u.model.setFilter("")
+	case tcell.KeyRune:
+		u.handleRune(ctx, ev.Rune())
+	}
+}
+
+func (u *UI) handleRune(ctx context.Context, r rune) {
+	switch r {
+	case 'q':
+		u.quit = true
+	case 'j':
+		u.model.move(1)
+	case 'k':
+		u.model.move(-1)
+	case 'l':
+		u.model.expand()
+	case 'h':
+		u.model.collapse()
+	case '/':
+		u.filtering = true
+	case '?':
+		u.help = true
+	case 'c':
+		u.copy(ctx, Password)
+	case 'u':
+		u.copy(ctx, Username)
+	case 'o':
+		u.copy(ctx, OTP)
+	case 'e':
+		u.edit(ctx)
+	case 'm':
+		u.move()
+	case 'd':
+		u.delete()
+	case 'r':
+		u.reload(ctx, "Reloaded.")
+	case 'L':
+		u.lock(ctx, "Locked.")
+	}
+}
+
+// errNoSecret is shown if an action needs a secret but a folder is selected.
+var errNoSecret = errors.New("no secret selected")
+
+// selectedSecret returns the name of the selected secret.
+func (u *UI) selectedSecret() (string, error) {
+	it, ok := u.model.selected()
+	if !ok || it.folder {
+		return "", errNoSecret
+	}
+
+	return it.name, nil
+}
+
+func (u *UI) copy(ctx context.Context, field Field) {
+	name, err := u.selectedSecret()
+	if err != nil {
+		u.setStatus("", err)
+
+		return
+	}
+
+	var msg string
+	if err := u.withTerminal(func() error {
+		var err error
+		msg, err = u.backend.Copy(ctx, name, field)
+
+		return err
+	}); err != nil {
+		u.setStatus("", fmt.Errorf("failed to copy %s of %s: %w", field, name, err))
+
+		return
+	}
+	u.setStatus(msg, nil)
+}
+
+func (u *UI) edit(ctx context.Context) {
+	name, err := u.selectedSecret()
+	if err != nil {
+		u.setStatus("", err)
+
+		return
+	}
+
+	if err := u.withTerminal(func() error { return u.backend.Edit(ctx, name) }); err != nil {
+		u.setStatus("", fmt.Errorf("failed to edit %s: %w", name, err))
+
+		return
+	}
+	u.reload(ctx, fmt.Sprintf("Saved %s.", name))
+}
+
+func (u *UI) move() {
+	from, err := u.selectedSecret()
+	if err != nil {
+		u.setStatus("", err)
+
+		return
+	}
+
+	u.prompt = &prompt{
+		label: fmt.Sprintf("Move %s to: ", from),
+		value: from,
+		submit: func(ctx context.Context, to string) {
+			if to == "" || to == from {
+				u.setStatus("Canceled.", nil)
+
+				return
+			}
+
+			doMove := func(ctx context.Context) {
+				if err := u.backend.Move(ctx, from, to); err != nil {
+					u.setStatus("", fmt.Errorf("failed to move %s: %w", from, err))
+
+					return
+				}
+				u.reload(ctx, fmt.Sprintf("Moved %s to %s.", from, to))
+			}
+
+			if !u.model.hasSecret(to) {
+				doMove(ctx)
+
+				return
+			}
+
+			u.confirm(fmt.Sprintf("%s already exists. Overwrite it?", to), doMove)
+		},
+	}
+}
+
+func (u *UI) delete() {
+	name, err := u.selectedSecret()
+	if err != nil {
+		u.setStatus("", err)
+
+		return
+	}
+
+	u.confirm(fmt.Sprintf("Delete %s?", name), func(ctx context.Context) {
+		if err := u.backend.Delete(ctx, name); err != nil {
+			u.setStatus("", fmt.Errorf("failed to delete %s: %w", name, err))
+
+			return
+		}
+		u.reload(ctx, fmt.Sprintf("Deleted %s.", name))
+	})
+}
+
+// confirm asks a yes/no question in the status line.
+func (u *UI) confirm(question string, yes func(ctx context.Context)) {
+	u.prompt = &prompt{
+		label: question + " [y/N] ",
+		submit: func(ctx context.Context, answer string) {
+			if answer != "y" && answer != "Y" {
+				u.setStatus("Canceled.", nil)
+
+				return
+			}
+			yes(ctx)
+		},
+	}
+}
+
+// reload reads the tree again after it was changed.
+func (u *UI) reload(ctx context.Context, msg string) {
+	root, err := u.backend.Tree(ctx)
+	if err != nil {
+		u.setStatus("", fmt.Errorf("failed to list secrets: %w", err))
+
+		return
+	}
+
+	u.model.setTree(root)
+	u.previewName = ""
+	u.setStatus(msg, nil)
+}
+
+// loadPreview returns the preview of the selected secret. It's only
+// decrypted again if the selection changed. It must not be called while
+// drawing since it may suspend the screen.
+func (u *UI) loadPreview(ctx context.Context) string {
+	it, ok := u.model.selected()
+	if !ok {
+		return ""
+	}
+	if it.folder {
+		return fmt.Sprintf("%s/\n\nPress enter to open the folder.", it.name)
+	}
+
+	if u.previewName == it.name {
+		return u.preview
+	}
+
+	var content string
+	err := u.withTerminal(func() error {
+		var err error
+		content, err = u.backend.Preview(ctx, it.name)
+
+		return err
+	})
+	if err != nil {
+		content = fmt.Sprintf("Failed to decrypt %s: %s", it.name, err)
+	}
+	u.previewName = it.name
+	u.preview = content
+
+	return content
+}
@@ -0,0 +1,375 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gopasspw/gopass/internal/tree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackend struct {
+	mu sync.Mutex
+
+	secrets   map[string]string
+	copied    []string
+	edited    []string
+	locks     int
+	unlocks   int
+	unlockErr error
+
+	// screen records if the operations that may prompt have the terminal.
+	screen   *suspendScreen
+	onScreen []string
+}
+
+// prompt records an operation that ran while the TUI still had the terminal.
+func (f *fakeBackend) prompt(op string) {
+	if f.screen != nil && !f.screen.suspended.Load() {
+		f.onScreen = append(f.onScreen, op)
+	}
+}
+
+func (f *fakeBackend) Tree(context.Context) (*tree.Root, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	root := tree.New("gopass")
+	for name := range f.secrets {
+		if err := root.AddFile(name, "text/plain"); err != nil {
+			return nil, err
+		}
+	}
+
+	return root, nil
+}
+
+func (f *fakeBackend) Preview(_ context.Context, name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.prompt("preview " + name)
+
+	return "password: *****\n" + f.secrets[name], nil
+}
+
+func (f *fakeBackend) Copy(_ context.Context, name string, field Field) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.prompt("copy " + name)
+	f.copied = append(f.copied, name+":"+field.String())
+
+	return fmt.Sprintf("Copied %s of %s.", field, name), nil
+}
+
+func (f *fakeBackend) Edit(_ context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.prompt("edit " + name)
+	f.edited = append(f.edited, name)
+
+	return nil
+}
+
+func (f *fakeBackend) Move(_ context.Context, from, to string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.secrets[to] = f.secrets[from]
+	delete(f.secrets, from)
+
+	return nil
+}
+
+func (f *fakeBackend) Delete(_ context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.secrets, name)
+
+	return nil
+}
+
+func (f *fakeBackend) Lock(context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.locks++
+
+	return nil
+}
+
+func (f *fakeBackend) Unlock(context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.prompt("unlock")
+	f.unlocks++
+
+	return f.unlockErr
+}
+
+func (f *fakeBackend) has(name string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, found := f.secrets[name]
+
+	return found
+}
+
+// suspendScreen is a simulated terminal that tracks if it's suspended.
+type suspendScreen struct {
+	tcell.SimulationScreen
+
+	suspended atomic.Bool
+}
+
+func (s *suspendScreen) Suspend() error {
+	s.suspended.Store(true)
+
+	return s.SimulationScreen.Suspend()
+}
+
+func (s *suspendScreen) Resume() error {
+	s.suspended.Store(false)
+
+	return s.SimulationScreen.Resume()
+}
+
+// harness runs the TUI on a simulated terminal.
+type harness struct {
+	t      *testing.T
+	screen tcell.SimulationScreen
+	done   chan error
+}
+
+func start(t *testing.T, b *fakeBackend, opts Options) *harness {
+	t.Helper()
+
+	screen := &suspendScreen{SimulationScreen: tcell.NewSimulationScreen("UTF-8")}
+	require.NoError(t, screen.Init())
+	t.Cleanup(screen.Fini)
+	b.screen = screen
+
+	h := &harness{
+		t:      t,
+		screen: screen,
+		done:   make(chan error, 1),
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	t.Cleanup(cancel)
+
+	go func() {
+		h.done <- New(b, screen, opts).Run(ctx)
+	}()
+
+	h.waitFor("gopass")
+
+	return h
+}
+
+// text returns the content of the screen. It reads the cells one by one
+// since GetContents does not copy the cells.
+func (h *harness) text() string {
+	w, ht := h.screen.Size()
+
+	var sb strings.Builder
+	for y := range ht {
+		for x := range w {
+			r, _, _, _ := h.screen.GetContent(x, y)
+			sb.WriteRune(r)
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+func (h *harness) waitFor(s string) {
+	h.t.Helper()
+
+	require.Eventually(h.t, func() bool {
+		return strings.Contains(h.text(), s)
+	}, 2*time.Second, 5*time.Millisecond, "screen does not contain %q:\n%s", s, h.text())
+}
+
+func (h *harness) waitForNot(s string) {
+	h.t.Helper()
+
+	require.Eventually(h.t, func() bool {
+		return !strings.Contains(h.text(), s)
+	}, 2*time.Second, 5*time.Millisecond, "screen still contains %q:\n%s", s, h.text())
+}
+
+func (h *harness) keys(s string) {
+	for _, r := range s {
+		h.screen.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+	}
+}
+
+func (h *harness) key(k tcell.Key) {
+	h.screen.InjectKey(k, 0, tcell.ModNone)
+}
+
+func (h *harness) quit() {
+	h.t.Helper()
+
+	h.keys("q")
+	select {
+	case err := <-h.done:
+		require.NoError(h.t, err)
+	case <-time.After(2 * time.Second):
+		h.t.Fatal("TUI did not quit")
+	}
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		secrets: map[string]string{
+			"email/work":                 "user: alice@example.com",
+			"websites/github.com/bob":    "user: bob",
+			"websites/example.com/alice": "url: https://example.com",
+			"wifi":                       "ssid: home",
+		},
+	}
+}
+
+func TestBrowse(t *testing.T) {
+	b := newFakeBackend()
+	h := start(t, b, Options{})
+
+	h.waitFor("▸ email/")
+	h.waitFor("▸ websites/")
+
+	// open the email folder and select the work secret.
+	h.key(tcell.KeyEnter)
+	h.waitFor("▾ email/")
+	h.keys("j")
+	h.waitFor("user: alice@example.com")
+	h.waitFor("password: *****")
+
+	// copy password, username and OTP.
+	h.keys("cuo")
+	h.waitFor("Copied OTP of email/work.")
+
+	h.keys("e")
+	h.waitFor("Saved email/work.")
+
+	h.keys("?")
+	h.waitFor("Key bindings")
+	h.keys("k")
+	h.waitForNot("Key bindings")
+
+	h.quit()
+
+	assert.Equal(t, []string{"email/work:password", "email/work:username", "email/work:OTP"}, b.copied)
+	assert.Equal(t, []string{"email/work"}, b.edited)
+	// prompts for credentials need the terminal.
+	assert.Empty(t, b.onScreen)
+}
+
+func TestFilterMoveDelete(t *testing.T) {
+	b := newFakeBackend()
+	h := start(t, b, Options{})
+
+	// filter incrementally.
+	h.keys("/gh")
+	h.waitFor("filter: gh")
+	h.waitFor("user: bob")
+	h.keys("b")
+	h.waitFor("filter: ghb")
+	h.waitForNot("wifi")
+	h.key(tcell.KeyEnter)
+
+	// folders can't be copied.
+	h.keys("k")
+	h.keys("c")
+	h.waitFor("no secret selected")
+	h.keys("j")
+
+	// move the selected secret.
+	h.keys("m")
+	h.waitFor("Move websites/github.com/bob to: websites/github.com/bob")
+	for range len("bob") {
+		h.key(tcell.KeyBackspace2)
+	}
+	h.keys("robert")
+	h.key(tcell.KeyEnter)
+	h.waitFor("Moved websites/github.com/bob to websites/github.com/robert.")
+	assert.True(t, b.has("websites/github.com/robert"))
+
+	// clear the filter.
+	h.key(tcell.KeyEscape)
+	h.waitFor("▸ email/")
+
+	// canceling a delete keeps the secret.
+	h.key(tcell.KeyEnd)
+	h.keys("d")
+	h.waitFor("Delete wifi? [y/N]")
+	h.keys("n")
+	h.key(tcell.KeyEnter)
+	h.waitFor("Canceled.")
+	assert.True(t, b.has("wifi"))
+
+	h.keys("dy")
+	h.key(tcell.KeyEnter)
+	h.waitFor("Deleted wifi.")
+	assert.False(t, b.has("wifi"))
+
+	h.quit()
+}
+
+func TestIdleLock(t *testing.T) {
+	b := newFakeBackend()
+	h := start(t, b, Options{IdleTimeout: 300 * time.Millisecond})
+
+	h.key(tcell.KeyEnd)
+	h.waitFor("ssid: home")
+
+	h.waitFor("gopass is locked")
+	h.waitForNot("ssid: home")
+	h.waitForNot("wifi")
+
+	// other keys don't unlock.
+	h.keys("c")
+	h.waitFor("gopass is locked")
+
+	// the content is only shown again after the credentials were entered.
+	b.mu.Lock()
+	b.unlockErr = fmt.Errorf("bad passphrase")
+	b.mu.Unlock()
+	h.key(tcell.KeyEnter)
+	h.waitFor("failed to unlock: bad passphrase")
+	h.waitFor("gopass is locked")
+	h.waitForNot("ssid: home")
+
+	b.mu.Lock()
+	b.unlockErr = nil
+	b.mu.Unlock()
+	h.key(tcell.KeyEnter)
+	h.waitFor("ssid: home")
+
+	// lock manually.
+	h.keys("L")
+	h.waitFor("gopass is locked")
+
+	h.quit()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	assert.GreaterOrEqual(t, b.locks, 2)
+	assert.Equal(t, 2, b.unlocks)
+	assert.Empty(t, b.copied)
+	assert.Empty(t, b.onScreen)
+}
@@ -0,0 +1,213 @@
+package tui
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var (
+	styleDefault  = tcell.StyleDefault
+	styleTitle    = tcell.StyleDefault.Reverse(true).Bold(true)
+	styleSelected = tcell.StyleDefault.Reverse(true)
+	styleFolder   = tcell.StyleDefault.Foreground(tcell.ColorBlue).Bold(true)
+	styleMount    = tcell.StyleDefault.Foreground(tcell.ColorTeal).Bold(true)
+	styleLink     = tcell.StyleDefault.Foreground(tcell.ColorYellow)
+	styleError    = tcell.StyleDefault.Foreground(tcell.ColorRed).Bold(true)
+	styleDim      = tcell.StyleDefault.Dim(true)
+)
+
+// keyHelp is shown in the last line of the screen.
+const keyHelp = "/ filter  c copy  u user  o otp  e edit  m move  d delete  L lock  ? help  q quit"
+
+// helpText is shown in the preview pane when ? is pressed.
+var helpText = []string{
+	"Key bindings",
+	"",
+	"up/down, j/k     select secret",
+	"right/enter, l   open folder",
+	"left, h          close folder, go to parent",
+	"/                fuzzy filter, enter to keep, esc to clear",
+	"c                copy password to the clipboard",
+	"u                copy username to the clipboard",
+	"o                copy the current OTP to the clipboard",
+	"e                edit secret",
+	"m                move secret",
+	"d                delete secret",
+	"r                reload",
+	"L                lock",
+	"q, ctrl+c        quit",
+	"",
+	"The clipboard is cleared after core.cliptimeout seconds.",
+	"The TUI is locked after tui.idle-timeout seconds without a key press.",
+}
+
+// treeHeight is the number of rows of the tree pane.
+func (u *UI) treeHeight() int {
+	_, h := u.screen.Size()
+
+	return max(1, h-3)
+}
+
+// draw renders the whole screen.
+func (u *UI) draw(ctx context.Context) {
+	// decrypting the preview may prompt for credentials, so it's
+	// done before drawing.
+	var preview string
+	if !u.locked && !u.help {
+		preview = u.loadPreview(ctx)
+	}
+
+	s := u.screen
+	s.Clear()
+	s.HideCursor()
+	w, h := s.Size()
+	if w < 1 || h < 1 {
+		return
+	}
+
+	title := " gopass"
+	if u.model.filter != "" || u.filtering {
+		title += " | filter: " + u.model.filter
+	}
+	fill(s, 0, 0, w, styleTitle)
+	putStr(s, 0, 0, w, title, styleTitle)
+
+	if u.locked {
+		msg := "gopass is locked. Press enter to unlock or q to quit."
+		x := max(0, (w-len(msg))/2)
+		putStr(s, x, h/2, w-x, msg, styleDefault)
+		u.drawStatus(w, h)
+		s.Show()
+
+		return
+	}
+
+	treeW := max(20, w*2/5)
+	if treeW >= w {
+		treeW = w
+	}
+	u.drawTree(treeW)
+
+	if treeW < w {
+		for y := 1; y < h-2; y++ {
+			s.SetContent(treeW, y, '│', nil, styleDim)
+		}
+		u.drawPreview(preview, treeW+2, w-treeW-2)
+	}
+
+	u.drawStatus(w, h)
+	s.Show()
+}
+
+func (u *UI) drawTree(width int) {
+	height := u.treeHeight() - 1
+	m := u.model
+	m.scroll(height)
+
+	if len(m.items) == 0 {
+		msg := "No secrets."
+		if m.filter != "" {
+			msg = "No secrets match the filter."
+		}
+		putStr(u.screen, 1, 1, width, msg, styleDim)
+
+		return
+	}
+
+	for row := range height {
+		i := m.offset + row
+		if i >= len(m.items) {
+			break
+		}
+		it := m.items[i]
+
+		var sb strings.Builder
+		sb.WriteString(strings.Repeat("  ", it.depth))
+		style := styleDefault
+		switch {
+		case it.folder && m.isExpanded(it):
+			sb.WriteString("▾ " + it.label + "/")
+			style = styleFolder
+		case it.folder:
+			sb.WriteString("▸ " + it.label + "/")
+			style = styleFolder
+		case it.link:
+			sb.WriteString("  " + it.label + " →")
+			style = styleLink
+		default:
+			sb.WriteString("  " + it.label)
+		}
+		if it.mount {
+			style = styleMount
+		}
+		if i == m.cursor {
+			style = styleSelected
+			fill(u.screen, 0, row+1, width, style)
+		}
+		putStr(u.screen, 0, row+1, width, sb.String(), style)
+	}
+}
+
+func (u *UI) drawPreview(preview string, x, width int) {
+	height := u.treeHeight() - 1
+
+	lines := helpText
+	if !u.help {
+		lines = nil
+		if it, ok := u.model.selected(); ok && !it.folder {
+			lines = append(lines, it.name, "")
+		}
+		lines = append(lines, strings.Split(preview, "\n")...)
+	}
+
+	for y, line := range lines {
+		if y >= height {
+			break
+		}
+		style := styleDefault
+		if y == 0 {
+			style = style.Bold(true)
+		}
+		putStr(u.screen, x, y+1, width, line, style)
+	}
+}
+
+func (u *UI) drawStatus(w, h int) {
+	s := u.screen
+
+	switch {
+	case u.prompt != nil:
+		putStr(s, 0, h-2, w, u.prompt.label+u.prompt.value, styleDefault)
+		s.ShowCursor(min(w-1, len([]rune(u.prompt.label+u.prompt.value))), h-2)
+	case u.filtering:
+		putStr(s, 0, h-2, w, "/"+u.model.filter, styleDefault)
+		s.ShowCursor(min(w-1, len([]rune(u.model.filter))+1), h-2)
+	case u.isError:
+		putStr(s, 0, h-2, w, u.status, styleError)
+	default:
+		putStr(s, 0, h-2, w, u.status, styleDefault)
+	}
+
+	putStr(s, 0, h-1, w, keyHelp, styleDim)
+}
+
+// putStr writes a string starting at x, y and cuts it at the given width.
+func putStr(s tcell.Screen, x, y, width int, str string, style tcell.Style) {
+	end := x + width
+	for _, r := range str {
+		if x >= end {
+			return
+		}
+		s.SetContent(x, y, r, nil, style)
+		x++
+	}
+}
+
+// fill fills the given width of a line with the style.
+func fill(s tcell.Screen, x, y, width int, style tcell.Style) {
+	for end := x + width; x < end; x++ {
+		s.SetContent(x, y, ' ', nil, style)
+	}
+}
@@ -0,0 +1,25 @@
+// Package username finds the username stored in a secret.
+package username
+
+import (
+	"path"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+// Keys are the keys of a secret holding the username, in order of
+// preference. New secrets store the username in the first one, like the
+// templates of gopass create do.
+var Keys = []string{"username", "user", "login"}
+
+// Get returns the username of the secret. If there is no username key the
+// last element of the name is used.
+func Get(name string, sec gopass.Secret) string {
+	for _, k := range Keys {
+		if v, found := sec.Get(k); found && v != "" {
+			return v
+		}
+	}
+
+	return path.Base(name)
+}
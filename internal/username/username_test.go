@@ -0,0 +1,30 @@
+package username
+
+import (
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "username", in: "pw\nlogin: carol\nuser: bob\nusername: alice\n", want: "alice"},
+		{name: "user", in: "pw\nlogin: carol\nuser: bob\n", want: "bob"},
+		{name: "login", in: "pw\nlogin: carol\n", want: "carol"},
+		{name: "empty value", in: "pw\nusername: \nlogin: carol\n", want: "carol"},
+		{name: "name", in: "pw\nurl: https://example.com\n", want: "dave"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, Get("websites/example.com/dave", secrets.ParseAKV([]byte(tc.in))))
+		})
+	}
+}
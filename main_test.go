@@ -66,8 +66,10 @@ var commandsWithError = set.Map([]string{
 	".edit",
 	".env",
 	".find",
+	".fossil",
 	".fscopy",
 	".fsmove",
+	".fuse.mount",
 	".generate",
 	".git",
 	".git.push",
@@ -79,24 +81,38 @@ var commandsWithError = set.Map([]string{
 	".history",
 	".init",
 	".insert",
+	".jj",
 	".link",
 	".merge",
 	".mounts.add",
 	".mounts.remove",
 	".move",
+	".native-host.install",
 	".otp",
+	".otp.add",
+	".passkey.assert",
+	".passkey.create",
+	".passkey.import",
 	".pull",
 	".process",
 	".push",
 	".recipients.add",
 	".recipients.remove",
+	".render",
+	".render.docker-env",
+	".render.k8s-secret",
+	".serve",
+	".serve.token.add",
+	".serve.token.remove",
 	".show",
 	".sum",
 	".templates.edit",
 	".templates.remove",
 	".templates.show",
+	".tui",
 	".unclip",
 	".reorg",
+	".rotate",
 	".audit",
 })
 
@@ -135,7 +151,7 @@ func TestGetCommands(t *testing.T) {
 	}
 
 	commands := getCommands(act, app)
-	assert.Len(t, commands, 45)
+	assert.Len(t, commands, 54)
 
 	prefix := ""
 	testCommands(t, ctx, app, commands, prefix)
@@ -29,6 +29,7 @@ var (
 func CopyTo(ctx context.Context, name string, content []byte, timeout int) error {
 	debug.Log("Copying to clipboard: %s for %ds", name, timeout)
 
+	native := false
 	clipboardCopyCMD := os.Getenv("GOPASS_CLIPBOARD_COPY_CMD")
 	if clipboardCopyCMD != "" {
 		if err := callCommand(ctx, clipboardCopyCMD, name, content); err != nil {
@@ -36,6 +37,9 @@ func CopyTo(ctx context.Context, name string, content []byte, timeout int) error
 
 			return fmt.Errorf("failed to call clipboard copy command: %w", err)
 		}
+	} else if copyNative(ctx, name, content, timeout) {
+		// the unclip helper owns the clipboard and clears it after the timeout.
+		native = true
 	} else if clipboard.IsUnsupported() {
 		out.Errorf(ctx, "%s", ErrNotSupported)
 		_ = notify.Notify(ctx, "gopass - clipboard", ErrNotSupported.Error())
@@ -56,7 +60,9 @@ func CopyTo(ctx context.Context, name string, content []byte, timeout int) error
 		return nil
 	}
 
-	if err := clearClip(ctx, name, content, timeout); err != nil {
+	if native {
+		debug.Log("Clipboard is cleared by the native unclip helper.")
+	} else if err := clearClip(ctx, name, content, timeout); err != nil {
 		_ = notify.Notify(ctx, "gopass - clipboard", "failed to clear clipboard")
 
 		return fmt.Errorf("failed to clear clipboard: %w", err)
@@ -0,0 +1,238 @@
+//go:build linux
+
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/gopasspw/clipboard"
+	"github.com/gopasspw/gopass/internal/clipboard/wayland"
+	"github.com/gopasspw/gopass/internal/clipboard/x11"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/gopasspw/gopass/internal/notify"
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// readyTimeout is how long CopyTo waits for the unclip helper to own
+// the selections.
+var readyTimeout = 5 * time.Second
+
+// nativeClipboard is an X11 or Wayland client that owns the selections
+// itself. This allows to offer the password manager hint alongside the
+// text and to clear only selections that still hold the copied content.
+type nativeClipboard interface {
+	supports(sels selections) bool
+	serve(ctx context.Context, content []byte, sels selections, ready func()) (bool, error)
+	Close() error
+}
+
+type waylandClipboard struct {
+	*wayland.Client
+}
+
+func (w waylandClipboard) supports(sels selections) bool {
+	return !sels.primary || w.Supports(wayland.Primary)
+}
+
+func (w waylandClipboard) serve(ctx context.Context, content []byte, sels selections, ready func()) (bool, error) {
+	var ws []wayland.Selection
+	if sels.clipboard {
+		ws = append(ws, wayland.Clipboard)
+	}
+	if sels.primary {
+		ws = append(ws, wayland.Primary)
+	}
+
+	return w.Serve(ctx, content, ws, ready)
+}
+
+type x11Clipboard struct {
+	*x11.Client
+}
+
+func (x x11Clipboard) supports(selections) bool {
+	return true
+}
+
+func (x x11Clipboard) serve(ctx context.Context, content []byte, sels selections, ready func()) (bool, error) {
+	var xs []x11.Selection
+	if sels.clipboard {
+		xs = append(xs, x11.Clipboard)
+	}
+	if sels.primary {
+		xs = append(xs, x11.Primary)
+	}
+
+	return x.Serve(ctx, content, xs, ready)
+}
+
+// dialNative connects to the Wayland compositor, if it supports the data
+// control protocol, or to the X server.
+func dialNative() (nativeClipboard, error) {
+	wc, err := wayland.Dial()
+	if err == nil {
+		return waylandClipboard{wc}, nil
+	}
+	debug.V(1).Log("Wayland clipboard not available: %s", err)
+
+	xc, xerr := x11.Dial()
+	if xerr == nil {
+		return x11Clipboard{xc}, nil
+	}
+	debug.V(1).Log("X11 clipboard not available: %s", xerr)
+
+	return nil, fmt.Errorf("no native clipboard: %w", xerr)
+}
+
+// copyNative spawns a detached gopass unclip --serve process that owns the
+// selections and clears them after the timeout. It reports false if there
+// is no X11 or Wayland session that supports the configured selections, so
+// the caller can fall back to the clipboard helpers.
+func copyNative(ctx context.Context, name string, content []byte, timeout int) bool {
+	if clipboard.ForceUnsupported || os.Getenv("GOPASS_CLIPBOARD_NO_NATIVE") != "" {
+		return false
+	}
+
+	nc, err := dialNative()
+	if err != nil {
+		return false
+	}
+	supported := nc.supports(selectionsFromConfig(ctx))
+	_ = nc.Close()
+	if !supported {
+		debug.Log("native clipboard does not support the primary selection")
+
+		return false
+	}
+
+	// kill any pending unclip processes, they own the selections of the
+	// previous copy.
+	_ = killPrecedessors()
+
+	if err := spawnServe(ctx, name, content, timeout); err != nil {
+		debug.Log("failed to serve clipboard natively: %s", err)
+
+		return false
+	}
+
+	return true
+}
+
+func spawnServe(ctx context.Context, name string, content []byte, timeout int) error {
+	stdin, contentW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create pipe: %w", err)
+	}
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		_ = stdin.Close()
+		_ = contentW.Close()
+
+		return fmt.Errorf("failed to create pipe: %w", err)
+	}
+	defer func() {
+		_ = readyR.Close()
+	}()
+
+	cmd := exec.Command(os.Args[0], "unclip", "--serve", "--timeout", strconv.Itoa(timeout))
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+	cmd.Stdin = stdin
+	// the helper signals on fd 3 once it owns the selections.
+	cmd.ExtraFiles = []*os.File{readyW}
+	cmd.Env = append(os.Environ(), "GOPASS_UNCLIP_NAME="+name)
+	if !config.Bool(ctx, "core.notifications") {
+		cmd.Env = append(cmd.Env, "GOPASS_NO_NOTIFY=true")
+	}
+
+	err = cmd.Start()
+	_ = stdin.Close()
+	_ = readyW.Close()
+	if err != nil {
+		_ = contentW.Close()
+
+		return fmt.Errorf("failed to invoke unclip: %w", err)
+	}
+
+	if _, err := contentW.Write(content); err != nil {
+		_ = contentW.Close()
+
+		return fmt.Errorf("failed to pass content to unclip: %w", err)
+	}
+	if err := contentW.Close(); err != nil {
+		return fmt.Errorf("failed to pass content to unclip: %w", err)
+	}
+
+	if err := readyR.SetReadDeadline(time.Now().Add(readyTimeout)); err != nil {
+		debug.Log("failed to set deadline: %s", err)
+	}
+	buf, err := io.ReadAll(readyR)
+	if !bytes.HasPrefix(buf, []byte("ok")) {
+		return fmt.Errorf("unclip did not take over the clipboard: %w", err)
+	}
+
+	return nil
+}
+
+// Serve owns the configured selections with content until the timeout
+// expired or another application took them over. It writes "ok" to ready
+// once the content can be pasted. A timeout of zero or less serves until the
+// content is replaced. This is used by the unclip helper.
+func Serve(ctx context.Context, name string, content []byte, timeout int, ready io.WriteCloser) error {
+	defer func() {
+		_ = ready.Close()
+	}()
+
+	nc, err := dialNative()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = nc.Close()
+	}()
+
+	// the selections are cleared once sctx is done.
+	sctx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		sctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+
+	cleared, err := nc.serve(sctx, content, selectionsFromConfig(ctx), func() {
+		_, _ = ready.Write([]byte("ok\n"))
+		_ = ready.Close()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serve clipboard: %w", err)
+	}
+
+	if !cleared {
+		debug.Log("clipboard content of %s was replaced, not clearing", name)
+
+		return nil
+	}
+
+	if err := clearClipboardHistory(ctx); err != nil {
+		_ = notify.Notify(ctx, "gopass - clipboard", "Failed to clear clipboard history")
+
+		return fmt.Errorf("failed to clear clipboard history: %w", err)
+	}
+
+	if err := notify.Notify(ctx, "gopass - clipboard", "Clipboard has been cleared"); err != nil {
+		return fmt.Errorf("failed to send unclip notification: %w", err)
+	}
+
+	debug.Log("clipboard cleared (%s)", name)
+
+	return nil
+}
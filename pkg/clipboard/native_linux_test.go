@@ -0,0 +1,38 @@
+//go:build linux
+
+package clipboard
+
+import (
+	"testing"
+
+	"github.com/gopasspw/clipboard"
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyNativeUnavailable(t *testing.T) {
+	ctx := config.NewContextInMemory()
+
+	t.Run("no display", func(t *testing.T) {
+		t.Setenv("WAYLAND_DISPLAY", "")
+		t.Setenv("DISPLAY", "")
+
+		assert.False(t, copyNative(ctx, "foo", []byte("bar"), 0))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Setenv("GOPASS_CLIPBOARD_NO_NATIVE", "true")
+
+		assert.False(t, copyNative(ctx, "foo", []byte("bar"), 0))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		old := clipboard.ForceUnsupported
+		clipboard.ForceUnsupported = true
+		defer func() {
+			clipboard.ForceUnsupported = old
+		}()
+
+		assert.False(t, copyNative(ctx, "foo", []byte("bar"), 0))
+	})
+}
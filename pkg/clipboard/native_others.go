@@ -0,0 +1,20 @@
+//go:build !linux
+
+package clipboard
+
+import (
+	"context"
+	"io"
+)
+
+// copyNative is only supported on Linux.
+func copyNative(context.Context, string, []byte, int) bool {
+	return false
+}
+
+// Serve is only supported on Linux.
+func Serve(_ context.Context, _ string, _ []byte, _ int, ready io.WriteCloser) error {
+	_ = ready.Close()
+
+	return ErrNotSupported
+}
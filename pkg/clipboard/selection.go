@@ -0,0 +1,27 @@
+package clipboard
+
+import (
+	"context"
+
+	"github.com/gopasspw/gopass/internal/config"
+)
+
+// selections are the X11 and Wayland selections the content is copied to.
+// Other platforms only have a clipboard.
+type selections struct {
+	clipboard bool
+	primary   bool
+}
+
+// selectionsFromConfig returns the selections configured with
+// core.clipselection: clipboard (default), primary or both.
+func selectionsFromConfig(ctx context.Context) selections {
+	switch config.String(ctx, "core.clipselection") {
+	case "primary":
+		return selections{primary: true}
+	case "both":
+		return selections{clipboard: true, primary: true}
+	default:
+		return selections{clipboard: true}
+	}
+}
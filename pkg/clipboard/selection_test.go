@@ -0,0 +1,27 @@
+package clipboard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gopasspw/gopass/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectionsFromConfig(t *testing.T) {
+	t.Parallel()
+
+	for value, want := range map[string]selections{
+		"":          {clipboard: true},
+		"clipboard": {clipboard: true},
+		"primary":   {primary: true},
+		"both":      {clipboard: true, primary: true},
+		"invalid":   {clipboard: true},
+	} {
+		cfg := config.NewInMemory()
+		require.NoError(t, cfg.Set("", "core.clipselection", value))
+
+		assert.Equal(t, want, selectionsFromConfig(cfg.WithConfig(context.Background())), value)
+	}
+}
@@ -0,0 +1,137 @@
+package otp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/pquerna/otp"
+)
+
+// KeyCounter is the key of a secret that holds the counter of the next
+// HOTP token. It is kept separate from the otpauth URL so that concurrent
+// increments only conflict on this field.
+const KeyCounter = "counter"
+
+// resyncWindow is the number of counters searched in each direction of the
+// stored counter when resynchronizing a HOTP secret.
+const resyncWindow = 1000
+
+// ErrResync is returned if the counter of a HOTP secret could not be
+// found from the given tokens.
+var ErrResync = errors.New("tokens do not match any counter")
+
+// Counter returns the counter of the next HOTP token of a secret. It is
+// read from the counter field of the secret, or else from the counter
+// parameter of the otpauth URL. It defaults to 1.
+func Counter(key *otp.Key, sec gopass.Secret) uint64 {
+	if c, ok := secretCounter(sec); ok {
+		return c
+	}
+
+	if u, err := url.Parse(key.URL()); err == nil {
+		if c, err := strconv.ParseUint(u.Query().Get("counter"), 10, 64); err == nil {
+			return c
+		}
+	}
+
+	return 1
+}
+
+func secretCounter(sec gopass.Secret) (uint64, bool) {
+	sv, found := sec.Get(KeyCounter)
+	if !found {
+		return 0, false
+	}
+
+	c, err := strconv.ParseUint(strings.TrimSpace(sv), 10, 64)
+	if err != nil || c == 0 {
+		return 0, false
+	}
+
+	return c, true
+}
+
+// SetCounter sets the counter of the next HOTP token of a secret. The
+// counter never decreases, i.e. if the secret already has a higher
+// counter it is kept. It returns the counter of the secret.
+func SetCounter(sec gopass.Secret, counter uint64) (uint64, error) {
+	if c, ok := secretCounter(sec); ok && c > counter {
+		return c, nil
+	}
+
+	if err := sec.Set(KeyCounter, strconv.FormatUint(counter, 10)); err != nil {
+		return 0, fmt.Errorf("failed to set %s: %w", KeyCounter, err)
+	}
+
+	return counter, nil
+}
+
+// Resync returns the counter of the token following the two consecutive
+// tokens. The counters around the given counter are searched for them.
+func Resync(key *otp.Key, counter uint64, token1, token2 string) (uint64, error) {
+	if key.Type() != "hotp" {
+		return 0, fmt.Errorf("%w: only HOTP secrets can be resynchronized", ErrType)
+	}
+
+	from := uint64(0)
+	if counter > resyncWindow {
+		from = counter - resyncWindow
+	}
+
+	for c := from; c <= counter+resyncWindow; c++ {
+		token, err := Generate(key, time.Time{}, c)
+		if err != nil {
+			return 0, err
+		}
+		if token != token1 {
+			continue
+		}
+
+		next, err := Generate(key, time.Time{}, c+1)
+		if err != nil {
+			return 0, err
+		}
+		if next == token2 {
+			return c + 2, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: searched %d to %d", ErrResync, from, counter+resyncWindow)
+}
+
+// ResolveCounterConflict resolves a conflict between two versions of a HOTP
+// secret that only differ in their counter, e.g. after two users generated
+// a token at the same time. The version with the higher counter wins. It
+// returns false for ok if the versions differ in anything else.
+func ResolveCounterConflict(ours, theirs gopass.Secret) (useTheirs, ok bool) {
+	oc, found := secretCounter(ours)
+	if !found {
+		return false, false
+	}
+	tc, found := secretCounter(theirs)
+	if !found {
+		return false, false
+	}
+
+	if !sameExceptCounter(ours, theirs) {
+		return false, false
+	}
+
+	return tc > oc, true
+}
+
+func sameExceptCounter(a, b gopass.Secret) bool {
+	ca := secrets.ParseAKV(a.Bytes())
+	ca.Del(KeyCounter)
+	cb := secrets.ParseAKV(b.Bytes())
+	cb.Del(KeyCounter)
+
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
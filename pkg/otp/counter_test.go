@@ -0,0 +1,92 @@
+package otp
+
+import (
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/pquerna/otp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hotpURL is the secret of the HOTP test vectors of RFC 4226, appendix D.
+const hotpURL = "otpauth://hotp/gopass:test?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=gopass"
+
+func TestCounter(t *testing.T) {
+	t.Parallel()
+
+	key, err := otp.NewKeyFromURL(hotpURL)
+	require.NoError(t, err)
+
+	sec := secrets.NewAKV()
+	assert.Equal(t, uint64(1), Counter(key, sec))
+
+	urlKey, err := otp.NewKeyFromURL(hotpURL + "&counter=42")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), Counter(urlKey, sec))
+
+	require.NoError(t, sec.Set(KeyCounter, "7"))
+	assert.Equal(t, uint64(7), Counter(key, sec))
+	assert.Equal(t, uint64(7), Counter(urlKey, sec))
+
+	// the counter never decreases
+	c, err := SetCounter(sec, 5)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), c)
+	c, err = SetCounter(sec, 8)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), c)
+	v, _ := sec.Get(KeyCounter)
+	assert.Equal(t, "8", v)
+}
+
+func TestResync(t *testing.T) {
+	t.Parallel()
+
+	key, err := otp.NewKeyFromURL(hotpURL)
+	require.NoError(t, err)
+
+	// RFC 4226 tokens for the counters 5 and 6.
+	c, err := Resync(key, 1, "254676", "287922")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), c)
+
+	// counters below the stored one are found as well.
+	c, err = Resync(key, 900, "755224", "287082")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), c)
+
+	// not consecutive
+	_, err = Resync(key, 1, "254676", "162583")
+	require.ErrorIs(t, err, ErrResync)
+
+	totp, err := otp.NewKeyFromURL(totpURL)
+	require.NoError(t, err)
+	_, err = Resync(totp, 1, "254676", "287922")
+	require.ErrorIs(t, err, ErrType)
+}
+
+func TestResolveCounterConflict(t *testing.T) {
+	t.Parallel()
+
+	ours := secrets.ParseAKV([]byte("password\notpauth: " + hotpURL + "\ncounter: 3\n"))
+	theirs := secrets.ParseAKV([]byte("password\notpauth: " + hotpURL + "\ncounter: 5\n"))
+
+	useTheirs, ok := ResolveCounterConflict(ours, theirs)
+	assert.True(t, ok)
+	assert.True(t, useTheirs)
+
+	useTheirs, ok = ResolveCounterConflict(theirs, ours)
+	assert.True(t, ok)
+	assert.False(t, useTheirs)
+
+	// other changes can not be resolved
+	changed := secrets.ParseAKV([]byte("new password\notpauth: " + hotpURL + "\ncounter: 5\n"))
+	_, ok = ResolveCounterConflict(ours, changed)
+	assert.False(t, ok)
+
+	// neither can secrets without a counter
+	plain := secrets.ParseAKV([]byte("password\notpauth: " + hotpURL + "\n"))
+	_, ok = ResolveCounterConflict(ours, plain)
+	assert.False(t, ok)
+}
@@ -0,0 +1,224 @@
+package otp
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MigrationScheme is the scheme of the URLs used by Google Authenticator to
+// export several accounts at once.
+const MigrationScheme = "otpauth-migration"
+
+// ErrMigration is returned if an otpauth-migration URL can not be parsed.
+var ErrMigration = errors.New("invalid otpauth-migration URL")
+
+// MigrationAccount is an account of a Google Authenticator export.
+type MigrationAccount struct {
+	Secret    []byte
+	Name      string
+	Issuer    string
+	Algorithm string
+	Digits    int
+	Type      string
+	Counter   uint64
+}
+
+// AccountName returns the name of the account without the issuer prefix.
+func (a MigrationAccount) AccountName() string {
+	if a.Issuer != "" {
+		return strings.TrimPrefix(a.Name, a.Issuer+":")
+	}
+
+	return a.Name
+}
+
+// URL returns the otpauth URL of the account.
+func (a MigrationAccount) URL() string {
+	label := a.AccountName()
+	if a.Issuer != "" {
+		label = a.Issuer + ":" + label
+	}
+
+	q := url.Values{}
+	q.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(a.Secret))
+	if a.Issuer != "" {
+		q.Set("issuer", a.Issuer)
+	}
+	q.Set("algorithm", a.Algorithm)
+	q.Set("digits", strconv.Itoa(a.Digits))
+	if a.Type == "hotp" {
+		q.Set("counter", strconv.FormatUint(a.Counter, 10))
+	} else {
+		q.Set("period", "30")
+	}
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     a.Type,
+		Path:     "/" + label,
+		RawQuery: q.Encode(),
+	}
+
+	return u.String()
+}
+
+// ParseMigrationURL returns the accounts of an otpauth-migration URL as
+// exported by Google Authenticator. The data parameter holds a base64
+// encoded protocol buffer message (MigrationPayload).
+func ParseMigrationURL(s string) ([]MigrationAccount, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMigration, err)
+	}
+	if u.Scheme != MigrationScheme {
+		return nil, fmt.Errorf("%w: unexpected scheme %q", ErrMigration, u.Scheme)
+	}
+
+	// an unescaped + in the query is decoded as a space
+	data := strings.ReplaceAll(u.Query().Get("data"), " ", "+")
+	if data == "" {
+		return nil, fmt.Errorf("%w: missing data", ErrMigration)
+	}
+
+	buf, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		buf, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "="))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMigration, err)
+		}
+	}
+
+	var accounts []MigrationAccount
+	// MigrationPayload: repeated OtpParameters otp_parameters = 1;
+	if err := protoFields(buf, func(num int, _ uint64, b []byte) error {
+		if num != 1 {
+			return nil
+		}
+
+		a, err := parseMigrationAccount(b)
+		if err != nil {
+			return err
+		}
+		accounts = append(accounts, a)
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+func parseMigrationAccount(buf []byte) (MigrationAccount, error) {
+	a := MigrationAccount{
+		Algorithm: "SHA1",
+		Digits:    6,
+		Type:      "totp",
+	}
+
+	// OtpParameters
+	err := protoFields(buf, func(num int, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			a.Secret = b
+		case 2:
+			a.Name = string(b)
+		case 3:
+			a.Issuer = string(b)
+		case 4:
+			switch v {
+			case 0, 1:
+				a.Algorithm = "SHA1"
+			case 2:
+				a.Algorithm = "SHA256"
+			case 3:
+				a.Algorithm = "SHA512"
+			default:
+				return fmt.Errorf("%w: unsupported algorithm %d", ErrMigration, v)
+			}
+		case 5:
+			if v == 2 {
+				a.Digits = 8
+			}
+		case 6:
+			if v == 1 {
+				a.Type = "hotp"
+			}
+		case 7:
+			a.Counter = v
+		}
+
+		return nil
+	})
+	if err != nil {
+		return a, err
+	}
+
+	if len(a.Secret) == 0 {
+		return a, fmt.Errorf("%w: account %q has no secret", ErrMigration, a.Name)
+	}
+
+	return a, nil
+}
+
+// protoFields calls fn for each field of a protocol buffer message. Varint
+// fields are passed in v, length-delimited fields in b. Fixed size fields
+// are skipped.
+// See: https://protobuf.dev/programming-guides/encoding/
+func protoFields(buf []byte, fn func(num int, v uint64, b []byte) error) error {
+	for len(buf) > 0 {
+		tag, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return fmt.Errorf("%w: invalid tag", ErrMigration)
+		}
+		buf = buf[n:]
+
+		num := int(tag >> 3) //nolint:gosec
+		var v uint64
+		var b []byte
+
+		switch tag & 0x7 {
+		case 0: // varint
+			v, n = binary.Uvarint(buf)
+			if n <= 0 {
+				return fmt.Errorf("%w: invalid varint in field %d", ErrMigration, num)
+			}
+			buf = buf[n:]
+		case 1: // fixed64
+			if len(buf) < 8 {
+				return fmt.Errorf("%w: truncated field %d", ErrMigration, num)
+			}
+			buf = buf[8:]
+
+			continue
+		case 2: // length-delimited
+			l, n := binary.Uvarint(buf)
+			if n <= 0 || uint64(len(buf)-n) < l {
+				return fmt.Errorf("%w: truncated field %d", ErrMigration, num)
+			}
+			b = buf[n : n+int(l)] //nolint:gosec
+			buf = buf[n+int(l):]  //nolint:gosec
+		case 5: // fixed32
+			if len(buf) < 4 {
+				return fmt.Errorf("%w: truncated field %d", ErrMigration, num)
+			}
+			buf = buf[4:]
+
+			continue
+		default:
+			return fmt.Errorf("%w: unsupported wire type %d", ErrMigration, tag&0x7)
+		}
+
+		if err := fn(num, v, b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package otp
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pquerna/otp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// migrationURL contains two accounts:
+//   - Example:alice@example.com, TOTP, SHA1, 6 digits, secret JBSWY3DPEHPK3PXP
+//   - bob (ACME Co), HOTP, SHA256, 8 digits, counter 42, secret "12345678901234567890"
+const migrationURL = "otpauth-migration://offline?data=CjYKCkhlbGxvId6tvu8SGUV4YW1wbGU6YWxpY2VAZXhhbXBsZS5jb20aB0V4YW1wbGUgASgBMAIKLAoUMTIzNDU2Nzg5MDEyMzQ1Njc4OTASA2JvYhoHQUNNRSBDbyACKAIwATgqEAEYASAAKMDEBw%3D%3D"
+
+func TestParseMigrationURL(t *testing.T) {
+	t.Parallel()
+
+	accounts, err := ParseMigrationURL(migrationURL)
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+
+	alice := accounts[0]
+	assert.Equal(t, "Example", alice.Issuer)
+	assert.Equal(t, "alice@example.com", alice.AccountName())
+	assert.Equal(t, "totp", alice.Type)
+
+	key, err := otp.NewKeyFromURL(alice.URL())
+	require.NoError(t, err)
+	assert.Equal(t, "totp", key.Type())
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", key.Secret())
+	assert.Equal(t, "Example", key.Issuer())
+	assert.Equal(t, "alice@example.com", key.AccountName())
+	assert.Equal(t, otp.AlgorithmSHA1, key.Algorithm())
+	assert.Equal(t, otp.DigitsSix, key.Digits())
+	assert.Equal(t, uint64(30), key.Period())
+
+	bob := accounts[1]
+	assert.Equal(t, "bob", bob.AccountName())
+	assert.Equal(t, uint64(42), bob.Counter)
+
+	key, err = otp.NewKeyFromURL(bob.URL())
+	require.NoError(t, err)
+	assert.Equal(t, "hotp", key.Type())
+	assert.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", key.Secret())
+	assert.Equal(t, "ACME Co", key.Issuer())
+	assert.Equal(t, otp.AlgorithmSHA256, key.Algorithm())
+	assert.Equal(t, otp.DigitsEight, key.Digits())
+	assert.Contains(t, bob.URL(), "counter=42")
+}
+
+func TestParseMigrationURLErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, in := range []string{
+		"otpauth://totp/foo?secret=JBSWY3DPEHPK3PXP",
+		"otpauth-migration://offline",
+		"otpauth-migration://offline?data=!!!",
+		// truncated payload
+		"otpauth-migration://offline?data=CjYKCkhlbGxv",
+	} {
+		_, err := ParseMigrationURL(in)
+		require.ErrorIs(t, err, ErrMigration, in)
+	}
+}
+
+func TestDecodeQRImage(t *testing.T) {
+	t.Parallel()
+
+	key, err := otp.NewKeyFromURL(totpURL)
+	require.NoError(t, err)
+
+	fn := filepath.Join(t.TempDir(), "qr.png")
+	require.NoError(t, WriteQRFile(key, fn))
+
+	fh, err := os.Open(fn)
+	require.NoError(t, err)
+	defer fh.Close() //nolint:errcheck
+
+	txt, err := DecodeQRImage(fh)
+	require.NoError(t, err)
+	assert.Equal(t, totpURL, txt)
+
+	// not an image
+	_, err = DecodeQRImage(fh)
+	require.Error(t, err)
+
+	// no QR code
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 50, 50))))
+	_, err = DecodeQRImage(&buf)
+	require.ErrorIs(t, err, ErrNoQRCode)
+}
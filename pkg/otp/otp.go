@@ -4,23 +4,69 @@ package otp
 
 import (
 	"bytes"
+	"crypto/md5" //nolint:gosec // mOTP is defined using MD5.
+	"encoding/hex"
 	"fmt"
 	"image/png"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gopasspw/gopass/internal/out"
 	"github.com/gopasspw/gopass/pkg/debug"
 	"github.com/gopasspw/gopass/pkg/gopass"
 	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/pquerna/otp/totp"
+)
+
+// Keys of a secret that override the parameters of the OTP. They take
+// precedence over the parameters of an otpauth URL.
+const (
+	// KeyDigits is the number of digits (or characters) of the token.
+	KeyDigits = "totp-digits"
+	// KeyPeriod is the period of a TOTP token in seconds.
+	KeyPeriod = "totp-period"
+	// KeyAlgorithm is the HMAC algorithm, i.e. SHA1, SHA256 or SHA512.
+	KeyAlgorithm = "totp-algorithm"
+	// KeyEncoder is the encoder of the token. Only "steam" is supported.
+	KeyEncoder = "encoder"
+	// KeyMOTP is the secret of a Mobile-OTP token.
+	KeyMOTP = "motp"
+	// KeyMOTPPin is the PIN of a Mobile-OTP token.
+	KeyMOTPPin = "motp-pin"
+)
+
+const (
+	// typeMOTP is the pseudo otpauth type used for Mobile-OTP tokens.
+	typeMOTP = "motp"
+	// motpPeriod is the period of Mobile-OTP tokens in seconds.
+	motpPeriod = 10
+	// motpDigits is the length of Mobile-OTP tokens.
+	motpDigits = 6
+	// steamDigits is the length of Steam Guard codes.
+	steamDigits = 5
 )
 
 // Calculate will compute an OTP code from a given secret.
-// It will look for a field named "otpauth" or "totp" or "hotp".
-// If none is found it will fall back to the password.
+// It will look for a field named "otpauth", "totp", "hotp" or "motp".
+// If none is found it will fall back to the password. The parameters of the
+// OTP can be overridden by the keys totp-digits, totp-period, totp-algorithm
+// and encoder.
 //
 //nolint:ireturn
 func Calculate(name string, sec gopass.Secret) (*otp.Key, error) {
+	key, err := calculate(sec)
+	if err != nil {
+		return nil, err
+	}
+
+	return withParams(key, sec)
+}
+
+func calculate(sec gopass.Secret) (*otp.Key, error) {
 	otpURL := getOTPURL(sec)
 
 	if otpURL != "" {
@@ -41,11 +87,133 @@ func Calculate(name string, sec gopass.Secret) (*otp.Key, error) {
 		return parseOTP("hotp", secKey)
 	}
 
+	// mOTP
+	if secKey, found := sec.Get(KeyMOTP); found {
+		pin, _ := sec.Get(KeyMOTPPin)
+
+		return parseMOTP(secKey, pin)
+	}
+
 	debug.Log("no totp secret found, falling back to password")
 
 	return parseOTP("totp", sec.Password())
 }
 
+// withParams returns the key with the parameters given in the secret.
+func withParams(key *otp.Key, sec gopass.Secret) (*otp.Key, error) {
+	u, err := url.Parse(key.URL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse otpauth URL: %w", err)
+	}
+	q := u.Query()
+
+	if v, found := sec.Get(KeyEncoder); found {
+		switch enc := strings.ToLower(strings.TrimSpace(v)); enc {
+		case string(otp.EncoderSteam):
+			q.Set("encoder", enc)
+			// Steam Guard codes always have five characters.
+			if q.Get("digits") == "" {
+				q.Set("digits", strconv.Itoa(steamDigits))
+			}
+		case "", "default":
+			q.Del("encoder")
+		default:
+			return nil, fmt.Errorf("invalid %s %q: only steam is supported", KeyEncoder, v)
+		}
+	}
+
+	if v, found := sec.Get(KeyDigits); found {
+		d, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || d < 4 || d > 10 {
+			return nil, fmt.Errorf("invalid %s %q: must be between 4 and 10", KeyDigits, v)
+		}
+		q.Set("digits", strconv.Itoa(d))
+	}
+
+	if v, found := sec.Get(KeyPeriod); found {
+		p, err := strconv.ParseUint(strings.TrimSpace(v), 10, 32)
+		if err != nil || p == 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive number of seconds", KeyPeriod, v)
+		}
+		q.Set("period", strconv.FormatUint(p, 10))
+	}
+
+	if v, found := sec.Get(KeyAlgorithm); found {
+		switch alg := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(v), "-", "")); alg {
+		case "SHA1", "SHA256", "SHA512":
+			q.Set("algorithm", alg)
+		default:
+			return nil, fmt.Errorf("invalid %s %q: must be SHA1, SHA256 or SHA512", KeyAlgorithm, v)
+		}
+	}
+
+	u.RawQuery = q.Encode()
+
+	return otp.NewKeyFromURL(u.String()) //nolint:wrapcheck
+}
+
+// Generate returns the token of the key at the given time. The counter is
+// only used for HOTP keys.
+func Generate(key *otp.Key, t time.Time, counter uint64) (string, error) {
+	switch key.Type() {
+	case "totp":
+		return totp.GenerateCodeCustom(key.Secret(), t, totp.ValidateOpts{ //nolint:wrapcheck
+			Period:    uint(key.Period()),
+			Skew:      1,
+			Digits:    key.Digits(),
+			Algorithm: key.Algorithm(),
+			Encoder:   key.Encoder(),
+		})
+	case "hotp":
+		return hotp.GenerateCodeCustom(key.Secret(), counter, hotp.ValidateOpts{ //nolint:wrapcheck
+			Digits:    key.Digits(),
+			Algorithm: key.Algorithm(),
+			Encoder:   key.Encoder(),
+		})
+	case typeMOTP:
+		return generateMOTP(key, t)
+	default:
+		return "", fmt.Errorf("%w: unsupported OTP type %q", ErrType, key.Type())
+	}
+}
+
+// parseMOTP returns a key for a Mobile-OTP secret. Since there is no
+// otpauth URL for mOTP, a pseudo URL of the type motp is used.
+// See: https://motp.sourceforge.net/
+func parseMOTP(secKey, pin string) (*otp.Key, error) {
+	q := url.Values{}
+	q.Set("secret", strings.TrimSpace(secKey))
+	q.Set("pin", strings.TrimSpace(pin))
+	q.Set("period", strconv.Itoa(motpPeriod))
+	q.Set("digits", strconv.Itoa(motpDigits))
+	q.Set("issuer", "gopass")
+
+	key, err := otp.NewKeyFromURL("otpauth://" + typeMOTP + "/new?" + q.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("invalid mOTP secret: %w", err)
+	}
+
+	return key, nil
+}
+
+// generateMOTP returns the first digits of the hex encoded MD5 hash of the
+// current period (in tens of seconds), the secret and the PIN.
+func generateMOTP(key *otp.Key, t time.Time) (string, error) {
+	u, err := url.Parse(key.URL())
+	if err != nil {
+		return "", fmt.Errorf("failed to parse mOTP key: %w", err)
+	}
+
+	if key.Secret() == "" {
+		return "", fmt.Errorf("empty mOTP secret")
+	}
+
+	msg := strconv.FormatInt(t.Unix()/motpPeriod, 10) + key.Secret() + u.Query().Get("pin")
+	sum := md5.Sum([]byte(msg)) //nolint:gosec
+
+	return hex.EncodeToString(sum[:])[:key.Digits().Length()], nil
+}
+
 func getOTPURL(sec gopass.Secret) string {
 	// check if we have a key-value entry
 	if url, found := sec.Get("otpauth"); found {
@@ -93,6 +261,10 @@ func parseOTP(typ string, secKey string) (*otp.Key, error) {
 
 // WriteQRFile writes the given OTP key as a QR image to disk.
 func WriteQRFile(key *otp.Key, file string) error {
+	if key.Type() == typeMOTP {
+		return ErrOathOTP
+	}
+
 	// Convert TOTP key into a QR code encoded as a PNG image.
 	var buf bytes.Buffer
 	img, err := key.Image(200, 200)
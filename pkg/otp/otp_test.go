@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gopasspw/gopass/pkg/gopass"
 	"github.com/gopasspw/gopass/pkg/gopass/secrets/secparse"
@@ -83,3 +84,133 @@ func TestGetOTPURL(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateParams(t *testing.T) {
+	t.Parallel()
+
+	const (
+		sha1Secret   = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+		sha256Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZA"
+		sha512Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNA"
+	)
+
+	// test vectors from RFC 6238, appendix B.
+	for _, tc := range []struct {
+		name string
+		sec  string
+		ts   int64
+		want string
+	}{
+		{
+			name: "defaults",
+			sec:  "totp: " + sha1Secret,
+			ts:   59,
+			want: "287082",
+		},
+		{
+			name: "sha1-8-digits",
+			sec:  "totp: " + sha1Secret + "\ntotp-digits: 8",
+			ts:   1111111109,
+			want: "07081804",
+		},
+		{
+			name: "sha256-8-digits",
+			sec:  "totp: " + sha256Secret + "\ntotp-digits: 8\ntotp-algorithm: SHA256",
+			ts:   59,
+			want: "46119246",
+		},
+		{
+			name: "sha512-8-digits",
+			sec:  "totp: " + sha512Secret + "\ntotp-digits: 8\ntotp-algorithm: sha-512",
+			ts:   1111111109,
+			want: "25091201",
+		},
+		{
+			name: "override-url",
+			sec:  "otpauth: otpauth://totp/example?secret=" + sha256Secret + "&digits=6\ntotp-digits: 8\ntotp-algorithm: SHA256",
+			ts:   59,
+			want: "46119246",
+		},
+		{
+			// the token of the 60 second period starting at 0 is the token
+			// of the 30 second period starting at 0.
+			name: "period",
+			sec:  "totp: " + sha1Secret + "\ntotp-period: 60",
+			ts:   59,
+			want: "755224",
+		},
+		{
+			// 1094287082 (RFC 4226, appendix D, count 1) in base 26
+			// using the Steam alphabet.
+			name: "steam",
+			sec:  "totp: " + sha1Secret + "\nencoder: steam",
+			ts:   59,
+			want: "PV9M4",
+		},
+		{
+			// md5("170000000" + secret + pin)
+			name: "motp",
+			sec:  "motp: 1234567890abcdef\nmotp-pin: 1234",
+			ts:   1700000000,
+			want: "660af9",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			key, err := Calculate("test", secparse.MustParse(t, "password\n"+tc.sec))
+			require.NoError(t, err)
+
+			token, err := Generate(key, time.Unix(tc.ts, 0), 0)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, token)
+		})
+	}
+}
+
+func TestCalculateSteam(t *testing.T) {
+	t.Parallel()
+
+	key, err := Calculate("test", secparse.MustParse(t, "password\ntotp: "+totpSecret+"\nencoder: Steam"))
+	require.NoError(t, err)
+	assert.Equal(t, otp.EncoderSteam, key.Encoder())
+	assert.Equal(t, otp.Digits(5), key.Digits())
+
+	// the QR code contains the encoder and the digits
+	assert.Contains(t, key.URL(), "encoder=steam")
+	assert.Contains(t, key.URL(), "digits=5")
+
+	token, err := Generate(key, time.Now(), 0)
+	require.NoError(t, err)
+	assert.Len(t, token, 5)
+	for _, c := range token {
+		assert.Contains(t, "23456789BCDFGHJKMNPQRTVWXY", string(c))
+	}
+}
+
+func TestCalculateInvalidParams(t *testing.T) {
+	t.Parallel()
+
+	for _, kv := range []string{
+		"totp-digits: 3",
+		"totp-digits: eight",
+		"totp-period: 0",
+		"totp-algorithm: MD4",
+		"encoder: yubico",
+	} {
+		t.Run(kv, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Calculate("test", secparse.MustParse(t, "password\ntotp: "+totpSecret+"\n"+kv))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestWriteMOTP(t *testing.T) {
+	t.Parallel()
+
+	key, err := Calculate("test", secparse.MustParse(t, "password\nmotp: 1234567890abcdef"))
+	require.NoError(t, err)
+	require.ErrorIs(t, WriteQRFile(key, filepath.Join(t.TempDir(), "qr.png")), ErrOathOTP)
+}
@@ -0,0 +1,40 @@
+package otp
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // support JPEG screenshots
+	"io"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// ErrNoQRCode is returned if an image does not contain a QR code.
+var ErrNoQRCode = errors.New("no QR code found")
+
+// DecodeQRImage decodes a QR code from a PNG or JPEG image and returns its
+// text content.
+func DecodeQRImage(r io.Reader) (string, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return decodeQR(img)
+}
+
+func decodeQR(img image.Image) (string, error) {
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+
+	result, err := qrcode.NewQRCodeReader().Decode(bmp, nil)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrNoQRCode, err)
+	}
+
+	return result.GetText(), nil
+}
@@ -4,12 +4,11 @@ package otp
 
 import (
 	"context"
+	"errors"
 	"strings"
 
 	"github.com/gopasspw/gopass/internal/out"
 	"github.com/kbinani/screenshot"
-	"github.com/makiuchi-d/gozxing"
-	"github.com/makiuchi-d/gozxing/qrcode"
 	"github.com/urfave/cli/v3"
 )
 
@@ -36,20 +35,18 @@ func ParseScreen(ctx context.Context) (string, error) {
 		}
 
 		out.OKf(ctx, "Area scanned on screen n°%d: %v", i, img.Bounds())
-		bmp, err := gozxing.NewBinaryBitmapFromImage(img)
-		if err != nil {
-			return "", err
-		}
-		qrReader := qrcode.NewQRCodeReader()
-		result, err := qrReader.Decode(bmp, nil)
-		if err != nil {
+		qr, err := decodeQR(img)
+		if errors.Is(err, ErrNoQRCode) {
 			out.Warningf(ctx, "No QR code found while parsing screen n°%d.", i)
 
 			continue
 		}
+		if err != nil {
+			return "", err
+		}
 
 		out.Noticef(ctx, "Found a qrcode, checking.")
-		if qr := result.GetText(); strings.HasPrefix(qr, "otpauth://") {
+		if strings.HasPrefix(qr, "otpauth://") {
 			out.OKf(ctx, "Found an otpauth:// QR code on screen n°%d (%v) for %s", i, img.Bounds(),
 				// otpauth:// is 10 char, we display label information, but not the parameters containing the secret
 				qr[10:10+strings.Index(qr[10:], "?")])
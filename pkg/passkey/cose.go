@@ -0,0 +1,288 @@
+package passkey
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// COSEAlgorithm is a COSE algorithm identifier as used in the
+// pubKeyCredParams of a WebAuthn registration request.
+// See: https://www.iana.org/assignments/cose/cose.xhtml#algorithms
+type COSEAlgorithm int
+
+// Supported COSE algorithms.
+const (
+	// ES256 is ECDSA with P-256 and SHA-256.
+	ES256 COSEAlgorithm = -7
+	// EdDSA is Ed25519.
+	EdDSA COSEAlgorithm = -8
+	// RS256 is RSASSA-PKCS1-v1_5 with SHA-256.
+	RS256 COSEAlgorithm = -257
+)
+
+// rsaKeyBits is the size of generated RSA keys.
+const rsaKeyBits = 2048
+
+// ErrUnsupportedAlgorithm is returned if none of the requested algorithms is
+// supported or if a key does not match any supported algorithm.
+var ErrUnsupportedAlgorithm = errors.New("unsupported algorithm")
+
+// String returns the name of the algorithm.
+func (a COSEAlgorithm) String() string {
+	switch a {
+	case ES256:
+		return "ES256"
+	case EdDSA:
+		return "EdDSA"
+	case RS256:
+		return "RS256"
+	default:
+		return strconv.Itoa(int(a))
+	}
+}
+
+// ParseCOSEAlgorithm parses an algorithm given by its name (e.g. EdDSA) or
+// its COSE identifier (e.g. -8).
+func ParseCOSEAlgorithm(s string) (COSEAlgorithm, error) {
+	for _, a := range []COSEAlgorithm{ES256, EdDSA, RS256} {
+		if s == a.String() || s == strconv.Itoa(int(a)) {
+			return a, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, s)
+}
+
+// NegotiateAlgorithm returns the first supported algorithm of the list of
+// acceptable algorithms, which is ordered by the preference of the relying
+// party. If the list is empty ES256 is used.
+func NegotiateAlgorithm(algs []COSEAlgorithm) (COSEAlgorithm, error) {
+	if len(algs) == 0 {
+		return ES256, nil
+	}
+
+	for _, a := range algs {
+		switch a {
+		case ES256, EdDSA, RS256:
+			return a, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: none of %v", ErrUnsupportedAlgorithm, algs)
+}
+
+func generateKey(alg COSEAlgorithm) (crypto.Signer, error) {
+	switch alg {
+	case ES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case EdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+
+		return key, err
+	case RS256:
+		return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+	}
+}
+
+// keyAlgorithm returns the algorithm to use with the given key.
+func keyAlgorithm(key any) (COSEAlgorithm, error) {
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return 0, fmt.Errorf("%w: ECDSA curve %s", ErrUnsupportedAlgorithm, k.Curve.Params().Name)
+		}
+
+		return ES256, nil
+	case ed25519.PrivateKey:
+		return EdDSA, nil
+	case *rsa.PrivateKey:
+		return RS256, nil
+	default:
+		return 0, fmt.Errorf("%w: private key type %T", ErrUnsupportedAlgorithm, key)
+	}
+}
+
+// signerFromKey returns the parsed PKCS #8 key if it is supported.
+func signerFromKey(key any) (crypto.Signer, COSEAlgorithm, error) {
+	alg, err := keyAlgorithm(key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: private key type %T", ErrUnsupportedAlgorithm, key)
+	}
+
+	return signer, alg, nil
+}
+
+// sign signs the message with the algorithm of the key. EdDSA signs the
+// message itself, the other algorithms its SHA-256 hash.
+// See: https://www.w3.org/TR/webauthn-2/#sctn-op-get-assertion (step 11)
+func sign(key crypto.Signer, message []byte) ([]byte, error) {
+	alg, err := keyAlgorithm(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if alg == EdDSA {
+		return key.Sign(rand.Reader, message, crypto.Hash(0))
+	}
+
+	digest := sha256.Sum256(message)
+
+	return key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// COSE key parameters.
+// See: https://www.rfc-editor.org/rfc/rfc9053.html#section-7
+const (
+	coseKeyKty = 1
+	coseKeyAlg = 3
+
+	coseKtyOKP = 1
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseCrvP256    = 1
+	coseCrvEd25519 = 6
+)
+
+// COSEKey returns the public key of the credential as a COSE_Key in the
+// CTAP2 canonical CBOR encoding.
+// See: https://www.w3.org/TR/webauthn-2/#sctn-encoded-credPubKey-examples
+func (cred *Credential) COSEKey() ([]byte, error) {
+	alg, err := keyAlgorithm(cred.Signer())
+	if err != nil {
+		return nil, err
+	}
+
+	var m cborMap
+	switch pub := cred.Signer().Public().(type) {
+	case *ecdsa.PublicKey:
+		ecdh, err := pub.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		// uncompressed point: 0x04 || x || y
+		point := ecdh.Bytes()
+		m = cborMap{
+			{coseKeyKty, coseKtyEC2},
+			{coseKeyAlg, int(alg)},
+			{-1, coseCrvP256},
+			{-2, point[1:33]},
+			{-3, point[33:]},
+		}
+	case ed25519.PublicKey:
+		m = cborMap{
+			{coseKeyKty, coseKtyOKP},
+			{coseKeyAlg, int(alg)},
+			{-1, coseCrvEd25519},
+			{-2, []byte(pub)},
+		}
+	case *rsa.PublicKey:
+		m = cborMap{
+			{coseKeyKty, coseKtyRSA},
+			{coseKeyAlg, int(alg)},
+			{-1, pub.N.Bytes()},
+			{-2, big.NewInt(int64(pub.E)).Bytes()},
+		}
+	}
+
+	return m.encode(), nil
+}
+
+// aaguid is the AAGUID reported in the attested credential data. It is all
+// zeros since gopass does not provide an attestation.
+var aaguid = make([]byte, 16)
+
+// AttestedCredentialData returns the attested credential data of the
+// credential, i.e. the AAGUID, the credential ID and the COSE encoded
+// public key.
+// See: https://www.w3.org/TR/webauthn-2/#sctn-attested-credential-data
+func (cred *Credential) AttestedCredentialData() ([]byte, error) {
+	id, err := cred.RawID()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := cred.COSEKey()
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0, len(aaguid)+2+len(id)+len(key))
+	data = append(data, aaguid...)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(id))) //nolint:gosec // credential IDs are at most 1023 bytes
+	data = append(data, id...)
+	data = append(data, key...)
+
+	return data, nil
+}
+
+// cborMap is a CBOR map with integer keys whose values are integers or
+// byte strings. This is all that is needed to encode COSE keys. The entries
+// must be given in canonical order.
+type cborMap []struct {
+	key   int
+	value any
+}
+
+const (
+	cborUnsigned = 0 << 5
+	cborNegative = 1 << 5
+	cborBytes    = 2 << 5
+	cborMapType  = 5 << 5
+)
+
+func (m cborMap) encode() []byte {
+	buf := cborHeader(nil, cborMapType, uint64(len(m)))
+	for _, kv := range m {
+		buf = cborInt(buf, kv.key)
+		switch v := kv.value.(type) {
+		case int:
+			buf = cborInt(buf, v)
+		case []byte:
+			buf = cborHeader(buf, cborBytes, uint64(len(v)))
+			buf = append(buf, v...)
+		}
+	}
+
+	return buf
+}
+
+func cborInt(buf []byte, i int) []byte {
+	if i < 0 {
+		return cborHeader(buf, cborNegative, uint64(-1-i))
+	}
+
+	return cborHeader(buf, cborUnsigned, uint64(i))
+}
+
+func cborHeader(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= 0xff:
+		return append(buf, major|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(buf, major|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(buf, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, major|27), n)
+	}
+}
@@ -0,0 +1,231 @@
+package passkey_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/gopasspw/gopass/pkg/passkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// es256Key is a P-256 key.
+	es256Key = "MIGHAgEAMBMGByqGSM49AgEGCCqGSM49AwEHBG0wawIBAQQguwHMZQBDfVuCg6vZCKi3wvWeKtxttlyk0XgrouQx5gWhRANCAASGhKvkAtJR74iDzfYk0xaOMwPyzUyF+mKvO01d1SUmcVmtLTwqKd6OSSXEE5qM5M1mD/osduRbJQBKJDG5uTF+"
+	// eddsaKey is the Ed25519 key of RFC 8032, section 7.1, TEST 1.
+	eddsaKey = "MC4CAQAwBQYDK2VwBCIEIJ1hsZ3v/VpguoRK9JLsLMREScVpezJpGXA7rAMcrn9g"
+	// rs256Key is a 2048 bit RSA key.
+	rs256Key = "MIIEvQIBADANBgkqhkiG9w0BAQEFAASCBKcwggSjAgEAAoIBAQCoPHSAqZOShC0JtCGxXxOPRgKn9/5IsvqizxUOZcFRAgNOjHlzCjjkhrudEGMazgBsKRCtg7aFCwXifw8KbQLsEZ+YYatdNYu5DxvEpaFK6gPpURjJrVwSPbpCWVFW2bz4nlnQjhh9ZpgyZMM3O249ORb6omHNFWBFShIsaLvAe7XzcNVbkDVN9dJYZMUhydEEpj5o3fkooAwudJ2xnFRYEXEhXjV5PexXKzg03aK7anJtFjXxCgiDDG2atmMBiKBg3quqhVPZRfhqp8p1SXSqpQxg3mg5NkUgpMYJQVQQQ43oc9vOp+ZVp2TpCIK6pDRXst0CtWtKc5/oB51Nz5GhAgMBAAECggEAJ6Dx+nq3ccxjxYTiaOMR+u5NYt6WTe6m/mHUPmt2sYvNVpvdYd1XEHGyUOmSxrYewFud+5lqjYVOsg8m7lhGV3BBTUY4dQDPdFycOGKhj0F3gWaidaM57GwQ618Zu2EX0+KNGoTWPPfFQx2cJG8B5TcE9C365rGx1zB+/mn/S/QWKRtMWXZzbidQnWqEpVZgS10+3j79Lnh6OPwr4sKAaI6mbqV/+4aagA6u1xyGEuO3ZxEquBNd5DoOS+A5YbwVVZfQ7utrOoIHHt7nbTXSDL9okIupo4vW5jH1YdGKwwoXBpYHL2FuH9QkwxBSLj3gxfxNQKudtwGUDE8ljmq99wKBgQDdpwGGJTeevHQxbFbqH0cQWZA5BUbgp4g7uoFysVM63KVj9k/ze0Ydz/6cCG99Nd53xKjdRrl2byesuqiiDbhrrdarvq8phHHrOvaq8ztFWnnykkHSfiGMv5dXS/oUKLQh629I+KuKctAAUEW8k9YbETkbRWocXfOI1wQrDGNJpwKBgQDCTmrKkaFlx4vY+iGBXVGhr/G1byric+HM3QNhR0VbdwKRMnPtDbQuTOpHnsnybC/DuNfYjKE22mSVQ1gVLZjqwLnvTziO4BJNTD9Ve9lp1Vr6ob4atvO+7+aYCwMqs6qM75OSknMZ9MNeE3+d/Iz14ZM7jN++ndejnhTvcIWjdwKBgEK+4reZh3k2eKrVXAreZlDnF4YEL8bAzEZoMEjwWwbToL5MUuEYExIxKxOjeqcyzI42Wz9e58RhToo2H0fFZ+6NB6LqJecTqhZSyCrMWeHVj/laz5VqRAIVQ6aXC3R8mVnJgtZvg4CKeFZP1eAmiIfYFOFAcuad6BMh5HBuJ4vrAoGBAKCOv7vHfsBllW+jsM/+1pdulaJAzAT3kJwB/OsDQ5KC0sI8GILHkh24PNcVpPYG46ktMl6kRgrXh8m3Li/Bz9wbiQjwQ2H8UeNBoAv5HPxQ6q6zvCzDBMK/5aG5Z9J/R+zRgvXYwfeuw0kXf5qCt8/tqAXLMI+ISknPNKluJ1+dAoGAKUs0LIOg6dCZcoXzAqdbxuPnedao4voNGsBCyoH9rN84EuDutSP7C4IjYwAo+bMeCpHFBvffaM074lNHi/yLSGeCpOrSmLggpmFYygK+j8FNIsO3+YECwcrYHFcGOpIMobqYaVqkGoAwJLyItONO111MgpWvwh5UOog2ifGEfpU="
+
+	// rpIDHash is the SHA-256 hash of example.com.
+	rpIDHash = "a379a6f6eeafb9a55e378c118034e2751e682fab9f2d30ab13d2125586ce1947"
+)
+
+func vectorCredential(t *testing.T, key string) *passkey.Credential {
+	t.Helper()
+
+	// the credential ID is "gopass"
+	cred, err := passkey.FromSecret(secrets.ParseAKV([]byte(key + "\npasskey: Z29wYXNz\nrpid: example.com\nflags: up,uv\n")))
+	require.NoError(t, err)
+
+	return cred
+}
+
+// algorithmName returns the name stored for credentials of the algorithm.
+func algorithmName(alg passkey.COSEAlgorithm) string {
+	if alg == passkey.ES256 {
+		return "ECDSA"
+	}
+
+	return alg.String()
+}
+
+func TestNegotiateAlgorithm(t *testing.T) {
+	for _, tc := range []struct {
+		in   []passkey.COSEAlgorithm
+		want passkey.COSEAlgorithm
+	}{
+		{nil, passkey.ES256},
+		{[]passkey.COSEAlgorithm{passkey.EdDSA, passkey.ES256}, passkey.EdDSA},
+		// ES384 and PS256 are not supported
+		{[]passkey.COSEAlgorithm{-35, -37, passkey.RS256}, passkey.RS256},
+	} {
+		got, err := passkey.NegotiateAlgorithm(tc.in)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got, "%v", tc.in)
+	}
+
+	_, err := passkey.NegotiateAlgorithm([]passkey.COSEAlgorithm{-35, -36})
+	require.ErrorIs(t, err, passkey.ErrUnsupportedAlgorithm)
+
+	_, err = passkey.CreateCredential("test.com", "user", flags, -35)
+	require.ErrorIs(t, err, passkey.ErrUnsupportedAlgorithm)
+}
+
+func TestParseCOSEAlgorithm(t *testing.T) {
+	for in, want := range map[string]passkey.COSEAlgorithm{
+		"ES256": passkey.ES256,
+		"-7":    passkey.ES256,
+		"EdDSA": passkey.EdDSA,
+		"-8":    passkey.EdDSA,
+		"RS256": passkey.RS256,
+		"-257":  passkey.RS256,
+	} {
+		got, err := passkey.ParseCOSEAlgorithm(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, in)
+	}
+
+	_, err := passkey.ParseCOSEAlgorithm("ES384")
+	require.ErrorIs(t, err, passkey.ErrUnsupportedAlgorithm)
+}
+
+func TestCOSEKeyVectors(t *testing.T) {
+	rsaKey := vectorCredential(t, rs256Key).Key.(*rsa.PrivateKey) //nolint:forcetypeassert
+
+	for _, tc := range []struct {
+		name string
+		key  string
+		alg  passkey.COSEAlgorithm
+		want string
+	}{
+		{
+			name: "ES256",
+			key:  es256Key,
+			alg:  passkey.ES256,
+			// {1: 2, 3: -7, -1: 1, -2: x, -3: y}
+			want: "a5" + "0102" + "0326" + "2001" +
+				"215820" + "8684abe402d251ef8883cdf624d3168e3303f2cd4c85fa62af3b4d5dd5252671" +
+				"225820" + "59ad2d3c2a29de8e4925c4139a8ce4cd660ffa2c76e45b25004a2431b9b9317e",
+		},
+		{
+			name: "EdDSA",
+			key:  eddsaKey,
+			alg:  passkey.EdDSA,
+			// {1: 1, 3: -8, -1: 6, -2: x}
+			want: "a4" + "0101" + "0327" + "2006" +
+				"215820" + "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a",
+		},
+		{
+			name: "RS256",
+			key:  rs256Key,
+			alg:  passkey.RS256,
+			// {1: 3, 3: -257, -1: n, -2: e}
+			want: "a4" + "0103" + "03390100" +
+				"20590100" + hex.EncodeToString(rsaKey.N.Bytes()) +
+				"2143" + "010001",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cred := vectorCredential(t, tc.key)
+			assert.Equal(t, algorithmName(tc.alg), cred.Algorithm)
+
+			alg, err := cred.COSEAlgorithm()
+			require.NoError(t, err)
+			assert.Equal(t, tc.alg, alg)
+
+			key, err := cred.COSEKey()
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, hex.EncodeToString(key))
+
+			// rpIdHash || flags (UP, UV, AT) || counter || AAGUID || len(id) || id || key
+			authData, err := cred.RegistrationAuthData()
+			require.NoError(t, err)
+			assert.Equal(t, rpIDHash+"45"+"00000000"+
+				"00000000000000000000000000000000"+"0006"+hex.EncodeToString([]byte("gopass"))+
+				tc.want, hex.EncodeToString(authData))
+		})
+	}
+}
+
+func TestAssertionVectors(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		key    string
+		verify func(pub crypto.PublicKey, message, sig []byte) bool
+	}{
+		{
+			name: "ES256",
+			key:  es256Key,
+			verify: func(pub crypto.PublicKey, message, sig []byte) bool {
+				digest := sha256.Sum256(message)
+
+				return ecdsa.VerifyASN1(pub.(*ecdsa.PublicKey), digest[:], sig) //nolint:forcetypeassert
+			},
+		},
+		{
+			name: "EdDSA",
+			key:  eddsaKey,
+			verify: func(pub crypto.PublicKey, message, sig []byte) bool {
+				return ed25519.Verify(pub.(ed25519.PublicKey), message, sig) //nolint:forcetypeassert
+			},
+		},
+		{
+			name: "RS256",
+			key:  rs256Key,
+			verify: func(pub crypto.PublicKey, message, sig []byte) bool {
+				digest := sha256.Sum256(message)
+
+				return rsa.VerifyPKCS1v15(pub.(*rsa.PublicKey), crypto.SHA256, digest[:], sig) == nil //nolint:forcetypeassert
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cred := vectorCredential(t, tc.key)
+
+			rsp, err := cred.GetAssertion("Y2hhbGxlbmdl", "https://example.com")
+			require.NoError(t, err)
+
+			// rpIdHash || flags (UP, UV) || counter
+			assert.Equal(t, rpIDHash+"05"+"00000001", hex.EncodeToString(rsp.AuthenticatorData))
+			assert.JSONEq(t, `{"type":"webauthn.get","challenge":"Y2hhbGxlbmdl","origin":"https://example.com"}`, string(rsp.ClientDataJSON))
+
+			clientDataHash := sha256.Sum256(rsp.ClientDataJSON)
+			message := append(rsp.AuthenticatorData, clientDataHash[:]...)
+			assert.True(t, tc.verify(cred.Signer().Public(), message, rsp.Signature))
+		})
+	}
+}
+
+func TestEdDSASignatureVector(t *testing.T) {
+	// Ed25519 signatures are deterministic.
+	cred := vectorCredential(t, eddsaKey)
+
+	rsp, err := cred.GetAssertion("Y2hhbGxlbmdl", "https://example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "a796631e5a3885c061c994d9478387677cc4c9bce48e5097ce80446037af9da2"+
+		"b38d95578dbb4a4e8a5202bab241f2cc672a71c19802294a6d5b6199aecc3708", hex.EncodeToString(rsp.Signature))
+}
+
+func TestCreateCredentialAlgorithms(t *testing.T) {
+	for _, alg := range []passkey.COSEAlgorithm{passkey.ES256, passkey.EdDSA, passkey.RS256} {
+		t.Run(alg.String(), func(t *testing.T) {
+			cred, err := passkey.CreateCredential("test.com", "user", flags, -35, alg)
+			require.NoError(t, err)
+			assert.Equal(t, algorithmName(alg), cred.Algorithm)
+
+			_, err = cred.GetAssertion("Y2hhbGxlbmdl", "https://test.com")
+			require.NoError(t, err)
+
+			// secret round trip
+			sec, err := cred.Secret()
+			require.NoError(t, err)
+			got, err := passkey.FromSecret(secrets.ParseAKV(sec.Bytes()))
+			require.NoError(t, err)
+			assert.Equal(t, algorithmName(alg), got.Algorithm)
+			assert.Equal(t, uint32(1), got.Counter)
+
+			want, err := cred.COSEKey()
+			require.NoError(t, err)
+			key, err := got.COSEKey()
+			require.NoError(t, err)
+			assert.Equal(t, want, key)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package passkey
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// This file implements the passkey parts of the FIDO Alliance Credential
+// Exchange Format (CXF).
+// See: https://fidoalliance.org/specifications-credential-exchange-specifications/
+
+const (
+	// CXFVersionMajor is the major version of the CXF format we produce.
+	CXFVersionMajor = 1
+	// CXFVersionMinor is the minor version of the CXF format we produce.
+	CXFVersionMinor = 0
+	// CXFTypePasskey is the credential type of passkeys.
+	CXFTypePasskey = "passkey"
+	// CXFExtensionAuthenticator is the name of the item extension that holds
+	// the authenticator state, i.e. the signature counter and the flags.
+	// They are not part of the passkey credential in CXF, but are needed to
+	// keep using the credential with the same relying party.
+	CXFExtensionAuthenticator = "gopass-authenticator"
+
+	cxfExporterRpID        = "gopass.pw"
+	cxfExporterDisplayName = "gopass"
+)
+
+// ErrCXFVersion is returned if a CXF document has an unsupported version.
+var ErrCXFVersion = errors.New("unsupported CXF version")
+
+// CXF is the header of a CXF document.
+type CXF struct {
+	Version             CXFVersion   `json:"version"`
+	ExporterRpID        string       `json:"exporterRpId"`
+	ExporterDisplayName string       `json:"exporterDisplayName"`
+	Timestamp           int64        `json:"timestamp"`
+	Accounts            []CXFAccount `json:"accounts"`
+}
+
+// CXFVersion is the version of a CXF document.
+type CXFVersion struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+}
+
+// CXFAccount is a single user account of the exporting password manager.
+type CXFAccount struct {
+	ID       string    `json:"id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	Items    []CXFItem `json:"items"`
+}
+
+// CXFItem is an entry of an account. It may hold several credentials.
+type CXFItem struct {
+	ID          string          `json:"id"`
+	CreationAt  int64           `json:"creationAt,omitempty"`
+	ModifiedAt  int64           `json:"modifiedAt,omitempty"`
+	Title       string          `json:"title"`
+	Credentials []CXFCredential `json:"credentials"`
+	Extensions  []CXFExtension  `json:"extensions,omitempty"`
+}
+
+// CXFCredential is a credential of an item. Only the fields of the passkey
+// type are supported, credentials of other types are ignored on import.
+type CXFCredential struct {
+	Type            string `json:"type"`
+	CredentialID    string `json:"credentialId,omitempty"`
+	RpID            string `json:"rpId,omitempty"`
+	Username        string `json:"username,omitempty"`
+	UserDisplayName string `json:"userDisplayName,omitempty"`
+	UserHandle      string `json:"userHandle,omitempty"`
+	// Key is the base64url encoded PKCS #8 private key.
+	Key string `json:"key,omitempty"`
+}
+
+// CXFExtension is an item extension. Only the authenticator extension is
+// supported, other extensions are ignored on import.
+type CXFExtension struct {
+	Name    string  `json:"name"`
+	Counter *uint32 `json:"counter,omitempty"`
+	Flags   string  `json:"flags,omitempty"`
+}
+
+// CXFItem returns the credential as a CXF item.
+func (cred *Credential) CXFItem() (CXFItem, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(cred.Signer())
+	if err != nil {
+		return CXFItem{}, fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	counter := cred.Counter
+	item := CXFItem{
+		ID:    cred.ID,
+		Title: cred.Rp,
+		Credentials: []CXFCredential{{
+			Type:            CXFTypePasskey,
+			CredentialID:    cred.ID,
+			RpID:            cred.Rp,
+			Username:        cred.UserName,
+			UserDisplayName: cred.UserName,
+			UserHandle:      base64.RawURLEncoding.EncodeToString(cred.UserHandle),
+			Key:             base64.RawURLEncoding.EncodeToString(der),
+		}},
+		Extensions: []CXFExtension{{
+			Name:    CXFExtensionAuthenticator,
+			Counter: &counter,
+			Flags:   formatFlags(cred.Flags),
+		}},
+	}
+	if !cred.Created.IsZero() {
+		item.CreationAt = cred.Created.Unix()
+	}
+
+	return item, nil
+}
+
+// FromCXFItem returns the passkey contained in a CXF item. It returns
+// ErrNotAPasskey if the item does not contain one.
+func FromCXFItem(item CXFItem) (*Credential, error) {
+	for _, c := range item.Credentials {
+		if c.Type != CXFTypePasskey {
+			continue
+		}
+
+		der, err := base64.RawURLEncoding.DecodeString(c.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode private key: %w", err)
+		}
+		key, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		signer, alg, err := signerFromKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		userHandle, err := base64.RawURLEncoding.DecodeString(c.UserHandle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode user handle: %w", err)
+		}
+
+		cred := &Credential{
+			ID:         c.CredentialID,
+			Rp:         c.RpID,
+			UserName:   c.Username,
+			UserHandle: userHandle,
+			// without the authenticator extension we can only assume
+			// that the user was present.
+			Flags: CredentialFlags{UserPresent: true},
+		}
+		cred.setKey(signer, alg)
+		if item.CreationAt > 0 {
+			cred.Created = time.Unix(item.CreationAt, 0).UTC()
+		}
+
+		for _, ext := range item.Extensions {
+			if ext.Name != CXFExtensionAuthenticator {
+				continue
+			}
+			if ext.Counter != nil {
+				cred.Counter = *ext.Counter
+			}
+			cred.Flags = parseFlags(ext.Flags)
+		}
+
+		return cred, nil
+	}
+
+	return nil, ErrNotAPasskey
+}
+
+// MarshalCXF returns a CXF document containing the given credentials.
+func MarshalCXF(creds []*Credential, now time.Time) ([]byte, error) {
+	doc := CXF{
+		Version: CXFVersion{
+			Major: CXFVersionMajor,
+			Minor: CXFVersionMinor,
+		},
+		ExporterRpID:        cxfExporterRpID,
+		ExporterDisplayName: cxfExporterDisplayName,
+		Timestamp:           now.Unix(),
+		Accounts: []CXFAccount{{
+			ID:       base64.RawURLEncoding.EncodeToString([]byte(cxfExporterDisplayName)),
+			Username: cxfExporterDisplayName,
+			Items:    make([]CXFItem, 0, len(creds)),
+		}},
+	}
+
+	for _, cred := range creds {
+		item, err := cred.CXFItem()
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", cred.ID, err)
+		}
+		doc.Accounts[0].Items = append(doc.Accounts[0].Items, item)
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// UnmarshalCXF returns all passkeys contained in a CXF document. Items
+// without a passkey are skipped.
+func UnmarshalCXF(buf []byte) ([]*Credential, error) {
+	var doc CXF
+	if err := json.Unmarshal(buf, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse CXF document: %w", err)
+	}
+
+	if doc.Version.Major != CXFVersionMajor {
+		return nil, fmt.Errorf("%w: %d.%d", ErrCXFVersion, doc.Version.Major, doc.Version.Minor)
+	}
+
+	var creds []*Credential
+	for _, account := range doc.Accounts {
+		for _, item := range account.Items {
+			cred, err := FromCXFItem(item)
+			if errors.Is(err, ErrNotAPasskey) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to import item %q: %w", item.ID, err)
+			}
+			creds = append(creds, cred)
+		}
+	}
+
+	return creds, nil
+}
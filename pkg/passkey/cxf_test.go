@@ -0,0 +1,185 @@
+package passkey_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/gopasspw/gopass/pkg/passkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cxfVector is a CXF document with a passkey, a passkey exported without the
+// authenticator extension and an item without a passkey.
+const cxfVector = `{
+  "version": {
+    "major": 1,
+    "minor": 0
+  },
+  "exporterRpId": "gopass.pw",
+  "exporterDisplayName": "gopass",
+  "timestamp": 1700000100,
+  "accounts": [
+    {
+      "id": "Z29wYXNz",
+      "username": "gopass",
+      "email": "",
+      "items": [
+        {
+          "id": "Z29wYXNzLXRlc3QtY3JlZGVudGlhbC1pZC0wMDAwMDE",
+          "creationAt": 1700000000,
+          "title": "example.com",
+          "credentials": [
+            {
+              "type": "passkey",
+              "credentialId": "Z29wYXNzLXRlc3QtY3JlZGVudGlhbC1pZC0wMDAwMDE",
+              "rpId": "example.com",
+              "username": "alice",
+              "userDisplayName": "alice",
+              "userHandle": "dXNlci1oYW5kbGUtMDAwMQ",
+              "key": "MIGHAgEAMBMGByqGSM49AgEGCCqGSM49AwEHBG0wawIBAQQguwHMZQBDfVuCg6vZCKi3wvWeKtxttlyk0XgrouQx5gWhRANCAASGhKvkAtJR74iDzfYk0xaOMwPyzUyF-mKvO01d1SUmcVmtLTwqKd6OSSXEE5qM5M1mD_osduRbJQBKJDG5uTF-"
+            }
+          ],
+          "extensions": [
+            {
+              "name": "gopass-authenticator",
+              "counter": 7,
+              "flags": "up,uv"
+            }
+          ]
+        },
+        {
+          "id": "b3RoZXI",
+          "title": "other.example.com",
+          "credentials": [
+            {
+              "type": "passkey",
+              "credentialId": "b3RoZXI",
+              "rpId": "other.example.com",
+              "username": "bob",
+              "userHandle": "Ym9i",
+              "key": "MIGHAgEAMBMGByqGSM49AgEGCCqGSM49AwEHBG0wawIBAQQguwHMZQBDfVuCg6vZCKi3wvWeKtxttlyk0XgrouQx5gWhRANCAASGhKvkAtJR74iDzfYk0xaOMwPyzUyF-mKvO01d1SUmcVmtLTwqKd6OSSXEE5qM5M1mD_osduRbJQBKJDG5uTF-"
+            }
+          ]
+        },
+        {
+          "id": "bG9naW4",
+          "title": "login",
+          "credentials": [
+            {
+              "type": "basic-auth"
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}`
+
+// akvVector is the first passkey of cxfVector stored as a secret.
+const akvVector = `MIGHAgEAMBMGByqGSM49AgEGCCqGSM49AwEHBG0wawIBAQQguwHMZQBDfVuCg6vZCKi3wvWeKtxttlyk0XgrouQx5gWhRANCAASGhKvkAtJR74iDzfYk0xaOMwPyzUyF+mKvO01d1SUmcVmtLTwqKd6OSSXEE5qM5M1mD/osduRbJQBKJDG5uTF+
+passkey: Z29wYXNzLXRlc3QtY3JlZGVudGlhbC1pZC0wMDAwMDE
+rpid: example.com
+user: alice
+algorithm: ECDSA
+counter: 7
+flags: up,uv
+userhandle: dXNlci1oYW5kbGUtMDAwMQ
+created: 2023-11-14T22:13:20Z
+`
+
+func TestUnmarshalCXF(t *testing.T) {
+	creds, err := passkey.UnmarshalCXF([]byte(cxfVector))
+	require.NoError(t, err)
+	require.Len(t, creds, 2)
+
+	cred := creds[0]
+	assert.Equal(t, "Z29wYXNzLXRlc3QtY3JlZGVudGlhbC1pZC0wMDAwMDE", cred.ID)
+	assert.Equal(t, "example.com", cred.Rp)
+	assert.Equal(t, "alice", cred.UserName)
+	assert.Equal(t, []byte("user-handle-0001"), cred.UserHandle)
+	assert.Equal(t, uint32(7), cred.Counter)
+	assert.Equal(t, passkey.CredentialFlags{UserPresent: true, UserVerified: true}, cred.Flags)
+	assert.Equal(t, time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC), cred.Created)
+
+	// the key can still be used
+	rsp, err := cred.GetAssertion("Y2hhbGxlbmdl", "https://example.com")
+	require.NoError(t, err)
+	clientDataHash := sha256.Sum256(rsp.ClientDataJSON)
+	message := sha256.Sum256(append(rsp.AuthenticatorData, clientDataHash[:]...))
+	assert.True(t, ecdsa.VerifyASN1(cred.SecretKey.Public().(*ecdsa.PublicKey), message[:], rsp.Signature))
+	assert.Equal(t, uint32(8), cred.Counter)
+
+	// no authenticator extension and no creation time
+	cred = creds[1]
+	assert.Equal(t, "bob", cred.UserName)
+	assert.Equal(t, []byte("bob"), cred.UserHandle)
+	assert.Equal(t, uint32(0), cred.Counter)
+	assert.Equal(t, passkey.CredentialFlags{UserPresent: true}, cred.Flags)
+	assert.True(t, cred.Created.IsZero())
+}
+
+func TestUnmarshalCXFErrors(t *testing.T) {
+	_, err := passkey.UnmarshalCXF([]byte(`{"version":{"major":2,"minor":0}}`))
+	require.ErrorIs(t, err, passkey.ErrCXFVersion)
+
+	_, err = passkey.UnmarshalCXF([]byte(`{`))
+	require.Error(t, err)
+
+	_, err = passkey.UnmarshalCXF([]byte(`{"version":{"major":1},"accounts":[{"items":[{"credentials":[{"type":"passkey","key":"!"}]}]}]}`))
+	require.Error(t, err)
+
+	_, err = passkey.FromCXFItem(passkey.CXFItem{})
+	require.ErrorIs(t, err, passkey.ErrNotAPasskey)
+}
+
+func TestCXFSecretVectors(t *testing.T) {
+	creds, err := passkey.UnmarshalCXF([]byte(cxfVector))
+	require.NoError(t, err)
+
+	// CXF -> secret
+	sec, err := creds[0].Secret()
+	require.NoError(t, err)
+	assert.Equal(t, akvVector, string(sec.Bytes()))
+
+	// secret -> CXF
+	cred, err := passkey.FromSecret(secrets.ParseAKV([]byte(akvVector)))
+	require.NoError(t, err)
+	buf, err := passkey.MarshalCXF([]*passkey.Credential{cred}, time.Unix(1700000100, 0))
+	require.NoError(t, err)
+
+	var want, got passkey.CXF
+	require.NoError(t, json.Unmarshal([]byte(cxfVector), &want))
+	require.NoError(t, json.Unmarshal(buf, &got))
+	want.Accounts[0].Items = want.Accounts[0].Items[:1]
+	assert.Equal(t, want, got)
+}
+
+func TestCXFRoundTrip(t *testing.T) {
+	cred, err := passkey.CreateCredential("test.com", "user", flags)
+	require.NoError(t, err)
+	cred.Counter = 42
+	assert.Len(t, cred.UserHandle, 16)
+	assert.False(t, cred.Created.IsZero())
+
+	buf, err := passkey.MarshalCXF([]*passkey.Credential{cred}, time.Now())
+	require.NoError(t, err)
+
+	creds, err := passkey.UnmarshalCXF(buf)
+	require.NoError(t, err)
+	require.Len(t, creds, 1)
+	got := creds[0]
+
+	assert.Equal(t, cred.ID, got.ID)
+	assert.Equal(t, cred.Rp, got.Rp)
+	assert.Equal(t, cred.UserName, got.UserName)
+	assert.Equal(t, cred.UserHandle, got.UserHandle)
+	assert.Equal(t, cred.Counter, got.Counter)
+	assert.Equal(t, cred.Flags, got.Flags)
+	assert.Equal(t, cred.Created, got.Created)
+	assert.True(t, cred.SecretKey.Equal(got.SecretKey))
+}
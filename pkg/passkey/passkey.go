@@ -4,14 +4,16 @@
 package passkey
 
 import (
+	"crypto"
 	"crypto/ecdsa"
-	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // CredentialFlags for the credential parameters.
@@ -29,9 +31,20 @@ type Credential struct {
 	Rp        string
 	UserName  string
 	Algorithm string
+	// SecretKey is the private key of ES256 credentials. It is nil for
+	// other algorithms, use Signer to get the key of any credential.
 	SecretKey *ecdsa.PrivateKey
-	Counter   uint32
-	Flags     CredentialFlags
+	// Key is the private key of the credential, an *ecdsa.PrivateKey
+	// (ES256), an ed25519.PrivateKey (EdDSA) or an *rsa.PrivateKey (RS256).
+	// It is only used if SecretKey is not set.
+	Key     crypto.Signer
+	Counter uint32
+	Flags   CredentialFlags
+	// UserHandle is the opaque user ID assigned by the relying party.
+	// See: https://www.w3.org/TR/webauthn-2/#user-handle
+	UserHandle []byte
+	// Created is the time the credential was created. It is zero if unknown.
+	Created time.Time
 }
 
 // ClientData for signature.
@@ -73,25 +86,122 @@ func authDataFlags(options CredentialFlags) uint8 {
 	return flags
 }
 
+// AssertionJSON is the JSON serialization of a PublicKeyCredential with an
+// authenticator assertion response, as expected by relying parties.
+// See: https://www.w3.org/TR/webauthn-3/#dictdef-authenticationresponsejson
+type AssertionJSON struct {
+	ID       string                `json:"id"`
+	RawID    string                `json:"rawId"`
+	Type     string                `json:"type"`
+	Response AssertionResponseJSON `json:"response"`
+}
+
+// AssertionResponseJSON holds the base64url encoded assertion.
+type AssertionResponseJSON struct {
+	AuthenticatorData string `json:"authenticatorData"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	Signature         string `json:"signature"`
+}
+
+// JSON returns the assertion of the given credential in the WebAuthn JSON format.
+func (r *Response) JSON(cred *Credential) AssertionJSON {
+	enc := base64.RawURLEncoding
+
+	return AssertionJSON{
+		ID:    cred.ID,
+		RawID: cred.ID,
+		Type:  "public-key",
+		Response: AssertionResponseJSON{
+			AuthenticatorData: enc.EncodeToString(r.AuthenticatorData),
+			ClientDataJSON:    enc.EncodeToString(r.ClientDataJSON),
+			Signature:         enc.EncodeToString(r.Signature),
+		},
+	}
+}
+
+// Signer returns the private key of the credential.
+func (cred *Credential) Signer() crypto.Signer {
+	if cred.SecretKey != nil {
+		return cred.SecretKey
+	}
+
+	return cred.Key
+}
+
+// setKey sets the private key and the algorithm of the credential. ES256
+// credentials keep the algorithm name of older versions, which only
+// supported ECDSA.
+func (cred *Credential) setKey(key crypto.Signer, alg COSEAlgorithm) {
+	cred.Key = key
+	cred.Algorithm = alg.String()
+	if k, ok := key.(*ecdsa.PrivateKey); ok {
+		cred.SecretKey = k
+		cred.Algorithm = "ECDSA"
+	}
+}
+
+// PublicKey returns the DER encoded public key of the credential
+// (SubjectPublicKeyInfo). This is needed to register it with a relying party.
+func (cred *Credential) PublicKey() ([]byte, error) {
+	key := cred.Signer()
+	if key == nil {
+		return nil, fmt.Errorf("%w: no private key", ErrUnsupportedAlgorithm)
+	}
+
+	return x509.MarshalPKIXPublicKey(key.Public())
+}
+
+// COSEAlgorithm returns the algorithm used by the credential.
+func (cred *Credential) COSEAlgorithm() (COSEAlgorithm, error) {
+	return keyAlgorithm(cred.Signer())
+}
+
+// RawID returns the decoded credential ID.
+func (cred *Credential) RawID() ([]byte, error) {
+	id, err := base64.RawURLEncoding.DecodeString(cred.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential ID %q: %w", cred.ID, err)
+	}
+
+	return id, nil
+}
+
 // CreateCredential is an implementation of the authenticatorMakeCredential Operation.
+// The key algorithm is negotiated from the acceptable algorithms given by the
+// relying party in order of preference. If none are given ES256 is used.
 // See: https://www.w3.org/TR/webauthn-2/#sctn-op-make-cred
-func CreateCredential(rp string, user string, flags CredentialFlags) (*Credential, error) {
+func CreateCredential(rp string, user string, flags CredentialFlags, algs ...COSEAlgorithm) (*Credential, error) {
+	alg, err := NegotiateAlgorithm(algs)
+	if err != nil {
+		return nil, err
+	}
+
 	rawID := make([]byte, 32)
-	_, err := rand.Read(rawID)
+	_, err = rand.Read(rawID)
 	if err != nil {
 		return nil, fmt.Errorf("error while generating random ID: %w", err)
 	}
-	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	userHandle := make([]byte, 16)
+	if _, err := rand.Read(userHandle); err != nil {
+		return nil, fmt.Errorf("error while generating user handle: %w", err)
+	}
+	privateKey, err := generateKey(alg)
+	if err != nil {
+		return nil, fmt.Errorf("error while generating key: %w", err)
+	}
 
-	return &Credential{
-		ID:        base64.RawURLEncoding.EncodeToString(rawID),
-		Rp:        rp,
-		UserName:  user,
-		Algorithm: "ECDSA",
-		SecretKey: privateKey,
-		Counter:   0,
-		Flags:     flags,
-	}, nil
+	cred := &Credential{
+		ID:         base64.RawURLEncoding.EncodeToString(rawID),
+		Rp:         rp,
+		UserName:   user,
+		Counter:    0,
+		Flags:      flags,
+		UserHandle: userHandle,
+		Created:    time.Now().UTC().Truncate(time.Second),
+	}
+	cred.setKey(privateKey, alg)
+
+	return cred, nil
 }
 
 // GetAssertion is an implementation of the authenticatorGetAssertion Operation.
@@ -109,19 +219,13 @@ func (cred *Credential) GetAssertion(challenge string, origin string) (*Response
 	}
 
 	clientDataHash := sha256.Sum256(clientDataJSON)
-	rpIDHash := sha256.Sum256([]byte(cred.Rp))
-	flags := []byte{authDataFlags(cred.Flags)}
 
 	// Signature counter is incremented according to https://www.w3.org/TR/webauthn-2/#signature-counter
 	cred.Counter += 1
-	signCount := make([]byte, 4)
-	binary.BigEndian.PutUint32(signCount, cred.Counter)
-	authData := append(rpIDHash[:], flags...)
-	authData = append(authData[:], signCount[:]...)
-	message := sha256.Sum256(append(authData[:], clientDataHash[:]...))
-	signature, serr := ecdsa.SignASN1(rand.Reader, cred.SecretKey, message[:])
+	authData := cred.authenticatorData(cred.Flags, nil)
+	signature, err := sign(cred.Signer(), append(authData, clientDataHash[:]...))
 	if err != nil {
-		return nil, serr
+		return nil, fmt.Errorf("error while signing: %w", err)
 	}
 
 	return &Response{
@@ -131,3 +235,32 @@ func (cred *Credential) GetAssertion(challenge string, origin string) (*Response
 		Login:             cred.UserName,
 	}, nil
 }
+
+// RegistrationAuthData returns the authenticator data of the
+// authenticatorMakeCredential operation. It includes the attested credential
+// data, i.e. the public key, that a relying party needs for registration.
+func (cred *Credential) RegistrationAuthData() ([]byte, error) {
+	attested, err := cred.AttestedCredentialData()
+	if err != nil {
+		return nil, err
+	}
+
+	flags := cred.Flags
+	flags.AttestationData = true
+
+	return cred.authenticatorData(flags, attested), nil
+}
+
+// authenticatorData returns rpIdHash || flags || signCount || attestedCredentialData.
+// See: https://www.w3.org/TR/webauthn-2/#sctn-authenticator-data
+func (cred *Credential) authenticatorData(flags CredentialFlags, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(cred.Rp))
+
+	authData := make([]byte, 0, len(rpIDHash)+1+4+len(attested))
+	authData = append(authData, rpIDHash[:]...)
+	authData = append(authData, authDataFlags(flags))
+	authData = binary.BigEndian.AppendUint32(authData, cred.Counter)
+	authData = append(authData, attested...)
+
+	return authData
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"testing"
 
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
 	"github.com/gopasspw/gopass/pkg/passkey"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -39,5 +40,52 @@ func TestGetAssertion(t *testing.T) {
 	require.NoError(t, err)
 
 	message := sha256.Sum256(append(authData[:], clientDataHash[:]...))
-	assert.True(t, ecdsa.VerifyASN1(&cred.SecretKey.PublicKey, message[:], rsp.Signature))
+	assert.True(t, ecdsa.VerifyASN1(cred.SecretKey.Public().(*ecdsa.PublicKey), message[:], rsp.Signature))
+}
+
+func TestSecretRoundTrip(t *testing.T) {
+	cred, err := passkey.CreateCredential("test.com", "user", flags)
+	require.NoError(t, err)
+	cred.Counter = 42
+
+	sec, err := cred.Secret()
+	require.NoError(t, err)
+	assert.True(t, passkey.IsPasskey(sec))
+
+	// the private key is stored as the password
+	assert.NotEmpty(t, sec.Password())
+	rpID, _ := sec.Get(passkey.KeyRp)
+	assert.Equal(t, "test.com", rpID)
+
+	got, err := passkey.FromSecret(secrets.ParseAKV(sec.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, cred.ID, got.ID)
+	assert.Equal(t, cred.Rp, got.Rp)
+	assert.Equal(t, cred.UserName, got.UserName)
+	assert.Equal(t, cred.Algorithm, got.Algorithm)
+	assert.Equal(t, cred.Counter, got.Counter)
+	assert.Equal(t, cred.Flags, got.Flags)
+	assert.Equal(t, cred.UserHandle, got.UserHandle)
+	assert.Equal(t, cred.Created, got.Created)
+	assert.True(t, cred.SecretKey.Equal(got.SecretKey))
+
+	_, err = passkey.FromSecret(secrets.NewAKV())
+	require.ErrorIs(t, err, passkey.ErrNotAPasskey)
+}
+
+func TestAssertionJSON(t *testing.T) {
+	cred, err := passkey.CreateCredential("test.com", "user", flags)
+	require.NoError(t, err)
+
+	rsp, err := cred.GetAssertion("Y2hhbGxlbmdl", "https://test.com")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), cred.Counter)
+
+	aj := rsp.JSON(cred)
+	assert.Equal(t, cred.ID, aj.ID)
+	assert.Equal(t, "public-key", aj.Type)
+
+	sig, err := base64.RawURLEncoding.DecodeString(aj.Response.Signature)
+	require.NoError(t, err)
+	assert.Equal(t, rsp.Signature, sig)
 }
@@ -0,0 +1,173 @@
+package passkey
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+// Keys used to store a credential in a secret. The private key is stored as
+// the password so it is hidden by default.
+const (
+	KeyID        = "passkey"
+	KeyRp        = "rpid"
+	KeyUser      = "user"
+	KeyAlgorithm = "algorithm"
+	KeyCounter   = "counter"
+	KeyFlags     = "flags"
+	// KeyUserHandle holds the base64url encoded user handle.
+	KeyUserHandle = "userhandle"
+	// KeyCreated holds the creation time in RFC 3339 format.
+	KeyCreated = "created"
+)
+
+// ErrNotAPasskey is returned if a secret does not contain a credential.
+var ErrNotAPasskey = errors.New("not a passkey")
+
+// IsPasskey returns true if the secret contains a credential.
+func IsPasskey(sec gopass.Secret) bool {
+	_, found := sec.Get(KeyID)
+
+	return found
+}
+
+// FromSecret parses a credential stored in a secret.
+func FromSecret(sec gopass.Secret) (*Credential, error) {
+	id, found := sec.Get(KeyID)
+	if !found {
+		return nil, ErrNotAPasskey
+	}
+
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(sec.Password()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key: %w", err)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	signer, alg, err := signerFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// the algorithm is always derived from the key.
+	cred := &Credential{ID: id}
+	cred.setKey(signer, alg)
+	cred.Rp, _ = sec.Get(KeyRp)
+	cred.UserName, _ = sec.Get(KeyUser)
+
+	if cv, found := sec.Get(KeyCounter); found {
+		c, err := strconv.ParseUint(cv, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid counter %q: %w", cv, err)
+		}
+		cred.Counter = uint32(c)
+	}
+
+	if fv, found := sec.Get(KeyFlags); found {
+		cred.Flags = parseFlags(fv)
+	}
+
+	if uh, found := sec.Get(KeyUserHandle); found {
+		cred.UserHandle, err = base64.RawURLEncoding.DecodeString(uh)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user handle %q: %w", uh, err)
+		}
+	}
+
+	if cv, found := sec.Get(KeyCreated); found {
+		cred.Created, err = time.Parse(time.RFC3339, cv)
+		if err != nil {
+			return nil, fmt.Errorf("invalid creation time %q: %w", cv, err)
+		}
+	}
+
+	return cred, nil
+}
+
+// Secret returns a new secret containing the credential.
+func (cred *Credential) Secret() (*secrets.AKV, error) {
+	sec := secrets.NewAKV()
+	if err := cred.WriteSecret(sec); err != nil {
+		return nil, err
+	}
+
+	return sec, nil
+}
+
+// WriteSecret stores the credential in an existing secret, keeping any
+// other fields intact.
+func (cred *Credential) WriteSecret(sec gopass.Secret) error {
+	der, err := x509.MarshalPKCS8PrivateKey(cred.Signer())
+	if err != nil {
+		return fmt.Errorf("failed to encode private key: %w", err)
+	}
+	sec.SetPassword(base64.StdEncoding.EncodeToString(der))
+
+	kvs := [][2]string{
+		{KeyID, cred.ID},
+		{KeyRp, cred.Rp},
+		{KeyUser, cred.UserName},
+		{KeyAlgorithm, cred.Algorithm},
+		{KeyCounter, strconv.FormatUint(uint64(cred.Counter), 10)},
+		{KeyFlags, formatFlags(cred.Flags)},
+	}
+	if len(cred.UserHandle) > 0 {
+		kvs = append(kvs, [2]string{KeyUserHandle, base64.RawURLEncoding.EncodeToString(cred.UserHandle)})
+	}
+	if !cred.Created.IsZero() {
+		kvs = append(kvs, [2]string{KeyCreated, cred.Created.UTC().Format(time.RFC3339)})
+	}
+
+	for _, kv := range kvs {
+		if err := sec.Set(kv[0], kv[1]); err != nil {
+			return fmt.Errorf("failed to set %s: %w", kv[0], err)
+		}
+	}
+
+	return nil
+}
+
+func formatFlags(f CredentialFlags) string {
+	var flags []string
+	if f.UserPresent {
+		flags = append(flags, "up")
+	}
+	if f.UserVerified {
+		flags = append(flags, "uv")
+	}
+	if f.AttestationData {
+		flags = append(flags, "at")
+	}
+	if f.ExtensionData {
+		flags = append(flags, "ed")
+	}
+
+	return strings.Join(flags, ",")
+}
+
+func parseFlags(s string) CredentialFlags {
+	var f CredentialFlags
+	for flag := range strings.SplitSeq(s, ",") {
+		switch strings.TrimSpace(flag) {
+		case "up":
+			f.UserPresent = true
+		case "uv":
+			f.UserVerified = true
+		case "at":
+			f.AttestationData = true
+		case "ed":
+			f.ExtensionData = true
+		}
+	}
+
+	return f
+}
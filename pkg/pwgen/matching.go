@@ -0,0 +1,616 @@
+package pwgen
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// commonPasswords are some of the most common passwords of public password
+// leaks, most common first.
+var commonPasswords = strings.Fields(`123456 password 12345678 qwerty 123456789
+12345 1234 111111 1234567 dragon 123123 baseball abc123 football monkey
+letmein shadow master 666666 qwertyuiop 123321 mustang 1234567890 michael
+654321 superman 1qaz2wsx 7777777 121212 000000 qazwsx 123qwe killer trustno1
+jordan jennifer zxcvbnm asdfgh hunter buster soccer harley batman andrew
+tigger sunshine iloveyou 2000 charlie robert thomas hockey ranger daniel
+starwars klaster 112233 george computer michelle jessica pepper 1111 zxcvbn
+555555 11111111 131313 freedom 777777 pass maggie 159753 aaaaaa ginger
+princess joshua cheese amanda summer love ashley nicole chelsea biteme
+matthew access yankees 987654321 dallas austin thunder taylor matrix admin
+welcome login secret changeme root toor test guest qwerty123 passwd
+hello whatever dragon1 monkey1 football1 flower hottie loveme zaq12wsx
+password1 master1 abcdef abcd1234 qwer1234 letmein1 iloveyou1 gopass`)
+
+// dictionaries are the ranked word lists used to find dictionary words.
+var dictionaries = []struct {
+	name  string
+	ranks map[string]int
+}{
+	{"common password", rankList(commonPasswords, 1)},
+	{"english word", rankList(wordlist, len(wordlist))},
+}
+
+// rankList maps the words to their rank. If flat is larger than one all
+// words get the same rank since the list is not ordered by frequency.
+func rankList(words []string, flat int) map[string]int {
+	ranks := make(map[string]int, len(words))
+	for i, w := range words {
+		if _, found := ranks[w]; found {
+			continue
+		}
+		if flat > 1 {
+			ranks[w] = flat
+
+			continue
+		}
+		ranks[w] = i + 1
+	}
+
+	return ranks
+}
+
+// minWordLength is the minimum length of dictionary matches. Shorter words
+// are cheaper to guess by brute force anyway.
+const minWordLength = 3
+
+// maxWordLength is the length of the longest dictionary word.
+const maxWordLength = 10
+
+// l33tTable maps common l33t substitutions to the letters they replace.
+var l33tTable = map[rune][]rune{
+	'4': {'a'},
+	'@': {'a'},
+	'8': {'b'},
+	'(': {'c'},
+	'3': {'e'},
+	'6': {'g'},
+	'1': {'i', 'l'},
+	'!': {'i'},
+	'|': {'i', 'l'},
+	'0': {'o'},
+	'$': {'s'},
+	'5': {'s'},
+	'7': {'t'},
+	'+': {'t'},
+	'2': {'z'},
+}
+
+// keyboardRows are the rows of common keyboard layouts. Walking along a row
+// is a popular way to make up a password.
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+	"qwertzuiopü",
+	"yxcvbnm,.-",
+	"azertyuiop",
+	"qsdfghjklm",
+	"wxcvbn",
+	"789456123",
+}
+
+// keyboardKeys is the number of keys a keyboard pattern can start with.
+const keyboardKeys = 47
+
+// minYearSpace is the minimum number of years an attacker has to try.
+const minYearSpace = 20
+
+// findMatches returns all guessable patterns of the password. Dates are
+// estimated relative to the current year, years close to it are more common.
+func findMatches(pw []rune, currentYear int) []Match {
+	var ms []Match
+	ms = append(ms, dictionaryMatches(pw)...)
+	ms = append(ms, keyboardMatches(pw)...)
+	ms = append(ms, sequenceMatches(pw)...)
+	ms = append(ms, repeatMatches(pw)...)
+	ms = append(ms, dateMatches(pw, currentYear)...)
+
+	return ms
+}
+
+// dictionaryMatches finds dictionary words, also capitalized, reversed or
+// in l33t speak.
+func dictionaryMatches(pw []rune) []Match {
+	var ms []Match
+
+	lower := []rune(strings.ToLower(string(pw)))
+	variants := unl33t(lower)
+	for i := range pw {
+		for j := i + minWordLength; j <= len(pw) && j-i <= maxWordLength; j++ {
+			token := string(pw[i:j])
+			for _, v := range variants {
+				word := string(v[i:j])
+				subs := countSubs(lower[i:j], v[i:j])
+				if m, found := lookupWord(word, token, subs, false); found {
+					m.i, m.j = i, j
+					ms = append(ms, m)
+				}
+				if m, found := lookupWord(reverse(word), token, subs, true); found {
+					m.i, m.j = i, j
+					ms = append(ms, m)
+				}
+			}
+		}
+	}
+
+	return ms
+}
+
+// lookupWord looks up a word in the dictionaries and returns the match with
+// the lowest rank.
+func lookupWord(word, token string, subs int, reversed bool) (Match, bool) {
+	var best Match
+	var found bool
+	for _, d := range dictionaries {
+		rank, ok := d.ranks[word]
+		if !ok || (found && float64(rank) >= best.Guesses) {
+			continue
+		}
+
+		var notes []string
+		guesses := float64(rank) * uppercaseVariations(token)
+		if uppercaseVariations(token) > 1 {
+			notes = append(notes, "capitalized")
+		}
+		if subs > 0 {
+			guesses *= math.Pow(2, float64(subs))
+			notes = append(notes, "l33t")
+		}
+		if reversed {
+			guesses *= 2
+			notes = append(notes, "reversed")
+		}
+
+		detail := fmt.Sprintf("%s %q", d.name, word)
+		if len(notes) > 0 {
+			detail += ", " + strings.Join(notes, ", ")
+		}
+
+		best = Match{
+			Pattern: "dictionary",
+			Token:   token,
+			Detail:  detail,
+			Guesses: math.Max(guesses, 1),
+		}
+		found = true
+	}
+
+	return best, found
+}
+
+// unl33t returns the password with the l33t substitutions undone. Since some
+// characters replace more than one letter there can be more than one variant.
+func unl33t(pw []rune) [][]rune {
+	a := make([]rune, len(pw))
+	b := make([]rune, len(pw))
+	for i, r := range pw {
+		a[i], b[i] = r, r
+		if subs, found := l33tTable[r]; found {
+			a[i] = subs[0]
+			b[i] = subs[len(subs)-1]
+		}
+	}
+
+	if string(a) == string(b) {
+		if string(a) == string(pw) {
+			return [][]rune{pw}
+		}
+
+		return [][]rune{pw, a}
+	}
+
+	return [][]rune{pw, a, b}
+}
+
+// countSubs returns the number of substituted characters.
+func countSubs(orig, word []rune) int {
+	var n int
+	for i := range orig {
+		if orig[i] != word[i] {
+			n++
+		}
+	}
+
+	return n
+}
+
+// uppercaseVariations returns the number of guesses needed to find the
+// capitalization of a word. All lowercase needs no extra guesses,
+// capitalized or all uppercase only one more.
+func uppercaseVariations(token string) float64 {
+	var upper, lower int
+	for _, r := range token {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+
+	switch {
+	case upper == 0:
+		return 1
+	case lower == 0:
+		return 2
+	case upper == 1 && unicode.IsUpper([]rune(token)[0]):
+		return 2
+	}
+
+	var variations float64
+	for k := 1; k <= min(upper, lower); k++ {
+		variations += binomial(upper+lower, k)
+	}
+
+	return variations
+}
+
+// keyboardMatches finds runs of at least three keys along a keyboard row in
+// either direction.
+func keyboardMatches(pw []rune) []Match {
+	var ms []Match
+
+	lower := []rune(strings.ToLower(string(pw)))
+	for i := range lower {
+		best := i
+		for _, row := range keyboardRows {
+			for _, dir := range []int{1, -1} {
+				j := i + 1
+				for j < len(lower) && adjacent(row, lower[j-1], lower[j], dir) {
+					j++
+				}
+				if j > best {
+					best = j
+				}
+			}
+		}
+		if best-i < 3 {
+			continue
+		}
+
+		// report every prefix of the run, the cheapest cover is picked later.
+		for j := i + 3; j <= best; j++ {
+			token := string(pw[i:j])
+			guesses := float64(keyboardKeys) * float64(j-i) * 2
+			if token != string(lower[i:j]) {
+				guesses *= 2
+			}
+			ms = append(ms, Match{
+				Pattern: "keyboard",
+				Token:   token,
+				Guesses: guesses,
+				i:       i,
+				j:       j,
+			})
+		}
+	}
+
+	return ms
+}
+
+// adjacent returns true if b follows a on the keyboard row in the given
+// direction.
+func adjacent(row string, a, b rune, dir int) bool {
+	r := []rune(row)
+	for k := range r {
+		if r[k] != a {
+			continue
+		}
+		n := k + dir
+		if n >= 0 && n < len(r) && r[n] == b {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sequenceMatches finds sequences like abc, 1357 or 9876 with a constant
+// step of one or two.
+func sequenceMatches(pw []rune) []Match {
+	var ms []Match
+
+	for i := 0; i < len(pw)-2; {
+		delta := int(pw[i+1]) - int(pw[i])
+		if delta == 0 || delta < -2 || delta > 2 || !sameClass(pw[i], pw[i+1]) {
+			i++
+
+			continue
+		}
+
+		j := i + 2
+		for j < len(pw) && int(pw[j])-int(pw[j-1]) == delta && sameClass(pw[j-1], pw[j]) {
+			j++
+		}
+		if j-i < 3 {
+			i++
+
+			continue
+		}
+
+		var base float64
+		switch first := pw[i]; {
+		case strings.ContainsRune("aAzZ019", first):
+			base = 4
+		case unicode.IsDigit(first):
+			base = 10
+		default:
+			base = 26
+		}
+		if delta < 0 {
+			base *= 2
+		}
+
+		ms = append(ms, Match{
+			Pattern: "sequence",
+			Token:   string(pw[i:j]),
+			Guesses: base * float64(j-i),
+			i:       i,
+			j:       j,
+		})
+		i = j - 1
+	}
+
+	return ms
+}
+
+// sameClass returns true if both runes are lowercase letters, uppercase
+// letters or digits.
+func sameClass(a, b rune) bool {
+	switch {
+	case unicode.IsLower(a):
+		return unicode.IsLower(b)
+	case unicode.IsUpper(a):
+		return unicode.IsUpper(b)
+	case unicode.IsDigit(a):
+		return unicode.IsDigit(b)
+	}
+
+	return false
+}
+
+// repeatMatches finds repeated characters or groups of characters like aaa
+// or abcabc.
+func repeatMatches(pw []rune) []Match {
+	var ms []Match
+
+	// the guesses of each chunk are only estimated once. Long runs of the
+	// same characters would be estimated again for every position otherwise.
+	bases := make(map[string]float64, 8)
+
+	for i := range pw {
+		for size := 1; size <= (len(pw)-i)/2 && size <= maxRepeatChunk; size++ {
+			chunk := pw[i : i+size]
+			// a chunk like abab is covered by the repeat of ab already.
+			if isRepeat(chunk) {
+				continue
+			}
+			j := i + size
+			for j+size <= len(pw) && string(pw[j:j+size]) == string(chunk) {
+				j += size
+			}
+			count := (j - i) / size
+			if count < 2 || (size == 1 && count < 3) {
+				continue
+			}
+
+			base, found := bases[string(chunk)]
+			if !found {
+				base = Estimate(string(chunk)).Guesses
+				bases[string(chunk)] = base
+			}
+			ms = append(ms, Match{
+				Pattern: "repeat",
+				Token:   string(pw[i:j]),
+				Detail:  fmt.Sprintf("%q %d times", string(chunk), count),
+				Guesses: base * float64(count),
+				i:       i,
+				j:       j,
+			})
+		}
+	}
+
+	return ms
+}
+
+// isRepeat returns true if the chunk consists of a shorter group of
+// characters repeated several times.
+func isRepeat(chunk []rune) bool {
+	for size := 1; size <= len(chunk)/2; size++ {
+		if len(chunk)%size != 0 {
+			continue
+		}
+
+		repeated := true
+		for k := size; k < len(chunk); k++ {
+			if chunk[k] != chunk[k-size] {
+				repeated = false
+
+				break
+			}
+		}
+		if repeated {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maxRepeatChunk is the length of the longest repeated group of characters
+// that is detected.
+const maxRepeatChunk = 16
+
+// dateSeparators are the separators of dates like 1990-05-12.
+const dateSeparators = " -/\\_."
+
+// dateMatches finds years and dates with or without separators.
+func dateMatches(pw []rune, currentYear int) []Match {
+	var ms []Match
+
+	for i := range pw {
+		for j := i + 4; j <= len(pw) && j-i <= 10; j++ {
+			token := string(pw[i:j])
+			if m, found := matchDate(token, currentYear); found {
+				m.i, m.j = i, j
+				ms = append(ms, m)
+			}
+		}
+	}
+
+	return ms
+}
+
+// matchDate checks if the token is a year or a date.
+func matchDate(token string, currentYear int) (Match, bool) {
+	if len(token) == 4 {
+		if year, err := strconv.Atoi(token); err == nil && year >= 1900 && year <= 2099 {
+			return Match{
+				Pattern: "date",
+				Token:   token,
+				Detail:  "year",
+				Guesses: yearSpace(year, currentYear),
+			}, true
+		}
+	}
+
+	var parts []string
+	sep := strings.IndexAny(token, dateSeparators)
+	if sep > 0 {
+		s := token[sep : sep+1]
+		parts = strings.Split(token, s)
+		if len(parts) != 3 {
+			return Match{}, false
+		}
+	} else if len(token) <= 8 {
+		// try all splits into day, month and year. Years are either two or
+		// four digits long.
+		for _, split := range dateSplits(len(token)) {
+			p := []string{token[:split[0]], token[split[0]:split[1]], token[split[1]:]}
+			if _, found := parseDate(p); found {
+				parts = p
+
+				break
+			}
+		}
+	}
+	if parts == nil {
+		return Match{}, false
+	}
+
+	year, found := parseDate(parts)
+	if !found {
+		return Match{}, false
+	}
+
+	guesses := 365 * yearSpace(year, currentYear)
+	if sep > 0 {
+		guesses *= 4
+	}
+
+	return Match{
+		Pattern: "date",
+		Token:   token,
+		Guesses: guesses,
+	}, true
+}
+
+// dateSplits returns the positions to split a date without separators.
+func dateSplits(n int) [][2]int {
+	switch n {
+	case 4:
+		return [][2]int{{1, 2}, {2, 3}}
+	case 5:
+		return [][2]int{{1, 3}, {2, 3}}
+	case 6:
+		return [][2]int{{1, 2}, {2, 4}, {4, 5}}
+	case 7:
+		return [][2]int{{1, 3}, {2, 3}, {4, 5}, {4, 6}}
+	case 8:
+		return [][2]int{{2, 4}, {4, 6}}
+	default:
+		return nil
+	}
+}
+
+// parseDate checks if the parts are a valid date in day-month-year,
+// month-day-year or year-month-day order and returns the year.
+func parseDate(parts []string) (int, bool) {
+	nums := make([]int, 0, 3)
+	for _, p := range parts {
+		if p == "" || len(p) > 4 || len(p) == 3 {
+			return 0, false
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, false
+		}
+		nums = append(nums, n)
+	}
+
+	validDay := func(d, m int) bool { return d >= 1 && d <= 31 && m >= 1 && m <= 12 }
+
+	// year last
+	if len(parts[0]) <= 2 && len(parts[1]) <= 2 {
+		if year, ok := fullYear(nums[2], len(parts[2])); ok && (validDay(nums[0], nums[1]) || validDay(nums[1], nums[0])) {
+			return year, true
+		}
+	}
+	// year first
+	if len(parts[1]) <= 2 && len(parts[2]) <= 2 {
+		if year, ok := fullYear(nums[0], len(parts[0])); ok && validDay(nums[2], nums[1]) {
+			return year, true
+		}
+	}
+
+	return 0, false
+}
+
+// fullYear expands two digit years and checks the range of the year.
+func fullYear(year, digits int) (int, bool) {
+	switch {
+	case digits == 2 && year > 50:
+		return 1900 + year, true
+	case digits == 2:
+		return 2000 + year, true
+	case digits == 4 && year >= 1900 && year <= 2099:
+		return year, true
+	default:
+		return 0, false
+	}
+}
+
+// yearSpace returns the number of years an attacker has to try to guess the
+// year.
+func yearSpace(year, currentYear int) float64 {
+	d := year - currentYear
+	if d < 0 {
+		d = -d
+	}
+
+	return float64(max(d, minYearSpace))
+}
+
+// binomial returns n choose k.
+func binomial(n, k int) float64 {
+	r := 1.0
+	for i := 1; i <= k; i++ {
+		r = r * float64(n-k+i) / float64(i)
+	}
+
+	return r
+}
+
+// reverse reverses a string.
+func reverse(s string) string {
+	r := []rune(s)
+	for a, b := 0, len(r)-1; a < b; a, b = a+1, b-1 {
+		r[a], r[b] = r[b], r[a]
+	}
+
+	return string(r)
+}
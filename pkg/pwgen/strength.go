@@ -0,0 +1,266 @@
+package pwgen
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MaxScore is the best score a password can get.
+const MaxScore = 4
+
+// guessesPerSecond is the assumed speed of an offline attack against a slow
+// password hash, e.g. bcrypt, used to estimate the crack time.
+const guessesPerSecond = 1e4
+
+// maxPatternLength limits the part of a password that is searched for
+// patterns. The rest is treated as brute force to keep long secrets fast.
+const maxPatternLength = 256
+
+// Match is a part of a password that matches a guessable pattern.
+type Match struct {
+	// Pattern is the kind of match: dictionary, keyboard, sequence, repeat,
+	// date or bruteforce.
+	Pattern string
+	// Token is the matched part of the password.
+	Token string
+	// Detail describes the match, e.g. the dictionary word.
+	Detail string
+	// Guesses is the number of guesses needed to find the token.
+	Guesses float64
+
+	i, j int
+}
+
+// String returns a human readable description of the match.
+func (m Match) String() string {
+	if m.Detail == "" {
+		return fmt.Sprintf("%s %q", m.Pattern, m.Token)
+	}
+
+	return fmt.Sprintf("%s %q (%s)", m.Pattern, m.Token, m.Detail)
+}
+
+// Strength is the estimated strength of a password.
+type Strength struct {
+	// Score is the strength from 0 (too guessable) to 4 (very unguessable).
+	Score int
+	// Guesses is the estimated number of guesses to find the password.
+	Guesses float64
+	// CrackTime is the estimated time of an offline attack against a slow
+	// password hash.
+	CrackTime time.Duration
+	// Matches are the parts of the password. Parts that don't match any
+	// pattern are reported as bruteforce.
+	Matches []Match
+}
+
+// Patterns returns the guessable patterns found in the password.
+func (s Strength) Patterns() []string {
+	p := make([]string, 0, len(s.Matches))
+	for _, m := range s.Matches {
+		if m.Pattern == "bruteforce" {
+			continue
+		}
+		p = append(p, m.String())
+	}
+
+	return p
+}
+
+// Explain returns a human readable summary of the estimate.
+func (s Strength) Explain() string {
+	msg := fmt.Sprintf("score %d/%d, estimated crack time %s", s.Score, MaxScore, HumanizeDuration(s.CrackTime))
+	if p := s.Patterns(); len(p) > 0 {
+		msg += ", found " + strings.Join(p, ", ")
+	}
+
+	return msg
+}
+
+// Score estimates how hard a password is to guess on a scale from 0 (too
+// guessable) to 4 (very unguessable), see Estimate.
+func Score(pw string) int {
+	return Estimate(pw).Score
+}
+
+// Estimate estimates the strength of a password like zxcvbn does. It looks
+// for dictionary words, also in l33t speak or reversed, keyboard patterns,
+// sequences, repeats and dates and finds the cheapest way to guess the
+// password by combining them with brute force.
+func Estimate(pw string) Strength {
+	return estimate(pw, time.Now().Year())
+}
+
+// estimate estimates the strength of a password, see Estimate. Dates are
+// compared with the given year.
+func estimate(pw string, currentYear int) Strength {
+	runes := []rune(pw)
+	if len(runes) == 0 {
+		return Strength{}
+	}
+
+	matches := make(map[int][]Match, len(runes))
+	for _, m := range findMatches(runes[:min(len(runes), maxPatternLength)], currentYear) {
+		matches[m.i] = append(matches[m.i], m)
+	}
+	pool := float64(poolSize(pw))
+
+	// best[k] is the minimal number of guesses for the first k runes
+	// and from[k] the match that ends there.
+	n := len(runes)
+	best := make([]float64, n+1)
+	from := make([]*Match, n+1)
+	count := make([]int, n+1)
+	best[0] = 1
+	for k := 1; k <= n; k++ {
+		best[k] = math.Inf(1)
+	}
+	for k := range n {
+		// brute force a single character
+		if g := best[k] * pool; g < best[k+1] {
+			best[k+1] = g
+			from[k+1] = nil
+			count[k+1] = count[k]
+		}
+		for mi := range matches[k] {
+			m := &matches[k][mi]
+			// the attacker also has to guess how the patterns are combined.
+			g := best[k] * m.Guesses * float64(count[k]+1)
+			if g < best[m.j] {
+				best[m.j] = g
+				from[m.j] = m
+				count[m.j] = count[k] + 1
+			}
+		}
+	}
+
+	s := Strength{
+		Guesses: best[n],
+		Matches: collectMatches(runes, from, pool),
+	}
+	s.Score = scoreGuesses(s.Guesses)
+	secs := s.Guesses / guessesPerSecond
+	if secs > float64(math.MaxInt64/int64(time.Second)) {
+		s.CrackTime = time.Duration(math.MaxInt64)
+	} else {
+		s.CrackTime = time.Duration(secs * float64(time.Second))
+	}
+
+	return s
+}
+
+// collectMatches walks back through the optimal matches and merges the
+// characters in between into bruteforce matches.
+func collectMatches(runes []rune, from []*Match, pool float64) []Match {
+	var ms []Match
+	k := len(runes)
+	end := k
+	flush := func() {
+		if end > k {
+			ms = append(ms, Match{Pattern: "bruteforce", Token: string(runes[k:end]), Guesses: math.Pow(pool, float64(end-k)), i: k, j: end})
+		}
+	}
+	for k > 0 {
+		m := from[k]
+		if m == nil {
+			k--
+
+			continue
+		}
+		flush()
+		ms = append(ms, *m)
+		k = m.i
+		end = k
+	}
+	flush()
+
+	// reverse to the order of the password
+	for a, b := 0, len(ms)-1; a < b; a, b = a+1, b-1 {
+		ms[a], ms[b] = ms[b], ms[a]
+	}
+
+	return ms
+}
+
+// scoreGuesses uses the same thresholds as zxcvbn: 10^3, 10^6, 10^8 and 10^10
+// guesses.
+func scoreGuesses(guesses float64) int {
+	switch l := math.Log10(guesses); {
+	case l < 3:
+		return 0
+	case l < 6:
+		return 1
+	case l < 8:
+		return 2
+	case l < 10:
+		return 3
+	default:
+		return MaxScore
+	}
+}
+
+// HumanizeDuration returns a rough human readable representation of a
+// crack time.
+func HumanizeDuration(d time.Duration) string {
+	const (
+		day   = 24 * time.Hour
+		month = 30 * day
+		year  = 365 * day
+	)
+
+	unit := func(n int64, name string) string {
+		if n == 1 {
+			return "1 " + name
+		}
+
+		return fmt.Sprintf("%d %ss", n, name)
+	}
+
+	switch {
+	case d < time.Second:
+		return "less than a second"
+	case d < time.Minute:
+		return unit(int64(d/time.Second), "second")
+	case d < time.Hour:
+		return unit(int64(d/time.Minute), "minute")
+	case d < day:
+		return unit(int64(d/time.Hour), "hour")
+	case d < month:
+		return unit(int64(d/day), "day")
+	case d < year:
+		return unit(int64(d/month), "month")
+	case d < 100*year:
+		return unit(int64(d/year), "year")
+	default:
+		return "centuries"
+	}
+}
+
+// poolSize returns the number of characters an attacker has to try for each
+// character of the password.
+func poolSize(pw string) int {
+	var size int
+	if strings.IndexFunc(pw, unicode.IsLower) >= 0 {
+		size += len(Lower)
+	}
+	if strings.IndexFunc(pw, unicode.IsUpper) >= 0 {
+		size += len(Upper)
+	}
+	if strings.IndexFunc(pw, unicode.IsDigit) >= 0 {
+		size += len(Digits)
+	}
+	if strings.ContainsAny(pw, Syms+" ") {
+		size += len(Syms) + 1
+	}
+	if strings.IndexFunc(pw, func(r rune) bool { return r > unicode.MaxASCII }) >= 0 {
+		size += 100
+	}
+	if size < 2 {
+		size = 2
+	}
+
+	return size
+}
@@ -0,0 +1,163 @@
+package pwgen
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScore(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		pw    string
+		score int
+	}{
+		{"", 0},
+		{"ab", 0},
+		{"abc", 0},
+		{"short", 1},
+		{"12345678", 0},
+		{"aaaaaaaaaaaa", 0},
+		{"P@ssw0rd1990", 1},
+		{"Summer2024!", 1},
+		{"kxqzvmwp", MaxScore},
+		{"kxq7zvMw!p2Lq", MaxScore},
+		{"correct horse battery staple", MaxScore},
+		{strings.Repeat("kxq7zvMw!p2Lq", 50), MaxScore},
+	} {
+		assert.Equal(t, tc.score, Score(tc.pw), tc.pw)
+	}
+}
+
+func TestEstimatePatterns(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		pw      string
+		pattern string
+		token   string
+		detail  string
+	}{
+		{"password", "dictionary", "password", `common password "password"`},
+		{"P@ssw0rd", "dictionary", "P@ssw0rd", `common password "password", capitalized, l33t`},
+		{"drowssap", "dictionary", "drowssap", `common password "password", reversed`},
+		{"xq7Kcastle", "dictionary", "castle", `english word "castle"`},
+		{"asdfghjkl", "keyboard", "asdfghjkl", ""},
+		{"x7#lkjhg", "keyboard", "lkjhg", ""},
+		{"Kx#mnopqr", "sequence", "mnopqr", ""},
+		{"Kx#97531", "sequence", "97531", ""},
+		{"Kx#zzzzzz", "repeat", "zzzzzz", `"z" 6 times`},
+		{"Kx#1990-05-12", "date", "1990-05-12", ""},
+		{"Kx#12051990", "date", "12051990", ""},
+		{"Kx#1987", "date", "1987", "year"},
+	} {
+		s := Estimate(tc.pw)
+		var found bool
+		for _, m := range s.Matches {
+			if m.Pattern == tc.pattern && m.Token == tc.token {
+				assert.Equal(t, tc.detail, m.Detail, tc.pw)
+				found = true
+			}
+		}
+		assert.True(t, found, "%s: no %s match %q in %v", tc.pw, tc.pattern, tc.token, s.Matches)
+	}
+}
+
+func TestEstimateYear(t *testing.T) {
+	t.Parallel()
+
+	yearGuesses := func(pw string, currentYear int) float64 {
+		t.Helper()
+
+		for _, m := range estimate(pw, currentYear).Matches {
+			if m.Pattern == "date" {
+				return m.Guesses
+			}
+		}
+		require.Fail(t, "no date match", pw)
+
+		return 0
+	}
+
+	// years close to the current one are guessed first.
+	assert.InDelta(t, float64(minYearSpace), yearGuesses("Kx#2030", 2030), 0)
+	assert.InDelta(t, 40.0, yearGuesses("Kx#2030", 1990), 0)
+	assert.InDelta(t, 365*40.0, yearGuesses("Kx#12052030", 1990), 0)
+}
+
+func TestEstimate(t *testing.T) {
+	t.Parallel()
+
+	s := Estimate("qwerty123")
+	assert.Equal(t, 0, s.Score)
+	assert.Less(t, s.CrackTime, time.Second)
+	require.Len(t, s.Matches, 2)
+	assert.Equal(t, []string{`dictionary "qwerty" (common password "qwerty")`, `sequence "123"`}, s.Patterns())
+	assert.Equal(t, `score 0/4, estimated crack time less than a second, found dictionary "qwerty" (common password "qwerty"), sequence "123"`, s.Explain())
+
+	// the unmatched characters are reported as brute force.
+	s = Estimate("hunter2")
+	require.Len(t, s.Matches, 2)
+	assert.Equal(t, "bruteforce", s.Matches[1].Pattern)
+	assert.Equal(t, "2", s.Matches[1].Token)
+	assert.Equal(t, []string{`dictionary "hunter" (common password "hunter")`}, s.Patterns())
+
+	s = Estimate("kxq7zvMw!p2Lq")
+	assert.Empty(t, s.Patterns())
+	assert.Equal(t, "score 4/4, estimated crack time centuries", s.Explain())
+}
+
+func TestRepeatMatches(t *testing.T) {
+	t.Parallel()
+
+	// only the shortest repeated group is reported, not "aa" 128 times etc.
+	ms := repeatMatches([]rune(strings.Repeat("a", 256)))
+	require.Len(t, ms, 254)
+	for _, m := range ms {
+		assert.True(t, strings.HasPrefix(m.Detail, `"a" `), m.Detail)
+	}
+
+	s := Estimate(strings.Repeat("ab", 128))
+	require.Len(t, s.Matches, 1)
+	assert.Equal(t, `"ab" 128 times`, s.Matches[0].Detail)
+
+	for chunk, want := range map[string]bool{
+		"a":      false,
+		"ab":     false,
+		"aa":     true,
+		"abab":   true,
+		"abcabc": true,
+		"ababa":  false,
+		"abcab":  false,
+	} {
+		assert.Equal(t, want, isRepeat([]rune(chunk)), chunk)
+	}
+}
+
+func BenchmarkEstimateRepeat(b *testing.B) {
+	pw := strings.Repeat("a", 256)
+	for n := 0; n < b.N; n++ { //nolint:intrange // b.N is evaluated at each iteration.
+		Estimate(pw)
+	}
+}
+
+func TestHumanizeDuration(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[time.Duration]string{
+		0:                      "less than a second",
+		time.Second:            "1 second",
+		90 * time.Second:       "1 minute",
+		5 * time.Hour:          "5 hours",
+		72 * time.Hour:         "3 days",
+		24 * 90 * time.Hour:    "3 months",
+		24 * 365 * time.Hour:   "1 year",
+		24 * 36500 * time.Hour: "centuries",
+	} {
+		assert.Equal(t, want, HumanizeDuration(in), in.String())
+	}
+}
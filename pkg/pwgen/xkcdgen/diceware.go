@@ -0,0 +1,190 @@
+package xkcdgen
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/martinhoefling/goxkcdpwgen/xkcdpwgen"
+)
+
+// Names of the built-in wordlists in addition to the language codes en and
+// de. See: https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+const (
+	// WordlistEFFLarge is the EFF large wordlist with 7776 words. It is the
+	// default wordlist, also known as en.
+	WordlistEFFLarge = "eff-large"
+	// WordlistEFFShort is the EFF short wordlist with 1296 words.
+	WordlistEFFShort = "eff-short"
+)
+
+// minWordlistSize is the minimum number of distinct words of a custom
+// wordlist. Smaller lists yield passphrases that are too long to be useful.
+const minWordlistSize = 64
+
+// numberChoices is the number of suffixes added to each word if numbers are
+// enabled, i.e. the numbers 0 to 10.
+const numberChoices = 11
+
+// ErrWordlist is returned if a wordlist can not be used.
+var ErrWordlist = errors.New("invalid wordlist")
+
+// builtinWordlists maps the names of the built-in wordlists to the name of
+// the xkcdpwgen wordlist and its number of words. All of them are Diceware
+// lists with 6^5 or 6^4 words.
+var builtinWordlists = map[string]struct {
+	lang string
+	size int
+}{
+	"en":             {"en", 7776},
+	WordlistEFFLarge: {"en", 7776},
+	"en_eff_short":   {"en_eff_short", 1296},
+	WordlistEFFShort: {"en_eff_short", 1296},
+	"de":             {"de", 7776},
+	"de_short":       {"de_short", 1296},
+}
+
+// Diceware generates passphrases from the words of a wordlist and reports
+// their entropy.
+type Diceware struct {
+	// Delimiter separates the words. If it is empty the words are capitalized.
+	Delimiter string
+	// Capitalize capitalizes the first letter of each word.
+	Capitalize bool
+	// Numbers adds a random number to each word.
+	Numbers bool
+
+	lang  string
+	words []string
+	size  int
+}
+
+// NewDiceware returns a generator using the given wordlist. It is either the
+// name of a built-in wordlist (en, de, de_short, eff-large, eff-short) or
+// the path to a wordlist file, see ParseWordlist.
+func NewDiceware(wordlist string) (*Diceware, error) {
+	if wordlist == "" {
+		wordlist = "en"
+	}
+
+	if b, found := builtinWordlists[wordlist]; found {
+		return &Diceware{
+			Delimiter: " ",
+			lang:      b.lang,
+			size:      b.size,
+		}, nil
+	}
+
+	fh, err := os.Open(wordlist)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q is neither a built-in wordlist nor a readable file: %w", ErrWordlist, wordlist, err)
+	}
+	defer fh.Close() //nolint:errcheck
+
+	words, err := ParseWordlist(fh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wordlist %s: %w", wordlist, err)
+	}
+
+	return &Diceware{
+		Delimiter: " ",
+		words:     words,
+		size:      len(words),
+	}, nil
+}
+
+// ParseWordlist reads a wordlist with one word per line. Lines in the
+// Diceware format, i.e. dice rolls followed by the word, are supported as
+// well. Empty lines and lines starting with # are skipped. Duplicate words
+// are removed since they would reduce the entropy.
+func ParseWordlist(r io.Reader) ([]string, error) {
+	seen := make(map[string]bool)
+	words := make([]string, 0, 1024)
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		word := fields[len(fields)-1]
+		if len(fields) > 2 || (len(fields) == 2 && strings.IndexFunc(fields[0], func(r rune) bool { return !unicode.IsDigit(r) }) >= 0) {
+			return nil, fmt.Errorf("%w: invalid line %q", ErrWordlist, line)
+		}
+
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		words = append(words, word)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(words) < minWordlistSize {
+		return nil, fmt.Errorf("%w: %d distinct words, need at least %d", ErrWordlist, len(words), minWordlistSize)
+	}
+
+	return words, nil
+}
+
+// Size returns the number of words of the wordlist.
+func (d *Diceware) Size() int {
+	return d.size
+}
+
+// EntropyPerWord returns the entropy in bits each word adds to a passphrase.
+func (d *Diceware) EntropyPerWord() float64 {
+	bits := math.Log2(float64(d.size))
+	if d.Numbers {
+		bits += math.Log2(numberChoices)
+	}
+
+	return bits
+}
+
+// Entropy returns the entropy in bits of a passphrase with the given number
+// of words.
+func (d *Diceware) Entropy(words int) float64 {
+	return float64(words) * d.EntropyPerWord()
+}
+
+// WordsFor returns the number of words a passphrase needs to have at least
+// the given entropy in bits.
+func (d *Diceware) WordsFor(bits float64) int {
+	n := int(math.Ceil(bits / d.EntropyPerWord()))
+	if n < 1 {
+		return 1
+	}
+
+	return n
+}
+
+// Generate returns a passphrase with the given number of words.
+func (d *Diceware) Generate(words int) (string, error) {
+	if words < 1 {
+		return "", fmt.Errorf("invalid number of words: %d", words)
+	}
+
+	g := xkcdpwgen.NewGenerator()
+	g.SetNumWords(words)
+	g.SetDelimiter(d.Delimiter)
+	g.SetCapitalize(d.Delimiter == "" || d.Capitalize)
+	g.SetRandomNumbers(d.Numbers)
+
+	if d.words != nil {
+		g.UseCustomWordlist(d.words)
+	} else if err := g.UseLangWordlist(d.lang); err != nil {
+		return "", fmt.Errorf("failed to use wordlist %s: %w", d.lang, err)
+	}
+
+	return g.GeneratePasswordString(), nil
+}
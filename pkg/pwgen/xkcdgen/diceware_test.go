@@ -0,0 +1,108 @@
+package xkcdgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDicewareBuiltin(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		size int
+	}{
+		{"", 7776},
+		{"en", 7776},
+		{WordlistEFFLarge, 7776},
+		{WordlistEFFShort, 1296},
+		{"de", 7776},
+		{"de_short", 1296},
+	} {
+		d, err := NewDiceware(tc.name)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.size, d.Size(), tc.name)
+
+		pw, err := d.Generate(5)
+		require.NoError(t, err, tc.name)
+		assert.Len(t, strings.Fields(pw), 5, tc.name)
+	}
+
+	_, err := NewDiceware("cn_ZH")
+	require.ErrorIs(t, err, ErrWordlist)
+}
+
+func TestDicewareEntropy(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewDiceware(WordlistEFFLarge)
+	require.NoError(t, err)
+
+	// log2(7776) = 12.925
+	assert.InDelta(t, 12.925, d.EntropyPerWord(), 0.001)
+	assert.InDelta(t, 77.55, d.Entropy(6), 0.01)
+	assert.Equal(t, 6, d.WordsFor(70))
+	assert.Equal(t, 6, d.WordsFor(d.Entropy(6)))
+	assert.Equal(t, 1, d.WordsFor(0))
+
+	// log2(1296) = 10.34
+	d, err = NewDiceware(WordlistEFFShort)
+	require.NoError(t, err)
+	assert.Equal(t, 7, d.WordsFor(70))
+
+	d.Numbers = true
+	assert.InDelta(t, 10.34+3.459, d.EntropyPerWord(), 0.001)
+	assert.Equal(t, 6, d.WordsFor(70))
+}
+
+func TestDicewareCustom(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	sb.WriteString("# Internal wordlist without umlauts\n\n")
+	for i := range 128 {
+		fmt.Fprintf(&sb, "%05d\twort%d\n", 11111+i, i)
+	}
+	// duplicates do not count
+	sb.WriteString("wort1\n")
+
+	fn := filepath.Join(t.TempDir(), "words.txt")
+	require.NoError(t, os.WriteFile(fn, []byte(sb.String()), 0o600))
+
+	d, err := NewDiceware(fn)
+	require.NoError(t, err)
+	assert.Equal(t, 128, d.Size())
+	assert.InDelta(t, 7.0, d.EntropyPerWord(), 0.001)
+
+	d.Delimiter = "-"
+	pw, err := d.Generate(4)
+	require.NoError(t, err)
+	words := strings.Split(pw, "-")
+	require.Len(t, words, 4)
+	for _, w := range words {
+		assert.True(t, strings.HasPrefix(w, "wort"), w)
+	}
+
+	pw, err = RandomLengthDelim(3, ".", fn, true, false)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(pw, "."), 3)
+}
+
+func TestParseWordlist(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseWordlist(strings.NewReader("foo\nbar\n"))
+	require.ErrorIs(t, err, ErrWordlist)
+
+	_, err = ParseWordlist(strings.NewReader("foo bar baz\n"))
+	require.ErrorIs(t, err, ErrWordlist)
+
+	_, err = ParseWordlist(strings.NewReader("abc foo\n"))
+	require.ErrorIs(t, err, ErrWordlist)
+}
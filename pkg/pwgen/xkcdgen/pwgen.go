@@ -4,8 +4,6 @@ package xkcdgen
 
 import (
 	"fmt"
-
-	"github.com/martinhoefling/goxkcdpwgen/xkcdpwgen"
 )
 
 // Random returns a random passphrase combined from four words.
@@ -22,17 +20,16 @@ func RandomLength(length int, lang string) (string, error) {
 }
 
 // RandomLengthDelim returns a random passphrase combined from the desired number
-// of words and the given delimiter. Words are drawn from lang.
+// of words and the given delimiter. Words are drawn from lang, which may be the
+// name of any built-in wordlist or the path to a wordlist file.
 func RandomLengthDelim(length int, delim, lang string, capitalize, numbers bool) (string, error) {
-	g := xkcdpwgen.NewGenerator()
-	g.SetNumWords(length)
-	g.SetDelimiter(delim)
-	g.SetCapitalize(delim == "" || capitalize)
-	g.SetRandomNumbers(numbers)
-
-	if err := g.UseLangWordlist(lang); err != nil {
+	d, err := NewDiceware(lang)
+	if err != nil {
 		return "", fmt.Errorf("failed to use wordlist for lang %s: %w", lang, err)
 	}
+	d.Delimiter = delim
+	d.Capitalize = capitalize
+	d.Numbers = numbers
 
-	return g.GeneratePasswordString(), nil
+	return d.Generate(length)
 }